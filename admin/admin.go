@@ -0,0 +1,239 @@
+// Package admin 管理服务
+// 按照框架配置中的 server.admin 配置段启动内部http服务，提供以下接口：
+//
+//	/healthz          存活检查，进程可以处理请求即返回200
+//	/readyz           就绪检查，开始退出、配置内容源不可访问或就绪检查函数失败时返回503
+//	/debug/pprof/     pprof，server.admin.pprof 为false时返回404，重新加载配置后立即生效
+//	/debug/config     当前生效的配置，敏感配置项被脱敏
+//	POST /-/reload    重新加载全部配置，配置了 reload_token 时需要携带 Authorization: Bearer <token>
+//
+//	server:
+//	  admin:
+//	    ip: 127.0.0.1
+//	    port: 9028
+//	    read_timeout: 3000    # 毫秒
+//	    write_timeout: 60000  # 毫秒，使用profile采样时需要大于采样时间
+//	    reload_token: xxx
+//	    enable_tls: true      # 使用 tls_cert、tls_key，证书文件变化时自动重新加载
+//	    tls_cert: /etc/tls/admin.crt
+//	    tls_key: /etc/tls/admin.key
+//
+//	app.Register(admin.New())
+package admin
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"sync"
+	"time"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/lifecycle"
+	"goProjectTmpl/tlsconf"
+)
+
+// SectionKey 框架配置中管理服务配置段的key
+const SectionKey = "server.admin"
+
+// Config 管理服务配置，时间单位为毫秒，修改后需要重启服务
+type Config struct {
+	IP           string `yaml:"ip" default:"127.0.0.1"`
+	Port         int    `yaml:"port" default:"9028" validate:"max=65535"`
+	ReadTimeout  int    `yaml:"read_timeout" default:"3000"`
+	WriteTimeout int    `yaml:"write_timeout" default:"60000"`
+	// ReloadToken 调用 /-/reload 需要携带的token，为空时不校验
+	ReloadToken string `yaml:"reload_token" sensitive:"true"`
+	EnableTLS   bool   `yaml:"enable_tls"`
+	TLSCert     string `yaml:"tls_cert"`
+	TLSKey      string `yaml:"tls_key"`
+}
+
+func init() {
+	// 使 sensitive tag 生效，reload_token 在调试接口和审计记录中脱敏
+	config.RegisterStruct(SectionKey, Config{})
+}
+
+// ReadyCheck 就绪检查函数，返回错误时 /readyz 返回503
+type ReadyCheck func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check ReadyCheck
+}
+
+// Admin 管理服务，实现了app的Module、Starter、Stopper接口
+type Admin struct {
+	lc  *lifecycle.Manager
+	mux *http.ServeMux
+
+	lock   sync.Mutex
+	cfg    *Config
+	pprof  *config.Toggle
+	checks []namedCheck
+	srv    *http.Server
+}
+
+// Option Admin选项
+type Option func(*Admin)
+
+// WithLifecycle 指定判断是否开始退出的Manager，默认为 lifecycle.DefaultManager，需要与app使用的Manager相同
+func WithLifecycle(m *lifecycle.Manager) Option {
+	return func(a *Admin) {
+		a.lc = m
+	}
+}
+
+// New 创建管理服务
+func New(opts ...Option) *Admin {
+	a := &Admin{lc: lifecycle.DefaultManager, mux: http.NewServeMux()}
+	for _, o := range opts {
+		o(a)
+	}
+	a.mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	a.mux.HandleFunc("/readyz", a.ready)
+	a.mux.Handle("/debug/pprof/", a.pprofOnly(http.HandlerFunc(pprof.Index)))
+	a.mux.Handle("/debug/pprof/cmdline", a.pprofOnly(http.HandlerFunc(pprof.Cmdline)))
+	a.mux.Handle("/debug/pprof/profile", a.pprofOnly(http.HandlerFunc(pprof.Profile)))
+	a.mux.Handle("/debug/pprof/symbol", a.pprofOnly(http.HandlerFunc(pprof.Symbol)))
+	a.mux.Handle("/debug/pprof/trace", a.pprofOnly(http.HandlerFunc(pprof.Trace)))
+	a.mux.Handle("/debug/config", config.DebugHandler())
+	return a
+}
+
+// Handle 注册额外的管理接口
+func (a *Admin) Handle(pattern string, h http.Handler) {
+	a.mux.Handle(pattern, h)
+}
+
+// AddReadyCheck 注册就绪检查函数
+func (a *Admin) AddReadyCheck(name string, check ReadyCheck) {
+	a.lock.Lock()
+	a.checks = append(a.checks, namedCheck{name: name, check: check})
+	a.lock.Unlock()
+}
+
+// Name 模块名
+func (a *Admin) Name() string {
+	return "admin"
+}
+
+// Init 解析 server.admin 配置段并注册 /-/reload
+func (a *Admin) Init(c config.Config) error {
+	cfg := &Config{IP: "127.0.0.1", Port: 9028, ReadTimeout: 3000, WriteTimeout: 60000}
+	if c.IsSet(SectionKey) {
+		if err := c.UnmarshalKey(SectionKey, cfg); err != nil {
+			return fmt.Errorf("app/admin: failed to parse %s: %v", SectionKey, err)
+		}
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.cfg == nil {
+		a.mux.Handle("/-/reload", config.ReloadHandler(cfg.ReloadToken))
+	}
+	a.cfg = cfg
+	a.pprof = config.NewToggle(c, SectionKey+".pprof", true)
+	return nil
+}
+
+// Start 监听管理服务地址
+func (a *Admin) Start() error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	cfg := a.cfg
+	l, err := net.Listen("tcp", net.JoinHostPort(cfg.IP, strconv.Itoa(cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("app/admin: %v", err)
+	}
+	if cfg.EnableTLS {
+		if cfg.TLSCert == "" || cfg.TLSKey == "" {
+			l.Close()
+			return tlsconf.ErrNoCertificate
+		}
+		m, err := tlsconf.New(tlsconf.Source{Cert: cfg.TLSCert, Key: cfg.TLSKey})
+		if err != nil {
+			l.Close()
+			return err
+		}
+		l = tls.NewListener(l, m.ServerConfig())
+	}
+	a.srv = &http.Server{
+		Handler:      a.mux,
+		ReadTimeout:  time.Duration(cfg.ReadTimeout) * time.Millisecond,
+		WriteTimeout: time.Duration(cfg.WriteTimeout) * time.Millisecond,
+	}
+	go func(srv *http.Server) {
+		if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+			config.Errorf("app/admin: stopped: %v", err)
+		}
+	}(a.srv)
+	config.Infof("app/admin: serving on %s", l.Addr())
+	return nil
+}
+
+// Stop 停止管理服务，通常最先注册，因此在其他模块全部停止后才停止
+func (a *Admin) Stop(ctx context.Context) error {
+	a.lock.Lock()
+	srv := a.srv
+	a.lock.Unlock()
+	if srv != nil {
+		return srv.Shutdown(ctx)
+	}
+	return nil
+}
+
+// pprofOnly server.admin.pprof 为false时返回404
+func (a *Admin) pprofOnly(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.lock.Lock()
+		t := a.pprof
+		a.lock.Unlock()
+		if t != nil && !t.Bool() {
+			http.NotFound(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// readiness /readyz 的返回内容
+type readiness struct {
+	Ready        bool              `json:"ready"`
+	ShuttingDown bool              `json:"shutting_down"`
+	Config       *config.Health    `json:"config"`
+	Checks       map[string]string `json:"checks,omitempty"`
+}
+
+func (a *Admin) ready(w http.ResponseWriter, r *http.Request) {
+	a.lock.Lock()
+	checks := append([]namedCheck(nil), a.checks...)
+	a.lock.Unlock()
+
+	res := &readiness{
+		ShuttingDown: a.lc.ShuttingDown(),
+		Config:       config.DefaultConfigLoader.Health(r.Context()),
+	}
+	res.Ready = !res.ShuttingDown && res.Config.Status == config.HealthOK
+	for _, c := range checks {
+		if err := c.check(r.Context()); err != nil {
+			if res.Checks == nil {
+				res.Checks = make(map[string]string)
+			}
+			res.Checks[c.name] = err.Error()
+			res.Ready = false
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !res.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,112 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goProjectTmpl/config/configtest"
+	"goProjectTmpl/lifecycle"
+)
+
+func serve(a *Admin, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	a.mux.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestReady(t *testing.T) {
+	c, _ := configtest.Load(t, "a: 1\n")
+	lc := lifecycle.NewManager(nil)
+	a := New(WithLifecycle(lc))
+	if err := a.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(a, http.MethodGet, "/healthz"); w.Code != http.StatusOK {
+		t.Fatalf("healthz: expect 200, got %d", w.Code)
+	}
+	if w := serve(a, http.MethodGet, "/readyz"); w.Code != http.StatusOK {
+		t.Fatalf("readyz: expect 200, got %d %s", w.Code, w.Body)
+	}
+
+	a.AddReadyCheck("db", func(context.Context) error { return errors.New("connection refused") })
+	w := serve(a, http.MethodGet, "/readyz")
+	var res readiness
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusServiceUnavailable || res.Ready || res.Checks["db"] != "connection refused" {
+		t.Fatalf("expect the failed check to be reported, got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestReadyShuttingDown(t *testing.T) {
+	c, _ := configtest.Load(t, "a: 1\n")
+	lc := lifecycle.NewManager(nil)
+	a := New(WithLifecycle(lc))
+	if err := a.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	lc.Stop(context.Background())
+	w := serve(a, http.MethodGet, "/readyz")
+	var res readiness
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusServiceUnavailable || !res.ShuttingDown {
+		t.Fatalf("expect 503 while shutting down, got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestPprofToggle(t *testing.T) {
+	c, p := configtest.Load(t, "server:\n  admin:\n    pprof: true\n")
+	a := New()
+	if err := a.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(a, http.MethodGet, "/debug/pprof/"); w.Code != http.StatusOK {
+		t.Fatalf("expect 200, got %d", w.Code)
+	}
+	// 重新加载配置后立即生效
+	p.Update(configtest.DefaultPath, []byte("server:\n  admin:\n    pprof: false\n"))
+	if w := serve(a, http.MethodGet, "/debug/pprof/"); w.Code != http.StatusNotFound {
+		t.Fatalf("expect 404 after pprof is disabled, got %d", w.Code)
+	}
+}
+
+func TestReloadToken(t *testing.T) {
+	c, _ := configtest.Load(t, "server:\n  admin:\n    reload_token: secret\n")
+	a := New()
+	if err := a.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(a, http.MethodPost, "/-/reload"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expect 401 without token, got %d", w.Code)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	c, _ := configtest.Load(t, "server:\n  admin:\n    port: 0\n")
+	a := New()
+	if err := a.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ = configtest.Load(t, "server:\n  admin:\n    port: 0\n    enable_tls: true\n")
+	a = New()
+	if err := a.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Start(); err == nil {
+		t.Fatal("expect an error for enable_tls without certificates")
+	}
+}
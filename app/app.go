@@ -0,0 +1,186 @@
+// Package app 服务启动骨架：加载框架配置、初始化插件和模块、向模块传递配置变更，并阻塞直到服务退出
+//
+//	func main() {
+//		app.Register(&myModule{})
+//		if err := app.Run(); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/lifecycle"
+	"goProjectTmpl/plugin"
+)
+
+// DefaultConfigPath 默认的框架配置文件路径
+const DefaultConfigPath = "./app.yaml"
+
+// Module 服务模块，按照注册顺序初始化
+type Module interface {
+	Name() string
+	// Init 使用框架配置初始化模块
+	Init(c config.Config) error
+}
+
+// Starter Module的可选接口，全部模块初始化完成后按照注册顺序启动
+type Starter interface {
+	Start() error
+}
+
+// Stopper Module的可选接口，服务退出时按照启动的相反顺序停止，ctx在 shutdown.timeout 到达时结束
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// Reloader Module的可选接口，框架配置重新加载后收到变更的配置项
+type Reloader interface {
+	Reload(c config.Config, changes []config.Change)
+}
+
+var (
+	modules []Module
+	lock    sync.RWMutex
+)
+
+// Register 注册模块，通常在模块包的init中调用
+func Register(m Module) {
+	lock.Lock()
+	modules = append(modules, m)
+	lock.Unlock()
+}
+
+// registered 返回全部已注册的模块
+func registered() []Module {
+	lock.RLock()
+	defer lock.RUnlock()
+	return append([]Module(nil), modules...)
+}
+
+// App 服务实例
+type App struct {
+	path    string
+	opts    []config.LoadOption
+	modules []Module
+	signals []os.Signal
+	lc      *lifecycle.Manager
+
+	cfg config.Config
+}
+
+// Option App选项
+type Option func(*App)
+
+// WithConfigPath 指定框架配置文件路径，默认为 ./app.yaml
+func WithConfigPath(path string) Option {
+	return func(a *App) {
+		a.path = path
+	}
+}
+
+// WithLoadOptions 加载框架配置时使用的选项，默认使用yaml格式的本地文件并自动重新加载
+func WithLoadOptions(opts ...config.LoadOption) Option {
+	return func(a *App) {
+		a.opts = append(a.opts, opts...)
+	}
+}
+
+// WithModules 在已注册的模块之后追加模块
+func WithModules(ms ...Module) Option {
+	return func(a *App) {
+		a.modules = append(a.modules, ms...)
+	}
+}
+
+// WithSignals 指定触发退出的信号，默认为 SIGINT、SIGTERM
+func WithSignals(sigs ...os.Signal) Option {
+	return func(a *App) {
+		a.signals = sigs
+	}
+}
+
+// WithLifecycle 指定管理退出流程的Manager，默认为 lifecycle.DefaultManager
+func WithLifecycle(m *lifecycle.Manager) Option {
+	return func(a *App) {
+		a.lc = m
+	}
+}
+
+// NewApp 创建服务实例，模块为创建时已注册的模块
+func NewApp(opts ...Option) *App {
+	a := &App{
+		path:    DefaultConfigPath,
+		opts:    []config.LoadOption{config.WithCodec("yaml"), config.WithProvider("file"), config.WithAutoReload()},
+		modules: registered(),
+		signals: []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		lc:      lifecycle.DefaultManager,
+	}
+	for _, o := range opts {
+		o(a)
+	}
+	return a
+}
+
+// Config 框架配置，Run 加载配置之前为nil
+func (a *App) Config() config.Config {
+	return a.cfg
+}
+
+// Run 启动服务并阻塞，直到ctx结束或收到退出信号，之后按照框架配置中的 shutdown 配置段停止全部已启动的模块
+func (a *App) Run(ctx context.Context) error {
+	if err := a.start(); err != nil {
+		// 启动失败时不需要等待摘除流量
+		a.lc.Stop(context.Background())
+		return err
+	}
+	return a.lc.ShutdownOnSignal(ctx, a.signals...)
+}
+
+// start 加载框架配置，初始化插件，并依次初始化、启动模块
+func (a *App) start() error {
+	c, err := config.Load(a.path, a.opts...)
+	if err != nil {
+		return fmt.Errorf("app: failed to load %s: %v", a.path, err)
+	}
+	a.cfg = c
+	a.lc.SetConfig(c)
+
+	if _, err := plugin.Setup(c); err != nil {
+		return err
+	}
+	for _, m := range a.modules {
+		if err := m.Init(c); err != nil {
+			return fmt.Errorf("app: failed to init module %s: %v", m.Name(), err)
+		}
+	}
+	c.OnChange(func(changes []config.Change) {
+		for _, m := range a.modules {
+			if r, ok := m.(Reloader); ok {
+				r.Reload(c, changes)
+			}
+		}
+	})
+	for _, m := range a.modules {
+		if s, ok := m.(Stopper); ok {
+			a.lc.OnStop(m.Name(), s.Stop)
+		}
+		if s, ok := m.(Starter); ok {
+			if err := s.Start(); err != nil {
+				return fmt.Errorf("app: failed to start module %s: %v", m.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Run 使用已注册的模块启动服务并阻塞直到收到退出信号
+func Run(opts ...Option) error {
+	return NewApp(opts...).Run(context.Background())
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/config/configtest"
+	"goProjectTmpl/lifecycle"
+)
+
+// recorder 记录模块各阶段的调用顺序
+type recorder struct {
+	lock  sync.Mutex
+	calls []string
+}
+
+func (r *recorder) add(call string) {
+	r.lock.Lock()
+	r.calls = append(r.calls, call)
+	r.lock.Unlock()
+}
+
+func (r *recorder) String() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return strings.Join(r.calls, ",")
+}
+
+type testModule struct {
+	name    string
+	rec     *recorder
+	initErr error
+	started chan struct{}
+	reloads chan []config.Change
+}
+
+func (m *testModule) Name() string { return m.name }
+
+func (m *testModule) Init(config.Config) error {
+	m.rec.add("init " + m.name)
+	return m.initErr
+}
+
+func (m *testModule) Start() error {
+	m.rec.add("start " + m.name)
+	if m.started != nil {
+		close(m.started)
+	}
+	return nil
+}
+
+func (m *testModule) Stop(context.Context) error {
+	m.rec.add("stop " + m.name)
+	return nil
+}
+
+func (m *testModule) Reload(_ config.Config, changes []config.Change) {
+	if m.reloads != nil {
+		m.reloads <- changes
+	}
+}
+
+// newTestApp 使用内存内容源中的配置创建App
+func newTestApp(t *testing.T, ms ...Module) (*App, *configtest.Provider) {
+	t.Helper()
+	p := configtest.NewProvider()
+	p.Set(configtest.DefaultPath, []byte("a: 1\n"))
+	a := NewApp(
+		WithConfigPath(configtest.DefaultPath),
+		WithLoadOptions(config.WithProvider(p.Name())),
+		WithLifecycle(lifecycle.NewManager(nil)),
+		WithModules(ms...),
+	)
+	return a, p
+}
+
+func TestRun(t *testing.T) {
+	rec := &recorder{}
+	first := &testModule{name: "first", rec: rec}
+	second := &testModule{name: "second", rec: rec, started: make(chan struct{}), reloads: make(chan []config.Change, 1)}
+	a, p := newTestApp(t, first, second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+	select {
+	case <-second.started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("expect the modules to start")
+	}
+	if got := a.Config().GetInt("a", 0); got != 1 {
+		t.Fatalf("expect a: 1, got %d", got)
+	}
+
+	// 配置变更传递给模块
+	p.Update(configtest.DefaultPath, []byte("a: 2\n"))
+	select {
+	case changes := <-second.reloads:
+		if len(changes) != 1 || changes[0].Key != "a" {
+			t.Fatalf("unexpected changes %+v", changes)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expect the module to be reloaded")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// 按照注册顺序初始化、启动，按照相反顺序停止
+	if got := rec.String(); got != "init first,init second,start first,start second,stop second,stop first" {
+		t.Fatalf("unexpected calls %s", got)
+	}
+}
+
+func TestRunInitError(t *testing.T) {
+	rec := &recorder{}
+	a, _ := newTestApp(t, &testModule{name: "ok", rec: rec}, &testModule{name: "bad", rec: rec, initErr: errors.New("boom")})
+	err := a.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "failed to init module bad: boom") {
+		t.Fatalf("expect the init error, got %v", err)
+	}
+	if got := rec.String(); got != "init ok,init bad" {
+		t.Fatalf("expect no module to start, got %s", got)
+	}
+}
+
+func TestRunLoadError(t *testing.T) {
+	a := NewApp(WithConfigPath("not-exist.yaml"), WithLoadOptions(config.WithProvider(configtest.NewProvider().Name())), WithLifecycle(lifecycle.NewManager(nil)))
+	if err := a.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "failed to load not-exist.yaml") {
+		t.Fatalf("expect a load error, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+// Package breaker 熔断器
+// 依赖按照名字使用熔断器，熔断阈值、半开状态的探测请求数和熔断时间在框架配置的 breakers 配置段中声明，
+// 配置重新加载后原子替换，不影响熔断器当前的状态：
+//
+//	breakers:
+//	  default:              # 没有单独配置的依赖使用的策略
+//	    failures: 5         # 连续失败多少次后熔断，0表示不熔断
+//	    half_open_probes: 1 # 熔断时间结束后放行的探测请求数，全部成功后恢复
+//	    cooldown: 5000      # 毫秒，熔断时间
+//	  mysql:
+//	    failures: 3
+//
+//	set := breaker.NewSet(c)
+//	err := set.Get("mysql").Do(func() error { return query() })
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// ErrOpen 熔断器处于熔断状态，请求被拒绝
+var ErrOpen = errors.New("app/breaker: circuit open")
+
+// State 熔断器状态
+type State int
+
+// 熔断器状态
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Policy 熔断策略，时间单位为毫秒
+type Policy struct {
+	// Failures 连续失败多少次后熔断，0表示不熔断
+	Failures int `yaml:"failures" default:"5" validate:"min=0"`
+	// HalfOpenProbes 熔断时间结束后放行的探测请求数，全部成功后恢复，任意一个失败时重新熔断
+	HalfOpenProbes int `yaml:"half_open_probes" default:"1" validate:"min=1"`
+	// Cooldown 熔断时间
+	Cooldown int `yaml:"cooldown" default:"5000" validate:"min=0"`
+}
+
+// DefaultPolicy 默认的熔断策略
+var DefaultPolicy = Policy{Failures: 5, HalfOpenProbes: 1, Cooldown: 5000}
+
+// Breaker 熔断器
+type Breaker struct {
+	name string
+	// policy 当前的熔断策略，类型为 *Policy
+	policy atomic.Value
+
+	lock     sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	// probes 半开状态下已放行的探测请求数，successes 其中成功的请求数
+	probes    int
+	successes int
+}
+
+// New 创建熔断器
+func New(name string, p Policy) *Breaker {
+	b := &Breaker{name: name}
+	b.policy.Store(&p)
+	return b
+}
+
+// Name 熔断器名字
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Policy 当前的熔断策略
+func (b *Breaker) Policy() Policy {
+	return *b.policy.Load().(*Policy)
+}
+
+// SetPolicy 替换熔断策略，熔断器的状态保持不变
+func (b *Breaker) SetPolicy(p Policy) {
+	if *b.policy.Load().(*Policy) != p {
+		b.policy.Store(&p)
+	}
+}
+
+// State 熔断器当前的状态
+func (b *Breaker) State() State {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.advance(b.Policy(), time.Now())
+	return b.state
+}
+
+// advance 熔断时间结束后进入半开状态，调用方需要持有锁
+func (b *Breaker) advance(p Policy, now time.Time) {
+	if b.state == Open && now.Sub(b.openedAt) >= time.Duration(p.Cooldown)*time.Millisecond {
+		b.setState(HalfOpen)
+		b.probes, b.successes = 0, 0
+	}
+}
+
+func (b *Breaker) setState(s State) {
+	if b.state != s {
+		config.Infof("app/breaker: %s changed from %s to %s", b.name, b.state, s)
+		b.state = s
+	}
+}
+
+// Allow 是否放行请求，放行后需要调用 Done 记录结果
+func (b *Breaker) Allow() bool {
+	p := b.Policy()
+	if p.Failures <= 0 {
+		return true
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.advance(p, time.Now())
+	switch b.state {
+	case Open:
+		return false
+	case HalfOpen:
+		if b.probes >= p.HalfOpenProbes {
+			return false
+		}
+		b.probes++
+	}
+	return true
+}
+
+// Done 记录放行的请求的结果
+func (b *Breaker) Done(success bool) {
+	p := b.Policy()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	switch b.state {
+	case HalfOpen:
+		if !success {
+			b.trip()
+			return
+		}
+		if b.successes++; b.successes >= p.HalfOpenProbes {
+			b.failures = 0
+			b.setState(Closed)
+		}
+	case Closed:
+		if success {
+			b.failures = 0
+			return
+		}
+		if b.failures++; p.Failures > 0 && b.failures >= p.Failures {
+			b.trip()
+		}
+	}
+}
+
+// trip 进入熔断状态，调用方需要持有锁
+func (b *Breaker) trip() {
+	b.openedAt = time.Now()
+	b.setState(Open)
+}
+
+// Do 在熔断器的保护下执行fn，熔断时返回 ErrOpen，fn返回错误视为失败
+func (b *Breaker) Do(fn func() error) error {
+	if !b.Allow() {
+		return ErrOpen
+	}
+	err := fn()
+	b.Done(err == nil)
+	return err
+}
@@ -0,0 +1,83 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config/configtest"
+)
+
+var errFailed = errors.New("failed")
+
+func TestBreaker(t *testing.T) {
+	b := New("mysql", Policy{Failures: 2, HalfOpenProbes: 1, Cooldown: 20})
+	fail := func() error { return errFailed }
+	ok := func() error { return nil }
+
+	b.Do(fail)
+	b.Do(fail)
+	if b.State() != Open {
+		t.Fatalf("expect open after 2 failures, got %s", b.State())
+	}
+	if err := b.Do(ok); err != ErrOpen {
+		t.Fatalf("expect ErrOpen, got %v", err)
+	}
+
+	// 熔断时间结束后放行一个探测请求，失败时重新熔断
+	time.Sleep(30 * time.Millisecond)
+	if b.State() != HalfOpen {
+		t.Fatalf("expect half-open after cooldown, got %s", b.State())
+	}
+	b.Do(fail)
+	if b.State() != Open {
+		t.Fatalf("expect open after a failed probe, got %s", b.State())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if !b.Allow() || b.Allow() {
+		t.Fatal("expect exactly one probe in half-open state")
+	}
+	b.Done(true)
+	if b.State() != Closed {
+		t.Fatalf("expect closed after a successful probe, got %s", b.State())
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	b := New("redis", Policy{Failures: 0, HalfOpenProbes: 1})
+	for i := 0; i < 10; i++ {
+		b.Do(func() error { return errFailed })
+	}
+	if b.State() != Closed || !b.Allow() {
+		t.Fatal("expect a breaker with failures 0 never to open")
+	}
+}
+
+func TestSetReload(t *testing.T) {
+	c, p := configtest.Load(t, "breakers:\n  default:\n    failures: 3\n  mysql:\n    failures: 1\n")
+	s := NewSet(c)
+	if got := s.Get("mysql").Policy().Failures; got != 1 {
+		t.Fatalf("expect mysql failures 1, got %d", got)
+	}
+	if got := s.Get("redis").Policy().Failures; got != 3 {
+		t.Fatalf("expect the default policy for redis, got %d", got)
+	}
+
+	// 重新加载后更新策略，熔断器的状态保持不变
+	b := s.Get("mysql")
+	b.Do(func() error { return errFailed })
+	p.Update(configtest.DefaultPath, []byte("breakers:\n  mysql:\n    failures: 10\n"))
+	if s.Get("mysql") != b || b.Policy().Failures != 10 || b.State() != Open {
+		t.Fatalf("expect the same open breaker with failures 10, got %+v %s", b.Policy(), b.State())
+	}
+	if got := s.Get("redis").Policy(); got != DefaultPolicy {
+		t.Fatalf("expect DefaultPolicy after default is removed, got %+v", got)
+	}
+
+	// 解析失败时继续使用原来的策略
+	p.Update(configtest.DefaultPath, []byte("breakers:\n  mysql:\n    failures: x\n"))
+	if got := b.Policy().Failures; got != 10 {
+		t.Fatalf("expect the old policy to stay, got %d", got)
+	}
+}
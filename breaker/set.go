@@ -0,0 +1,76 @@
+package breaker
+
+import (
+	"sync"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中熔断配置段的key
+const SectionKey = "breakers"
+
+// DefaultName 没有单独配置的依赖使用的策略名
+const DefaultName = "default"
+
+// Set 按照名字管理依赖的熔断器
+type Set struct {
+	c        config.Config
+	lock     sync.RWMutex
+	policies map[string]Policy
+	breakers map[string]*Breaker
+}
+
+// NewSet 解析c中的 breakers 配置段，配置重新加载后更新全部熔断器的策略，配置段解析失败时继续使用原来的策略
+func NewSet(c config.Config) *Set {
+	s := &Set{c: c, policies: map[string]Policy{}, breakers: make(map[string]*Breaker)}
+	if err := s.reload(); err != nil {
+		config.Errorf("app/breaker: %v", err)
+	}
+	config.WatchSection(c, SectionKey, s.reload)
+	return s
+}
+
+func (s *Set) reload() error {
+	policies := make(map[string]Policy)
+	if s.c.IsSet(SectionKey) {
+		if err := s.c.UnmarshalKey(SectionKey, &policies); err != nil {
+			return err
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.policies = policies
+	for name, b := range s.breakers {
+		b.SetPolicy(s.policy(name))
+	}
+	return nil
+}
+
+// policy 名字对应的策略，调用方需要持有锁
+func (s *Set) policy(name string) Policy {
+	if p, ok := s.policies[name]; ok {
+		return p
+	}
+	if p, ok := s.policies[DefaultName]; ok {
+		return p
+	}
+	return DefaultPolicy
+}
+
+// Get 获取依赖的熔断器，没有时按照配置的策略创建
+func (s *Set) Get(name string) *Breaker {
+	s.lock.RLock()
+	b, ok := s.breakers[name]
+	s.lock.RUnlock()
+	if ok {
+		return b
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if b, ok = s.breakers[name]; !ok {
+		b = New(name, s.policy(name))
+		s.breakers[name] = b
+	}
+	return b
+}
@@ -0,0 +1,360 @@
+// Package bundle 配置包
+// 将一组配置文件和清单打包为 tar、tar.gz 或 zip 发布，加载时校验清单中每个文件的sha256和清单的签名，
+// Bundle 作为内容源注册到config，配置包中的路径即为配置文件的路径。配置包变化时整体校验通过后才替换，
+// 包含的全部配置文件同时切换到新版本，校验失败时继续使用原来的配置包：
+//
+//	bundle:
+//	  provider: file                 # 配置包所在的内容源，etcd等通过 config.RegisterProvider 注册
+//	  path: /etc/app/config.tar.gz
+//	  require_signature: true        # 配置包中必须包含清单的签名 manifest.yaml.sig
+//
+//	# manifest.yaml
+//	version: "20261015.1"
+//	files:
+//	  app.yaml: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//	  rules/limits.yaml: 60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752
+//
+//	b, err := bundle.Load(c, "bundle", "bundle", verifier)
+//	config.RegisterProvider(b)
+//	app, err := config.Load("app.yaml", config.WithProvider("bundle"), config.WithAutoReload())
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+
+	"goProjectTmpl/config"
+)
+
+const (
+	// ManifestName 配置包中清单的文件名
+	ManifestName = "manifest.yaml"
+	// SignatureName 配置包中清单签名的文件名
+	SignatureName = ManifestName + ".sig"
+)
+
+// maxBundleSize 解压后配置包的最大大小
+const maxBundleSize = 64 << 20
+
+var (
+	// ErrNotFound 配置包中没有该文件
+	ErrNotFound = errors.New("app/bundle: file not found in bundle")
+	// ErrChecksum 文件内容与清单中的sha256不同，或者文件没有在清单中列出
+	ErrChecksum = errors.New("app/bundle: checksum mismatch")
+	// ErrUnsigned 要求签名但配置包中没有清单的签名
+	ErrUnsigned = errors.New("app/bundle: bundle not signed")
+)
+
+// Verifier 校验清单的签名，例如 sign 包中的Ed25519、cosign实现
+type Verifier interface {
+	Verify(payload, signature []byte) error
+}
+
+// Source 配置包所在的内容源
+type Source struct {
+	Provider string `yaml:"provider" default:"file"`
+	Path     string `yaml:"path" validate:"required"`
+	// RequireSignature 配置包中必须包含清单的签名
+	RequireSignature bool `yaml:"require_signature"`
+}
+
+// Manifest 配置包清单
+type Manifest struct {
+	Version string `yaml:"version"`
+	// Files 配置包中的文件及其内容的sha256，十六进制
+	Files map[string]string `yaml:"files"`
+}
+
+// contents 一个版本的配置包
+type contents struct {
+	manifest *Manifest
+	files    map[string][]byte
+}
+
+// Bundle 配置包内容源，实现了 config.DataProvider 和 config.VersionedProvider
+type Bundle struct {
+	name string
+	src  Source
+	p    config.DataProvider
+	v    Verifier
+
+	// reloadLock 串行执行重新读取
+	reloadLock sync.Mutex
+	// cur 当前的配置包，类型为 *contents
+	cur atomic.Value
+
+	lock      sync.Mutex
+	callbacks []config.ProviderCallback
+}
+
+// Load 解析c中key对应的配置段并创建名字为name的配置包内容源，v为nil时不校验签名
+func Load(c config.Config, key, name string, v Verifier) (*Bundle, error) {
+	src := Source{Provider: "file"}
+	if err := c.UnmarshalKey(key, &src); err != nil {
+		return nil, fmt.Errorf("app/bundle: failed to parse %s: %v", key, err)
+	}
+	return New(name, src, v)
+}
+
+// New 读取并校验src中的配置包，监听配置包的变化
+func New(name string, src Source, v Verifier) (*Bundle, error) {
+	if src.Provider == "" {
+		src.Provider = "file"
+	}
+	if src.RequireSignature && v == nil {
+		return nil, errors.New("app/bundle: require_signature requires a verifier")
+	}
+	p := config.GetProvider(src.Provider)
+	if p == nil {
+		return nil, fmt.Errorf("app/bundle: %s: %v", src.Provider, config.ErrProviderNotExist)
+	}
+
+	b := &Bundle{name: name, src: src, p: p, v: v}
+	cur, err := b.read()
+	if err != nil {
+		return nil, err
+	}
+	b.cur.Store(cur)
+	p.Watch(b.onChange)
+	return b, nil
+}
+
+// Name provider名字
+func (b *Bundle) Name() string {
+	return b.name
+}
+
+// Read 读取配置包中的文件
+func (b *Bundle) Read(name string) ([]byte, error) {
+	data, _, err := b.ReadWithVersion(name)
+	return data, err
+}
+
+// ReadWithVersion 读取配置包中的文件，版本号为清单中的版本，同一配置包中的文件版本号相同
+func (b *Bundle) ReadWithVersion(name string) ([]byte, string, error) {
+	cur := b.current()
+	data, ok := cur.files[clean(name)]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return data, cur.manifest.Version, nil
+}
+
+// Watch 注册变化回调，配置包替换后对内容变化的每个文件调用
+func (b *Bundle) Watch(cb config.ProviderCallback) {
+	b.lock.Lock()
+	b.callbacks = append(b.callbacks, cb)
+	b.lock.Unlock()
+}
+
+// Manifest 当前配置包的清单
+func (b *Bundle) Manifest() Manifest {
+	return *b.current().manifest
+}
+
+func (b *Bundle) current() *contents {
+	return b.cur.Load().(*contents)
+}
+
+// read 从内容源读取并校验配置包
+func (b *Bundle) read() (*contents, error) {
+	data, err := b.p.Read(b.src.Path)
+	if err != nil {
+		return nil, fmt.Errorf("app/bundle: failed to read %s: %v", b.src.Path, err)
+	}
+	cur, signed, err := open(data, b.v)
+	if err != nil {
+		return nil, fmt.Errorf("app/bundle: %s: %w", b.src.Path, err)
+	}
+	if b.src.RequireSignature && !signed {
+		return nil, fmt.Errorf("%w: %s", ErrUnsigned, b.src.Path)
+	}
+	return cur, nil
+}
+
+// onChange 配置包变化时整体校验后替换，之后对内容变化的文件调用回调
+func (b *Bundle) onChange(p string, _ []byte) {
+	if p != b.src.Path {
+		return
+	}
+	b.reloadLock.Lock()
+	defer b.reloadLock.Unlock()
+	next, err := b.read()
+	if err != nil {
+		config.Warnf("app/bundle: failed to reload, keep using the old bundle: %v", err)
+		return
+	}
+	old := b.current()
+	b.cur.Store(next)
+	if old.manifest.Version != next.manifest.Version {
+		config.Infof("app/bundle: %s switched from %s to %s", b.name, old.manifest.Version, next.manifest.Version)
+	}
+
+	b.lock.Lock()
+	callbacks := append([]config.ProviderCallback(nil), b.callbacks...)
+	b.lock.Unlock()
+	for name, data := range next.files {
+		if prev, ok := old.files[name]; ok && bytes.Equal(prev, data) {
+			continue
+		}
+		for _, cb := range callbacks {
+			cb(name, data)
+		}
+	}
+}
+
+// open 解包配置包并校验清单，v不为nil且配置包中包含签名时校验签名，signed表示签名校验通过
+func open(data []byte, v Verifier) (cur *contents, signed bool, err error) {
+	files, err := unpack(data)
+	if err != nil {
+		return nil, false, err
+	}
+	raw, ok := files[ManifestName]
+	if !ok {
+		return nil, false, fmt.Errorf("%w: %s", ErrNotFound, ManifestName)
+	}
+	if sig, ok := files[SignatureName]; ok && v != nil {
+		if err := v.Verify(raw, sig); err != nil {
+			return nil, false, fmt.Errorf("app/bundle: invalid signature: %v", err)
+		}
+		signed = true
+	}
+	delete(files, ManifestName)
+	delete(files, SignatureName)
+
+	m := &Manifest{}
+	if err := yaml.Unmarshal(raw, m); err != nil {
+		return nil, false, fmt.Errorf("app/bundle: invalid manifest: %v", err)
+	}
+	if m.Version == "" {
+		return nil, false, errors.New("app/bundle: manifest version required")
+	}
+	listed := make(map[string]bool, len(m.Files))
+	for name, sum := range m.Files {
+		content, ok := files[clean(name)]
+		if !ok {
+			return nil, false, fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		actual := sha256.Sum256(content)
+		if !strings.EqualFold(hex.EncodeToString(actual[:]), sum) {
+			return nil, false, fmt.Errorf("%w: %s", ErrChecksum, name)
+		}
+		listed[clean(name)] = true
+	}
+	// 清单之外的文件没有经过签名，不能作为配置使用
+	for name := range files {
+		if !listed[name] {
+			return nil, false, fmt.Errorf("%w: %s not listed in manifest", ErrChecksum, name)
+		}
+	}
+	return &contents{manifest: m, files: files}, signed, nil
+}
+
+// unpack 根据文件头识别 zip、tar.gz 或 tar，返回配置包中的全部普通文件
+func unpack(data []byte) (map[string][]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return unzip(data)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("app/bundle: invalid gzip: %v", err)
+		}
+		defer zr.Close()
+		return untar(zr)
+	default:
+		return untar(bytes.NewReader(data))
+	}
+}
+
+func untar(r io.Reader) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	var total int64
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("app/bundle: invalid tar: %v", err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if total += h.Size; total > maxBundleSize {
+			return nil, fmt.Errorf("app/bundle: bundle larger than %d bytes", maxBundleSize)
+		}
+		if err := add(files, h.Name, tr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func unzip(data []byte) (map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("app/bundle: invalid zip: %v", err)
+	}
+	files := make(map[string][]byte)
+	var total uint64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if total += f.UncompressedSize64; total > maxBundleSize {
+			return nil, fmt.Errorf("app/bundle: bundle larger than %d bytes", maxBundleSize)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("app/bundle: %s: %v", f.Name, err)
+		}
+		err = add(files, f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// add 读取文件内容，拒绝重复的文件和配置包之外的路径
+func add(files map[string][]byte, name string, r io.Reader) error {
+	key := clean(name)
+	if key == "" {
+		return fmt.Errorf("app/bundle: invalid file name %q", name)
+	}
+	if _, ok := files[key]; ok {
+		return fmt.Errorf("app/bundle: duplicate file %s", key)
+	}
+	// 头部中的大小不可信，读取时再次限制
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxBundleSize+1))
+	if err != nil {
+		return fmt.Errorf("app/bundle: %s: %v", key, err)
+	}
+	if len(data) > maxBundleSize {
+		return fmt.Errorf("app/bundle: bundle larger than %d bytes", maxBundleSize)
+	}
+	files[key] = data
+	return nil
+}
+
+// clean 配置包中的相对路径，去掉开头的 / 、./ 和超出配置包的 ..
+func clean(name string) string {
+	name = path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	return strings.TrimPrefix(name, "/")
+}
@@ -0,0 +1,208 @@
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/config/configtest"
+)
+
+// verifierFunc 测试用的签名校验，签名等于 "ok" 时通过
+type verifierFunc func(payload, signature []byte) error
+
+func (f verifierFunc) Verify(payload, signature []byte) error {
+	return f(payload, signature)
+}
+
+var okVerifier = verifierFunc(func(_, sig []byte) error {
+	if string(sig) != "ok" {
+		return errors.New("bad signature")
+	}
+	return nil
+})
+
+// manifest 生成列出files全部文件的清单
+func manifest(version string, files map[string]string) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	m := "version: \"" + version + "\"\nfiles:\n"
+	for _, name := range names {
+		sum := sha256.Sum256([]byte(files[name]))
+		m += fmt.Sprintf("  %s: %s\n", name, hex.EncodeToString(sum[:]))
+	}
+	return m
+}
+
+// tarGz 将files打包为tar.gz，files中不包含清单时自动生成
+func tarGz(t *testing.T, version string, files map[string]string) []byte {
+	t.Helper()
+	all := map[string]string{ManifestName: manifest(version, files)}
+	for name, data := range files {
+		all[name] = data
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for name, data := range all {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(data))
+	}
+	tw.Close()
+	zw.Close()
+	return buf.Bytes()
+}
+
+func zipOf(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(data))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestLoad(t *testing.T) {
+	up := configtest.NewProvider()
+	up.Set("config.tar.gz", tarGz(t, "v1", map[string]string{"app.yaml": "a: 1\n", "rules/limits.yaml": "qps: 10\n"}))
+	c, _ := configtest.Load(t, "bundle:\n  provider: "+up.Name()+"\n  path: config.tar.gz\n")
+	b, err := Load(c, "bundle", "bundle-load", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, version, err := b.ReadWithVersion("./rules/limits.yaml")
+	if err != nil || string(data) != "qps: 10\n" || version != "v1" {
+		t.Fatalf("expect qps: 10 of v1, got %q %s %v", data, version, err)
+	}
+	if _, err := b.Read("other.yaml"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect ErrNotFound, got %v", err)
+	}
+
+	// 作为内容源加载配置
+	config.RegisterProvider(b)
+	app, err := config.Load("app.yaml", config.WithProvider("bundle-load"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := app.GetInt("a", 0); got != 1 {
+		t.Fatalf("expect a: 1, got %d", got)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	files := map[string]string{"app.yaml": "a: 1\n"}
+	tests := []struct {
+		name  string
+		data  map[string]string
+		check error
+	}{
+		{"valid", map[string]string{ManifestName: manifest("v1", files), "app.yaml": "a: 1\n"}, nil},
+		{"checksum mismatch", map[string]string{ManifestName: manifest("v1", files), "app.yaml": "a: 2\n"}, ErrChecksum},
+		{"unlisted file", map[string]string{ManifestName: manifest("v1", files), "app.yaml": "a: 1\n", "extra.yaml": "b: 1\n"}, ErrChecksum},
+		{"missing file", map[string]string{ManifestName: manifest("v1", files)}, ErrNotFound},
+		{"missing manifest", map[string]string{"app.yaml": "a: 1\n"}, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := open(zipOf(t, tt.data), nil)
+			if tt.check == nil && err != nil {
+				t.Fatal(err)
+			}
+			if tt.check != nil && !errors.Is(err, tt.check) {
+				t.Fatalf("expect %v, got %v", tt.check, err)
+			}
+		})
+	}
+
+	if _, _, err := open(zipOf(t, map[string]string{ManifestName: "files: {}\n"}), nil); err == nil {
+		t.Fatal("expect an error for a manifest without version")
+	}
+}
+
+func TestSignature(t *testing.T) {
+	files := map[string]string{"app.yaml": "a: 1\n"}
+	up := configtest.NewProvider()
+	src := Source{Provider: up.Name(), Path: "config.zip", RequireSignature: true}
+
+	if _, err := New("bundle-sign", src, nil); err == nil {
+		t.Fatal("expect an error for require_signature without a verifier")
+	}
+
+	up.Set("config.zip", zipOf(t, map[string]string{ManifestName: manifest("v1", files), "app.yaml": "a: 1\n"}))
+	if _, err := New("bundle-sign", src, okVerifier); !errors.Is(err, ErrUnsigned) {
+		t.Fatalf("expect ErrUnsigned, got %v", err)
+	}
+	up.Set("config.zip", zipOf(t, map[string]string{ManifestName: manifest("v1", files), SignatureName: "bad", "app.yaml": "a: 1\n"}))
+	if _, err := New("bundle-sign", src, okVerifier); err == nil {
+		t.Fatal("expect an invalid signature error")
+	}
+	up.Set("config.zip", zipOf(t, map[string]string{ManifestName: manifest("v1", files), SignatureName: "ok", "app.yaml": "a: 1\n"}))
+	b, err := New("bundle-sign", src, okVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 签名不作为配置文件读取
+	if _, err := b.Read(SignatureName); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect ErrNotFound for the signature, got %v", err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	up := configtest.NewProvider()
+	up.Set("config.tar.gz", tarGz(t, "v1", map[string]string{"app.yaml": "a: 1\n", "db.yaml": "host: a\n"}))
+	b, err := New("bundle-reload", Source{Provider: up.Name(), Path: "config.tar.gz"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changed []string
+	b.Watch(func(path string, data []byte) { changed = append(changed, path+" "+string(data)) })
+
+	// 只回调内容变化的文件
+	up.Update("config.tar.gz", tarGz(t, "v2", map[string]string{"app.yaml": "a: 2\n", "db.yaml": "host: a\n"}))
+	if len(changed) != 1 || changed[0] != "app.yaml a: 2\n" {
+		t.Fatalf("expect only app.yaml to change, got %q", changed)
+	}
+	if got := b.Manifest().Version; got != "v2" {
+		t.Fatalf("expect v2, got %s", got)
+	}
+
+	// 校验失败时继续使用原来的配置包
+	up.Update("config.tar.gz", []byte("not a bundle"))
+	if got := b.Manifest().Version; got != "v2" || len(changed) != 1 {
+		t.Fatalf("expect v2 to be kept, got %s", got)
+	}
+}
+
+func TestClean(t *testing.T) {
+	for name, want := range map[string]string{
+		"app.yaml":         "app.yaml",
+		"./rules/a.yaml":   "rules/a.yaml",
+		"/abs/a.yaml":      "abs/a.yaml",
+		"../../etc/passwd": "etc/passwd",
+		"rules\\a.yaml":    "rules/a.yaml",
+	} {
+		if got := clean(name); got != want {
+			t.Errorf("%s: expect %s, got %s", name, want, got)
+		}
+	}
+}
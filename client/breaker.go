@@ -0,0 +1,20 @@
+package client
+
+import (
+	"sync/atomic"
+
+	"goProjectTmpl/breaker"
+)
+
+// targetState 单个后端在配置重新加载后需要保留的状态
+type targetState struct {
+	breaker *breaker.Breaker
+	// next 轮询endpoint的计数
+	next uint64
+}
+
+// endpoint 轮询选择endpoint
+func (s *targetState) endpoint(eps []string) string {
+	i := atomic.AddUint64(&s.next, 1) - 1
+	return eps[i%uint64(len(eps))]
+}
@@ -0,0 +1,144 @@
+// Package client 根据框架配置中的 client 配置段调用后端
+// 每个后端的地址、超时、重试、熔断阈值在配置重新加载后原子替换，调整后端调用参数不需要重新发布：
+//
+//	app.Register(client.DefaultClient)
+//
+//	err := client.Invoke(ctx, "app.redis.business.master", func(ctx context.Context, endpoint string) error {
+//		return call(ctx, endpoint)
+//	})
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"goProjectTmpl/breaker"
+	"goProjectTmpl/config"
+)
+
+var (
+	// ErrTargetNotFound client 配置段中不存在该后端
+	ErrTargetNotFound = errors.New("app/client: target not found")
+	// ErrCircuitOpen 后端已熔断
+	ErrCircuitOpen = errors.New("app/client: circuit open")
+	// ErrNotInitialized Client 尚未初始化
+	ErrNotInitialized = errors.New("app/client: not initialized")
+)
+
+// Client 后端调用，实现了app的Module接口
+type Client struct {
+	binding *config.Binding
+	lock    sync.RWMutex
+	// states 按照后端名字保存的熔断器和轮询状态，类型为 *targetState
+	states sync.Map
+}
+
+// New 创建Client，Init 之后才能使用
+func New() *Client {
+	return &Client{}
+}
+
+// DefaultClient 默认的Client
+var DefaultClient = New()
+
+// Name 模块名
+func (c *Client) Name() string {
+	return "client"
+}
+
+// Init 绑定 client 配置段，重新加载时新配置通过校验后替换
+func (c *Client) Init(cfg config.Config) error {
+	b, err := cfg.Bind(SectionKey, &Config{}, index)
+	if err != nil {
+		return fmt.Errorf("app/client: failed to bind %s: %v", SectionKey, err)
+	}
+	c.lock.Lock()
+	c.binding = b
+	c.lock.Unlock()
+	return nil
+}
+
+// config 当前生效的配置
+func (c *Client) config() *Config {
+	c.lock.RLock()
+	b := c.binding
+	c.lock.RUnlock()
+	if b == nil {
+		return nil
+	}
+	return b.Load().(*Config)
+}
+
+// Target 获取后端当前生效的配置，返回值不可修改
+func (c *Client) Target(name string) (*TargetConfig, error) {
+	cfg := c.config()
+	if cfg == nil {
+		return nil, ErrNotInitialized
+	}
+	t, ok := cfg.targets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTargetNotFound, name)
+	}
+	return t, nil
+}
+
+// Invoke 调用后端，按照后端配置设置超时时间、轮询endpoint、失败重试并熔断
+// 每次重试都读取当前生效的配置，ctx结束时不再重试
+func (c *Client) Invoke(ctx context.Context, name string, call func(ctx context.Context, endpoint string) error) error {
+	t, err := c.Target(name)
+	if err != nil {
+		return err
+	}
+	v, ok := c.states.Load(name)
+	if !ok {
+		v, _ = c.states.LoadOrStore(name, &targetState{breaker: breaker.New(name, t.Breaker.policy())})
+	}
+	st := v.(*targetState)
+
+	for attempt := 0; ; attempt++ {
+		st.breaker.SetPolicy(t.Breaker.policy())
+		if !st.breaker.Allow() {
+			return fmt.Errorf("%w: %s", ErrCircuitOpen, name)
+		}
+		err = invoke(ctx, t, st.endpoint(t.Endpoints()), call)
+		st.breaker.Done(err == nil)
+		if err == nil || attempt >= t.Retries || ctx.Err() != nil {
+			return err
+		}
+		if t.RetryBackoff > 0 {
+			timer := time.NewTimer(time.Duration(t.RetryBackoff) * time.Millisecond)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+		if t, err = c.Target(name); err != nil {
+			return err
+		}
+	}
+}
+
+// invoke 按照后端的超时时间调用一次
+func invoke(ctx context.Context, t *TargetConfig, endpoint string, call func(ctx context.Context, endpoint string) error) error {
+	if d := t.TimeoutDuration(); d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	return call(ctx, endpoint)
+}
+
+// Target 获取默认Client中后端当前生效的配置
+func Target(name string) (*TargetConfig, error) {
+	return DefaultClient.Target(name)
+}
+
+// Invoke 使用默认Client调用后端
+func Invoke(ctx context.Context, name string, call func(ctx context.Context, endpoint string) error) error {
+	return DefaultClient.Invoke(ctx, name, call)
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"goProjectTmpl/config/configtest"
+)
+
+const clientYAML = `client:
+  timeout: 800
+  namespace: Production
+  service:
+    - name: redis
+      target: ip://127.0.0.1:6380, 127.0.0.1:6381
+      retries: 2
+    - name: db
+      namespace: Development
+      target: dsn://root@tcp(127.0.0.1:3306)/app
+      timeout: 100
+      breaker:
+        failures: 2
+        open_timeout: 60000
+`
+
+func newClient(t *testing.T) (*Client, *configtest.Provider) {
+	t.Helper()
+	c, p := configtest.Load(t, clientYAML)
+	cli := New()
+	if err := cli.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	return cli, p
+}
+
+func TestTarget(t *testing.T) {
+	if _, err := New().Target("redis"); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("expect ErrNotInitialized, got %v", err)
+	}
+	cli, _ := newClient(t)
+	redis, err := cli.Target("redis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 未配置的 timeout、namespace 使用全局配置
+	if redis.Timeout != 800 || redis.Namespace != "Production" || redis.Scheme() != "ip" {
+		t.Fatalf("unexpected target %+v", redis)
+	}
+	if eps := redis.Endpoints(); len(eps) != 2 || eps[1] != "127.0.0.1:6381" {
+		t.Fatalf("unexpected endpoints %v", eps)
+	}
+	db, _ := cli.Target("db")
+	if db.Timeout != 100 || db.Namespace != "Development" || db.Endpoints()[0] != "root@tcp(127.0.0.1:3306)/app" {
+		t.Fatalf("unexpected target %+v", db)
+	}
+	if _, err := cli.Target("other"); !errors.Is(err, ErrTargetNotFound) {
+		t.Fatalf("expect ErrTargetNotFound, got %v", err)
+	}
+}
+
+func TestInvokeRetry(t *testing.T) {
+	cli, _ := newClient(t)
+	var endpoints []string
+	err := cli.Invoke(context.Background(), "redis", func(ctx context.Context, endpoint string) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expect a deadline")
+		}
+		endpoints = append(endpoints, endpoint)
+		if len(endpoints) < 3 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每次重试轮询下一个endpoint
+	if got := strings.Join(endpoints, ","); got != "127.0.0.1:6380,127.0.0.1:6381,127.0.0.1:6380" {
+		t.Fatalf("unexpected endpoints %s", got)
+	}
+}
+
+func TestInvokeBreaker(t *testing.T) {
+	cli, _ := newClient(t)
+	fail := func(context.Context, string) error { return errors.New("failed") }
+	for i := 0; i < 2; i++ {
+		if err := cli.Invoke(context.Background(), "db", fail); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("attempt %d: expect the call error, got %v", i, err)
+		}
+	}
+	called := false
+	err := cli.Invoke(context.Background(), "db", func(context.Context, string) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrCircuitOpen) || called {
+		t.Fatalf("expect ErrCircuitOpen without calling, got %v", err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	cli, p := newClient(t)
+	p.Update(configtest.DefaultPath, []byte("client:\n  service:\n    - name: redis\n      target: ip://127.0.0.1:7000\n"))
+	redis, err := cli.Target("redis")
+	if err != nil || redis.Endpoints()[0] != "127.0.0.1:7000" {
+		t.Fatalf("expect the new target, got %v", err)
+	}
+	if _, err := cli.Target("db"); !errors.Is(err, ErrTargetNotFound) {
+		t.Fatalf("expect db to be removed, got %v", err)
+	}
+
+	// 检查失败的配置不会替换当前配置
+	p.Update(configtest.DefaultPath, []byte("client:\n  service:\n    - name: redis\n      target: ip://127.0.0.1:7001\n    - name: redis\n      target: ip://127.0.0.1:7002\n"))
+	if redis, _ := cli.Target("redis"); redis.Endpoints()[0] != "127.0.0.1:7000" {
+		t.Fatalf("expect the duplicate config to be rejected, got %v", redis.Endpoints())
+	}
+	p.Update(configtest.DefaultPath, []byte("client:\n  service:\n    - name: redis\n      target: \"ip://,\"\n"))
+	if redis, _ := cli.Target("redis"); redis.Endpoints()[0] != "127.0.0.1:7000" {
+		t.Fatalf("expect the config without endpoints to be rejected, got %v", redis.Endpoints())
+	}
+}
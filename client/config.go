@@ -0,0 +1,117 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"goProjectTmpl/breaker"
+)
+
+// SectionKey 框架配置中客户端配置段的key
+const SectionKey = "client"
+
+// Config 客户端配置，时间单位为毫秒
+type Config struct {
+	// Timeout 针对所有后端的请求最长处理时间
+	Timeout int `yaml:"timeout"`
+	// Namespace 针对所有后端的环境
+	Namespace string `yaml:"namespace"`
+	// Filter 针对所有后端调用函数前后的拦截器列表
+	Filter []string `yaml:"filter"`
+	// Service 针对单个后端的配置
+	Service []*TargetConfig `yaml:"service"`
+
+	// targets 按照名字索引的后端配置，在绑定值发布前建立，与配置一起原子替换
+	targets map[string]*TargetConfig
+}
+
+// TargetConfig 单个后端的配置，未配置的 timeout、namespace 使用全局配置
+type TargetConfig struct {
+	Name      string `yaml:"name" validate:"required"`
+	Namespace string `yaml:"namespace"`
+	Network   string `yaml:"network" default:"tcp"`
+	Protocol  string `yaml:"protocol"`
+	// Target 后端地址，格式为 scheme://endpoint，ip协议可以用逗号分隔多个地址，例如 ip://127.0.0.1:6380,127.0.0.1:6381
+	Target   string `yaml:"target" validate:"required"`
+	Password string `yaml:"password"`
+	Timeout  int    `yaml:"timeout"`
+	// Retries 失败后的重试次数
+	Retries int `yaml:"retries" validate:"min=0"`
+	// RetryBackoff 重试前的等待时间
+	RetryBackoff int `yaml:"retry_backoff" validate:"min=0"`
+	// Breaker 熔断配置
+	Breaker BreakerConfig `yaml:"breaker"`
+}
+
+// BreakerConfig 熔断配置，Failures为0时不熔断
+type BreakerConfig struct {
+	// Failures 连续失败多少次后熔断
+	Failures int `yaml:"failures" validate:"min=0"`
+	// OpenTimeout 熔断持续时间，之后放行 HalfOpenProbes 个请求探测后端是否恢复
+	OpenTimeout int `yaml:"open_timeout" default:"5000" validate:"min=0"`
+	// HalfOpenProbes 熔断结束后放行的探测请求数，全部成功后恢复
+	HalfOpenProbes int `yaml:"half_open_probes" default:"1" validate:"min=1"`
+}
+
+// policy 转换为熔断器的策略
+func (b BreakerConfig) policy() breaker.Policy {
+	probes := b.HalfOpenProbes
+	if probes < 1 {
+		probes = 1
+	}
+	return breaker.Policy{Failures: b.Failures, HalfOpenProbes: probes, Cooldown: b.OpenTimeout}
+}
+
+// Scheme 后端地址的scheme，例如 ip、dsn
+func (t *TargetConfig) Scheme() string {
+	if i := strings.Index(t.Target, "://"); i >= 0 {
+		return t.Target[:i]
+	}
+	return ""
+}
+
+// Endpoints 后端地址去掉scheme后的地址列表，只有ip协议按照逗号拆分
+func (t *TargetConfig) Endpoints() []string {
+	addr := t.Target
+	if i := strings.Index(addr, "://"); i >= 0 {
+		addr = addr[i+3:]
+	}
+	if t.Scheme() != "ip" {
+		return []string{addr}
+	}
+	var eps []string
+	for _, ep := range strings.Split(addr, ",") {
+		if ep = strings.TrimSpace(ep); ep != "" {
+			eps = append(eps, ep)
+		}
+	}
+	return eps
+}
+
+// TimeoutDuration 单次请求的超时时间，0表示不限制
+func (t *TargetConfig) TimeoutDuration() time.Duration {
+	return time.Duration(t.Timeout) * time.Millisecond
+}
+
+// index 使用全局配置补全后端配置并建立索引，作为绑定的检查函数在新配置发布前执行
+func index(v interface{}) error {
+	cfg := v.(*Config)
+	cfg.targets = make(map[string]*TargetConfig, len(cfg.Service))
+	for _, t := range cfg.Service {
+		if _, ok := cfg.targets[t.Name]; ok {
+			return fmt.Errorf("duplicate service %s", t.Name)
+		}
+		if t.Timeout == 0 {
+			t.Timeout = cfg.Timeout
+		}
+		if t.Namespace == "" {
+			t.Namespace = cfg.Namespace
+		}
+		if len(t.Endpoints()) == 0 {
+			return fmt.Errorf("service %s has no endpoint in target %q", t.Name, t.Target)
+		}
+		cfg.targets[t.Name] = t
+	}
+	return nil
+}
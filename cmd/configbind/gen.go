@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+var tmpl = template.Must(template.New("configbind").Funcs(template.FuncMap{
+	"ident": ident,
+}).Parse(`// Code generated by configbind. DO NOT EDIT.
+
+package {{.Pkg}}
+
+import (
+	"reflect"
+	"sync"
+
+	"goProjectTmpl/config"
+)
+{{range .Types}}{{$load := ident .Name "Load" ""}}{{$bind := ident .Name "Bind" ""}}{{$binding := ident .Name "" "Binding"}}
+// {{$load}} 解码c中的 {{.Key}} 配置段并校验{{if .Check}}，然后执行 Check{{end}}，返回值不会随配置重新加载更新
+func {{$load}}(c config.Config) (*{{.Name}}, error) {
+	v := &{{.Name}}{}
+	if err := c.UnmarshalKey({{printf "%q" .Key}}, v); err != nil {
+		return nil, err
+	}
+{{- if .Check}}
+	if err := v.Check(); err != nil {
+		return nil, err
+	}
+{{- end}}
+	return v, nil
+}
+
+// {{$binding}} 绑定到 {{.Key}} 配置段的 {{.Name}}，配置重新加载成功后原子替换
+type {{$binding}} struct {
+	b *config.Binding
+
+	lock     sync.Mutex
+	last     *{{.Name}}
+	watchers []func(old, new *{{.Name}})
+}
+
+// {{$bind}} 将c中的 {{.Key}} 配置段绑定到 {{.Name}}，
+// 重新加载时新配置解码、校验{{if .Check}}或 Check {{end}}失败会拒绝整个配置并继续使用原来的值
+func {{$bind}}(c config.Config) (*{{$binding}}, error) {
+	b, err := c.Bind({{printf "%q" .Key}}, &{{.Name}}{}{{if .Check}}, func(v interface{}) error {
+		return v.(*{{.Name}}).Check()
+	}{{end}})
+	if err != nil {
+		return nil, err
+	}
+	x := &{{$binding}}{b: b, last: b.Load().(*{{.Name}})}
+	c.OnChange(x.onChange)
+	return x, nil
+}
+
+// Load 当前的配置，调用方不能修改返回值
+func (x *{{$binding}}) Load() *{{.Name}} {
+	return x.b.Load().(*{{.Name}})
+}
+
+// Watch 注册回调，{{.Key}} 配置段变化时按注册顺序以旧值和新值调用
+func (x *{{$binding}}) Watch(fn func(old, new *{{.Name}})) {
+	x.lock.Lock()
+	x.watchers = append(x.watchers, fn)
+	x.lock.Unlock()
+}
+
+// onChange 重新加载后每个绑定都会替换为新的实例，只有内容变化时才调用回调
+func (x *{{$binding}}) onChange([]config.Change) {
+	cur := x.Load()
+	x.lock.Lock()
+	old := x.last
+	x.last = cur
+	watchers := append(([]func(old, new *{{.Name}}))(nil), x.watchers...)
+	x.lock.Unlock()
+	if old == cur || reflect.DeepEqual(old, cur) {
+		return
+	}
+	for _, fn := range watchers {
+		fn(old, cur)
+	}
+}
+{{end}}`))
+
+// generate 生成pkg包中types的绑定代码
+func generate(pkg string, types []*bindType) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]interface{}{"Pkg": pkg, "Types": types}); err != nil {
+		return nil, err
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %v", err)
+	}
+	return code, nil
+}
+
+// ident 生成的标识符，与name的导出状态相同，例如 ident("settings", "Load", "") 返回 loadSettings
+func ident(name, prefix, suffix string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	exported := unicode.IsUpper(r)
+	if prefix == "" {
+		return name + suffix
+	}
+	base := string(unicode.ToUpper(r)) + name[size:]
+	if !exported {
+		p, psize := utf8.DecodeRuneInString(prefix)
+		prefix = string(unicode.ToLower(p)) + prefix[psize:]
+	}
+	return prefix + base + suffix
+}
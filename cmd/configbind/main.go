@@ -0,0 +1,69 @@
+// configbind 为带有 //config:bind 注释的结构体生成加载、绑定和监听配置变化的代码
+//
+//	//go:generate go run goProjectTmpl/cmd/configbind
+//
+//	// Settings 模块配置
+//	//config:bind mymodule
+//	type Settings struct {
+//		Timeout int `yaml:"timeout" default:"1000" validate:"min=1"`
+//	}
+//
+//	// Check 可选，跨字段的检查，重新加载时检查失败会拒绝整个配置
+//	func (s *Settings) Check() error
+//
+// 为 Settings 生成：
+//
+//	LoadSettings(c) (*Settings, error)        解码并校验一次
+//	BindSettings(c) (*SettingsBinding, error) 绑定到配置，重新加载成功后原子替换
+//	(*SettingsBinding).Load() *Settings       读取当前的配置
+//	(*SettingsBinding).Watch(fn)              配置段变化时以旧值和新值调用fn
+//
+// 未导出的结构体生成未导出的函数和类型，例如 settings 生成 loadSettings、bindSettings、settingsBinding
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// defaultOutput 默认的输出文件名
+const defaultOutput = "configbind_gen.go"
+
+func main() {
+	fs := flag.NewFlagSet("configbind", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "package directory")
+	out := fs.String("o", defaultOutput, "output file, relative to the package directory")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: configbind [flags]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+
+	if err := run(*dir, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "configbind: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, out string) error {
+	if !filepath.IsAbs(out) {
+		out = filepath.Join(dir, out)
+	}
+	pkg, types, err := scan(dir, filepath.Base(out))
+	if err != nil {
+		return err
+	}
+	if len(types) == 0 {
+		return fmt.Errorf("no struct annotated with %s in %s", directive, dir)
+	}
+	code, err := generate(pkg, types)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(out, code, 0644)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// directive 标记需要生成绑定代码的结构体，后面是配置段的key，为空时绑定整个配置
+const directive = "//config:bind"
+
+// bindType 需要生成绑定代码的结构体
+type bindType struct {
+	Name string
+	Key  string
+	// Check 结构体或其指针有 Check() error 方法
+	Check bool
+}
+
+// scan 解析dir中的go文件，返回包名和带有 directive 注释的结构体，skip为生成的文件名
+func scan(dir, skip string) (string, []*bindType, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	fset := token.NewFileSet()
+	pkg := ""
+	var types []*bindType
+	checks := make(map[string]bool)
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == skip {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return "", nil, err
+		}
+		if pkg == "" {
+			pkg = f.Name.Name
+		} else if pkg != f.Name.Name {
+			return "", nil, fmt.Errorf("multiple packages in %s: %s, %s", dir, pkg, f.Name.Name)
+		}
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				types = append(types, annotated(d)...)
+			case *ast.FuncDecl:
+				if recv := checkReceiver(d); recv != "" {
+					checks[recv] = true
+				}
+			}
+		}
+	}
+	for _, t := range types {
+		t.Check = checks[t.Name]
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Name < types[j].Name
+	})
+	return pkg, types, nil
+}
+
+// annotated 返回声明中带有 directive 注释的结构体
+// 注释可以写在 type 关键字上方，也可以写在括号中的单个类型上方
+func annotated(d *ast.GenDecl) []*bindType {
+	if d.Tok != token.TYPE {
+		return nil
+	}
+	var types []*bindType
+	for _, spec := range d.Specs {
+		ts := spec.(*ast.TypeSpec)
+		if _, ok := ts.Type.(*ast.StructType); !ok {
+			continue
+		}
+		doc := ts.Doc
+		if doc == nil && len(d.Specs) == 1 {
+			doc = d.Doc
+		}
+		if key, ok := bindKey(doc); ok {
+			types = append(types, &bindType{Name: ts.Name.Name, Key: key})
+		}
+	}
+	return types
+}
+
+func bindKey(doc *ast.CommentGroup) (string, bool) {
+	if doc == nil {
+		return "", false
+	}
+	for _, c := range doc.List {
+		if c.Text == directive || strings.HasPrefix(c.Text, directive+" ") {
+			return strings.TrimSpace(strings.TrimPrefix(c.Text, directive)), true
+		}
+	}
+	return "", false
+}
+
+// checkReceiver 方法为 Check() error 时返回接收者的类型名
+func checkReceiver(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) != 1 || d.Name.Name != "Check" {
+		return ""
+	}
+	if d.Type.Params.NumFields() != 0 || d.Type.Results.NumFields() != 1 {
+		return ""
+	}
+	if res, ok := d.Type.Results.List[0].Type.(*ast.Ident); !ok || res.Name != "error" {
+		return ""
+	}
+	recv := d.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if id, ok := recv.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const source = `package settings
+
+//config:bind server
+type Server struct {
+	Port int
+}
+
+func (s *Server) Check() error { return nil }
+
+type (
+	//config:bind
+	root struct{}
+
+	// other 没有注释的结构体不生成代码
+	other struct{}
+)
+
+// Check 参数不匹配的方法不算作校验方法
+func (other) Check(int) error { return nil }
+`
+
+// writeDir 在临时目录中写入go文件
+func writeDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "configbind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestScan(t *testing.T) {
+	dir := writeDir(t, map[string]string{
+		"settings.go":      source,
+		"settings_test.go": "package settings_test\n",
+		"bind_gen.go":      "package other\n",
+	})
+	// 测试文件和生成的文件不参与解析
+	pkg, types, err := scan(dir, "bind_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*bindType{{Name: "Server", Key: "server", Check: true}, {Name: "root"}}
+	if pkg != "settings" || !reflect.DeepEqual(types, want) {
+		t.Fatalf("unexpected result %s %+v", pkg, types)
+	}
+
+	if _, _, err := scan(dir, ""); err == nil || !strings.Contains(err.Error(), "multiple packages") {
+		t.Fatalf("expect a multiple packages error, got %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := writeDir(t, map[string]string{"settings.go": source})
+	if err := run(dir, "bind_gen.go"); err != nil {
+		t.Fatal(err)
+	}
+	code, err := ioutil.ReadFile(filepath.Join(dir, "bind_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package settings",
+		"func LoadServer(c config.Config) (*Server, error) {",
+		"return v.(*Server).Check()",
+		"func BindServer(c config.Config) (*ServerBinding, error) {",
+		"func loadRoot(c config.Config) (*root, error) {",
+		`c.UnmarshalKey("", v)`,
+		"func bindRoot(c config.Config) (*rootBinding, error) {",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("expect %q in the generated code:\n%s", want, code)
+		}
+	}
+
+	if err := run(writeDir(t, map[string]string{"a.go": "package a\n"}), "bind_gen.go"); err == nil {
+		t.Fatal("expect an error without annotated structs")
+	}
+}
+
+func TestIdent(t *testing.T) {
+	tests := []struct {
+		name, prefix, suffix, want string
+	}{
+		{"Settings", "Load", "", "LoadSettings"},
+		{"settings", "Load", "", "loadSettings"},
+		{"settings", "", "Binding", "settingsBinding"},
+		{"Settings", "", "Binding", "SettingsBinding"},
+	}
+	for _, tt := range tests {
+		if got := ident(tt.name, tt.prefix, tt.suffix); got != tt.want {
+			t.Errorf("%s: expect %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
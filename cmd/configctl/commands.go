@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"goProjectTmpl/config"
+)
+
+// runValidate 使用完整的加载流程校验配置文件，并执行 config.Lint 检查
+// 非strict模式下只有无法加载的文件会导致失败
+func runValidate(args []string) error {
+	fs := newFlagSet("validate", "file...")
+	codec := fs.String("codec", "", "codec of the files, detected by extension by default")
+	strict := fs.Bool("strict", false, "treat lint findings, deprecated and unknown keys as errors")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errFailed
+	}
+
+	failed := false
+	for _, path := range fs.Args() {
+		name := codecName(path, *codec)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+
+		findings := config.Lint(data, name)
+		for _, f := range findings {
+			fmt.Printf("%s:%s\n", path, f)
+			if *strict || f.Rule == "syntax" {
+				failed = true
+			}
+		}
+		if len(findings) > 0 && findings[0].Rule == "syntax" {
+			continue
+		}
+
+		opts := []config.LoadOption{config.WithCodec(name), config.WithProvider("file")}
+		if *strict {
+			opts = append(opts, config.WithStrictDeprecation(), config.WithUnknownKeys(config.UnknownKeyError))
+		} else {
+			opts = append(opts, config.WithUnknownKeys(config.UnknownKeyWarn))
+		}
+		abs, _ := filepath.Abs(path)
+		if _, err := config.Load(abs, opts...); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed = true
+		}
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// runConvert 将配置文件转换为其他格式输出
+func runConvert(args []string) error {
+	fs := newFlagSet("convert", "file")
+	codec := fs.String("codec", "", "codec of the file, detected by extension by default")
+	to := fs.String("to", "", "output format: yaml, json or toml")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *to == "" {
+		fs.Usage()
+		return errFailed
+	}
+
+	tree, err := decodeSource(fs.Arg(0), *codec)
+	if err != nil {
+		return err
+	}
+	out, err := config.Encode(tree, *to)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+// runDump 输出基础配置与profile配置、环境变量合并并解析占位符后的配置，敏感配置项默认脱敏
+func runDump(args []string) error {
+	fs := newFlagSet("dump", "file")
+	codec := fs.String("codec", "", "codec of the files, detected by extension by default")
+	profile := fs.String("profile", "", "comma separated profiles merged in order, e.g. local loads app.local.yaml over app.yaml")
+	env := fs.String("env", "", "prefix of environment variables overriding config keys, e.g. APP")
+	format := fs.String("o", "yaml", "output format: yaml, json or toml")
+	showSecrets := fs.Bool("show-secrets", false, "print sensitive values without redaction")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errFailed
+	}
+
+	path, _ := filepath.Abs(fs.Arg(0))
+	opts := []config.LoadOption{
+		config.WithCodec(codecName(path, *codec)),
+		config.WithProvider("file"),
+		config.WithEnv(*env),
+		config.WithPlaceholders(),
+	}
+	for _, p := range strings.Split(*profile, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			opts = append(opts, config.WithProfile(p))
+		}
+	}
+	c, err := config.Load(path, opts...)
+	if err != nil {
+		return err
+	}
+
+	var out interface{} = c.EffectiveConfig()
+	if !*showSecrets {
+		out = config.Redact("", out)
+	}
+	data, err := config.Encode(out, *format)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// runDiff 对比两个配置，存在差异时以状态码1退出
+func runDiff(args []string) error {
+	fs := newFlagSet("diff", "old new")
+	codec := fs.String("codec", "", "codec of the files, detected by extension by default")
+	format := fs.String("o", "text", "output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errFailed
+	}
+
+	old, err := decodeSource(fs.Arg(0), *codec)
+	if err != nil {
+		return err
+	}
+	new, err := decodeSource(fs.Arg(1), *codec)
+	if err != nil {
+		return err
+	}
+	changes := config.DiffTrees(old, new)
+
+	if *format == "json" {
+		out, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else {
+		for _, c := range changes {
+			switch c.Type {
+			case config.ChangeAdded:
+				fmt.Printf("+ %s: %s\n", c.Key, diffValue(c.New))
+			case config.ChangeRemoved:
+				fmt.Printf("- %s: %s\n", c.Key, diffValue(c.Old))
+			default:
+				fmt.Printf("~ %s: %s -> %s\n", c.Key, diffValue(c.Old), diffValue(c.New))
+			}
+		}
+	}
+	if len(changes) > 0 {
+		return errFailed
+	}
+	return nil
+}
+
+// diffValue 将配置值格式化为单行json
+func diffValue(v interface{}) string {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(out)
+}
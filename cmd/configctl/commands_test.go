@@ -0,0 +1,153 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// capture 执行fn并返回其间写入标准输出的内容
+func capture(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan []byte)
+	go func() {
+		out, _ := ioutil.ReadAll(r)
+		done <- out
+	}()
+	err = fn()
+	os.Stdout = stdout
+	w.Close()
+	return string(<-done), err
+}
+
+// writeFiles 在临时目录中写入配置文件
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "configctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestValidate(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"ok.yaml":  "server:\n  port: 8080\n",
+		"bad.yaml": "server: [\n",
+		"ok.json":  `{"server": {"port": 8080}}`,
+	})
+	tests := []struct {
+		name string
+		args []string
+		ok   bool
+	}{
+		{"yaml", []string{"ok.yaml"}, true},
+		{"json", []string{"ok.json"}, true},
+		{"syntax", []string{"ok.yaml", "bad.yaml"}, false},
+		{"missing", []string{"missing.yaml"}, false},
+		{"no args", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := make([]string, 0, len(tt.args))
+			for _, a := range tt.args {
+				args = append(args, filepath.Join(dir, a))
+			}
+			_, err := capture(t, func() error { return runValidate(args) })
+			if (err == nil) != tt.ok {
+				t.Fatalf("expect ok %v, got %v", tt.ok, err)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"app.yaml": "server:\n  port: 8080\n"})
+	out, err := capture(t, func() error { return runConvert([]string{"-to", "json", filepath.Join(dir, "app.yaml")}) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"port": 8080`) {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	if _, err := capture(t, func() error { return runConvert([]string{filepath.Join(dir, "app.yaml")}) }); err != errFailed {
+		t.Fatalf("expect errFailed without -to, got %v", err)
+	}
+}
+
+func TestDump(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.yaml":       "server:\n  port: 8080\n  name: ${SERVER_NAME:-demo}\ndb:\n  password: secret\n",
+		"app.local.yaml": "server:\n  port: 9090\n",
+	})
+	out, err := capture(t, func() error {
+		return runDump([]string{"-profile", "local", "-o", "json", filepath.Join(dir, "app.yaml")})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// profile配置覆盖基础配置，占位符已解析，敏感配置项脱敏
+	for _, want := range []string{`"port": 9090`, `"name": "demo"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expect %s in the output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "secret") {
+		t.Fatalf("expect the password to be redacted:\n%s", out)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"old.yaml": "a: 1\nb: x\n",
+		"new.json": `{"a": 2, "c": [1]}`,
+	})
+	out, err := capture(t, func() error {
+		return runDiff([]string{filepath.Join(dir, "old.yaml"), filepath.Join(dir, "new.json")})
+	})
+	// 存在差异时返回errFailed
+	if err != errFailed {
+		t.Fatalf("expect errFailed, got %v", err)
+	}
+	want := "~ a: 1 -> 2\n- b: \"x\"\n+ c: [1]\n"
+	if out != want {
+		t.Fatalf("expect:\n%s\ngot:\n%s", want, out)
+	}
+
+	out, err = capture(t, func() error {
+		return runDiff([]string{filepath.Join(dir, "old.yaml"), filepath.Join(dir, "old.yaml")})
+	})
+	if err != nil || out != "" {
+		t.Fatalf("expect no difference, got %v %q", err, out)
+	}
+}
+
+func TestReadSource(t *testing.T) {
+	if _, err := readSource("HEAD:not-exist.yaml"); err == nil || !strings.Contains(err.Error(), "git show") {
+		if err := exec.Command("git", "rev-parse", "HEAD").Run(); err != nil {
+			t.Skip("not in a git repository")
+		}
+		t.Fatalf("expect a git show error, got %v", err)
+	}
+	// 存在同名文件时读取文件
+	dir := writeFiles(t, map[string]string{"a:b.yaml": "a: 1\n"})
+	data, err := readSource(filepath.Join(dir, "a:b.yaml"))
+	if err != nil || string(data) != "a: 1\n" {
+		t.Fatalf("expect the file content, got %q %v", data, err)
+	}
+}
@@ -0,0 +1,70 @@
+// configctl 配置文件命令行工具，支持校验、格式转换、输出合并后的配置以及配置对比
+//
+//	configctl validate [-codec yaml] [-strict] file...
+//	configctl convert -to json [-codec yaml] file
+//	configctl dump [-profile local] [-o yaml] [-show-secrets] file
+//	configctl diff [-codec yaml] old new
+//
+// diff 的参数可以是文件路径，也可以是 <revision>:<path> 形式的git版本，例如 HEAD~1:app.yaml
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// command 子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []*command{
+	{name: "validate", usage: "validate config files with registered codecs, schemas and lint rules", run: runValidate},
+	{name: "convert", usage: "convert a config file to another format", run: runConvert},
+	{name: "dump", usage: "print the merged config of a profile", run: runDump},
+	{name: "diff", usage: "diff two config files or revisions", run: runDiff},
+}
+
+// errFailed 命令已输出错误信息，只需要以非0状态码退出
+var errFailed = fmt.Errorf("failed")
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, cmd := range commands {
+		if cmd.name != os.Args[1] {
+			continue
+		}
+		if err := cmd.run(os.Args[2:]); err != nil {
+			if err != errFailed && err != flag.ErrHelp {
+				fmt.Fprintf(os.Stderr, "configctl %s: %v\n", cmd.name, err)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: configctl <command> [flags] [args]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+// newFlagSet 创建子命令的参数解析
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: configctl %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
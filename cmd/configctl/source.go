@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"goProjectTmpl/config"
+)
+
+// codecName 根据文件扩展名推断codec，name不为空时直接使用name
+func codecName(path, name string) string {
+	if name != "" {
+		return name
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	}
+	return "yaml"
+}
+
+// readSource 读取配置内容，src为文件路径或 <revision>:<path> 形式的git版本
+func readSource(src string) ([]byte, error) {
+	if _, err := os.Stat(src); err == nil {
+		return ioutil.ReadFile(src)
+	}
+	i := strings.Index(src, ":")
+	if i <= 0 {
+		return ioutil.ReadFile(src)
+	}
+	rev, path := src[:i], src[i+1:]
+	// git show 中的路径相对于仓库根目录，./ 前缀表示相对于当前目录
+	if !strings.HasPrefix(path, "./") && !strings.HasPrefix(path, "../") {
+		path = "./" + path
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "show", rev+":"+path)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show %s: %s", src, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// decodeSource 读取并解码配置
+func decodeSource(src, codec string) (map[string]interface{}, error) {
+	data, err := readSource(src)
+	if err != nil {
+		return nil, err
+	}
+	c := config.GetCodec(codecName(src, codec))
+	if c == nil {
+		return nil, config.ErrCodecNotExist
+	}
+	tree := make(map[string]interface{})
+	if err := c.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("%s: %v", src, err)
+	}
+	return tree, nil
+}
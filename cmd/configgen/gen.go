@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// options 生成选项
+type options struct {
+	pkg      string
+	src      string
+	defaults bool
+}
+
+// rootType 配置根节点的类型名
+const rootType = "Config"
+
+// initialisms 按照go命名习惯全部大写的单词
+var initialisms = map[string]bool{
+	"API": true, "CA": true, "CPU": true, "DB": true, "DNS": true, "GRPC": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "MQ": true, "QPS": true, "RPC": true, "SQL": true, "SSL": true,
+	"TCP": true, "TLS": true, "TTL": true, "UDP": true, "UI": true, "URI": true, "URL": true, "UUID": true,
+	"XML": true, "YAML": true,
+}
+
+// goName 将配置项名转换为导出的go标识符，例如 read_timeout 转换为 ReadTimeout、http 转换为 HTTP
+func goName(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var sb strings.Builder
+	for _, w := range words {
+		if u := strings.ToUpper(w); initialisms[u] {
+			sb.WriteString(u)
+			continue
+		}
+		rs := []rune(w)
+		rs[0] = unicode.ToUpper(rs[0])
+		sb.WriteString(string(rs))
+	}
+	name := sb.String()
+	if name == "" {
+		return ""
+	}
+	if r := []rune(name)[0]; !unicode.IsUpper(r) {
+		// 以数字或没有大小写的字符开头
+		name = "X" + name
+	}
+	return name
+}
+
+// generator 生成代码，types 记录已使用的类型名
+type generator struct {
+	opts  *options
+	buf   bytes.Buffer
+	types map[string]bool
+}
+
+// generate 生成root对应的go代码
+func generate(root *node, opts *options) ([]byte, error) {
+	g := &generator{opts: opts, types: map[string]bool{rootType: true, "New": true}}
+	g.printf("// Code generated by configgen from %s. DO NOT EDIT.\n\n", opts.src)
+	g.printf("// Package %s %s 中配置项的读取方法\n", opts.pkg, opts.src)
+	g.printf("package %s\n\nimport \"goProjectTmpl/config\"\n\n", opts.pkg)
+	g.doc(rootType+" 配置根节点，方法每次调用时读取c中当前生效的值", root.doc)
+	g.printf("type %s struct {\n\tc config.Config\n}\n\n", rootType)
+	g.printf("// New 使用c创建配置根节点\nfunc New(c config.Config) %s {\n\treturn %s{c: c}\n}\n\n", rootType, rootType)
+	g.section(rootType, "", root)
+
+	code, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %v", err)
+	}
+	return code, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// doc 输出文档注释，detail为配置文件中的注释
+func (g *generator) doc(summary, detail string) {
+	g.printf("// %s\n", summary)
+	if detail == "" {
+		return
+	}
+	for _, l := range strings.Split(detail, "\n") {
+		g.printf("// %s\n", l)
+	}
+}
+
+// typeName 配置段的类型名，与已有类型名相同时添加数字后缀
+func (g *generator) typeName(prefix, name string) string {
+	t := prefix + name
+	for i := 2; g.types[t]; i++ {
+		t = prefix + name + strconv.Itoa(i)
+	}
+	g.types[t] = true
+	return t
+}
+
+// section 输出typ类型上n的全部子配置项的方法，子配置段的类型在之后输出
+func (g *generator) section(typ, prefix string, n *node) {
+	methods := make(map[string]bool)
+	type pending struct {
+		typ, prefix string
+		n           *node
+	}
+	var sections []pending
+	for _, c := range n.children {
+		name := goName(c.key)
+		if name == "" || methods[name] {
+			fmt.Fprintf(os.Stderr, "configgen: skip %q, no distinct go name\n", c.path)
+			continue
+		}
+		methods[name] = true
+
+		if c.kind == kindSection {
+			ct := g.typeName(prefix, name)
+			g.doc(fmt.Sprintf("%s %s 配置段", name, c.path), c.doc)
+			g.printf("func (v %s) %s() %s {\n\treturn %s{c: v.c}\n}\n\n", typ, name, ct, ct)
+			sections = append(sections, pending{typ: ct, prefix: ct, n: c})
+			continue
+		}
+		g.accessor(typ, name, c)
+	}
+
+	for _, s := range sections {
+		g.doc(fmt.Sprintf("%s %s 配置段", s.typ, s.n.path), "")
+		g.printf("type %s struct {\n\tc config.Config\n}\n\n", s.typ)
+		g.section(s.typ, s.prefix, s.n)
+	}
+}
+
+// accessor 输出配置项的读取方法
+func (g *generator) accessor(typ, name string, n *node) {
+	key := strconv.Quote(n.path)
+	switch n.kind {
+	case kindScalar:
+		g.doc(fmt.Sprintf("%s %s", name, n.path), n.doc)
+		getter := map[string]string{"int": "GetInt", "float64": "GetFloat64", "bool": "GetBool", "string": "GetString"}[n.typ]
+		g.printf("func (v %s) %s() %s {\n\treturn v.c.%s(%s, %s)\n}\n\n", typ, name, n.typ, getter, key, g.defaultValue(n))
+	case kindList:
+		g.doc(fmt.Sprintf("%s %s，不存在或无法解析时返回nil", name, n.path), n.doc)
+		g.printf("func (v %s) %s() []%s {\n", typ, name, n.typ)
+		g.printf("\tvar out []%s\n\tif err := v.c.UnmarshalKey(%s, &out); err != nil {\n\t\treturn nil\n\t}\n\treturn out\n}\n\n", n.typ, key)
+	default:
+		g.doc(fmt.Sprintf("%s %s，不存在时返回nil", name, n.path), n.doc)
+		g.printf("func (v %s) %s() interface{} {\n\treturn v.c.Get(%s, nil)\n}\n\n", typ, name, key)
+	}
+}
+
+// defaultValue 配置项不存在时的返回值
+func (g *generator) defaultValue(n *node) string {
+	if !g.opts.defaults {
+		return map[string]string{"int": "0", "float64": "0", "bool": "false", "string": `""`}[n.typ]
+	}
+	switch v := n.value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(n.value)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoName(t *testing.T) {
+	tests := []struct {
+		key, want string
+	}{
+		{"read_timeout", "ReadTimeout"},
+		{"http", "HTTP"},
+		{"user-id", "UserID"},
+		{"maxConns", "MaxConns"},
+		{"2fa", "X2fa"},
+		{"__", ""},
+	}
+	for _, tt := range tests {
+		if got := goName(tt.key); got != tt.want {
+			t.Errorf("%s: expect %q, got %q", tt.key, tt.want, got)
+		}
+	}
+}
+
+// describe 以 path:kind:typ 的形式列出全部配置项，typ只对标量和列表有意义
+func describe(n *node) []string {
+	var out []string
+	for _, c := range n.children {
+		typ := c.typ
+		if c.kind == kindSection || c.kind == kindAny {
+			typ = ""
+		}
+		out = append(out, c.path+":"+[]string{"section", "scalar", "list", "any"}[c.kind]+":"+typ)
+		out = append(out, describe(c)...)
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	data := `# 示例配置
+base: &base
+  timeout: 100 # 毫秒
+server:
+  <<: *base
+  # 监听端口
+  port: 8080
+  ratio: 0.5
+  debug: true
+  hosts: [a, b]
+  mixed: [1, a]
+  empty: ~
+  a.b: skipped
+`
+	root, err := parse([]byte(data), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// yaml保留配置项的顺序，合并的配置项排在后面
+	want := "base:section:,base.timeout:scalar:int,server:section:,server.port:scalar:int,server.ratio:scalar:float64," +
+		"server.debug:scalar:bool,server.hosts:list:string,server.mixed:any:,server.empty:any:,server.timeout:scalar:int"
+	if got := strings.Join(describe(root), ","); got != want {
+		t.Fatalf("unexpected nodes:\n%s", got)
+	}
+	if port := root.children[1].children[0]; port.doc != "监听端口" {
+		t.Fatalf("expect the head comment as doc, got %q", port.doc)
+	}
+	if timeout := root.children[0].children[0]; timeout.doc != "毫秒" {
+		t.Fatalf("expect the line comment as doc, got %q", timeout.doc)
+	}
+
+	// json按照key排序，整数按照int处理
+	root, err = parse([]byte(`{"b": {"n": 1, "f": 1.5}, "a": ["x"]}`), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(describe(root), ","); got != "a:list:string,b:section:,b.f:scalar:float64,b.n:scalar:int" {
+		t.Fatalf("unexpected nodes:\n%s", got)
+	}
+
+	if _, err := parse([]byte("- a\n"), "yaml"); err == nil {
+		t.Fatal("expect an error for a non-mapping document")
+	}
+	if _, err := parse([]byte("{}"), "not-exist"); err == nil {
+		t.Fatal("expect an error for an unknown codec")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	root, err := parse([]byte("http:\n  port: 8080\n  hosts: [a]\nname: demo\n"), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, defaults := range []bool{false, true} {
+		code, err := generate(root, &options{pkg: "appconf", src: "app.yaml", defaults: defaults})
+		if err != nil {
+			t.Fatal(err)
+		}
+		port, name := `v.c.GetInt("http.port", 0)`, `v.c.GetString("name", "")`
+		if defaults {
+			port, name = `v.c.GetInt("http.port", 8080)`, `v.c.GetString("name", "demo")`
+		}
+		for _, want := range []string{
+			"package appconf",
+			"func (v Config) HTTP() HTTP {",
+			"func (v HTTP) Port() int {",
+			port,
+			"func (v HTTP) Hosts() []string {",
+			"func (v Config) Name() string {",
+			name,
+		} {
+			if !strings.Contains(string(code), want) {
+				t.Errorf("defaults %v: expect %q in the generated code:\n%s", defaults, want, code)
+			}
+		}
+	}
+}
+
+func TestCodecName(t *testing.T) {
+	tests := []struct {
+		path, name, want string
+	}{
+		{"app.yaml", "", "yaml"},
+		{"app.JSON", "", "json"},
+		{"app.toml", "", "toml"},
+		{"app.conf", "", "yaml"},
+		{"app.conf", "toml", "toml"},
+	}
+	for _, tt := range tests {
+		if got := codecName(tt.path, tt.name); got != tt.want {
+			t.Errorf("%s: expect %s, got %s", tt.path, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// configgen 根据示例配置文件生成带类型的配置读取代码，避免在代码中散落字符串形式的key
+//
+//	//go:generate go run goProjectTmpl/cmd/configgen -pkg appconf -o appconf/appconf.go app.yaml
+//
+// 生成的包中每个配置段对应一个类型，每个配置项对应一个方法，yaml中配置项上方和行尾的注释作为方法的文档：
+//
+//	cfg := appconf.New(c)
+//	port := cfg.Server().HTTP().Port() // c.GetInt("server.http.port", 0)
+//
+// 方法每次调用时读取c中当前生效的值，因此配置重新加载后立即生效；配置项不存在时返回零值，
+// -defaults 为true时返回示例配置中的值。key中包含 . 的配置项无法通过路径读取，会被跳过
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	fs := flag.NewFlagSet("configgen", flag.ContinueOnError)
+	pkg := fs.String("pkg", "", "package name of the generated file, defaults to the name of the output directory")
+	out := fs.String("o", "", "output file, defaults to stdout")
+	codec := fs.String("codec", "", "codec of the sample file, detected by extension by default")
+	defaults := fs.Bool("defaults", false, "use values in the sample file as defaults of the accessors")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: configgen [flags] file\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(fs.Arg(0), *codec, *pkg, *out, *defaults); err != nil {
+		fmt.Fprintf(os.Stderr, "configgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(src, codec, pkg, out string, defaults bool) error {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	root, err := parse(data, codecName(src, codec))
+	if err != nil {
+		return fmt.Errorf("%s: %v", src, err)
+	}
+	if pkg == "" {
+		pkg = "config"
+		if out != "" {
+			abs, err := filepath.Abs(out)
+			if err != nil {
+				return err
+			}
+			pkg = strings.Replace(filepath.Base(filepath.Dir(abs)), "-", "_", -1)
+		}
+	}
+
+	code, err := generate(root, &options{pkg: pkg, src: filepath.Base(src), defaults: defaults})
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(out, code, 0644)
+}
+
+// codecName 根据文件扩展名推断codec，name不为空时直接使用name
+func codecName(path, name string) string {
+	if name != "" {
+		return name
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	}
+	return "yaml"
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"goProjectTmpl/config"
+)
+
+// 配置项的种类
+const (
+	kindSection = iota // 配置段，生成类型
+	kindScalar         // 标量，生成 GetInt、GetString 等
+	kindList           // 元素类型相同的标量列表
+	kindAny            // null、元素类型不同的列表等，生成 Get
+)
+
+// node 示例配置中的配置项
+type node struct {
+	key  string
+	path string
+	doc  string
+	kind int
+	// typ 标量或列表元素的go类型：int、float64、bool、string
+	typ string
+	// value 标量在示例配置中的值
+	value    interface{}
+	children []*node
+}
+
+// parse 解析示例配置，yaml保留配置项的顺序和注释，其他格式按照key排序
+func parse(data []byte, codec string) (*node, error) {
+	root := &node{kind: kindSection}
+	if codec == "yaml" {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			return root, nil
+		}
+		m := resolve(doc.Content[0])
+		if m.Kind != yaml.MappingNode {
+			return nil, errors.New("top level must be a mapping")
+		}
+		root.doc = comment(doc.HeadComment)
+		yamlChildren(root, m)
+		return root, nil
+	}
+
+	c := config.GetCodec(codec)
+	if c == nil {
+		return nil, config.ErrCodecNotExist
+	}
+	tree := make(map[string]interface{})
+	if err := c.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	mapChildren(root, tree)
+	return root, nil
+}
+
+// child 创建配置项，key中包含 . 时返回nil
+func child(parent *node, key string) *node {
+	if strings.Contains(key, ".") {
+		fmt.Fprintf(os.Stderr, "configgen: skip %q, key contains '.'\n", joinKey(parent.path, key))
+		return nil
+	}
+	return &node{key: key, path: joinKey(parent.path, key)}
+}
+
+func joinKey(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// resolve 返回别名指向的节点
+func resolve(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
+
+// yamlChildren 按照出现顺序添加mapping中的配置项，<<合并的配置项排在后面，已存在时忽略
+func yamlChildren(parent *node, m *yaml.Node) {
+	seen := make(map[string]bool)
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		k, v := m.Content[i], m.Content[i+1]
+		if k.Tag == "!!merge" {
+			merges = append(merges, v)
+			continue
+		}
+		if seen[k.Value] {
+			continue
+		}
+		seen[k.Value] = true
+		n := child(parent, k.Value)
+		if n == nil {
+			continue
+		}
+		n.doc = joinComment(comment(k.HeadComment), comment(k.LineComment), comment(v.LineComment))
+		yamlValue(n, resolve(v))
+		parent.children = append(parent.children, n)
+	}
+	for _, v := range merges {
+		v = resolve(v)
+		sources := []*yaml.Node{v}
+		if v.Kind == yaml.SequenceNode {
+			sources = v.Content
+		}
+		for _, s := range sources {
+			s = resolve(s)
+			if s.Kind != yaml.MappingNode {
+				continue
+			}
+			for i := 0; i+1 < len(s.Content); i += 2 {
+				k := s.Content[i]
+				if seen[k.Value] || k.Tag == "!!merge" {
+					continue
+				}
+				seen[k.Value] = true
+				if n := child(parent, k.Value); n != nil {
+					n.doc = joinComment(comment(k.HeadComment), comment(k.LineComment))
+					yamlValue(n, resolve(s.Content[i+1]))
+					parent.children = append(parent.children, n)
+				}
+			}
+		}
+	}
+}
+
+func yamlValue(n *node, v *yaml.Node) {
+	switch v.Kind {
+	case yaml.MappingNode:
+		n.kind = kindSection
+		yamlChildren(n, v)
+	case yaml.SequenceNode:
+		n.kind, n.typ = kindList, ""
+		for _, e := range v.Content {
+			e = resolve(e)
+			t := ""
+			if e.Kind == yaml.ScalarNode {
+				t, _ = yamlScalar(e)
+			}
+			if t == "" || (n.typ != "" && n.typ != t) {
+				n.kind = kindAny
+				return
+			}
+			n.typ = t
+		}
+		if n.typ == "" {
+			n.kind = kindAny
+		}
+	default:
+		n.typ, n.value = yamlScalar(v)
+		n.kind = kindScalar
+		if n.typ == "" {
+			n.kind = kindAny
+		}
+	}
+}
+
+// yamlScalar 标量的go类型和值，null返回空字符串
+func yamlScalar(v *yaml.Node) (string, interface{}) {
+	switch v.Tag {
+	case "!!null":
+		return "", nil
+	case "!!int":
+		var i int64
+		if err := v.Decode(&i); err != nil {
+			return "string", v.Value
+		}
+		return "int", i
+	case "!!float":
+		var f float64
+		if err := v.Decode(&f); err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return "string", v.Value
+		}
+		return "float64", f
+	case "!!bool":
+		var b bool
+		if err := v.Decode(&b); err != nil {
+			return "string", v.Value
+		}
+		return "bool", b
+	}
+	return "string", v.Value
+}
+
+// mapChildren 按照key排序添加配置项
+func mapChildren(parent *node, m map[string]interface{}) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		n := child(parent, k)
+		if n == nil {
+			continue
+		}
+		mapValue(n, m[k])
+		parent.children = append(parent.children, n)
+	}
+}
+
+func mapValue(n *node, v interface{}) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		n.kind = kindSection
+		mapChildren(n, t)
+	case []map[string]interface{}:
+		n.kind = kindAny
+	case []interface{}:
+		n.kind = kindList
+		for _, e := range t {
+			typ, _ := scalar(e)
+			if typ == "" || (n.typ != "" && n.typ != typ) {
+				n.kind, n.typ = kindAny, ""
+				return
+			}
+			n.typ = typ
+		}
+		if n.typ == "" {
+			n.kind = kindAny
+		}
+	default:
+		n.typ, n.value = scalar(v)
+		n.kind = kindScalar
+		if n.typ == "" {
+			n.kind = kindAny
+		}
+	}
+}
+
+// scalar json、toml解码后标量的go类型和值，json中的整数解码为float64，按照int处理
+func scalar(v interface{}) (string, interface{}) {
+	switch t := v.(type) {
+	case bool:
+		return "bool", t
+	case string:
+		return "string", t
+	case int:
+		return "int", int64(t)
+	case int64:
+		return "int", t
+	case float64:
+		if t == math.Trunc(t) && math.Abs(t) < 1<<53 {
+			return "int", int64(t)
+		}
+		return "float64", t
+	case time.Time:
+		return "string", t.Format(time.RFC3339)
+	}
+	return "", nil
+}
+
+// comment 去掉注释的 # 前缀
+func comment(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(l), "#"))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func joinComment(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditRecord 配置变更审计记录
+type AuditRecord struct {
+	Time     time.Time `json:"time"`
+	Path     string    `json:"path"`
+	Provider string    `json:"provider"`
+	// Version 内容源提供的版本号，不支持版本号的内容源为空
+	Version string `json:"version"`
+	// Fingerprint 新配置内容的sha256，PrevFingerprint 为变更前的sha256
+	Fingerprint     string   `json:"fingerprint"`
+	PrevFingerprint string   `json:"prev_fingerprint"`
+	Changes         []Change `json:"changes"`
+}
+
+// AuditSink 审计记录的输出目标，如文件、HTTP服务、消息队列
+type AuditSink interface {
+	Write(*AuditRecord) error
+}
+
+// AuditSinkFunc 函数形式的AuditSink
+type AuditSinkFunc func(*AuditRecord) error
+
+// Write 实现AuditSink接口
+func (f AuditSinkFunc) Write(r *AuditRecord) error {
+	return f(r)
+}
+
+var auditSinks SinkRegistry
+
+// auditQueueSize 等待写入的审计记录上限，输出目标持续阻塞时丢弃新的记录，不阻塞重新加载
+const auditQueueSize = 1024
+
+var (
+	auditQueue     = make(chan *AuditRecord, auditQueueSize)
+	auditWriteOnce sync.Once
+)
+
+// RegisterAuditSink 注册审计记录输出目标，每次重新加载成功后都会写入一条审计记录。
+// 记录由单独的goroutine按生效顺序写入，输出目标阻塞不会影响重新加载
+func RegisterAuditSink(s AuditSink) {
+	auditSinks.Register(func(r interface{}) error { return s.Write(r.(*AuditRecord)) })
+}
+
+// audit 生成重新加载的审计记录并放入写入队列，调用方持有reloadLock，不能等待输出目标
+func (c *FrameworkConfig) audit(cur *snapshot, prevFingerprint string, changes []Change) {
+	if auditSinks.Empty() {
+		return
+	}
+
+	r := &AuditRecord{
+		Time:            cur.loadedAt,
+		Path:            c.path,
+		Provider:        c.p.Name(),
+		Version:         cur.version,
+		Fingerprint:     cur.fingerprint,
+		PrevFingerprint: prevFingerprint,
+		Changes:         changes,
+	}
+	auditWriteOnce.Do(func() { go writeAudit() })
+	select {
+	case auditQueue <- r:
+	default:
+		logger.Warnf("app/config: audit queue is full, dropping the record of %s (%s)", c.path, cur.fingerprint)
+	}
+}
+
+// writeAudit 按顺序将队列中的审计记录写入全部输出目标
+func writeAudit() {
+	for r := range auditQueue {
+		for _, err := range auditSinks.Write(r) {
+			logger.Errorf("app/config: failed to write audit record of %s: %v", r.Path, err)
+		}
+	}
+}
+
+// contentHash 计算配置内容的sha256
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// FileAuditSink 以JSON Lines格式追加写入文件的审计输出
+type FileAuditSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileAuditSink 创建追加写入path的审计输出
+func NewFileAuditSink(path string) *FileAuditSink {
+	return &FileAuditSink{path: path}
+}
+
+// Write 追加一条审计记录
+func (s *FileAuditSink) Write(r *AuditRecord) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	// Encoder 在末尾追加换行
+	if err := json.NewEncoder(buf).Encode(r); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// HTTPAuditSink 以JSON格式POST到指定地址的审计输出
+type HTTPAuditSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPAuditSink 创建POST到url的审计输出
+func NewHTTPAuditSink(url string) *HTTPAuditSink {
+	return &HTTPAuditSink{url: url, client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// Write 发送一条审计记录
+func (s *HTTPAuditSink) Write(r *AuditRecord) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
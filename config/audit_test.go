@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuditDoesNotBlockReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeFile(t, path, "server:\n  port: 80\n")
+
+	release := make(chan struct{})
+	got := make(chan *AuditRecord, 2)
+	RegisterAuditSink(AuditSinkFunc(func(r *AuditRecord) error {
+		if r.Path != path {
+			return nil
+		}
+		<-release
+		got <- r
+		return nil
+	}))
+
+	cfg, err := newFullConfigLoad().Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cfg.(*FrameworkConfig)
+	// 输出目标阻塞期间重新加载仍然立即完成
+	for _, port := range []int{81, 82} {
+		writeFile(t, path, fmt.Sprintf("server:\n  port: %d\n", port))
+		if err := c.reloadAndReport(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(release)
+
+	for _, port := range []int{81, 82} {
+		select {
+		case r := <-got:
+			if len(r.Changes) != 1 || fmt.Sprint(r.Changes[0].New) != fmt.Sprint(port) {
+				t.Fatalf("expect the change to %d, got %+v", port, r.Changes)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("expect the audit record of %d", port)
+		}
+	}
+}
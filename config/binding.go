@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// BindCheck 绑定值的检查函数，参数类型与 Bind 时传入的指针类型一致
+// 用于检查跨字段的约束，例如 read_timeout 必须小于 idle_timeout
+type BindCheck func(interface{}) error
+
+// Binding 绑定到配置子树的结构体
+// 每次重新加载成功后，Load 返回新解码出的实例
+type Binding struct {
+	key    string
+	typ    reflect.Type
+	checks []BindCheck
+	// value 最新的绑定值，请求路径上读取只需要一次原子操作
+	value atomic.Value
+}
+
+// Key 绑定的配置key
+func (b *Binding) Key() string {
+	return b.key
+}
+
+// Load 获取最新的绑定值，类型与 Bind 时传入的指针类型一致
+func (b *Binding) Load() interface{} {
+	return b.value.Load()
+}
+
+// store 发布新的绑定值，v与 Bind 时传入的指针类型一致
+func (b *Binding) store(v interface{}) {
+	b.value.Store(v)
+}
+
+// decode 将配置树中对应的子树解码到一个新的实例中
+func (b *Binding) decode(c *FrameworkConfig, data map[string]interface{}) (interface{}, error) {
+	var sub interface{} = data
+	if b.key != "" {
+		v, err := c.search(data, c.parseKey(b.key))
+		if err != nil {
+			return nil, fmt.Errorf("app/config: binding %s: %s", b.key, err.Error())
+		}
+		sub = v
+	}
+
+	out := reflect.New(b.typ.Elem()).Interface()
+	if err := decodeTree(b.key, sub, out, c.decoder.Name()); err != nil {
+		return nil, err
+	}
+	if err := validateStruct(b.key, sub, out, c.decoder.Name()); err != nil {
+		return nil, err
+	}
+	if err := b.check(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// check 执行绑定的检查函数
+func (b *Binding) check(v interface{}) error {
+	for _, fn := range b.checks {
+		if err := fn(v); err != nil {
+			return fmt.Errorf("app/config: binding %s: %s", b.key, err.Error())
+		}
+	}
+	return nil
+}
+
+// Bind 将key对应的配置子树解码到out（结构体指针）中，并注册该绑定
+// 重新加载时会先将新配置解码到新的实例中，只有全部绑定都解码、校验并通过checks才会替换配置
+func (c *FrameworkConfig) Bind(key string, out interface{}, checks ...BindCheck) (*Binding, error) {
+	t := reflect.TypeOf(out)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("app/config: binding %s: out must be a pointer", key)
+	}
+
+	b := &Binding{key: key, typ: t, checks: checks}
+	b.value.Store(out)
+	if err := c.UnmarshalKey(key, out); err != nil {
+		return nil, err
+	}
+	if err := b.check(out); err != nil {
+		return nil, err
+	}
+
+	markSensitiveFields(key, t, c.decoder.Name())
+
+	c.bindLock.Lock()
+	c.bindings = append(c.bindings, b)
+	c.bindLock.Unlock()
+	return b, nil
+}
+
+// decodeBindings 将新的配置树解码到全部绑定的新实例中，任意一个失败则返回错误
+func (c *FrameworkConfig) decodeBindings(data map[string]interface{}) ([]*Binding, []interface{}, error) {
+	c.bindLock.Lock()
+	bindings := append([]*Binding(nil), c.bindings...)
+	c.bindLock.Unlock()
+
+	values := make([]interface{}, len(bindings))
+	for i, b := range bindings {
+		v, err := b.decode(c, data)
+		if err != nil {
+			return nil, nil, err
+		}
+		values[i] = v
+	}
+	return bindings, values, nil
+}
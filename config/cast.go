@@ -0,0 +1,52 @@
+package config
+
+import (
+	"reflect"
+
+	"github.com/spf13/cast"
+)
+
+// castKey 类型转换结果的缓存key
+type castKey struct {
+	key  string
+	kind reflect.Kind
+}
+
+// castResult 类型转换结果，ok为false表示无法转换
+type castResult struct {
+	value interface{}
+	ok    bool
+}
+
+// cast 将key的配置值v转换为kind类型，结果缓存在snapshot中
+// snapshot发布后不再修改，重新加载后使用新的snapshot，缓存随之失效
+func (s *snapshot) cast(key string, kind reflect.Kind, v interface{}, fn func(interface{}) (interface{}, error)) (interface{}, bool) {
+	ck := castKey{key: key, kind: kind}
+	s.castLock.RLock()
+	r, ok := s.casts[ck]
+	s.castLock.RUnlock()
+	if ok {
+		return r.value, r.ok
+	}
+
+	value, err := fn(v)
+	r = castResult{value: value, ok: err == nil}
+	s.castLock.Lock()
+	if s.casts == nil {
+		s.casts = make(map[castKey]castResult)
+	}
+	s.casts[ck] = r
+	s.castLock.Unlock()
+	return r.value, r.ok
+}
+
+func toInt(v interface{}) (interface{}, error)     { return cast.ToIntE(v) }
+func toInt32(v interface{}) (interface{}, error)   { return cast.ToInt32E(v) }
+func toInt64(v interface{}) (interface{}, error)   { return cast.ToInt64E(v) }
+func toUint(v interface{}) (interface{}, error)    { return cast.ToUintE(v) }
+func toUint32(v interface{}) (interface{}, error)  { return cast.ToUint32E(v) }
+func toUint64(v interface{}) (interface{}, error)  { return cast.ToUint64E(v) }
+func toFloat64(v interface{}) (interface{}, error) { return cast.ToFloat64E(v) }
+func toFloat32(v interface{}) (interface{}, error) { return cast.ToFloat32E(v) }
+func toBool(v interface{}) (interface{}, error)    { return cast.ToBoolE(v) }
+func toString(v interface{}) (interface{}, error)  { return cast.ToStringE(v) }
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock 时钟，配置加载时间、重新加载记录、过期时间等都从时钟读取，测试中可以替换为可控制的时钟
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// clockHolder atomic.Value 要求每次存储的类型相同
+type clockHolder struct {
+	c Clock
+}
+
+var clock atomic.Value
+
+func init() {
+	clock.Store(clockHolder{c: realClock{}})
+}
+
+// SetClock 替换全局时钟，c为nil时恢复系统时钟，返回恢复为替换前时钟的函数
+func SetClock(c Clock) (restore func()) {
+	if c == nil {
+		c = realClock{}
+	}
+	old := clock.Load().(clockHolder)
+	clock.Store(clockHolder{c: c})
+	return func() {
+		clock.Store(old)
+	}
+}
+
+// Now 全局时钟的当前时间
+func Now() time.Time {
+	return clock.Load().(clockHolder).c.Now()
+}
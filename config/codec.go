@@ -0,0 +1,47 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrCodecPanic 解码时发生panic，通常是Codec依赖的解析库在畸形输入上的缺陷
+//
+// Codec 约定：Unmarshal 对任意字节序列都不能panic，无法解析时返回错误。
+// 配置内容来自文件、远程配置中心等不受控的来源，解码时的panic会导致加载配置的进程或
+// 自动重新加载的协程崩溃。内置的yaml、json、toml Codec 会将解析库的panic转换为 ErrCodecPanic，
+// 加载器调用 RegisterCodec 注册的其他Codec时也会这样处理，但自定义Codec仍然应当满足约定，
+// 可以参考 codec_fuzz_test.go 编写模糊测试检查
+var ErrCodecPanic = errors.New("app/config: codec panicked")
+
+// recoverDecode 将解码过程中的panic转换为 ErrCodecPanic，需要在defer中直接调用
+// 访问映射内存的错误不是Codec的问题，继续panic交给 recoverFault 处理
+func recoverDecode(err *error) {
+	if r := recover(); r != nil {
+		if isFault(r) {
+			panic(r)
+		}
+		*err = fmt.Errorf("%w: %v", ErrCodecPanic, r)
+	}
+}
+
+// decode 使用codec解码，codec违反约定panic时返回 ErrCodecPanic
+func decode(codec Codec, data []byte, out interface{}) (err error) {
+	defer recoverDecode(&err)
+	return codec.Unmarshal(data, out)
+}
+
+// unmarshalYAML 与 yaml.Unmarshal 相同，panic时返回 ErrCodecPanic
+func unmarshalYAML(data []byte, out interface{}) (err error) {
+	defer recoverDecode(&err)
+	return yaml.Unmarshal(data, out)
+}
+
+// unmarshalTOML 与 toml.Unmarshal 相同，panic时返回 ErrCodecPanic
+func unmarshalTOML(data []byte, out interface{}) (err error) {
+	defer recoverDecode(&err)
+	return toml.Unmarshal(data, out)
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// checkCodec 检查名为name的Codec是否满足约定：分别解码到 map[string]interface{} 和 interface{}，
+// 再对解码结果执行加载器的规范化、索引和脱敏，任一步骤panic时返回错误；内容无法解析不视为违反约定
+func checkCodec(name string, data []byte) (err error) {
+	codec := GetCodec(name)
+	if codec == nil {
+		return ErrCodecNotExist
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %s: %v", ErrCodecPanic, name, r)
+		}
+	}()
+
+	var v interface{}
+	_ = codec.Unmarshal(data, &v)
+	tree := map[string]interface{}{}
+	if codec.Unmarshal(data, &tree) != nil {
+		return nil
+	}
+	normalizeTree(tree)
+	newSnapshot(data, "", tree, nil)
+	redactTree("", tree)
+	leafValues("", tree)
+	return nil
+}
+
+// checkKey 检查key的拆分、查找、设置和敏感判断，panic或设置后无法查找到时返回错误
+func checkKey(key string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("app/config: key %q panicked: %v", key, r)
+		}
+	}()
+
+	if got := strings.Join(splitKey(key), "."); got != key {
+		return fmt.Errorf("app/config: key %q split and joined as %q", key, got)
+	}
+	IsSensitive(key)
+	envName("APP", key)
+
+	tree := map[string]interface{}{}
+	setKey(tree, key, key)
+	if v, ok := lookupTree(tree, key); !ok || !reflect.DeepEqual(v, key) {
+		return fmt.Errorf("app/config: key %q not found after set", key)
+	}
+	c := newFullConfig("")
+	if v, err := c.search(tree, c.parseKey(key)); err != nil || !reflect.DeepEqual(v, key) {
+		return fmt.Errorf("app/config: key %q not found by search: %v", key, err)
+	}
+	return nil
+}
+
+func fuzzCodec(f *testing.F, name string, seeds ...string) {
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := checkCodec(name, data); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func FuzzYAML(f *testing.F) {
+	fuzzCodec(f, "yaml",
+		"",
+		"a: 1\n",
+		"server:\n  app: demo\n  port: 8000\n  tags: [a, b]\n",
+		"list:\n  - name: x\n    password: secret\n  - name: y\n",
+		"anchor: &a {k: v}\nref: *a\n",
+		"1: int key\ntrue: bool key\n~: null key\n",
+		"a: !!binary aGVsbG8=\nb: 2021-01-01T00:00:00Z\n",
+		"- not a map\n",
+		"a: [\n",
+	)
+}
+
+func FuzzJSON(f *testing.F) {
+	fuzzCodec(f, "json",
+		"{}",
+		`{"a":1}`,
+		`{"server":{"app":"demo","port":8000,"tags":["a","b"]}}`,
+		`{"list":[{"name":"x","password":"secret"},{"name":"y"}]}`,
+		`{"n":1e400,"s":"\u0000","nested":[[[]]]}`,
+		`[1,2,3]`,
+		`{"a":`,
+	)
+}
+
+func FuzzTOML(f *testing.F) {
+	fuzzCodec(f, "toml",
+		"",
+		"a = 1\n",
+		"[server]\napp = \"demo\"\nport = 8000\ntags = [\"a\", \"b\"]\n",
+		"[[list]]\nname = \"x\"\npassword = \"secret\"\n[[list]]\nname = \"y\"\n",
+		"d = 1979-05-27T07:32:00Z\nt = 07:32:00\n",
+		"a.b.c = 1\n",
+		"[a\n",
+	)
+}
+
+func FuzzKey(f *testing.F) {
+	for _, s := range []string{
+		"a",
+		"server.app",
+		"plugins.database.default.password",
+		"a-b.c_d",
+		"tenants.t1001./api/export.limit",
+		"a..b",
+		".",
+		"",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, key string) {
+		if err := checkKey(key); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
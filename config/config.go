@@ -2,15 +2,12 @@
 package config
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"strconv"
 	"sync"
-
-	"github.com/BurntSushi/toml"
-
-	yaml "gopkg.in/yaml.v3"
 )
 
 // ErrConfigNotSupport 尚未支持
@@ -94,7 +91,7 @@ type YamlUnmarshaler struct{}
 
 // Unmarshal yaml 解压
 func (yu *YamlUnmarshaler) Unmarshal(data []byte, val interface{}) error {
-	return yaml.Unmarshal(data, val)
+	return unmarshalYAML(data, val)
 }
 
 // JSONUnmarshaler json解码
@@ -110,7 +107,7 @@ type TomlUnmarshaler struct{}
 
 // Unmarshal toml解码
 func (tu *TomlUnmarshaler) Unmarshal(data []byte, val interface{}) error {
-	return toml.Unmarshal(data, val)
+	return unmarshalTOML(data, val)
 }
 
 func init() {
@@ -237,6 +234,8 @@ type Config interface {
 	Reload()
 	Get(string, interface{}) interface{}
 	Unmarshal(interface{}) error
+	UnmarshalKey(string, interface{}) error
+	Bind(string, interface{}, ...BindCheck) (*Binding, error)
 	IsSet(string) bool
 	GetInt(string, int) int
 	GetInt32(string, int32) int32
@@ -248,7 +247,18 @@ type Config interface {
 	GetFloat64(string, float64) float64
 	GetString(string, string) string
 	GetBool(string, bool) bool
+	GetMany([]KeySpec) []interface{}
+	Snapshot() Snapshot
+	Key(string) *Handle
 	Bytes() []byte
+	Fingerprint() string
+	Version() string
+	History() []HistoryEvent
+	EffectiveConfig() map[string]interface{}
+	OnChange(func([]Change))
+	Provenance(string) (Origin, bool)
+	Set(string, interface{}) error
+	Save() error
 }
 
 // ProviderCallback provider内容变更事件回调函数
@@ -265,12 +275,86 @@ type DataProvider interface {
 	Watch(ProviderCallback)
 }
 
+// VersionedProvider DataProvider的可选接口，读取配置的同时返回内容源中该配置的版本号
+// 例如ETCD的revision、配置中心的发布版本、文件的修改时间
+type VersionedProvider interface {
+	ReadWithVersion(string) ([]byte, string, error)
+}
+
+// MappedProvider DataProvider的可选接口，读取超大配置文件时直接映射文件内容，避免复制到内存中
+// 返回的data在调用release之前有效，调用方不能保留data
+type MappedProvider interface {
+	ReadMapped(string) (data []byte, version string, release func(), err error)
+}
+
+// BufferedProvider DataProvider的可选接口，将配置读取到调用方提供的缓冲区中
+// 使用 WithDiscardRaw 时加载器从缓冲池获取缓冲区，解码后放回，反复重新加载大配置时不必每次重新分配
+type BufferedProvider interface {
+	ReadTo(path string, buf *bytes.Buffer) (version string, err error)
+}
+
+// Writer DataProvider的可选接口，将配置写回内容源
+// version为调用方读取时的版本号，不为空且与内容源中当前的版本号不同时返回 ErrVersionConflict，
+// 成功时返回写入后的版本号
+type Writer interface {
+	Write(path string, data []byte, version string) (string, error)
+}
+
+// HealthChecker DataProvider的可选接口，检查远程内容源是否可以访问
+type HealthChecker interface {
+	CheckHealth(context.Context) error
+}
+
+// Delta 单个配置项的变更
+type Delta struct {
+	// Key 以 . 分隔的配置项
+	Key string
+	// Value 配置项的新值，可以是对象
+	Value interface{}
+	// Deleted 配置项被删除
+	Deleted bool
+}
+
+// DeltaProvider DataProvider的可选接口，内容变化时只推送变更的配置项，例如ETCD的key级别事件
+// 实现该接口的provider使用 WatchDelta 代替 Watch，version为变更后内容源中的版本号
+type DeltaProvider interface {
+	WatchDelta(func(path, version string, deltas []Delta))
+}
+
 // Codec 编解码器
 type Codec interface {
 	Name() string
 	Unmarshal([]byte, interface{}) error
 }
 
+// Schema 配置结构约束
+// 在配置加载和重新加载时对解码后的配置树进行检查，与使用何种Codec无关。
+// config/cueschema 提供了基于CUE的实现，将配置树与CUE schema进行unify并补全默认值
+type Schema interface {
+	// Check 检查配置树，返回非nil的配置树时将替换原有配置树（如补全了默认值）
+	Check(map[string]interface{}) (map[string]interface{}, error)
+}
+
+// Transform 在合并其他配置层之前转换从内容源读取并解码的配置文件，例如将旧版本的配置迁移为当前版本
+// path为配置文件的路径，profile和租户配置文件也会分别转换；返回非nil的配置树时替换原有配置树
+type Transform func(path string, tree map[string]interface{}) (map[string]interface{}, error)
+
+// ReloadGate 协调一组实例生效新配置的时机，例如分批生效并在每批之后检查健康状态
+// 重新加载的新配置校验通过后调用 Wait，fingerprint为新配置内容的摘要；
+// 返回错误时放弃本次重新加载并继续使用原来的配置，否则新配置生效并通知变化后调用done；
+// Wait 期间不阻塞 Set 等其他修改，修改先于新配置生效时调用done并重新读取，之后再次调用 Wait
+type ReloadGate interface {
+	Wait(ctx context.Context, path, fingerprint string) (done func(), err error)
+}
+
+// SchemaFunc 函数形式的Schema
+type SchemaFunc func(map[string]interface{}) (map[string]interface{}, error)
+
+// Check 实现Schema接口
+func (f SchemaFunc) Check(data map[string]interface{}) (map[string]interface{}, error) {
+	return f(data)
+}
+
 var providerMap = make(map[string]DataProvider)
 
 // RegisterProvider 注册配置服务提供者组件
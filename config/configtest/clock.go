@@ -0,0 +1,48 @@
+package configtest
+
+import (
+	"sync"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// Clock 手动推进的时钟
+type Clock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+// NewClock 创建当前时间为now的时钟
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now 当前时间
+func (c *Clock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// Advance 将时钟推进d
+func (c *Clock) Advance(d time.Duration) {
+	c.lock.Lock()
+	c.now = c.now.Add(d)
+	c.lock.Unlock()
+}
+
+// Set 将时钟设置为now
+func (c *Clock) Set(now time.Time) {
+	c.lock.Lock()
+	c.now = now
+	c.lock.Unlock()
+}
+
+// UseClock 在测试期间使用c作为 config 的全局时钟，测试结束时恢复，
+// secrets 的租约等基于 config.Now 的过期时间都使用该时钟
+func UseClock(t TB, c *Clock) {
+	t.Helper()
+	restore := config.SetClock(c)
+	t.Cleanup(restore)
+}
@@ -0,0 +1,73 @@
+// Package configtest 配置相关的单元测试工具
+// 提供内存内容源、同步触发的变化事件、生效配置的golden文件比较以及手动推进的时钟：
+//
+//	func TestReload(t *testing.T) {
+//		c, p := configtest.Load(t, "server:\n  timeout: 100\n")
+//		p.Update(configtest.DefaultPath, []byte("server:\n  timeout: 200\n"))
+//		if got := c.GetInt("server.timeout", 0); got != 200 { ... }
+//		configtest.AssertGolden(t, c, "testdata/effective.golden.yaml")
+//	}
+//
+// 设置环境变量 CONFIGTEST_UPDATE=1 运行测试时，AssertGolden 使用当前的生效配置更新golden文件
+package configtest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"goProjectTmpl/config"
+)
+
+// DefaultPath Load 使用的配置路径
+const DefaultPath = "app.yaml"
+
+// UpdateEnv 设置为1时 AssertGolden 更新golden文件
+const UpdateEnv = "CONFIGTEST_UPDATE"
+
+// TB testing.TB 中用到的方法
+type TB interface {
+	Helper()
+	Cleanup(func())
+	Fatalf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// Load 使用新的内存内容源加载yaml格式的配置，路径为 DefaultPath，并自动重新加载，opts可以覆盖默认选项
+func Load(t TB, yamlContent string, opts ...config.LoadOption) (config.Config, *Provider) {
+	t.Helper()
+	p := NewProvider()
+	p.Set(DefaultPath, []byte(yamlContent))
+	opts = append([]config.LoadOption{config.WithProvider(p.Name()), config.WithCodec("yaml"), config.WithAutoReload()}, opts...)
+	c, err := config.Load(DefaultPath, opts...)
+	if err != nil {
+		t.Fatalf("configtest: failed to load: %v", err)
+	}
+	return c, p
+}
+
+// AssertGolden 将c当前生效的完整配置（敏感配置项被脱敏）编码为yaml并与golden文件比较，不一致时报告测试失败
+func AssertGolden(t TB, c config.Config, golden string) {
+	t.Helper()
+	got, err := config.Encode(config.Redact("", c.EffectiveConfig()), "yaml")
+	if err != nil {
+		t.Fatalf("configtest: failed to encode effective config: %v", err)
+	}
+	if os.Getenv(UpdateEnv) == "1" {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatalf("configtest: %v", err)
+		}
+		if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatalf("configtest: %v", err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("configtest: failed to read golden file, run with %s=1 to create it: %v", UpdateEnv, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("configtest: effective config does not match %s\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
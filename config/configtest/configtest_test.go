@@ -0,0 +1,104 @@
+package configtest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+func TestProviderUpdate(t *testing.T) {
+	c, p := Load(t, "a: 1\n")
+	// Update 返回时已完成重新加载
+	p.Update(DefaultPath, []byte("a: 2\n"))
+	if got := c.GetInt("a", 0); got != 2 {
+		t.Fatalf("expect 2, got %d", got)
+	}
+
+	// 内容源故障时继续使用原来的配置
+	p.SetError(DefaultPath, errors.New("unavailable"))
+	p.Update(DefaultPath, []byte("a: 3\n"))
+	if got := c.GetInt("a", 0); got != 2 {
+		t.Fatalf("expect 2 while the provider fails, got %d", got)
+	}
+	p.SetError(DefaultPath, nil)
+	p.Trigger(DefaultPath)
+	if got := c.GetInt("a", 0); got != 3 {
+		t.Fatalf("expect 3 after the provider recovers, got %d", got)
+	}
+}
+
+func TestProviderWrite(t *testing.T) {
+	p := NewProvider()
+	p.Set("a.yaml", []byte("a: 1\n"))
+	_, version, err := p.ReadWithVersion("a.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Write("a.yaml", []byte("a: 2\n"), version); err != nil {
+		t.Fatal(err)
+	}
+	// 使用过期的版本号写入时冲突
+	if _, err := p.Write("a.yaml", []byte("a: 3\n"), version); !errors.Is(err, config.ErrVersionConflict) {
+		t.Fatalf("expect ErrVersionConflict, got %v", err)
+	}
+	p.Delete("a.yaml")
+	if _, err := p.Read("a.yaml"); !errors.Is(err, config.ErrConfigNotExist) {
+		t.Fatalf("expect ErrConfigNotExist, got %v", err)
+	}
+}
+
+// fakeTB 记录 AssertGolden 报告的失败
+type fakeTB struct {
+	*testing.T
+	failures []string
+}
+
+func (f *fakeTB) Fatalf(format string, args ...interface{}) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertGolden(t *testing.T) {
+	c, p := Load(t, "db:\n  password: secret\n  host: 127.0.0.1\n")
+	golden := filepath.Join(t.TempDir(), "effective.golden.yaml")
+
+	os.Setenv(UpdateEnv, "1")
+	AssertGolden(t, c, golden)
+	os.Unsetenv(UpdateEnv)
+	data, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// golden文件中敏感配置项被脱敏
+	if strings.Contains(string(data), "secret") || !strings.Contains(string(data), config.RedactedValue) {
+		t.Fatalf("expect the password to be redacted, got %q", data)
+	}
+	AssertGolden(t, c, golden)
+
+	p.Update(DefaultPath, []byte("db:\n  host: 127.0.0.2\n"))
+	tb := &fakeTB{T: t}
+	AssertGolden(tb, c, golden)
+	if len(tb.failures) != 1 {
+		t.Fatalf("expect a mismatch, got %v", tb.failures)
+	}
+}
+
+func TestUseClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	UseClock(t, clock)
+	clock.Advance(time.Hour)
+	if got := config.Now(); !got.Equal(start.Add(time.Hour)) {
+		t.Fatalf("expect %v, got %v", start.Add(time.Hour), got)
+	}
+}
@@ -0,0 +1,141 @@
+package configtest
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"goProjectTmpl/config"
+)
+
+var providerSeq int64
+
+// Provider 内存中的内容源，同时实现了 config.VersionedProvider、config.Writer 和 config.HealthChecker
+// 与file内容源不同，Update 和 Trigger 同步调用变化回调，返回时自动重新加载已经完成
+type Provider struct {
+	name string
+
+	lock      sync.RWMutex
+	files     map[string][]byte
+	versions  map[string]int
+	errs      map[string]error
+	healthErr error
+	callbacks []config.ProviderCallback
+}
+
+// NewProvider 创建并注册名字唯一的内存内容源，不同测试之间的配置缓存互不影响
+func NewProvider() *Provider {
+	p := &Provider{
+		name:     "configtest-" + strconv.FormatInt(atomic.AddInt64(&providerSeq, 1), 10),
+		files:    make(map[string][]byte),
+		versions: make(map[string]int),
+		errs:     make(map[string]error),
+	}
+	config.RegisterProvider(p)
+	return p
+}
+
+// Name Provider名字，加载时使用 config.WithProvider(p.Name())
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// Set 设置path的内容，不触发变化回调
+func (p *Provider) Set(path string, data []byte) {
+	p.lock.Lock()
+	p.files[path] = append([]byte(nil), data...)
+	p.versions[path]++
+	p.lock.Unlock()
+}
+
+// Update 设置path的内容并同步触发变化回调
+func (p *Provider) Update(path string, data []byte) {
+	p.Set(path, data)
+	p.Trigger(path)
+}
+
+// Delete 删除path，之后读取返回 config.ErrConfigNotExist
+func (p *Provider) Delete(path string) {
+	p.lock.Lock()
+	delete(p.files, path)
+	p.lock.Unlock()
+}
+
+// SetError 之后读取path时返回err，err为nil时恢复正常读取，用于模拟内容源故障
+func (p *Provider) SetError(path string, err error) {
+	p.lock.Lock()
+	if err == nil {
+		delete(p.errs, path)
+	} else {
+		p.errs[path] = err
+	}
+	p.lock.Unlock()
+}
+
+// SetHealth 设置 CheckHealth 返回的错误
+func (p *Provider) SetHealth(err error) {
+	p.lock.Lock()
+	p.healthErr = err
+	p.lock.Unlock()
+}
+
+// Trigger 以path当前的内容同步调用全部变化回调，内容不变时同样调用，用于模拟内容源推送的事件
+func (p *Provider) Trigger(path string) {
+	p.lock.RLock()
+	data := p.files[path]
+	callbacks := append([]config.ProviderCallback(nil), p.callbacks...)
+	p.lock.RUnlock()
+	for _, cb := range callbacks {
+		cb(path, data)
+	}
+}
+
+// Read 读取path的内容
+func (p *Provider) Read(path string) ([]byte, error) {
+	data, _, err := p.ReadWithVersion(path)
+	return data, err
+}
+
+// ReadWithVersion 读取path的内容，版本号为设置内容的次数
+func (p *Provider) ReadWithVersion(path string) ([]byte, string, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if err := p.errs[path]; err != nil {
+		return nil, "", err
+	}
+	data, ok := p.files[path]
+	if !ok {
+		return nil, "", config.ErrConfigNotExist
+	}
+	return append([]byte(nil), data...), strconv.Itoa(p.versions[path]), nil
+}
+
+// Write 写入path的内容并同步触发变化回调，version不为空且与当前版本号不同时返回 config.ErrVersionConflict
+func (p *Provider) Write(path string, data []byte, version string) (string, error) {
+	p.lock.Lock()
+	if version != "" && version != strconv.Itoa(p.versions[path]) {
+		p.lock.Unlock()
+		return "", config.ErrVersionConflict
+	}
+	p.files[path] = append([]byte(nil), data...)
+	p.versions[path]++
+	cur := strconv.Itoa(p.versions[path])
+	p.lock.Unlock()
+	p.Trigger(path)
+	return cur, nil
+}
+
+// Watch 注册变化回调
+func (p *Provider) Watch(cb config.ProviderCallback) {
+	p.lock.Lock()
+	p.callbacks = append(p.callbacks, cb)
+	p.lock.Unlock()
+}
+
+// CheckHealth 返回 SetHealth 设置的错误
+func (p *Provider) CheckHealth(context.Context) error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.healthErr
+}
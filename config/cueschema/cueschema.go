@@ -0,0 +1,109 @@
+// Package cueschema 使用CUE约束检查配置
+// CUE schema 与配置树unify后要求所有配置项都是具体值，schema中的默认值补全到配置中，
+// 约束不满足时拒绝加载或重新加载，错误中带有配置项的key，yaml配置文件还带有行列号：
+//
+//	// app.cue
+//	server: {
+//		app:  string & =~"^[a-z]+$"
+//		port: int & >0 & <65536 | *8000
+//		mode: "debug" | "release" | *"release"
+//	}
+//
+//	s, err := cueschema.CompileFile("app.cue")
+//	c, err := config.Load("app.yaml", s.LoadOption())
+//
+// 依赖的 cuelang.org/go 只在引用本包时编译进二进制
+package cueschema
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue"
+	cueerrors "cuelang.org/go/cue/errors"
+
+	"goProjectTmpl/config"
+)
+
+// Schema 编译后的CUE schema，实现了 config.Schema
+type Schema struct {
+	// lock cue.Runtime 不支持并发使用
+	lock  sync.Mutex
+	value cue.Value
+}
+
+// Compile 编译CUE源码，filename只用于错误信息
+func Compile(filename, src string) (*Schema, error) {
+	var r cue.Runtime
+	inst, err := r.Compile(filename, src)
+	if err != nil {
+		return nil, fmt.Errorf("app/config/cueschema: failed to compile %s: %v", filename, err)
+	}
+	v := inst.Value()
+	if err := v.Err(); err != nil {
+		return nil, fmt.Errorf("app/config/cueschema: invalid schema %s: %v", filename, err)
+	}
+	return &Schema{value: v}, nil
+}
+
+// CompileFile 读取并编译CUE文件
+func CompileFile(path string) (*Schema, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("app/config/cueschema: %v", err)
+	}
+	return Compile(path, string(src))
+}
+
+// MustCompile 与 Compile 相同，编译失败时panic，用于包级变量
+func MustCompile(filename, src string) *Schema {
+	s, err := Compile(filename, src)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// LoadOption 加载和重新加载时使用s检查配置
+func (s *Schema) LoadOption() config.LoadOption {
+	return config.WithSchema(s)
+}
+
+// Check 实现 config.Schema，将配置树与schema unify，返回补全了默认值的配置树
+func (s *Schema) Check(tree map[string]interface{}) (map[string]interface{}, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	v := s.value.Fill(tree)
+	if err := v.Validate(cue.Concrete(true)); err != nil {
+		return nil, validationErrors(err)
+	}
+	out := map[string]interface{}{}
+	if err := v.Decode(&out); err != nil {
+		return nil, fmt.Errorf("app/config/cueschema: %v", err)
+	}
+	return out, nil
+}
+
+// validationErrors 将CUE的错误转换为 config.ValidationErrors，加载器据此定位配置项的行列号
+func validationErrors(err error) error {
+	var errs config.ValidationErrors
+	for _, e := range cueerrors.Errors(err) {
+		format, args := e.Msg()
+		if strings.HasSuffix(format, "errors in empty disjunction:") {
+			// 只是后续各分支错误的标题
+			continue
+		}
+		errs = append(errs, &config.FieldError{
+			Key:  strings.Join(e.Path(), "."),
+			Rule: "cue",
+			Err:  fmt.Errorf(format, args...),
+		})
+	}
+	if len(errs) == 0 {
+		return err
+	}
+	return errs
+}
@@ -0,0 +1,72 @@
+package cueschema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/config/configtest"
+)
+
+const schemaSrc = `
+server: {
+	app:  string & =~"^[a-z]+$"
+	port: int & >0 & <65536 | *8000
+	mode: "debug" | "release" | *"release"
+}
+`
+
+func TestDefaults(t *testing.T) {
+	s := MustCompile("app.cue", schemaSrc)
+	c, _ := configtest.Load(t, "server:\n  app: demo\n", s.LoadOption())
+	if got := c.GetInt("server.port", 0); got != 8000 {
+		t.Errorf("expect the default port 8000, got %d", got)
+	}
+	if got := c.GetString("server.mode", ""); got != "release" {
+		t.Errorf("expect the default mode release, got %q", got)
+	}
+}
+
+func TestViolations(t *testing.T) {
+	s := MustCompile("app.cue", schemaSrc)
+	tests := []struct {
+		yaml string
+		key  string
+	}{
+		{"server:\n  app: Demo\n", "server.app"},
+		{"server:\n  app: demo\n  port: 70000\n", "server.port"},
+		{"server:\n  app: demo\n  mode: test\n", "server.mode"},
+		{"server:\n  port: 80\n", "server.app"},
+	}
+	for _, tt := range tests {
+		// 加载时的错误带有配置项的key
+		if _, err := config.NewFromString(tt.yaml, s.LoadOption()); err == nil || !strings.Contains(err.Error(), tt.key) {
+			t.Errorf("%q: expect a load error on %s, got %v", tt.yaml, tt.key, err)
+		}
+
+		plain, err := config.NewFromString(tt.yaml)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = s.Check(plain.EffectiveConfig())
+		var ve config.ValidationErrors
+		if !errors.As(err, &ve) {
+			t.Errorf("%q: expect ValidationErrors, got %v", tt.yaml, err)
+			continue
+		}
+		found := false
+		for _, fe := range ve {
+			found = found || fe.Key == tt.key
+		}
+		if !found {
+			t.Errorf("%q: expect an error on %s, got %v", tt.yaml, tt.key, err)
+		}
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	if _, err := Compile("bad.cue", "server: {"); err == nil {
+		t.Fatal("expect a compile error")
+	}
+}
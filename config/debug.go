@@ -0,0 +1,103 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadedConfig 已加载的配置信息
+type loadedConfig struct {
+	Path     string      `json:"path" yaml:"path"`
+	Codec    string      `json:"codec" yaml:"codec"`
+	Provider string      `json:"provider" yaml:"provider"`
+	Tenant   string      `json:"tenant,omitempty" yaml:"tenant,omitempty"`
+	Data     interface{} `json:"data" yaml:"data"`
+	// Provenance 各个配置项的来源，请求参数 provenance=true 时输出
+	Provenance map[string]Origin `json:"provenance,omitempty" yaml:"provenance,omitempty"`
+}
+
+// loaded 返回加载器中全部已加载的配置，按照路径排序
+func (loader *FullConfigLoader) loaded() []*FrameworkConfig {
+	configs := make([]*FrameworkConfig, 0)
+	for _, s := range loader.shards {
+		s.rwl.RLock()
+		for _, c := range s.loadedMap {
+			configs = append(configs, c)
+		}
+		s.rwl.RUnlock()
+	}
+
+	sort.Slice(configs, func(i, j int) bool { return configs[i].path < configs[j].path })
+	return configs
+}
+
+// loadedWithTenants 返回加载器及其租户加载器中全部已加载的配置，按照路径和租户排序
+func (loader *FullConfigLoader) loadedWithTenants() []*FrameworkConfig {
+	configs := loader.loaded()
+	for _, t := range loader.tenantLoaders() {
+		configs = append(configs, t.loaded()...)
+	}
+	sort.SliceStable(configs, func(i, j int) bool {
+		if configs[i].path != configs[j].path {
+			return configs[i].path < configs[j].path
+		}
+		return configs[i].tenant < configs[j].tenant
+	})
+	return configs
+}
+
+// DebugHandler 返回展示加载器中当前生效配置的http.Handler，敏感配置项会被脱敏
+// 包括租户加载器中的配置，支持参数 path 指定配置路径，tenant 指定租户，
+// format 指定输出格式（json、yaml，默认json），provenance=true 时输出各个配置项的来源
+func (loader *FullConfigLoader) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, tenant := r.URL.Query().Get("path"), r.URL.Query().Get("tenant")
+		result := make([]*loadedConfig, 0)
+		for _, c := range loader.loadedWithTenants() {
+			if (path != "" && c.path != path) || (tenant != "" && c.tenant != tenant) {
+				continue
+			}
+			lc := &loadedConfig{
+				Path:     c.path,
+				Codec:    c.decoder.Name(),
+				Provider: c.p.Name(),
+				Tenant:   c.tenant,
+				Data:     redactTree("", c.snap().tree),
+			}
+			if r.URL.Query().Get("provenance") == "true" {
+				lc.Provenance = c.provenances()
+			}
+			result = append(result, lc)
+		}
+		if (path != "" || tenant != "") && len(result) == 0 {
+			http.Error(w, ErrConfigNotExist.Error(), http.StatusNotFound)
+			return
+		}
+
+		var (
+			out []byte
+			err error
+		)
+		switch r.URL.Query().Get("format") {
+		case "yaml":
+			w.Header().Set("Content-Type", "application/x-yaml; charset=utf-8")
+			out, err = yaml.Marshal(result)
+		default:
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			out, err = json.MarshalIndent(result, "", "  ")
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(out)
+	})
+}
+
+// DebugHandler 返回展示默认加载器中当前生效配置的http.Handler，可挂载到 /debug/config
+func DebugHandler() http.Handler {
+	return DefaultConfigLoader.DebugHandler()
+}
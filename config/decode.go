@@ -0,0 +1,342 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// decodeTree 将解析后的配置树按照tag对应的字段名解码到out中
+func decodeTree(path string, in interface{}, out interface{}, tag string) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("app/config: decode %s: out must be a non-nil pointer", path)
+	}
+	return decodeValue(path, in, rv.Elem(), tag)
+}
+
+func decodeValue(path string, in interface{}, out reflect.Value, tag string) error {
+	if in == nil {
+		return nil
+	}
+
+	switch out.Type() {
+	case durationType:
+		d, err := cast.ToDurationE(in)
+		if err != nil {
+			return decodeError(path, in, out, err)
+		}
+		out.SetInt(int64(d))
+		return nil
+	case timeType:
+		t, err := cast.ToTimeE(in)
+		if err != nil {
+			return decodeError(path, in, out, err)
+		}
+		out.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	var err error
+	switch out.Kind() {
+	case reflect.Ptr:
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+		return decodeValue(path, in, out.Elem(), tag)
+	case reflect.Interface:
+		out.Set(reflect.ValueOf(in))
+		return nil
+	case reflect.Struct:
+		return decodeStruct(path, in, out, tag)
+	case reflect.Map:
+		return decodeMap(path, in, out, tag)
+	case reflect.Slice, reflect.Array:
+		return decodeSlice(path, in, out, tag)
+	case reflect.String:
+		var s string
+		if s, err = cast.ToStringE(in); err == nil {
+			out.SetString(s)
+		}
+	case reflect.Bool:
+		var b bool
+		if b, err = cast.ToBoolE(in); err == nil {
+			out.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = cast.ToInt64E(in); err == nil {
+			out.SetInt(i)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		if u, err = cast.ToUint64E(in); err == nil {
+			out.SetUint(u)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = cast.ToFloat64E(in); err == nil {
+			out.SetFloat(f)
+		}
+	default:
+		err = fmt.Errorf("unsupported kind %s", out.Kind())
+	}
+
+	if err != nil {
+		return decodeError(path, in, out, err)
+	}
+	return nil
+}
+
+func decodeStruct(path string, in interface{}, out reflect.Value, tag string) error {
+	m, ok := toStringMap(in)
+	if !ok {
+		return decodeError(path, in, out, fmt.Errorf("expect a map"))
+	}
+
+	t := out.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		name, inline, skip := fieldName(f, tag)
+		if skip {
+			continue
+		}
+		if inline {
+			if err := decodeValue(path, m, out.Field(i), tag); err != nil {
+				return err
+			}
+			continue
+		}
+		v, ok := lookupField(m, name)
+		if !ok {
+			if err := decodeDefault(joinKey(path, name), f, out.Field(i), tag); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := decodeValue(joinKey(path, name), v, out.Field(i), tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeDefault 配置中不存在该字段时，使用default tag指定的默认值
+// 未指定default的结构体字段会继续设置其内部字段的默认值
+func decodeDefault(path string, f reflect.StructField, out reflect.Value, tag string) error {
+	def, ok := f.Tag.Lookup("default")
+	if !ok {
+		if out.Kind() == reflect.Struct && out.Type() != timeType {
+			return decodeStruct(path, map[string]interface{}{}, out, tag)
+		}
+		return nil
+	}
+
+	if out.Kind() == reflect.Slice {
+		items := make([]interface{}, 0)
+		for _, item := range strings.Split(def, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return decodeValue(path, items, out, tag)
+	}
+	return decodeValue(path, def, out, tag)
+}
+
+// applyDefaults 对已经由Codec解码的out，为配置树in中不存在的字段设置默认值
+func applyDefaults(path string, in interface{}, out reflect.Value, tag string) error {
+	for out.Kind() == reflect.Ptr {
+		if out.IsNil() {
+			return nil
+		}
+		out = out.Elem()
+	}
+
+	switch out.Kind() {
+	case reflect.Struct:
+		if out.Type() == timeType {
+			return nil
+		}
+		m, _ := toStringMap(in)
+		t := out.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" && !f.Anonymous {
+				continue
+			}
+			name, inline, skip := fieldName(f, tag)
+			if skip {
+				continue
+			}
+			if inline {
+				if err := applyDefaults(path, in, out.Field(i), tag); err != nil {
+					return err
+				}
+				continue
+			}
+			key := joinKey(path, name)
+			v, ok := lookupField(m, name)
+			if !ok {
+				if err := decodeDefault(key, f, out.Field(i), tag); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := applyDefaults(key, v, out.Field(i), tag); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		iv := reflect.ValueOf(in)
+		if iv.Kind() != reflect.Slice && iv.Kind() != reflect.Array {
+			return nil
+		}
+		for i := 0; i < out.Len() && i < iv.Len(); i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if err := applyDefaults(p, iv.Index(i).Interface(), out.Index(i), tag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func decodeMap(path string, in interface{}, out reflect.Value, tag string) error {
+	m, ok := toStringMap(in)
+	if !ok {
+		return decodeError(path, in, out, fmt.Errorf("expect a map"))
+	}
+	t := out.Type()
+	if out.IsNil() {
+		out.Set(reflect.MakeMapWithSize(t, len(m)))
+	}
+	for k, v := range m {
+		key := reflect.New(t.Key()).Elem()
+		if err := decodeValue(joinKey(path, k), k, key, tag); err != nil {
+			return err
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if err := decodeValue(joinKey(path, k), v, elem, tag); err != nil {
+			return err
+		}
+		out.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+func decodeSlice(path string, in interface{}, out reflect.Value, tag string) error {
+	iv := reflect.ValueOf(in)
+	if iv.Kind() != reflect.Slice && iv.Kind() != reflect.Array {
+		return decodeError(path, in, out, fmt.Errorf("expect a list"))
+	}
+
+	n := iv.Len()
+	if out.Kind() == reflect.Slice {
+		out.Set(reflect.MakeSlice(out.Type(), n, n))
+	} else if n > out.Len() {
+		n = out.Len()
+	}
+	for i := 0; i < n; i++ {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		if err := decodeValue(p, iv.Index(i).Interface(), out.Index(i), tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldName 获取结构体字段在配置中对应的名字
+func fieldName(f reflect.StructField, tag string) (name string, inline bool, skip bool) {
+	tv := f.Tag.Get(tag)
+	if tv == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tv, ",")
+	name = parts[0]
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			inline = true
+		}
+	}
+	if name == "" && f.Anonymous && f.Type.Kind() == reflect.Struct {
+		inline = true
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, inline, false
+}
+
+// lookupField 查找字段，优先精确匹配，其次忽略大小写匹配
+func lookupField(m map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func toStringMap(in interface{}) (map[string]interface{}, bool) {
+	switch m := in.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		return cast.ToStringMap(m), true
+	case *lazySection:
+		return m.get(), true
+	default:
+		return nil, false
+	}
+}
+
+// normalizeTree 将配置树中各层级的 map[interface{}]interface{} 原地转换为 map[string]interface{}，
+// 解码时执行一次，读取配置时无需再转换
+func normalizeTree(v interface{}) interface{} {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		for k, sub := range m {
+			m[k] = normalizeTree(sub)
+		}
+		return m
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, sub := range m {
+			out[cast.ToString(k)] = normalizeTree(sub)
+		}
+		return out
+	case []interface{}:
+		for i, sub := range m {
+			m[i] = normalizeTree(sub)
+		}
+		return m
+	default:
+		return v
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func decodeError(path string, in interface{}, out reflect.Value, err error) error {
+	return &ErrTypeMismatch{Key: path, Want: out.Type().String(), Got: fmt.Sprintf("%T", in), Value: in, Err: err}
+}
@@ -1,15 +1,16 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
-	"strings"
+	"reflect"
+	"runtime/debug"
 	"sync"
-
-	"github.com/BurntSushi/toml"
-	"github.com/spf13/cast"
-	"gopkg.in/yaml.v3"
+	"sync/atomic"
+	"time"
 )
 
 var (
@@ -19,6 +20,10 @@ var (
 	ErrProviderNotExist = errors.New("app/config: provider not exist")
 	// ErrCodecNotExist codec不存在
 	ErrCodecNotExist = errors.New("app/config: codec not exist")
+	// ErrVersionConflict 写回时内容源中的配置已被修改
+	ErrVersionConflict = errors.New("app/config: version conflict")
+	// ErrProviderReadOnly provider不支持写回
+	ErrProviderReadOnly = errors.New("app/config: provider is read-only")
 )
 
 func init() {
@@ -30,51 +35,200 @@ func init() {
 // LoadOption 配置加载选项
 type LoadOption func(*FrameworkConfig)
 
+// loaderShards 加载器缓存的分片数
+const loaderShards = 32
+
 // FullConfigLoader 创建一个Config实例
+// 缓存按key的hash分片，并发加载不同配置时不会竞争同一把锁
 type FullConfigLoader struct {
+	shards [loaderShards]*loaderShard
+
+	// tenant 由 ForTenant 创建的租户加载器对应的租户，tenants 为已创建的租户加载器
+	tenant     string
+	tenants    map[string]*FullConfigLoader
+	tenantLock sync.Mutex
+	// removed 租户已被 RemoveTenant 移除，不再响应内容源的变化
+	removed int32
+	// watches 与租户加载器共用，分发内容源的变化
+	watches *watchHub
+}
+
+// loaderShard 加载器缓存的一个分片
+type loaderShard struct {
 	configMap map[string]Config
-	rwl       sync.RWMutex
+	// loadedMap 每个key最近一次加载成功的配置，内容源变化清除缓存后仍然保留，用于批量重新加载和调试
+	loadedMap map[string]*FrameworkConfig
+	// inflight 正在加载的配置
+	inflight map[string]*loadCall
+	rwl      sync.RWMutex
+}
+
+// loadCall 正在进行的加载
+type loadCall struct {
+	done chan struct{}
+	c    Config
+	err  error
+}
+
+// startLoad 登记key的加载，已有相同key的加载正在进行时返回该加载，leader为false
+// 登记前会再次检查缓存，避免等待锁期间其他加载已完成
+func (loader *FullConfigLoader) startLoad(key string) (call *loadCall, leader bool) {
+	s := loader.shard(key)
+	s.rwl.Lock()
+	defer s.rwl.Unlock()
+	if c, ok := s.configMap[key]; ok {
+		call = &loadCall{done: make(chan struct{}), c: c}
+		close(call.done)
+		return call, false
+	}
+	if call, ok := s.inflight[key]; ok {
+		return call, false
+	}
+	call = &loadCall{done: make(chan struct{})}
+	s.inflight[key] = call
+	return call, true
+}
+
+// finishLoad 结束key的加载，加载成功时写入缓存，并唤醒等待的加载
+func (loader *FullConfigLoader) finishLoad(key string, call *loadCall, c *FrameworkConfig, err error) {
+	s := loader.shard(key)
+	s.rwl.Lock()
+	if err == nil {
+		s.configMap[key] = c
+		s.loadedMap[key] = c
+		call.c = c
+	}
+	call.err = err
+	delete(s.inflight, key)
+	s.rwl.Unlock()
+	close(call.done)
+}
+
+// shard 返回key所在的分片，使用FNV-1a hash
+func (loader *FullConfigLoader) shard(key string) *loaderShard {
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return loader.shards[h%loaderShards]
+}
+
+// cached 获取缓存的配置
+func (loader *FullConfigLoader) cached(key string) (Config, bool) {
+	s := loader.shard(key)
+	s.rwl.RLock()
+	c, ok := s.configMap[key]
+	s.rwl.RUnlock()
+	return c, ok
+}
+
+// lastLoaded 获取key最近一次加载成功的配置
+func (loader *FullConfigLoader) lastLoaded(key string) (*FrameworkConfig, bool) {
+	s := loader.shard(key)
+	s.rwl.RLock()
+	c, ok := s.loadedMap[key]
+	s.rwl.RUnlock()
+	return c, ok
+}
+
+// evict 清除缓存，最近一次加载成功的配置仍然保留
+func (loader *FullConfigLoader) evict(key string) {
+	s := loader.shard(key)
+	s.rwl.Lock()
+	delete(s.configMap, key)
+	s.rwl.Unlock()
+}
+
+// cacheSize 返回缓存的配置数
+func (loader *FullConfigLoader) cacheSize() int {
+	n := 0
+	for _, s := range loader.shards {
+		s.rwl.RLock()
+		n += len(s.configMap)
+		s.rwl.RUnlock()
+	}
+	return n
 }
 
 // Load 根据参数加载指定配置
 func (loader *FullConfigLoader) Load(path string, opts ...LoadOption) (Config, error) {
 	yc := newFullConfig(path)
+	yc.tenant = loader.tenant
 	for _, o := range opts {
 		o(yc)
 	}
 
-	if yc.decoder == nil {
-		return nil, ErrCodecNotExist
-	}
-
 	if yc.p == nil {
 		return nil, ErrProviderNotExist
 	}
 
+	if err := yc.resolvePath(); err != nil {
+		return nil, err
+	}
+	path = yc.path
+
+	if yc.decoder == nil {
+		return nil, ErrCodecNotExist
+	}
+
 	key := fmt.Sprintf("%s.%s.%s", yc.decoder.Name(), yc.p.Name(), path)
-	loader.rwl.RLock()
-	if c, ok := loader.configMap[key]; ok {
-		loader.rwl.RUnlock()
+	if c, ok := loader.cached(key); ok {
+		if err := checkRequiredKeys(path, yc.requiredKeys, c.IsSet); err != nil {
+			return nil, err
+		}
 		return c, nil
 	}
-	loader.rwl.RUnlock()
 
+	// 同一配置的并发加载只有一个执行读取和解码，其他等待其结果
+	call, leader := loader.startLoad(key)
+	if !leader {
+		<-call.done
+		if call.err != nil {
+			return nil, call.err
+		}
+		if err := checkRequiredKeys(path, yc.requiredKeys, call.c.IsSet); err != nil {
+			return nil, err
+		}
+		return call.c, nil
+	}
+
+	// 读取和解码不持有加载器的锁
 	err := yc.Load()
+	loader.finishLoad(key, call, yc, err)
 	if err != nil {
 		return nil, err
 	}
 
-	loader.rwl.Lock()
-	loader.configMap[key] = yc
-	loader.rwl.Unlock()
-
-	yc.p.Watch(func(p string, data []byte) {
-		if p == path {
-			loader.rwl.Lock()
-			delete(loader.configMap, key)
-			loader.rwl.Unlock()
+	// 配置文件变化时重新加载，未开启自动重新加载时清除缓存，下次Load时重新读取
+	onChange := func() {
+		if loader.isRemoved() {
+			return
 		}
-	})
+		if yc.autoReload {
+			yc.Reload()
+			return
+		}
+		loader.evict(key)
+	}
+	loader.watchOverlays(key, yc, onChange)
+
+	e := &watchEntry{owner: loader, id: key, p: yc.p, path: path}
+	if _, ok := yc.p.(DeltaProvider); ok {
+		e.onDelta = func(version string, deltas []Delta) {
+			if loader.isRemoved() {
+				return
+			}
+			if yc.autoReload {
+				yc.applyDeltas(version, deltas)
+				return
+			}
+			loader.evict(key)
+		}
+	} else {
+		e.onChange = onChange
+	}
+	loader.watches.watch(e)
 
 	return yc, nil
 }
@@ -85,19 +239,27 @@ func (loader *FullConfigLoader) Reload(path string, opts ...LoadOption) error {
 	for _, o := range opts {
 		o(yc)
 	}
-	key := fmt.Sprintf("%s.%s.%s", yc.decoder.Name(), yc.p.Name(), path)
-	loader.rwl.RLock()
-	if config, ok := loader.configMap[key]; ok {
-		loader.rwl.RUnlock()
+	if err := yc.resolvePath(); err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s.%s.%s", yc.decoder.Name(), yc.p.Name(), yc.path)
+	if config, ok := loader.cached(key); ok {
 		config.Reload()
 		return nil
 	}
-	loader.rwl.RUnlock()
 	return ErrConfigNotExist
 }
 
 func newFullConfigLoad() *FullConfigLoader {
-	return &FullConfigLoader{configMap: map[string]Config{}, rwl: sync.RWMutex{}}
+	loader := &FullConfigLoader{watches: newWatchHub()}
+	for i := range loader.shards {
+		loader.shards[i] = &loaderShard{
+			configMap: map[string]Config{},
+			loadedMap: map[string]*FrameworkConfig{},
+			inflight:  map[string]*loadCall{},
+		}
+	}
+	return loader
 }
 
 // DefaultConfigLoader 默认配置加载器
@@ -113,7 +275,7 @@ func (*YamlCodec) Name() string {
 
 // Unmarshal yaml decode
 func (c *YamlCodec) Unmarshal(in []byte, out interface{}) error {
-	return yaml.Unmarshal(in, out)
+	return unmarshalYAML(in, out)
 }
 
 // JSONCodec JSON codec
@@ -139,21 +301,99 @@ func (*TomlCodec) Name() string {
 
 // Unmarshal toml decode
 func (c *TomlCodec) Unmarshal(in []byte, out interface{}) error {
-	return toml.Unmarshal(in, out)
+	return unmarshalTOML(in, out)
 }
 
 // FrameworkConfig 解析yaml类型的配置文件
 type FrameworkConfig struct {
-	p             DataProvider
-	unmarshedData interface{}
-	path          string
-	decoder       Codec
-	rawData       []byte
+	p            DataProvider
+	path         string
+	decoder      Codec
+	requiredKeys []string
+	schemas      []Schema
+	bindings     []*Binding
+	bindLock     sync.Mutex
+	// current 当前生效的 *snapshot
+	current atomic.Value
+
+	strictDeprecation bool
+	unknownKeyMode    UnknownKeyMode
+	tracer            Tracer
+
+	history *history
+	// staleErr 最近一次重新加载失败的原因，此时使用的是之前加载的配置
+	staleErr   error
+	staleSince time.Time
+	// drift 内容源中的配置与当前生效的配置不一致，staleLock 同时保护 drift
+	drift     *Drift
+	staleLock sync.Mutex
+
+	slowReadThreshold time.Duration
+	lazy              bool
+	parseCache        bool
+	discardRaw        bool
+	mmap              bool
+	compactIndex      bool
+	parseCacheDir     string
+
+	autoReload  bool
+	reloadLock  sync.Mutex
+	changeHooks []func([]Change)
+	hookLock    sync.Mutex
+
+	// 合并到配置文件上的其他配置层
+	defaults     map[string]interface{}
+	profiles     []string
+	tenant       string
+	envPrefix    string
+	envLookup    func(string) (string, bool)
+	envSeparator string
+	flags        *flag.FlagSet
+	overrides    map[string]interface{}
+	placeholders bool
+	transforms   []Transform
+	gate         ReloadGate
+	// searchDirs WithSearchPaths 指定的查找目录
+	searchDirs []string
+	// readOnly 不允许 Set，例如 FromEnviron 创建的配置
+	readOnly bool
+}
+
+// MissingKeysError 必需配置项缺失
+type MissingKeysError struct {
+	Path string
+	Keys []string
+}
+
+// Error 实现error接口
+func (e *MissingKeysError) Error() string {
+	return Render(e, nil)
+}
+
+// checkRequired 检查data中是否包含全部必需配置项
+func (c *FrameworkConfig) checkRequired(data map[string]interface{}) error {
+	return checkRequiredKeys(c.path, c.requiredKeys, func(key string) bool {
+		_, err := c.search(data, c.parseKey(key))
+		return err == nil
+	})
+}
+
+// checkRequiredKeys 检查keys是否全部存在，返回包含全部缺失项的错误
+func checkRequiredKeys(path string, keys []string, isSet func(string) bool) error {
+	var missing []string
+	for _, key := range keys {
+		if !isSet(key) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingKeysError{Path: path, Keys: missing}
+	}
+	return nil
 }
 
 func (c *FrameworkConfig) find(key string) (interface{}, error) {
-	subkeys := c.parseKey(key)
-	return c.locateSubkey(subkeys)
+	return c.lookup(c.snap(), key)
 }
 
 // Get 根据key读取配置
@@ -165,105 +405,81 @@ func (c *FrameworkConfig) Get(key string, defaultValue interface{}) interface{}
 	return defaultValue
 }
 
-// Bytes 获得原始配置
+// Bytes 获得原始配置，使用 WithDiscardRaw 时返回nil
 func (c *FrameworkConfig) Bytes() []byte {
-	return c.rawData
+	return c.snap().raw
 }
 
-func (c *FrameworkConfig) findWithDefaultValue(key string, defaultValue interface{}) interface{} {
-	v, err := c.find(key)
-	if err != nil {
-		return defaultValue
-	}
-
-	switch defaultValue.(type) {
-	case bool:
-		v, err = cast.ToBoolE(v)
-	case string:
-		v, err = cast.ToStringE(v)
-	case int:
-		v, err = cast.ToIntE(v)
-	case int32:
-		v, err = cast.ToInt32E(v)
-	case int64:
-		v, err = cast.ToInt64E(v)
-	case uint:
-		v, err = cast.ToUintE(v)
-	case uint32:
-		v, err = cast.ToUint32E(v)
-	case uint64:
-		v, err = cast.ToUint64E(v)
-	case float64:
-		v, err = cast.ToFloat64E(v)
-	case float32:
-		v, err = cast.ToFloat32E(v)
-	default:
-	}
-
-	if err != nil {
-		return defaultValue
-	}
+// Fingerprint 获得当前生效配置内容的sha256，内容不变时保持不变
+func (c *FrameworkConfig) Fingerprint() string {
+	return c.snap().fingerprint
+}
 
-	return v
+// Version 获得内容源提供的当前生效配置的版本号，provider未实现 VersionedProvider 时为空
+func (c *FrameworkConfig) Version() string {
+	return c.snap().version
 }
 
 // GetInt 根据key读取int类型配置
 func (c *FrameworkConfig) GetInt(key string, defaultValue int) int {
-	return cast.ToInt(c.findWithDefaultValue(key, defaultValue))
+	return c.Snapshot().GetInt(key, defaultValue)
 }
 
 // GetInt32 根据key读取int32类型配置
 func (c *FrameworkConfig) GetInt32(key string, defaultValue int32) int32 {
-	return cast.ToInt32(c.findWithDefaultValue(key, defaultValue))
+	return c.Snapshot().GetInt32(key, defaultValue)
 }
 
 // GetInt64 根据key读取int64类型配置
 func (c *FrameworkConfig) GetInt64(key string, defaultValue int64) int64 {
-	return cast.ToInt64(c.findWithDefaultValue(key, defaultValue))
+	return c.Snapshot().GetInt64(key, defaultValue)
 }
 
 // GetUint 根据key读取int类型配置
 func (c *FrameworkConfig) GetUint(key string, defaultValue uint) uint {
-	return cast.ToUint(c.findWithDefaultValue(key, defaultValue))
+	return c.Snapshot().GetUint(key, defaultValue)
 }
 
 // GetUint32 根据key读取uint32类型配置
 func (c *FrameworkConfig) GetUint32(key string, defaultValue uint32) uint32 {
-	return cast.ToUint32(c.findWithDefaultValue(key, defaultValue))
+	return c.Snapshot().GetUint32(key, defaultValue)
 }
 
 // GetUint64 根据key读取uint64类型配置
 func (c *FrameworkConfig) GetUint64(key string, defaultValue uint64) uint64 {
-	return cast.ToUint64(c.findWithDefaultValue(key, defaultValue))
+	return c.Snapshot().GetUint64(key, defaultValue)
 }
 
 // GetFloat64 根据key读取float64类型配置
 func (c *FrameworkConfig) GetFloat64(key string, defaultValue float64) float64 {
-	return cast.ToFloat64(c.findWithDefaultValue(key, defaultValue))
+	return c.Snapshot().GetFloat64(key, defaultValue)
 }
 
 // GetFloat32 根据key读取float32类型配置
 func (c *FrameworkConfig) GetFloat32(key string, defaultValue float32) float32 {
-	return cast.ToFloat32(c.findWithDefaultValue(key, defaultValue))
+	return c.Snapshot().GetFloat32(key, defaultValue)
 }
 
 // GetBool 根据key读取bool类型配置
 func (c *FrameworkConfig) GetBool(key string, defaultValue bool) bool {
-	return cast.ToBool(c.findWithDefaultValue(key, defaultValue))
+	return c.Snapshot().GetBool(key, defaultValue)
 }
 
 // IsSet 根据key判断配置是否存在
 func (c *FrameworkConfig) IsSet(key string) bool {
-	subkeys := c.parseKey(key)
-	_, err := c.locateSubkey(subkeys)
-	if err != nil {
-		return false
-	}
-	return true
+	_, err := c.find(key)
+	return err == nil
 }
 
-func (c *FrameworkConfig) locateSubkey(subkeys []string) (interface{}, error) {
-	return c.search(cast.ToStringMap(c.unmarshedData), subkeys)
+// lookup 读取s中key对应的配置值，优先使用索引
+func (c *FrameworkConfig) lookup(s *snapshot, key string) (interface{}, error) {
+	if v, ok := s.indexLookup(key); ok {
+		return resolveLazy(v), nil
+	}
+	if s.indexed {
+		return nil, ErrConfigNotExist
+	}
+	return c.search(s.tree, c.parseKey(key))
 }
 
 func (c *FrameworkConfig) search(unmarshedData map[string]interface{}, subkeys []string) (interface{}, error) {
@@ -271,20 +487,21 @@ func (c *FrameworkConfig) search(unmarshedData map[string]interface{}, subkeys [
 		return nil, ErrConfigNotExist
 	}
 
-	next, ok := unmarshedData[subkeys[0]]
-	if ok {
-		if len(subkeys) == 1 {
-			return next, nil
+	cur := unmarshedData
+	for i, subkey := range subkeys {
+		next, ok := cur[subkey]
+		if !ok {
+			return nil, ErrConfigNotExist
+		}
+		if i == len(subkeys)-1 {
+			return resolveLazy(next), nil
 		}
 
-		switch next.(type) {
-
-		case map[interface{}]interface{}:
-			return c.search(cast.ToStringMap(next), subkeys[1:])
-
+		switch m := next.(type) {
 		case map[string]interface{}:
-			return c.search(next.(map[string]interface{}), subkeys[1:])
-
+			cur = m
+		case *lazySection:
+			cur = m.get()
 		default:
 			return nil, ErrConfigNotExist
 		}
@@ -294,22 +511,7 @@ func (c *FrameworkConfig) search(unmarshedData map[string]interface{}, subkeys [
 
 // GetString 根据key读取string类型配置
 func (c *FrameworkConfig) GetString(key string, defaultValue string) string {
-	subkeys := c.parseKey(key)
-
-	value, err := c.locateSubkey(subkeys)
-	if err != nil {
-		return defaultValue
-	}
-
-	if result, ok := value.(string); ok {
-		return result
-	}
-
-	if result, err := cast.ToStringE(value); err == nil {
-		return result
-	}
-
-	return defaultValue
+	return c.Snapshot().GetString(key, defaultValue)
 }
 
 // Load 加载配置
@@ -318,48 +520,347 @@ func (c *FrameworkConfig) Load() error {
 		return ErrProviderNotExist
 	}
 
-	data, err := c.p.Read(c.path)
+	ctx, span := c.startSpan(context.Background(), "config.Load")
+	err := c.load(ctx)
+	endSpan(span, err)
+	metrics.add(metricLoads, 1, "path", c.path, "result", resultLabel(err))
+	return err
+}
+
+func (c *FrameworkConfig) load(ctx context.Context) (err error) {
+	data, version, release, err := c.read(ctx)
 	if err != nil {
 		return fmt.Errorf("app/config: failed to load %s: %s", c.path, err.Error())
 	}
-	c.rawData = data
-	c.unmarshedData = map[string]interface{}{}
-	err = c.decoder.Unmarshal(c.rawData, &c.unmarshedData)
+	defer release()
+	defer recoverFault(c.path, &err)
+
+	unmarshedData, origins, err := c.parse(data)
 	if err != nil {
-		return fmt.Errorf("app/config: failed to parse %s: %s", c.path, err.Error())
+		return err
 	}
+	c.publish(newSnapshot(data, version, unmarshedData, origins))
 	return nil
 }
 
 // Reload 重新载入
 func (c *FrameworkConfig) Reload() {
+	_ = c.reloadAndReport()
+}
+
+// reloadAndReport 重新载入并记录埋点，返回失败原因
+func (c *FrameworkConfig) reloadAndReport() error {
 	if c.p == nil {
-		return
+		return nil
+	}
+	ctx, span := c.startSpan(context.Background(), "config.Reload")
+	err := c.reload(ctx)
+	endSpan(span, err)
+	metrics.add(metricReloads, 1, "path", c.path, "result", resultLabel(err))
+	if err != nil {
+		logger.Errorf("%v", err)
+		c.recordReload(nil, err)
+	}
+	c.setStale(err)
+	return err
+}
+
+// maxReloadAttempts 等待ReloadGate期间配置被其他修改替换时，重新读取的最大次数
+const maxReloadAttempts = 3
+
+// reload 在reloadLock之外读取、校验新配置并等待ReloadGate许可，
+// 持有reloadLock后确认等待期间没有其他修改生效，否则放弃本次结果重新读取
+func (c *FrameworkConfig) reload(ctx context.Context) error {
+	for i := 0; i < maxReloadAttempts; i++ {
+		applied, err := c.tryReload(ctx)
+		if err != nil || applied {
+			return err
+		}
 	}
+	return fmt.Errorf("app/config: reload of %s superseded by concurrent changes %d times", c.path, maxReloadAttempts)
+}
 
-	data, err := c.p.Read(c.path)
+// tryReload 读取并生效一次新配置，等待期间配置被其他修改替换时返回false
+// 读取到的内容只在reloadLock之外访问，持有锁期间不会因为映射的文件被截断而出错
+func (c *FrameworkConfig) tryReload(ctx context.Context) (applied bool, err error) {
+	base := c.snap()
+	next, err := c.prepareReload(ctx)
 	if err != nil {
-		fmt.Printf("app/config: failed to reload %s: %v", c.path, err)
-		return
+		return false, err
 	}
+	defer next.release()
+	defer recoverFault(c.path, &err)
 
-	unmarshedData := map[string]interface{}{}
-	if err = c.decoder.Unmarshal(data, &unmarshedData); err != nil {
-		fmt.Printf("app/config: failed to parse %s: %v", c.path, err)
+	done := func() {}
+	if !next.unchanged {
+		if done, err = c.admit(ctx, next.data); err != nil {
+			return false, err
+		}
+	}
+	defer done()
+
+	var cur *snapshot
+	if !next.unchanged || next.version != base.version {
+		cur = newSnapshot(next.data, next.version, next.tree, next.origins)
+	}
+	c.reloadLock.Lock()
+	defer c.reloadLock.Unlock()
+	if c.snap() != base {
+		return false, nil
+	}
+	c.apply(next, cur)
+	return true, nil
+}
+
+// apply 生效已读取并校验通过的新配置cur，cur为nil表示内容和版本都没有变化，调用方需要持有reloadLock
+func (c *FrameworkConfig) apply(next *pendingReload, cur *snapshot) {
+	if cur == nil {
+		c.recordReload(nil, nil)
 		return
 	}
 
-	c.rawData = data
-	c.unmarshedData = unmarshedData
+	prev := c.publish(cur)
+	for i, b := range next.bindings {
+		b.store(next.values[i])
+	}
+	var changes []Change
+	if !next.unchanged {
+		changes = diffTrees(prev.tree, cur.tree)
+	}
+	c.audit(cur, prev.fingerprint, changes)
+	c.recordReload(changes, nil)
+	if len(changes) > 0 {
+		c.notifyChange(changes)
+	}
+}
+
+// admit 等待 WithReloadGate 指定的ReloadGate许可新配置生效，返回的done在新配置生效后调用
+func (c *FrameworkConfig) admit(ctx context.Context, data []byte) (func(), error) {
+	if c.gate == nil {
+		return func() {}, nil
+	}
+	_, span := c.startSpan(ctx, "config.ReloadGate.Wait")
+	done, err := c.gate.Wait(ctx, c.path, contentHash(data))
+	endSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("app/config: reload of %s not admitted: %s", c.path, err.Error())
+	}
+	if done == nil {
+		done = func() {}
+	}
+	return done, nil
+}
+
+// pendingReload 读取并校验通过、尚未生效的新配置
+type pendingReload struct {
+	data     []byte
+	version  string
+	tree     map[string]interface{}
+	origins  map[string]Origin
+	bindings []*Binding
+	values   []interface{}
+	// unchanged 内容与当前生效的配置相同，tree、origins沿用当前配置，无需更新绑定
+	unchanged bool
+	// release 释放data，使用 WithMmap 时data在释放前有效
+	release func()
+}
+
+// prepareReload 读取新配置，并完成解析、校验以及全部绑定的解码
+func (c *FrameworkConfig) prepareReload(ctx context.Context) (_ *pendingReload, err error) {
+	data, version, release, err := c.read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("app/config: failed to reload %s: %s", c.path, err.Error())
+	}
+	ok := false
+	defer func() {
+		if !ok {
+			release()
+		}
+	}()
+	defer recoverFault(c.path, &err)
+
+	// 只有配置文件一层时，内容不变则跳过解码，新读取的内容可以立即回收
+	if s := c.snap(); s != emptySnapshot && !c.layered() && contentHash(data) == s.fingerprint {
+		ok = true
+		return &pendingReload{data: data, version: version, tree: s.tree, origins: s.origins, unchanged: true, release: release}, nil
+	}
+
+	unmarshedData, origins, err := c.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	// 延迟解码的配置段中的语法错误在发布前发现，不会以空对象生效
+	if err := checkLazy(c.snap().tree, unmarshedData); err != nil {
+		return nil, fmt.Errorf("app/config: reject reload of %s: %s", c.path, err.Error())
+	}
+
+	bindings, values, err := c.decodeBindings(unmarshedData)
+	if err != nil {
+		return nil, fmt.Errorf("app/config: reject reload of %s: %s", c.path, c.locateErrors(data, err).Error())
+	}
+	ok = true
+	return &pendingReload{
+		data:     data,
+		version:  version,
+		tree:     unmarshedData,
+		origins:  origins,
+		bindings: bindings,
+		values:   values,
+		release:  release,
+	}, nil
+}
+
+// read 从provider读取配置，返回的release在不再使用data后调用
+func (c *FrameworkConfig) read(ctx context.Context) ([]byte, string, func(), error) {
+	_, span := c.startSpan(ctx, "config.provider.Read")
+	start := time.Now()
+	var (
+		data    []byte
+		version string
+		release = func() {}
+		err     error
+	)
+	mp, mapped := c.p.(MappedProvider)
+	bp, buffered := c.p.(BufferedProvider)
+	if c.mmap && mapped {
+		data, version, release, err = mp.ReadMapped(c.path)
+		if err == nil {
+			// 映射的文件被原地截断后访问超出文件末尾的页面会收到SIGBUS，
+			// 释放映射之前将其转换为panic，由 recoverFault 转换为错误
+			unmap, old := release, debug.SetPanicOnFault(true)
+			release = func() {
+				unmap()
+				debug.SetPanicOnFault(old)
+			}
+		}
+	} else if c.discardRaw && buffered {
+		// 原始配置不保留在快照中，解码后缓冲区即可复用
+		buf := getBuffer()
+		version, err = bp.ReadTo(c.path, buf)
+		data, release = buf.Bytes(), func() { putBuffer(buf) }
+	} else if vp, ok := c.p.(VersionedProvider); ok {
+		data, version, err = vp.ReadWithVersion(c.path)
+	} else {
+		data, err = c.p.Read(c.path)
+	}
+	elapsed := time.Since(start)
+	span.SetAttribute("config.bytes", len(data))
+	endSpan(span, err)
+	metrics.add(metricReadSeconds+"_sum", elapsed.Seconds(), "provider", c.p.Name())
+	metrics.add(metricReadSeconds+"_count", 1, "provider", c.p.Name())
+	c.checkSlowRead(elapsed)
+	return data, version, release, err
+}
+
+// hashRead 计算读取到的配置内容的sha256，之后释放data
+func (c *FrameworkConfig) hashRead(data []byte, release func()) (sum string, err error) {
+	defer release()
+	defer recoverFault(c.path, &err)
+	return contentHash(data), nil
+}
+
+// isFault 是否为 debug.SetPanicOnFault 开启后访问无效内存引起的panic
+func isFault(r interface{}) bool {
+	_, ok := r.(interface{ Addr() uintptr })
+	return ok
+}
+
+// recoverFault 将访问映射内存引起的panic转换为错误，需要在defer中直接调用，其他panic继续传递
+func recoverFault(path string, err *error) {
+	if r := recover(); r != nil {
+		if !isFault(r) {
+			panic(r)
+		}
+		*err = fmt.Errorf("app/config: %s was truncated while mapped, replace it by rename instead: %v", path, r)
+	}
+}
+
+// parse 解码原始配置，并执行schema、必需配置项等检查
+// 同时返回由配置文件之外的配置层提供的配置项及其来源
+func (c *FrameworkConfig) parse(data []byte) (map[string]interface{}, map[string]Origin, error) {
+	unmarshedData, ok := map[string]interface{}(nil), false
+	if c.lazy && len(c.schemas) == 0 && len(c.transforms) == 0 {
+		unmarshedData, ok = lazyDecode(c.decoder, data)
+	}
+	hash := ""
+	if !ok && c.parseCache {
+		hash = contentHash(data)
+		unmarshedData, ok = c.loadParseCache(hash)
+	}
+	if !ok {
+		unmarshedData = map[string]interface{}{}
+		if err := decode(c.decoder, data, &unmarshedData); err != nil {
+			return nil, nil, newParseError(c.path, data, err)
+		}
+		normalizeTree(unmarshedData)
+		if hash != "" {
+			c.storeParseCache(hash, unmarshedData)
+		}
+	}
+	unmarshedData, origins, err := c.mergeLayers(unmarshedData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, s := range c.schemas {
+		checked, err := s.Check(unmarshedData)
+		if err != nil {
+			return nil, nil, fmt.Errorf("app/config: %s does not match schema: %s", c.path, c.locateErrors(data, err).Error())
+		}
+		if checked != nil {
+			unmarshedData = checked
+		}
+	}
+
+	if c.layered() || len(c.schemas) > 0 {
+		// 其他配置层和schema可能引入未转换的值
+		normalizeTree(unmarshedData)
+	}
+
+	if err := c.check(data, unmarshedData); err != nil {
+		return nil, nil, err
+	}
+	return unmarshedData, origins, nil
 }
 
-// Unmarshal 反序列化
+// check 执行必需配置项、校验函数、废弃配置项和未声明配置项检查，data为用于定位错误的原始配置
+func (c *FrameworkConfig) check(data []byte, tree map[string]interface{}) error {
+	checks := []func(map[string]interface{}) error{
+		c.checkRequired,
+		c.runValidators,
+		c.checkDeprecated,
+		c.checkUnknown,
+	}
+	for _, check := range checks {
+		if err := check(tree); err != nil {
+			return c.locateErrors(data, err)
+		}
+	}
+	return nil
+}
+
+// Unmarshal 反序列化，并按照validate tag校验结果
 func (c *FrameworkConfig) Unmarshal(out interface{}) error {
-	return c.decoder.Unmarshal(c.rawData, out)
+	s := c.snap()
+	if c.layered() || s.patched || s.raw == nil {
+		return c.UnmarshalKey("", out)
+	}
+	if err := decode(c.decoder, s.raw, out); err != nil {
+		return err
+	}
+	if err := applyDefaults("", s.tree, reflect.ValueOf(out), c.decoder.Name()); err != nil {
+		return err
+	}
+	return c.locateErrors(s.raw, validateStruct("", s.tree, out, c.decoder.Name()))
+}
+
+// UnmarshalKey 将key对应的配置子树反序列化到out，并按照validate tag校验结果
+func (c *FrameworkConfig) UnmarshalKey(key string, out interface{}) error {
+	return c.Snapshot().UnmarshalKey(key, out)
 }
 
 func (c *FrameworkConfig) parseKey(key string) []string {
-	return strings.Split(key, ".")
+	return splitKey(key)
 }
 
 func newFullConfig(path string) *FrameworkConfig {
@@ -367,6 +868,10 @@ func newFullConfig(path string) *FrameworkConfig {
 		p:       GetProvider("file"),
 		path:    path,
 		decoder: &YamlCodec{},
+		tracer:  noopTracer{},
+		history: newHistory(defaultHistorySize),
+
+		slowReadThreshold: DefaultSlowReadThreshold,
 	}
 	return yc
 }
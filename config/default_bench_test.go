@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const benchYAML = `
+server:
+  app: demo
+  port: 8000
+  debug: true
+  ratio: 0.5
+plugins:
+  database:
+    default:
+      dsn: "tcp(127.0.0.1:3306)/demo"
+`
+
+func newBenchConfig(tb testing.TB) *FrameworkConfig {
+	path := filepath.Join(tb.TempDir(), "bench.yaml")
+	if err := os.WriteFile(path, []byte(benchYAML), 0644); err != nil {
+		tb.Fatal(err)
+	}
+	c, err := Load(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return c.(*FrameworkConfig)
+}
+
+func BenchmarkGet(b *testing.B) {
+	c := newBenchConfig(b)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Get("plugins.database.default.dsn", nil)
+		}
+	})
+}
+
+func BenchmarkGetString(b *testing.B) {
+	c := newBenchConfig(b)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.GetString("server.app", "")
+		}
+	})
+}
+
+func BenchmarkGetInt(b *testing.B) {
+	c := newBenchConfig(b)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.GetInt("server.port", 0)
+		}
+	})
+}
+
+func BenchmarkGetIntMissing(b *testing.B) {
+	c := newBenchConfig(b)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.GetInt("server.missing", 80)
+		}
+	})
+}
+
+// TestGetAllocs 读取已存在的配置项不分配内存
+func TestGetAllocs(t *testing.T) {
+	c := newBenchConfig(t)
+	for name, get := range map[string]func(){
+		"Get":        func() { c.Get("plugins.database.default.dsn", nil) },
+		"GetString":  func() { c.GetString("server.app", "") },
+		"GetInt":     func() { c.GetInt("server.port", 0) },
+		"GetBool":    func() { c.GetBool("server.debug", false) },
+		"GetFloat64": func() { c.GetFloat64("server.ratio", 0) },
+	} {
+		if n := testing.AllocsPerRun(100, get); n != 0 {
+			t.Errorf("%s allocates %v times per call, want 0", name, n)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// applyDeltas 将 DeltaProvider 推送的变更应用到当前配置，无需重新读取和解码整个配置
+// 使用了其他配置层或schema时，变更可能与它们冲突，改为完整重新加载
+func (c *FrameworkConfig) applyDeltas(version string, deltas []Delta) {
+	if c.layered() || len(c.schemas) > 0 {
+		c.Reload()
+		return
+	}
+	if err := c.patch(version, deltas, false); err != nil {
+		if err == ErrConfigNotSupport {
+			// codec不支持重新编码，无法生成一致的原始配置
+			c.Reload()
+			return
+		}
+		logger.Errorf("%v", err)
+		c.recordReload(nil, err)
+		c.setStale(err)
+	}
+}
+
+// patch 在当前配置的基础上应用变更，校验和绑定解码全部通过才发布新配置
+// local 表示变更由 Set 产生，尚未写回内容源
+func (c *FrameworkConfig) patch(version string, deltas []Delta, local bool) (err error) {
+	c.reloadLock.Lock()
+	defer c.reloadLock.Unlock()
+
+	ctx, span := c.startSpan(context.Background(), "config.ApplyDelta")
+	defer func() {
+		endSpan(span, err)
+		metrics.add(metricReloads, 1, "path", c.path, "result", resultLabel(err))
+	}()
+
+	prev := c.snap()
+	tree := patchTree(prev.tree, deltas)
+	raw, err := Encode(tree, c.decoder.Name())
+	if err != nil {
+		return err
+	}
+	if err := c.check(raw, tree); err != nil {
+		return fmt.Errorf("app/config: reject delta of %s: %s", c.path, err.Error())
+	}
+	bindings, values, err := c.decodeBindings(tree)
+	if err != nil {
+		return fmt.Errorf("app/config: reject delta of %s: %s", c.path, err.Error())
+	}
+	done := func() {}
+	if !local {
+		if done, err = c.admit(ctx, raw); err != nil {
+			return err
+		}
+	}
+
+	cur := newSnapshot(raw, version, tree, prev.origins)
+	cur.patched = true
+	cur.dirty = local || prev.dirty
+	c.publish(cur)
+	for i, b := range bindings {
+		b.store(values[i])
+	}
+
+	var changes []Change
+	for _, key := range deltaRoots(deltas) {
+		old, hadOld := lookupTree(prev.tree, key)
+		new, hasNew := lookupTree(tree, key)
+		switch {
+		case !hadOld && hasNew:
+			changes = append(changes, Change{Key: key, Type: ChangeAdded, New: redactTree(key, new)})
+		case hadOld && !hasNew:
+			changes = append(changes, Change{Key: key, Type: ChangeRemoved, Old: redactTree(key, old)})
+		case hadOld && hasNew:
+			diffValue(key, old, new, &changes)
+		}
+	}
+	c.audit(cur, prev.fingerprint, changes)
+	c.recordReload(changes, nil)
+	c.setStale(nil)
+	if len(changes) > 0 {
+		c.notifyChange(changes)
+	}
+	done()
+	return nil
+}
+
+// patchTree 返回应用变更后的配置树，只复制变更路径上的对象，其余部分与tree共享
+func patchTree(tree map[string]interface{}, deltas []Delta) map[string]interface{} {
+	root := copyMap(tree)
+	// copied 本次已复制的对象，同一对象下的多个变更只复制一次
+	copied := map[string]map[string]interface{}{"": root}
+	for _, d := range deltas {
+		segs := strings.Split(d.Key, ".")
+		cur := root
+		for i, seg := range segs[:len(segs)-1] {
+			path := strings.Join(segs[:i+1], ".")
+			next, ok := copied[path]
+			if !ok {
+				sub, isMap := toStringMap(cur[seg])
+				if !isMap {
+					if d.Deleted {
+						break
+					}
+					sub = map[string]interface{}{}
+				}
+				next = copyMap(sub)
+				copied[path] = next
+				cur[seg] = next
+			}
+			cur = next
+		}
+		if len(segs) > 1 && copied[strings.Join(segs[:len(segs)-1], ".")] == nil {
+			// 删除的配置项的上级不存在
+			continue
+		}
+
+		last := segs[len(segs)-1]
+		if d.Deleted {
+			delete(cur, last)
+		} else {
+			cur[last] = normalizeTree(copyTree(d.Value))
+		}
+		// 被替换的子树中已复制的对象不再属于配置树
+		for path := range copied {
+			if path == d.Key || strings.HasPrefix(path, d.Key+".") {
+				delete(copied, path)
+			}
+		}
+	}
+	return root
+}
+
+// deltaRoots 返回变更涉及的key，去掉已被其他变更的上级配置项包含的key，按照变更顺序排列
+func deltaRoots(deltas []Delta) []string {
+	var roots []string
+	for _, d := range deltas {
+		covered := false
+		for _, o := range deltas {
+			if strings.HasPrefix(d.Key, o.Key+".") {
+				covered = true
+				break
+			}
+		}
+		if !covered && !containsString(roots, d.Key) {
+			roots = append(roots, d.Key)
+		}
+	}
+	return roots
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// copyMap 浅拷贝对象
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
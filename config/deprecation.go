@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Deprecation 废弃配置项声明
+type Deprecation struct {
+	// Key 废弃的配置项
+	Key string
+	// Message 迁移说明，例如 "use server.timeout instead"
+	Message string
+	// Since 开始废弃的版本
+	Since string
+	// RemovedIn 计划移除的版本
+	RemovedIn string
+}
+
+var (
+	deprecations    = make(map[string]Deprecation)
+	deprecationLock = sync.RWMutex{}
+)
+
+// RegisterDeprecation 声明废弃的配置项
+// 加载的配置中出现该配置项时输出告警日志，使用 WithStrictDeprecation 时加载失败
+func RegisterDeprecation(d Deprecation) {
+	deprecationLock.Lock()
+	deprecations[d.Key] = d
+	deprecationLock.Unlock()
+}
+
+// checkDeprecated 检查配置中的废弃配置项
+func (c *FrameworkConfig) checkDeprecated(data map[string]interface{}) error {
+	deprecationLock.RLock()
+	defer deprecationLock.RUnlock()
+
+	var errs ValidationErrors
+	for key, d := range deprecations {
+		if _, err := c.search(data, c.parseKey(key)); err != nil {
+			continue
+		}
+		logger.Warnf("app/config: deprecated key path=%s key=%s since=%s removed_in=%s: %s",
+			c.path, key, d.Since, d.RemovedIn, d.Message)
+		if c.strictDeprecation {
+			errs = append(errs, &FieldError{
+				Key:  key,
+				Rule: "deprecated",
+				Err:  fmt.Errorf("deprecated since %s, removed in %s: %s", d.Since, d.RemovedIn, d.Message),
+			})
+		}
+	}
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Key < errs[j].Key })
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+)
+
+// ChangeType 配置项变更类型
+type ChangeType string
+
+const (
+	// ChangeAdded 新增配置项
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved 删除配置项
+	ChangeRemoved ChangeType = "removed"
+	// ChangeModified 修改配置项
+	ChangeModified ChangeType = "modified"
+)
+
+// Change 配置项变更
+type Change struct {
+	Key  string      `json:"key" yaml:"key"`
+	Type ChangeType  `json:"type" yaml:"type"`
+	Old  interface{} `json:"old,omitempty" yaml:"old,omitempty"`
+	New  interface{} `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// Diff 对比配置a和b，返回按照key排序的新增、删除以及修改的配置项，敏感配置项的值会被脱敏
+// a或b为nil时视为空配置
+func Diff(a, b Config) []Change {
+	return diffTrees(configTree(a), configTree(b))
+}
+
+// configTree 获取配置当前生效的配置树
+func configTree(c Config) interface{} {
+	if c == nil {
+		return map[string]interface{}{}
+	}
+	return c.EffectiveConfig()
+}
+
+// DiffTrees 对比两个解码后的配置树，返回按照key排序的变更列表，敏感配置项的值会被脱敏
+func DiffTrees(old, new interface{}) []Change {
+	return diffTrees(old, new)
+}
+
+// diffTrees 对比两个配置树，返回按照key排序的变更列表
+// 列表作为整体进行对比，敏感配置项的值会被脱敏
+func diffTrees(old, new interface{}) []Change {
+	changes := make([]Change, 0)
+	diffValue("", old, new, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+func diffValue(path string, old, new interface{}, changes *[]Change) {
+	// 原始内容相同的延迟解码配置段没有变化，无需解码
+	if lo, ok := old.(*lazySection); ok {
+		if ln, ok := new.(*lazySection); ok && bytes.Equal(lo.raw, ln.raw) {
+			return
+		}
+	}
+	om, oldIsMap := toStringMap(old)
+	nm, newIsMap := toStringMap(new)
+	if oldIsMap && newIsMap {
+		for k, ov := range om {
+			key := joinKey(path, k)
+			nv, ok := nm[k]
+			if !ok {
+				*changes = append(*changes, Change{Key: key, Type: ChangeRemoved, Old: redactTree(key, ov)})
+				continue
+			}
+			diffValue(key, ov, nv, changes)
+		}
+		for k, nv := range nm {
+			if _, ok := om[k]; !ok {
+				key := joinKey(path, k)
+				*changes = append(*changes, Change{Key: key, Type: ChangeAdded, New: redactTree(key, nv)})
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, Change{
+			Key:  path,
+			Type: ChangeModified,
+			Old:  redactTree(path, old),
+			New:  redactTree(path, new),
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"context"
+	"time"
+)
+
+// Drift 内容源中的配置与当前生效的配置不一致，例如丢失了变更通知或重新加载一直失败
+type Drift struct {
+	Path     string `json:"path"`
+	Provider string `json:"provider"`
+	// Version、Fingerprint 当前生效配置的版本号和sha256
+	Version     string `json:"version"`
+	Fingerprint string `json:"fingerprint"`
+	// RemoteVersion、RemoteFingerprint 内容源中配置的版本号和sha256
+	RemoteVersion     string    `json:"remote_version"`
+	RemoteFingerprint string    `json:"remote_fingerprint"`
+	Since             time.Time `json:"since"`
+}
+
+// drifted 返回当前记录的不一致状态
+func (c *FrameworkConfig) drifted() *Drift {
+	c.staleLock.Lock()
+	defer c.staleLock.Unlock()
+	return c.drift
+}
+
+// checkDrift 读取内容源中的配置并与当前生效配置的sha256对比，发现新的不一致时返回true
+func (c *FrameworkConfig) checkDrift(ctx context.Context) (*Drift, bool, error) {
+	data, version, release, err := c.read(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	remote, err := c.hashRead(data, release)
+	if err != nil {
+		return nil, false, err
+	}
+
+	cur := c.snap()
+	c.staleLock.Lock()
+	defer c.staleLock.Unlock()
+	// 增量更新生成的配置无法按内容比较，以版本号为准
+	if remote == cur.fingerprint || (cur.patched && version != "" && version == cur.version) {
+		c.drift = nil
+		return nil, false, nil
+	}
+	if c.drift != nil && c.drift.RemoteFingerprint == remote && c.drift.RemoteVersion == version {
+		return c.drift, false, nil
+	}
+	since := Now()
+	if c.drift != nil {
+		since = c.drift.Since
+	}
+	c.drift = &Drift{
+		Path:              c.path,
+		Provider:          c.p.Name(),
+		Version:           cur.version,
+		Fingerprint:       cur.fingerprint,
+		RemoteVersion:     version,
+		RemoteFingerprint: remote,
+		Since:             since,
+	}
+	return c.drift, true, nil
+}
+
+// CheckDrift 对比全部已加载配置与内容源中的配置，返回不一致的配置
+func (loader *FullConfigLoader) CheckDrift(ctx context.Context) []*Drift {
+	drifts := make([]*Drift, 0)
+	for _, c := range loader.loaded() {
+		d, _, err := c.checkDrift(ctx)
+		if err != nil {
+			logger.Warnf("app/config: failed to check drift path=%s provider=%s: %v", c.path, c.p.Name(), err)
+			continue
+		}
+		if d != nil {
+			drifts = append(drifts, d)
+		}
+	}
+	return drifts
+}
+
+// WatchDrift 每隔interval检查一次全部已加载配置是否与内容源一致，ctx结束后停止
+// 发现新的不一致时输出告警日志并调用handlers，当前状态通过 app_config_drift 指标和 Health 查看
+func (loader *FullConfigLoader) WatchDrift(ctx context.Context, interval time.Duration, handlers ...func(*Drift)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			for _, c := range loader.loaded() {
+				d, found, err := c.checkDrift(ctx)
+				if err != nil {
+					logger.Warnf("app/config: failed to check drift path=%s provider=%s: %v", c.path, c.p.Name(), err)
+					continue
+				}
+				if !found {
+					continue
+				}
+				logger.Warnf("app/config: config drift path=%s provider=%s fingerprint=%s remote_fingerprint=%s version=%s remote_version=%s",
+					d.Path, d.Provider, d.Fingerprint, d.RemoteFingerprint, d.Version, d.RemoteVersion)
+				for _, fn := range handlers {
+					fn(d)
+				}
+			}
+		}
+	}()
+}
+
+// WatchDrift 定期检查默认加载器中的配置是否与内容源一致
+func WatchDrift(ctx context.Context, interval time.Duration, handlers ...func(*Drift)) {
+	DefaultConfigLoader.WatchDrift(ctx, interval, handlers...)
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"context"
+	"fmt"
+)
+
+// DryRunResult 试运行重新加载的结果
+type DryRunResult struct {
+	Path   string `json:"path"`
+	Tenant string `json:"tenant,omitempty"`
+	// Version、Fingerprint 新配置的版本号和sha256
+	Version     string   `json:"version"`
+	Fingerprint string   `json:"fingerprint"`
+	Changes     []Change `json:"changes"`
+}
+
+// DryRunReload 读取新配置并完成与重新加载相同的全部校验（schema、校验规则、绑定及其检查函数），
+// 返回生效后将产生的变更，但不替换当前配置
+func (c *FrameworkConfig) DryRunReload() (*DryRunResult, error) {
+	if c.p == nil {
+		return nil, ErrProviderNotExist
+	}
+
+	ctx, span := c.startSpan(context.Background(), "config.DryRunReload")
+	next, err := c.prepareReload(ctx)
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := c.hashRead(next.data, next.release)
+	if err != nil {
+		return nil, err
+	}
+	return &DryRunResult{
+		Path:        c.path,
+		Tenant:      c.tenant,
+		Version:     next.version,
+		Fingerprint: fingerprint,
+		Changes:     diffTrees(c.snap().tree, next.tree),
+	}, nil
+}
+
+// DryRunReload 试运行重新加载指定配置，参数与 Reload 相同
+func (loader *FullConfigLoader) DryRunReload(path string, opts ...LoadOption) (*DryRunResult, error) {
+	yc := newFullConfig(path)
+	for _, o := range opts {
+		o(yc)
+	}
+	if err := yc.resolvePath(); err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s.%s.%s", yc.decoder.Name(), yc.p.Name(), yc.path)
+	c, ok := loader.lastLoaded(key)
+	if !ok {
+		return nil, ErrConfigNotExist
+	}
+	return c.DryRunReload()
+}
+
+// DryRunReload 试运行重新加载默认加载器中的指定配置
+func DryRunReload(path string, opts ...LoadOption) (*DryRunResult, error) {
+	return DefaultConfigLoader.DryRunReload(path, opts...)
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EffectiveConfig 返回当前生效的完整配置树的副本，
+// 即默认值、配置文件、profile、环境变量、命令行参数以及覆盖值合并并解析占位符后的结果
+func (c *FrameworkConfig) EffectiveConfig() map[string]interface{} {
+	tree, _ := copyTree(c.snap().tree).(map[string]interface{})
+	return tree
+}
+
+// WriteEffectiveConfig 将c当前生效的完整配置以codec格式写入文件，敏感配置项会被脱敏
+func WriteEffectiveConfig(c Config, path string, codec string) error {
+	data, err := Encode(Redact("", c.EffectiveConfig()), codec)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Encode 将配置树编码为codec格式，codec支持yaml、json、toml
+func Encode(v interface{}, codec string) ([]byte, error) {
+	switch codec {
+	case "yaml":
+		return yaml.Marshal(v)
+	case "json":
+		out, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
+	case "toml":
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, ErrConfigNotSupport
+}
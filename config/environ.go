@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EnvironPath FromEnviron 创建的配置的路径，出现在错误信息和配置项来源中
+const EnvironPath = "environ"
+
+// FromEnviron 捕获进程当前的环境变量并创建只读的Config，之后的 os.Setenv 不影响已创建的配置：
+//
+//	// APP_SERVER_PORT=8000 APP_LOG_LEVEL=debug
+//	c, err := config.FromEnviron("APP")
+//	c.GetInt("server.port", 80)        // 8000
+//	c.GetString("log.level", "info")   // debug
+//
+// prefix不为空时只保留以 prefix_ 开头的环境变量并去掉前缀，变量名转换为小写，_ 分隔配置层级，
+// 与 WithEnv 的变量名一致：server.port 对应 APP_SERVER_PORT。配置项名字中带有 _ 时，
+// 使用 WithEnvSeparator("__") 改为 __ 分隔层级，例如 APP_SERVER__READ_TIMEOUT 对应 server.read_timeout。
+// 值都是字符串，读取时按照需要的类型转换；Set 返回 ErrProviderReadOnly。
+// 已经使用 env.Capture 捕获快照时，使用 snap.Config(prefix) 从同一份快照创建
+func FromEnviron(prefix string, opts ...LoadOption) (Config, error) {
+	return NewFromEnviron(os.Environ(), prefix, opts...)
+}
+
+// NewFromEnviron 与 FromEnviron 相同，使用 KEY=value 格式的environ代替进程的环境变量，通常用于测试
+func NewFromEnviron(environ []string, prefix string, opts ...LoadOption) (Config, error) {
+	c := newFullConfig(EnvironPath)
+	for _, o := range opts {
+		o(c)
+	}
+	sep := c.envSeparator
+	if sep == "" {
+		sep = "_"
+	}
+	data, err := Encode(environTree(environ, prefix, sep), "yaml")
+	if err != nil {
+		return nil, fmt.Errorf("app/config: failed to encode environ: %v", err)
+	}
+	c.readOnly = true
+	if err := c.loadMemory(data); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// WithEnvSeparator FromEnviron 使用sep分隔环境变量名中的配置层级，默认为 _；
+// WithEnv 按照已有的配置项查找环境变量，不受影响
+func WithEnvSeparator(sep string) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.envSeparator = sep
+	}
+}
+
+// environTree 将环境变量转换为配置树，同一名字既是配置项又是配置段时保留配置段，例如 A=1 与 A_B=2
+func environTree(environ []string, prefix, sep string) map[string]interface{} {
+	if prefix != "" {
+		prefix = strings.ToUpper(strings.TrimSuffix(prefix, "_")) + "_"
+	}
+	vars := make(map[string]string, len(environ))
+	names := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		i := strings.Index(kv, "=")
+		if i <= 0 {
+			continue
+		}
+		name := kv[:i]
+		if prefix != "" {
+			if !strings.HasPrefix(strings.ToUpper(name), prefix) {
+				continue
+			}
+			name = name[len(prefix):]
+		}
+		if name == "" {
+			continue
+		}
+		if _, ok := vars[name]; !ok {
+			names = append(names, name)
+		}
+		vars[name] = kv[i+1:]
+	}
+	sort.Strings(names)
+
+	tree := make(map[string]interface{})
+	for _, name := range names {
+		segs := strings.Split(strings.ToLower(name), sep)
+		cur := tree
+		for _, seg := range segs[:len(segs)-1] {
+			next, ok := cur[seg].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				cur[seg] = next
+			}
+			cur = next
+		}
+		last := segs[len(segs)-1]
+		if _, ok := cur[last].(map[string]interface{}); ok {
+			continue
+		}
+		cur[last] = vars[name]
+	}
+	return tree
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// 配置错误的类别，作为消息目录中的消息名
+const (
+	CodeKeyNotFound  = "config.key_not_found"
+	CodeTypeMismatch = "config.type_mismatch"
+	CodeMissingKeys  = "config.missing_keys"
+)
+
+// CodedError 带有类别和上下文的配置错误，Error 按照当前的消息目录输出
+type CodedError interface {
+	error
+	// Code 错误类别，例如 CodeKeyNotFound
+	Code() string
+	// Params 消息模板中的参数
+	Params() map[string]string
+}
+
+// ErrKeyNotFound 配置项不存在，errors.Is(err, ErrConfigNotExist) 同样成立
+type ErrKeyNotFound struct {
+	Key string
+}
+
+// Code 错误类别
+func (e *ErrKeyNotFound) Code() string {
+	return CodeKeyNotFound
+}
+
+// Params 消息模板中的参数：key
+func (e *ErrKeyNotFound) Params() map[string]string {
+	return map[string]string{"key": e.Key}
+}
+
+// Error 实现error接口
+func (e *ErrKeyNotFound) Error() string {
+	return Render(e, nil)
+}
+
+// Is 兼容使用 ErrConfigNotExist 判断的调用方
+func (e *ErrKeyNotFound) Is(target error) bool {
+	return target == ErrConfigNotExist
+}
+
+// ErrTypeMismatch 配置值无法转换为需要的类型
+type ErrTypeMismatch struct {
+	Key string
+	// Want 需要的类型，Got 配置值的类型
+	Want string
+	Got  string
+	// Value 配置值
+	Value interface{}
+	// Err 转换失败的原因
+	Err error
+}
+
+// Code 错误类别
+func (e *ErrTypeMismatch) Code() string {
+	return CodeTypeMismatch
+}
+
+// Params 消息模板中的参数：key、want、got、value、reason
+func (e *ErrTypeMismatch) Params() map[string]string {
+	reason := ""
+	if e.Err != nil {
+		reason = e.Err.Error()
+	}
+	return map[string]string{
+		"key":    e.Key,
+		"want":   e.Want,
+		"got":    e.Got,
+		"value":  fmt.Sprint(e.Value),
+		"reason": reason,
+	}
+}
+
+// Error 实现error接口
+func (e *ErrTypeMismatch) Error() string {
+	return Render(e, nil)
+}
+
+// Unwrap 返回转换失败的原因
+func (e *ErrTypeMismatch) Unwrap() error {
+	return e.Err
+}
+
+// Code 错误类别
+func (e *MissingKeysError) Code() string {
+	return CodeMissingKeys
+}
+
+// Params 消息模板中的参数：path、keys
+func (e *MissingKeysError) Params() map[string]string {
+	return map[string]string{"path": e.Path, "keys": strings.Join(e.Keys, ", ")}
+}
+
+// Catalog 消息目录，按照错误类别返回消息模板，模板中的 {key} 等替换为错误的参数
+type Catalog interface {
+	Template(code string) (string, bool)
+}
+
+// MapCatalog 以map表示的消息目录
+type MapCatalog map[string]string
+
+// Template 返回code对应的消息模板
+func (m MapCatalog) Template(code string) (string, bool) {
+	t, ok := m[code]
+	return t, ok
+}
+
+// defaultCatalog 内置的消息模板，其他消息目录中没有对应模板时使用
+var defaultCatalog = MapCatalog{
+	CodeKeyNotFound:  "app/config: key {key} not found",
+	CodeTypeMismatch: "app/config: cannot decode {key} ({value}) into {want}: {reason}",
+	CodeMissingKeys:  "app/config: {path} missing required keys: {keys}",
+}
+
+// catalogHolder atomic.Value 要求每次存储的类型相同
+type catalogHolder struct {
+	c Catalog
+}
+
+var catalog atomic.Value
+
+func init() {
+	catalog.Store(catalogHolder{c: defaultCatalog})
+}
+
+// SetCatalog 替换全局消息目录，之后 CodedError 的 Error 使用新的目录，c为nil时恢复内置目录，
+// 返回恢复为替换前目录的函数
+func SetCatalog(c Catalog) (restore func()) {
+	if c == nil {
+		c = defaultCatalog
+	}
+	old := catalog.Load().(catalogHolder)
+	catalog.Store(catalogHolder{c: c})
+	return func() {
+		catalog.Store(old)
+	}
+}
+
+// Render 使用消息目录c输出err，c为nil时使用全局消息目录
+// err链中包含 CodedError 时按照其类别和参数输出，外层包装的上下文保持不变，c中没有对应模板时使用内置模板
+func Render(err error, c Catalog) string {
+	var ce CodedError
+	if !errors.As(err, &ce) {
+		return err.Error()
+	}
+	if c == nil {
+		c = catalog.Load().(catalogHolder).c
+	}
+	t, ok := c.Template(ce.Code())
+	if !ok {
+		if t, ok = defaultCatalog.Template(ce.Code()); !ok {
+			return err.Error()
+		}
+	}
+	params := ce.Params()
+	pairs := make([]string, 0, 2*len(params))
+	for k, v := range params {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	msg := strings.NewReplacer(pairs...).Replace(t)
+	if err == error(ce) {
+		return msg
+	}
+	return strings.Replace(err.Error(), ce.Error(), msg, 1)
+}
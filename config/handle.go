@@ -0,0 +1,141 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// Handle 预先解析好路径的配置项，在热点路径上代替按key读取
+// 每个配置版本只查找一次，之后读取只需比较当前配置版本
+type Handle struct {
+	c    *FrameworkConfig
+	key  string
+	path []string
+	// resolved 最近一次查找的结果
+	resolved atomic.Value
+}
+
+// handleValue Handle在某一配置版本中的查找结果
+type handleValue struct {
+	s     *snapshot
+	value interface{}
+	ok    bool
+}
+
+// Key 返回key对应的 Handle，可以在初始化时创建后长期持有
+func (c *FrameworkConfig) Key(key string) *Handle {
+	return &Handle{c: c, key: key, path: strings.Split(key, ".")}
+}
+
+// Name 配置项的key
+func (h *Handle) Name() string {
+	return h.key
+}
+
+// resolve 获取配置项在当前生效配置中的值
+func (h *Handle) resolve() (*snapshot, interface{}, bool) {
+	s := h.c.snap()
+	if hv, _ := h.resolved.Load().(*handleValue); hv != nil && hv.s == s {
+		return s, hv.value, hv.ok
+	}
+
+	hv := &handleValue{s: s}
+	if v, ok := s.indexLookup(h.key); ok {
+		hv.value, hv.ok = resolveLazy(v), true
+	} else if !s.indexed {
+		v, err := h.c.search(s.tree, h.path)
+		hv.value, hv.ok = v, err == nil
+	}
+	h.resolved.Store(hv)
+	return s, hv.value, hv.ok
+}
+
+// IsSet 配置项是否存在
+func (h *Handle) IsSet() bool {
+	_, _, ok := h.resolve()
+	return ok
+}
+
+// Value 读取配置项，不存在时返回defaultValue
+func (h *Handle) Value(defaultValue interface{}) interface{} {
+	if _, v, ok := h.resolve(); ok {
+		return v
+	}
+	return defaultValue
+}
+
+// Int 读取int类型配置
+func (h *Handle) Int(defaultValue int) int {
+	s, v, ok := h.resolve()
+	if !ok {
+		return defaultValue
+	}
+	if result, ok := v.(int); ok {
+		return result
+	}
+	if result, ok := s.cast(h.key, reflect.Int, v, toInt); ok {
+		return result.(int)
+	}
+	return defaultValue
+}
+
+// Int64 读取int64类型配置
+func (h *Handle) Int64(defaultValue int64) int64 {
+	s, v, ok := h.resolve()
+	if !ok {
+		return defaultValue
+	}
+	if result, ok := v.(int64); ok {
+		return result
+	}
+	if result, ok := s.cast(h.key, reflect.Int64, v, toInt64); ok {
+		return result.(int64)
+	}
+	return defaultValue
+}
+
+// Float64 读取float64类型配置
+func (h *Handle) Float64(defaultValue float64) float64 {
+	s, v, ok := h.resolve()
+	if !ok {
+		return defaultValue
+	}
+	if result, ok := v.(float64); ok {
+		return result
+	}
+	if result, ok := s.cast(h.key, reflect.Float64, v, toFloat64); ok {
+		return result.(float64)
+	}
+	return defaultValue
+}
+
+// Bool 读取bool类型配置
+func (h *Handle) Bool(defaultValue bool) bool {
+	s, v, ok := h.resolve()
+	if !ok {
+		return defaultValue
+	}
+	if result, ok := v.(bool); ok {
+		return result
+	}
+	if result, ok := s.cast(h.key, reflect.Bool, v, toBool); ok {
+		return result.(bool)
+	}
+	return defaultValue
+}
+
+// String 读取string类型配置
+func (h *Handle) String(defaultValue string) string {
+	s, v, ok := h.resolve()
+	if !ok {
+		return defaultValue
+	}
+	if result, ok := v.(string); ok {
+		return result
+	}
+	if result, ok := s.cast(h.key, reflect.String, v, toString); ok {
+		return result.(string)
+	}
+	return defaultValue
+}
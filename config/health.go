@@ -0,0 +1,135 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HealthStatus 健康状态
+type HealthStatus string
+
+const (
+	// HealthOK 内容源可以访问，且配置均为最新
+	HealthOK HealthStatus = "ok"
+	// HealthDegraded 内容源无法访问、重新加载失败或与内容源不一致，正在使用之前加载的配置
+	HealthDegraded HealthStatus = "degraded"
+)
+
+// ProviderHealth 内容源的健康状态，未实现 HealthChecker 的内容源不会被检查
+type ProviderHealth struct {
+	Name   string       `json:"name"`
+	Status HealthStatus `json:"status"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// ConfigHealth 已加载配置的健康状态
+type ConfigHealth struct {
+	Path     string       `json:"path"`
+	Provider string       `json:"provider"`
+	Status   HealthStatus `json:"status"`
+	LoadedAt time.Time    `json:"loaded_at"`
+	// Stale 最近一次重新加载失败，正在使用之前加载的配置
+	Stale      bool      `json:"stale"`
+	StaleSince time.Time `json:"stale_since,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	// Drift 内容源中的配置与当前生效的配置不一致
+	Drift *Drift `json:"drift,omitempty"`
+}
+
+// Health 配置组件的健康状态
+type Health struct {
+	Status    HealthStatus      `json:"status"`
+	Providers []*ProviderHealth `json:"providers"`
+	Configs   []*ConfigHealth   `json:"configs"`
+}
+
+// setStale 记录重新加载的结果，err不为nil时标记配置已过期
+func (c *FrameworkConfig) setStale(err error) {
+	c.staleLock.Lock()
+	if err == nil {
+		c.staleErr, c.staleSince, c.drift = nil, time.Time{}, nil
+	} else {
+		if c.staleErr == nil {
+			c.staleSince = Now()
+		}
+		c.staleErr = err
+	}
+	c.staleLock.Unlock()
+}
+
+func (c *FrameworkConfig) health() *ConfigHealth {
+	h := &ConfigHealth{Path: c.path, Provider: c.p.Name(), Status: HealthOK, LoadedAt: c.snap().loadedAt}
+	c.staleLock.Lock()
+	if c.staleErr != nil {
+		h.Status, h.Stale, h.StaleSince, h.Error = HealthDegraded, true, c.staleSince, c.staleErr.Error()
+	}
+	if c.drift != nil {
+		h.Status, h.Drift = HealthDegraded, c.drift
+	}
+	c.staleLock.Unlock()
+	return h
+}
+
+// Health 并发检查已加载配置使用的内容源，并汇总各个配置是否过期
+// 任一内容源无法访问或配置过期时状态为 HealthDegraded
+func (loader *FullConfigLoader) Health(ctx context.Context) *Health {
+	h := &Health{Status: HealthOK, Providers: make([]*ProviderHealth, 0), Configs: make([]*ConfigHealth, 0)}
+
+	checkers := make(map[string]HealthChecker)
+	for _, c := range loader.loaded() {
+		ch := c.health()
+		if ch.Status != HealthOK {
+			h.Status = HealthDegraded
+		}
+		h.Configs = append(h.Configs, ch)
+		if hc, ok := c.p.(HealthChecker); ok {
+			checkers[c.p.Name()] = hc
+		}
+	}
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+	)
+	for name, hc := range checkers {
+		wg.Add(1)
+		go func(name string, hc HealthChecker) {
+			defer wg.Done()
+			ph := &ProviderHealth{Name: name, Status: HealthOK}
+			if err := hc.CheckHealth(ctx); err != nil {
+				ph.Status, ph.Error = HealthDegraded, err.Error()
+			}
+			lock.Lock()
+			h.Providers = append(h.Providers, ph)
+			if ph.Status != HealthOK {
+				h.Status = HealthDegraded
+			}
+			lock.Unlock()
+		}(name, hc)
+	}
+	wg.Wait()
+
+	sort.Slice(h.Providers, func(i, j int) bool { return h.Providers[i].Name < h.Providers[j].Name })
+	return h
+}
+
+// HealthHandler 返回输出健康状态的http.Handler，状态为 HealthDegraded 时返回503，可用于k8s就绪检查
+func (loader *FullConfigLoader) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := loader.Health(r.Context())
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if h.Status != HealthOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(h)
+	})
+}
+
+// HealthHandler 返回输出默认加载器健康状态的http.Handler
+func HealthHandler() http.Handler {
+	return DefaultConfigLoader.HealthHandler()
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultHistorySize 每个配置默认保留的重新加载记录数
+const defaultHistorySize = 64
+
+// HistoryEvent 一次重新加载的记录
+type HistoryEvent struct {
+	Time time.Time `json:"time" yaml:"time"`
+	// Outcome 重新加载结果，success 或 failure
+	Outcome string `json:"outcome" yaml:"outcome"`
+	// Error 重新加载失败的原因
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+	// Version、Fingerprint 重新加载后生效配置的版本号和sha256，失败时为原配置的值
+	Version     string   `json:"version" yaml:"version"`
+	Fingerprint string   `json:"fingerprint" yaml:"fingerprint"`
+	Changes     []Change `json:"changes,omitempty" yaml:"changes,omitempty"`
+}
+
+// history 固定容量的重新加载记录环形缓冲区
+type history struct {
+	lock   sync.Mutex
+	events []HistoryEvent
+	// next 下一条记录写入的位置
+	next int
+	full bool
+}
+
+func newHistory(size int) *history {
+	if size <= 0 {
+		return nil
+	}
+	return &history{events: make([]HistoryEvent, size)}
+}
+
+// add 写入一条记录，缓冲区满时覆盖最早的记录
+func (h *history) add(e HistoryEvent) {
+	if h == nil {
+		return
+	}
+	h.lock.Lock()
+	h.events[h.next] = e
+	h.next = (h.next + 1) % len(h.events)
+	if h.next == 0 {
+		h.full = true
+	}
+	h.lock.Unlock()
+}
+
+// list 按照时间从早到晚返回全部记录
+func (h *history) list() []HistoryEvent {
+	if h == nil {
+		return nil
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if !h.full {
+		return append([]HistoryEvent(nil), h.events[:h.next]...)
+	}
+	out := make([]HistoryEvent, 0, len(h.events))
+	out = append(out, h.events[h.next:]...)
+	return append(out, h.events[:h.next]...)
+}
+
+// History 按照时间从早到晚返回最近的重新加载记录，变更内容中的敏感配置项已脱敏
+func (c *FrameworkConfig) History() []HistoryEvent {
+	return c.history.list()
+}
+
+// recordReload 记录一次重新加载的结果
+func (c *FrameworkConfig) recordReload(changes []Change, err error) {
+	s := c.snap()
+	e := HistoryEvent{
+		Time:        Now(),
+		Outcome:     resultLabel(err),
+		Version:     s.version,
+		Fingerprint: s.fingerprint,
+		Changes:     changes,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	c.history.add(e)
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"expvar"
+	"time"
+)
+
+func init() {
+	expvar.Publish("app_config", expvar.Func(func() interface{} {
+		return DefaultConfigLoader.Infos()
+	}))
+}
+
+// Info 已加载配置的版本信息
+type Info struct {
+	Path     string `json:"path"`
+	Provider string `json:"provider"`
+	Codec    string `json:"codec"`
+	// Fingerprint 配置内容的sha256
+	Fingerprint string `json:"fingerprint"`
+	// Version 内容源提供的版本号
+	Version  string    `json:"version"`
+	LoadedAt time.Time `json:"loaded_at"`
+	Size     int       `json:"size"`
+}
+
+// Infos 返回加载器中全部已加载配置的版本信息，按照路径排序
+// 默认加载器的版本信息同时通过expvar以 app_config 发布
+func (loader *FullConfigLoader) Infos() []Info {
+	configs := loader.loaded()
+	infos := make([]Info, 0, len(configs))
+	for _, c := range configs {
+		infos = append(infos, c.info())
+	}
+	return infos
+}
+
+func (c *FrameworkConfig) info() Info {
+	s := c.snap()
+	return Info{
+		Path:        c.path,
+		Provider:    c.p.Name(),
+		Codec:       c.decoder.Name(),
+		Fingerprint: s.fingerprint,
+		Version:     s.version,
+		LoadedAt:    s.loadedAt,
+		Size:        s.size,
+	}
+}
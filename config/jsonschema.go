@@ -0,0 +1,242 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonSchemaDraft 生成的JSON Schema版本
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema 根据结构体v生成JSON Schema，属性名使用tag指定的标签（如yaml）
+// 支持 default、doc、enum、range 以及 validate 中的 required、min、max、len、oneof
+func JSONSchema(v interface{}, tag string) ([]byte, error) {
+	s := typeSchema(reflect.TypeOf(v), tag)
+	s["$schema"] = jsonSchemaDraft
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// RegisteredJSONSchema 根据 RegisterStruct 声明的全部结构生成整个配置文件的JSON Schema
+func RegisteredJSONSchema(tag string) ([]byte, error) {
+	structLock.RLock()
+	keys := make([]string, 0, len(structMap))
+	types := make(map[string]reflect.Type, len(structMap))
+	for k, t := range structMap {
+		keys = append(keys, k)
+		types[k] = t
+	}
+	structLock.RUnlock()
+
+	// 先处理较短的key，保证子树的结构覆盖父结构中的同名属性
+	sort.Strings(keys)
+	root := map[string]interface{}{"type": "object"}
+	for _, key := range keys {
+		s := typeSchema(types[key], tag)
+		if key == "" {
+			root = s
+			continue
+		}
+		parent := root
+		segs := strings.Split(key, ".")
+		for _, seg := range segs[:len(segs)-1] {
+			parent = childSchema(parent, seg)
+		}
+		props, _ := parent["properties"].(map[string]interface{})
+		if props == nil {
+			props = map[string]interface{}{}
+			parent["properties"] = props
+		}
+		props[segs[len(segs)-1]] = s
+	}
+	root["$schema"] = jsonSchemaDraft
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// childSchema 获取或创建名为name的子对象结构
+func childSchema(parent map[string]interface{}, name string) map[string]interface{} {
+	props, _ := parent["properties"].(map[string]interface{})
+	if props == nil {
+		props = map[string]interface{}{}
+		parent["properties"] = props
+	}
+	child, _ := props[name].(map[string]interface{})
+	if child == nil {
+		child = map[string]interface{}{"type": "object"}
+		props[name] = child
+	}
+	return child
+}
+
+// typeSchema 生成类型t的JSON Schema
+func typeSchema(t reflect.Type, tag string) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case durationType:
+		return map[string]interface{}{"type": []string{"string", "integer"}}
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), tag)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), tag)}
+	case reflect.Struct:
+		s := map[string]interface{}{"type": "object", "additionalProperties": false}
+		props := map[string]interface{}{}
+		var required []string
+		structSchema(t, tag, props, &required)
+		s["properties"] = props
+		if len(required) > 0 {
+			sort.Strings(required)
+			s["required"] = required
+		}
+		return s
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema 将结构体字段的结构填充到props中，内嵌的结构体字段会被展开
+func structSchema(t reflect.Type, tag string, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		name, inline, skip := fieldName(f, tag)
+		if skip {
+			continue
+		}
+		if inline {
+			it := f.Type
+			for it.Kind() == reflect.Ptr {
+				it = it.Elem()
+			}
+			if it.Kind() == reflect.Struct {
+				structSchema(it, tag, props, required)
+			}
+			continue
+		}
+
+		s := typeSchema(f.Type, tag)
+		if doc := f.Tag.Get("doc"); doc != "" {
+			s["description"] = doc
+		}
+		if def, ok := f.Tag.Lookup("default"); ok {
+			s["default"] = schemaValue(f.Type, def)
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			s["enum"] = schemaEnum(f.Type, strings.Split(enum, ","))
+		}
+		if rng := f.Tag.Get("range"); len(rng) > 1 {
+			if i := strings.Index(rng[1:], "-") + 1; i > 0 {
+				schemaBound(s, f.Type, "min", strings.TrimSpace(rng[:i]))
+				schemaBound(s, f.Type, "max", strings.TrimSpace(rng[i+1:]))
+			}
+		}
+		for _, r := range strings.Split(f.Tag.Get("validate"), ",") {
+			rule, param := r, ""
+			if i := strings.Index(r, "="); i >= 0 {
+				rule, param = r[:i], r[i+1:]
+			}
+			switch rule {
+			case "required":
+				*required = append(*required, name)
+			case "min", "max":
+				schemaBound(s, f.Type, rule, param)
+			case "len":
+				schemaBound(s, f.Type, "min", param)
+				schemaBound(s, f.Type, "max", param)
+			case "oneof":
+				s["enum"] = schemaEnum(f.Type, strings.Fields(param))
+			}
+		}
+		props[name] = s
+	}
+}
+
+// schemaBound 根据字段类型设置最小值/最大值或长度约束
+func schemaBound(s map[string]interface{}, t reflect.Type, bound, param string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	n, err := strconv.ParseFloat(param, 64)
+	if param == "" || err != nil || t == durationType {
+		return
+	}
+
+	var prefix string
+	switch t.Kind() {
+	case reflect.String:
+		prefix = "Length"
+	case reflect.Slice, reflect.Array:
+		prefix = "Items"
+	case reflect.Map:
+		prefix = "Properties"
+	}
+	if prefix == "" {
+		if bound == "min" {
+			s["minimum"] = n
+		} else {
+			s["maximum"] = n
+		}
+		return
+	}
+	s[bound+prefix] = int(n)
+}
+
+func schemaEnum(t reflect.Type, opts []string) []interface{} {
+	values := make([]interface{}, 0, len(opts))
+	for _, opt := range opts {
+		values = append(values, schemaValue(t, strings.TrimSpace(opt)))
+	}
+	return values
+}
+
+// schemaValue 将tag中的字符串值转换为字段类型对应的JSON值
+func schemaValue(t reflect.Type, s string) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == durationType {
+		return s
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, 0)
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, schemaValue(t.Elem(), item))
+			}
+		}
+		return items
+	}
+	return s
+}
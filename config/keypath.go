@@ -0,0 +1,29 @@
+package config
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxKeyPaths 缓存的key路径数上限，超过后不再缓存新的key，避免动态拼接的key导致内存无限增长
+const maxKeyPaths = 4096
+
+var (
+	keyPaths     sync.Map
+	keyPathCount int32
+)
+
+// splitKey 将 . 分隔的key拆分为路径，结果会被缓存，调用方不能修改返回的切片
+func splitKey(key string) []string {
+	if v, ok := keyPaths.Load(key); ok {
+		return v.([]string)
+	}
+	subkeys := strings.Split(key, ".")
+	if atomic.LoadInt32(&keyPathCount) < maxKeyPaths {
+		if _, loaded := keyPaths.LoadOrStore(key, subkeys); !loaded {
+			atomic.AddInt32(&keyPathCount, 1)
+		}
+	}
+	return subkeys
+}
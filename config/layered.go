@@ -0,0 +1,49 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultsFS 编译进二进制的默认配置，embed.FS 和 fs.ReadFileFS 均满足该接口
+type DefaultsFS interface {
+	ReadFile(name string) ([]byte, error)
+}
+
+// NewLayered 创建常用的三层配置，优先级从低到高为：编译进二进制的默认配置、磁盘上的配置文件、环境变量：
+//
+//	//go:embed app.yaml
+//	var defaults embed.FS
+//
+//	c, err := config.NewLayered(defaults, "/etc/app/app.yaml", "APP")
+//
+// 默认配置为defaults中与filePath同名的文件，例如 app.yaml，编解码器按照扩展名选择；
+// 配置文件变化时自动重新加载，重新加载时同样合并默认配置和环境变量。
+// 配置文件不存在时只使用默认配置和环境变量，之后创建的文件不会被加载。
+// envPrefix为空时不使用环境变量层，opts在这三层之后生效，例如 WithProfile、WithFlags
+func NewLayered(defaults DefaultsFS, filePath, envPrefix string, opts ...LoadOption) (Config, error) {
+	name := filepath.Base(filePath)
+	codec := codecByExt(name)
+	data, err := defaults.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("app/config: failed to read embedded defaults %s: %v", name, err)
+	}
+	tree := map[string]interface{}{}
+	if err := decode(GetCodec(codec), data, &tree); err != nil {
+		return nil, newParseError(name, data, err)
+	}
+
+	layers := []LoadOption{WithDefaults(tree)}
+	if envPrefix != "" {
+		layers = append(layers, WithEnv(envPrefix))
+	}
+	opts = append(layers, opts...)
+
+	if _, err := os.Stat(filePath); errors.Is(err, os.ErrNotExist) {
+		logger.Warnf("app/config: %s not found, using embedded defaults", filePath)
+		return NewFromString("", opts...)
+	}
+	return Load(filePath, append([]LoadOption{WithCodec(codec), WithAutoReload()}, opts...)...)
+}
@@ -0,0 +1,363 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxPlaceholderDepth 占位符嵌套引用的最大解析轮数
+const maxPlaceholderDepth = 8
+
+// 配置层
+const (
+	LayerDefault  = "default"
+	LayerFile     = "file"
+	LayerProfile  = "profile"
+	LayerTenant   = "tenant"
+	LayerEnv      = "env"
+	LayerFlag     = "flag"
+	LayerOverride = "override"
+)
+
+// Origin 配置项的来源
+type Origin struct {
+	// Layer 提供配置项的配置层
+	Layer string `json:"layer" yaml:"layer"`
+	// Source 具体来源，如profile配置文件路径、环境变量名、命令行参数名
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+}
+
+// setOrigin 记录key的来源，key的子配置项的来源被清除
+func setOrigin(origins map[string]Origin, key string, o Origin) {
+	for k := range origins {
+		if strings.HasPrefix(k, key+".") {
+			delete(origins, k)
+		}
+	}
+	origins[key] = o
+}
+
+// deleteOrigin 清除key及其子配置项的来源，并清除key所在上级配置项的来源
+func deleteOrigin(origins map[string]Origin, key string) {
+	for k := range origins {
+		if k == key || strings.HasPrefix(k, key+".") || strings.HasPrefix(key, k+".") {
+			delete(origins, k)
+		}
+	}
+}
+
+var placeholderRefRegexp = regexp.MustCompile(`\$\{([^}:]+)(?::-([^}]*))?\}`)
+
+// layered 是否配置了文件之外的配置层
+func (c *FrameworkConfig) layered() bool {
+	return len(c.defaults) > 0 || len(c.profiles) > 0 || c.tenant != "" || c.envPrefix != "" ||
+		c.flags != nil || len(c.overrides) > 0 || c.placeholders || len(c.transforms) > 0
+}
+
+// transform 使用 WithTransform 注册的Transform转换path对应的配置文件
+func (c *FrameworkConfig) transform(path string, tree map[string]interface{}) (map[string]interface{}, error) {
+	for _, t := range c.transforms {
+		out, err := t(path, tree)
+		if err != nil {
+			return nil, fmt.Errorf("app/config: failed to transform %s: %s", path, err.Error())
+		}
+		if out != nil {
+			tree = normalizeTree(out).(map[string]interface{})
+		}
+	}
+	return tree, nil
+}
+
+// mergeLayers 按照 默认值 < 配置文件 < profile配置文件 < 租户配置文件 < 环境变量 < 命令行参数 < 覆盖值 的优先级合并配置，
+// 并解析其中的占位符，同时返回由配置文件之外的配置层提供的配置项及其来源
+func (c *FrameworkConfig) mergeLayers(file map[string]interface{}) (map[string]interface{}, map[string]Origin, error) {
+	origins := make(map[string]Origin)
+	if !c.layered() {
+		return file, origins, nil
+	}
+
+	file, err := c.transform(c.path, file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tree := make(map[string]interface{})
+	for k, v := range c.defaults {
+		setKey(tree, k, copyTree(v))
+		for lk := range leafValues(k, v) {
+			setOrigin(origins, lk, Origin{Layer: LayerDefault})
+		}
+	}
+	mergeTrees(tree, file)
+	for lk := range leafValues("", file) {
+		deleteOrigin(origins, lk)
+	}
+
+	for _, p := range c.profiles {
+		path := profilePath(c.path, p)
+		data, err := c.p.Read(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("app/config: failed to read profile %s: %s", path, err.Error())
+		}
+		overlay := map[string]interface{}{}
+		if err := decode(c.decoder, data, &overlay); err != nil {
+			return nil, nil, newParseError(path, data, err)
+		}
+		if overlay, err = c.transform(path, overlay); err != nil {
+			return nil, nil, err
+		}
+		mergeTrees(tree, overlay)
+		for lk := range leafValues("", overlay) {
+			setOrigin(origins, lk, Origin{Layer: LayerProfile, Source: path})
+		}
+	}
+
+	if c.tenant != "" {
+		path := TenantPath(c.path, c.tenant)
+		data, err := c.p.Read(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("app/config: failed to read tenant %s config %s: %s", c.tenant, path, err.Error())
+		}
+		overlay := map[string]interface{}{}
+		if err := decode(c.decoder, data, &overlay); err != nil {
+			return nil, nil, newParseError(path, data, err)
+		}
+		if overlay, err = c.transform(path, overlay); err != nil {
+			return nil, nil, err
+		}
+		mergeTrees(tree, overlay)
+		for lk := range leafValues("", overlay) {
+			setOrigin(origins, lk, Origin{Layer: LayerTenant, Source: path})
+		}
+	}
+
+	lookupEnv := c.envLookup
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	if c.envPrefix != "" {
+		for key, v := range leafValues("", tree) {
+			name := envName(c.envPrefix, key)
+			if s, ok := lookupEnv(name); ok {
+				setKey(tree, key, parseScalar(s, v))
+				setOrigin(origins, key, Origin{Layer: LayerEnv, Source: name})
+			}
+		}
+	}
+
+	if c.flags != nil {
+		c.flags.Visit(func(f *flag.Flag) {
+			old, _ := lookupTree(tree, f.Name)
+			setKey(tree, f.Name, parseScalar(f.Value.String(), old))
+			setOrigin(origins, f.Name, Origin{Layer: LayerFlag, Source: "-" + f.Name})
+		})
+	}
+
+	for k, v := range c.overrides {
+		setKey(tree, k, copyTree(v))
+		for lk := range leafValues(k, v) {
+			setOrigin(origins, lk, Origin{Layer: LayerOverride})
+		}
+	}
+
+	if c.placeholders {
+		for i := 0; i < maxPlaceholderDepth; i++ {
+			if !resolvePlaceholders(tree, tree, lookupEnv) {
+				break
+			}
+		}
+	}
+	return tree, origins, nil
+}
+
+// profilePath 返回profile对应的配置文件路径，例如 app.yaml 的 local 配置为 app.local.yaml
+func profilePath(path, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
+
+// envName 返回配置项对应的环境变量名，例如前缀 APP 下 server.bin_path 对应 APP_SERVER_BIN_PATH
+func envName(prefix, key string) string {
+	name := strings.NewReplacer(".", "_", "-", "_").Replace(key)
+	return strings.ToUpper(prefix + "_" + name)
+}
+
+// mergeTrees 将src合并到dst中，对象逐层合并，其他类型的值直接覆盖
+func mergeTrees(dst, src map[string]interface{}) {
+	for k, sv := range src {
+		sm, ok := toStringMap(sv)
+		if !ok {
+			dst[k] = copyTree(sv)
+			continue
+		}
+		dm, ok := dst[k].(map[string]interface{})
+		if !ok {
+			dm = make(map[string]interface{})
+			dst[k] = dm
+		}
+		mergeTrees(dm, sm)
+	}
+}
+
+// copyTree 深拷贝配置树，避免合并时修改调用方传入的数据
+func copyTree(v interface{}) interface{} {
+	if m, ok := toStringMap(v); ok {
+		out := make(map[string]interface{}, len(m))
+		for k, sub := range m {
+			out[k] = copyTree(sub)
+		}
+		return out
+	}
+	if list, ok := v.([]interface{}); ok {
+		out := make([]interface{}, len(list))
+		for i, sub := range list {
+			out[i] = copyTree(sub)
+		}
+		return out
+	}
+	return v
+}
+
+// setKey 将tree中以 . 分隔的key设置为v，中间层级不存在或不是对象时创建对象
+func setKey(tree map[string]interface{}, key string, v interface{}) {
+	segs := strings.Split(key, ".")
+	for _, seg := range segs[:len(segs)-1] {
+		next, ok := tree[seg].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			tree[seg] = next
+		}
+		tree = next
+	}
+	tree[segs[len(segs)-1]] = v
+}
+
+// lookupTree 获取tree中以 . 分隔的key的值
+func lookupTree(tree map[string]interface{}, key string) (interface{}, bool) {
+	var cur interface{} = tree
+	for _, seg := range strings.Split(key, ".") {
+		m, ok := toStringMap(cur)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[seg]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// leafValues 返回配置树中全部非对象的值，列表作为整体
+func leafValues(path string, v interface{}) map[string]interface{} {
+	leaves := make(map[string]interface{})
+	m, ok := toStringMap(v)
+	if !ok {
+		leaves[path] = v
+		return leaves
+	}
+	for k, sub := range m {
+		for lk, lv := range leafValues(joinKey(path, k), sub) {
+			leaves[lk] = lv
+		}
+	}
+	return leaves
+}
+
+// parseScalar 将环境变量、命令行参数中的字符串转换为与原配置值相同的类型，
+// 原配置值为字符串或不存在时保持字符串
+func parseScalar(s string, like interface{}) interface{} {
+	if _, ok := like.(string); ok || like == nil {
+		return s
+	}
+	var v interface{}
+	if err := unmarshalYAML([]byte(s), &v); err != nil || v == nil {
+		return s
+	}
+	return v
+}
+
+// resolvePlaceholders 将配置值中的 ${key} 或 ${key:-default} 替换为其他配置项的值，
+// 配置项不存在时使用lookupEnv查找同名环境变量，都不存在时使用默认值，没有默认值时保持不变。
+// 返回本轮是否有替换
+func resolvePlaceholders(root map[string]interface{}, v interface{}, lookupEnv func(string) (string, bool)) bool {
+	changed := false
+	resolve := func(s string) interface{} {
+		// 整个值为一个占位符时保留引用值的类型
+		if loc := placeholderRefRegexp.FindStringSubmatchIndex(s); loc != nil && loc[0] == 0 && loc[1] == len(s) {
+			if val, ok := placeholderValue(root, placeholderRefRegexp.FindStringSubmatch(s), lookupEnv); ok {
+				changed = true
+				return val
+			}
+			return s
+		}
+		return placeholderRefRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+			val, ok := placeholderValue(root, placeholderRefRegexp.FindStringSubmatch(ref), lookupEnv)
+			if !ok {
+				return ref
+			}
+			changed = true
+			return fmt.Sprint(val)
+		})
+	}
+
+	if m, ok := v.(map[string]interface{}); ok {
+		for k, sub := range m {
+			if s, ok := sub.(string); ok {
+				m[k] = resolve(s)
+				continue
+			}
+			if resolvePlaceholders(root, sub, lookupEnv) {
+				changed = true
+			}
+		}
+	}
+	if list, ok := v.([]interface{}); ok {
+		for i, sub := range list {
+			if s, ok := sub.(string); ok {
+				list[i] = resolve(s)
+				continue
+			}
+			if resolvePlaceholders(root, sub, lookupEnv) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// placeholderValue 获取占位符引用的值，match为占位符正则的匹配结果
+func placeholderValue(root map[string]interface{}, match []string, lookupEnv func(string) (string, bool)) (interface{}, bool) {
+	name := strings.TrimSpace(match[1])
+	if v, ok := lookupTree(root, name); ok {
+		if s, ok := v.(string); !ok || !placeholderRefRegexp.MatchString(s) {
+			return v, true
+		}
+		// 引用的值中仍有未解析的占位符，等待下一轮
+		return nil, false
+	}
+	if s, ok := lookupEnv(name); ok {
+		return s, true
+	}
+	if strings.Contains(match[0], ":-") {
+		return match[2], true
+	}
+	return nil, false
+}
+
+// watchOverlays 叠加在配置文件上的profile配置文件、租户配置文件变化时，与配置文件一样调用onChange
+func (loader *FullConfigLoader) watchOverlays(key string, yc *FrameworkConfig, onChange func()) {
+	paths := make([]string, 0, len(yc.profiles)+1)
+	for _, p := range yc.profiles {
+		paths = append(paths, profilePath(yc.path, p))
+	}
+	if yc.tenant != "" {
+		paths = append(paths, TenantPath(yc.path, yc.tenant))
+	}
+	for _, path := range paths {
+		loader.watches.watch(&watchEntry{owner: loader, id: key + "|" + path, p: yc.p, path: path, onChange: onChange})
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProfileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeFile(t, path, "server:\n  port: 80\n")
+	writeFile(t, profilePath(path, "local"), "server:\n  port: 8080\n")
+
+	c, err := newFullConfigLoad().Load(path, WithProfile("local"), WithAutoReload())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetInt("server.port", 0); got != 8080 {
+		t.Fatalf("expect the profile value, got %d", got)
+	}
+
+	// 只修改profile配置文件同样触发重新加载
+	writeFile(t, profilePath(path, "local"), "server:\n  port: 9090\n")
+	deadline := time.Now().Add(3 * time.Second)
+	for c.GetInt("server.port", 0) != 9090 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect a reload after the profile changed, got %d", c.GetInt("server.port", 0))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
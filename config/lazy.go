@@ -0,0 +1,209 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// lazySection 延迟解码的顶层配置段，第一次访问时才解码
+type lazySection struct {
+	key string
+	// raw 配置段的原始内容，内容相同的配置段无需解码即可判断没有变化
+	raw    []byte
+	decode func(raw []byte) (map[string]interface{}, error)
+	once   sync.Once
+	value  map[string]interface{}
+	err    error
+}
+
+// get 解码并返回配置段，解码失败时输出错误日志并返回空对象
+func (l *lazySection) get() map[string]interface{} {
+	l.once.Do(func() {
+		m, err := l.decode(l.raw)
+		if err != nil {
+			logger.Errorf("app/config: failed to decode section %s: %v", l.key, err)
+			m = map[string]interface{}{}
+		}
+		l.value, l.err = m, err
+	})
+	return l.value
+}
+
+// checkLazy 立即解码tree中内容与old不同的延迟解码配置段，返回第一个解码错误。
+// 内容相同的配置段沿用当前配置的结果，重新加载时不会因此失去延迟解码的收益
+func checkLazy(old, tree map[string]interface{}) error {
+	keys := make([]string, 0, len(tree))
+	for k := range tree {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		l, ok := tree[k].(*lazySection)
+		if !ok {
+			continue
+		}
+		if lo, ok := old[k].(*lazySection); ok && bytes.Equal(lo.raw, l.raw) {
+			continue
+		}
+		if l.get(); l.err != nil {
+			return fmt.Errorf("failed to decode section %s: %v", k, l.err)
+		}
+	}
+	return nil
+}
+
+// resolveLazy 将延迟解码的配置段转换为解码后的值
+func resolveLazy(v interface{}) interface{} {
+	if l, ok := v.(*lazySection); ok {
+		return l.get()
+	}
+	return v
+}
+
+// lazyDecode 只建立顶层配置段的索引，值为对象的配置段在第一次访问时才解码
+// 目前支持yaml和json，无法安全拆分的内容返回false，由调用方完整解码
+func lazyDecode(codec Codec, data []byte) (map[string]interface{}, bool) {
+	switch codec.Name() {
+	case "yaml":
+		return lazyDecodeYAML(codec, data)
+	case "json":
+		return lazyDecodeJSON(codec, data)
+	}
+	return nil, false
+}
+
+func lazyDecodeJSON(codec Codec, data []byte) (map[string]interface{}, bool) {
+	sections := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, false
+	}
+
+	tree := make(map[string]interface{}, len(sections))
+	for k, raw := range sections {
+		if len(raw) > 0 && raw[0] == '{' {
+			tree[k] = &lazySection{key: k, raw: raw, decode: func(raw []byte) (map[string]interface{}, error) {
+				m := map[string]interface{}{}
+				err := decode(codec, raw, &m)
+				normalizeTree(m)
+				return m, err
+			}}
+			continue
+		}
+		var v interface{}
+		if err := decode(codec, raw, &v); err != nil {
+			return nil, false
+		}
+		tree[k] = normalizeTree(v)
+	}
+	return tree, true
+}
+
+var (
+	// yamlTopKeyRegexp 顶格书写的未加引号的key
+	yamlTopKeyRegexp = regexp.MustCompile(`^([A-Za-z0-9_][A-Za-z0-9_.\-/]*)\s*:(\s+#.*|\s*)$|^([A-Za-z0-9_][A-Za-z0-9_.\-/]*)\s*:\s`)
+	// yamlRefRegexp 锚点、别名和标签，会引用或影响其他配置段，出现时不拆分
+	yamlRefRegexp = regexp.MustCompile(`(^|[\s\[{,])[&*!][^\s]`)
+)
+
+// yamlSection 按行拆分出的顶层配置段
+type yamlSection struct {
+	key   string
+	start int
+	// lazy 值为缩进书写的对象
+	lazy bool
+}
+
+func lazyDecodeYAML(codec Codec, data []byte) (map[string]interface{}, bool) {
+	if yamlRefRegexp.Match(data) {
+		return nil, false
+	}
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	var sections []*yamlSection
+	seen := make(map[string]bool)
+	for i, line := range lines {
+		s := strings.TrimRight(string(line), "\r\n")
+		trimmed := strings.TrimSpace(s)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || s[0] == ' ' || s[0] == '\t' {
+			continue
+		}
+		if s == "---" && i == 0 {
+			continue
+		}
+		if s[0] == '-' && len(sections) > 0 && !strings.HasPrefix(s, "---") && !strings.HasPrefix(s, "...") {
+			// 顶格书写的列表元素属于上一个配置段
+			continue
+		}
+		m := yamlTopKeyRegexp.FindStringSubmatch(s)
+		if m == nil {
+			return nil, false
+		}
+		key := m[1]
+		if key == "" {
+			key = m[3]
+		}
+		if seen[key] {
+			return nil, false
+		}
+		seen[key] = true
+		sections = append(sections, &yamlSection{key: key, start: i, lazy: m[1] != "" && nestedMapping(lines[i+1:])})
+	}
+
+	tree := make(map[string]interface{}, len(sections))
+	var eager []byte
+	for i, sec := range sections {
+		end := len(lines)
+		if i+1 < len(sections) {
+			end = sections[i+1].start
+		}
+		raw := bytes.Join(lines[sec.start:end], nil)
+		if !sec.lazy {
+			eager = append(eager, raw...)
+			if len(eager) > 0 && eager[len(eager)-1] != '\n' {
+				eager = append(eager, '\n')
+			}
+			continue
+		}
+		key := sec.key
+		tree[key] = &lazySection{key: key, raw: raw, decode: func(raw []byte) (map[string]interface{}, error) {
+			m := map[string]interface{}{}
+			if err := decode(codec, raw, &m); err != nil {
+				return nil, err
+			}
+			sub, _ := normalizeTree(m[key]).(map[string]interface{})
+			if sub == nil {
+				sub = map[string]interface{}{}
+			}
+			return sub, nil
+		}}
+	}
+
+	if len(eager) > 0 {
+		m := map[string]interface{}{}
+		if err := decode(codec, eager, &m); err != nil {
+			return nil, false
+		}
+		for k, v := range m {
+			tree[k] = v
+		}
+	}
+	return tree, true
+}
+
+// nestedMapping 判断配置段的值是否为缩进书写的对象
+func nestedMapping(lines [][]byte) bool {
+	for _, line := range lines {
+		s := strings.TrimRight(string(line), "\r\n")
+		trimmed := strings.TrimSpace(s)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		return (s[0] == ' ' || s[0] == '\t') && !strings.HasPrefix(trimmed, "- ") && trimmed != "-"
+	}
+	return false
+}
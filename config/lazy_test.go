@@ -0,0 +1,40 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLazyReloadRejectsBrokenSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeFile(t, path, "server:\n  port: 80\nredis:\n  addr: a\n")
+
+	cfg, err := newFullConfigLoad().Load(path, WithLazyDecode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cfg.(*FrameworkConfig)
+	if got := c.GetInt("server.port", 0); got != 80 {
+		t.Fatalf("expect 80, got %d", got)
+	}
+
+	// redis配置段缩进错误，整体解码同样会失败
+	writeFile(t, path, "server:\n  port: 81\nredis:\n  addr: a\n   port: 1\n")
+	if err := c.reloadAndReport(); err == nil {
+		t.Fatal("expect the reload to be rejected")
+	}
+	if got := c.GetInt("server.port", 0); got != 80 {
+		t.Fatalf("expect the current config to stay, got %d", got)
+	}
+	if got := c.GetString("redis.addr", ""); got != "a" {
+		t.Fatalf("expect the current redis section to stay, got %q", got)
+	}
+
+	writeFile(t, path, "server:\n  port: 81\nredis:\n  addr: b\n")
+	if err := c.reloadAndReport(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetString("redis.addr", ""); got != "b" {
+		t.Fatalf("expect b, got %q", got)
+	}
+}
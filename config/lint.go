@@ -0,0 +1,333 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Finding 配置检查发现的问题
+type Finding struct {
+	// Rule 发现问题的规则名
+	Rule string
+	// Key 问题所在的配置项，未知时为空
+	Key string
+	// Line、Column 问题在原始配置中的位置，未知时为0
+	Line    int
+	Column  int
+	Message string
+}
+
+// String 实现fmt.Stringer接口
+func (f Finding) String() string {
+	pos := ""
+	if f.Line > 0 && f.Column > 0 {
+		pos = fmt.Sprintf("%d:%d: ", f.Line, f.Column)
+	} else if f.Line > 0 {
+		pos = fmt.Sprintf("%d: ", f.Line)
+	}
+	key := ""
+	if f.Key != "" {
+		key = f.Key + ": "
+	}
+	return fmt.Sprintf("%s%s%s [%s]", pos, key, f.Message, f.Rule)
+}
+
+// LintRule 配置检查规则
+type LintRule interface {
+	Name() string
+	Lint(data []byte, codec string) []Finding
+}
+
+// lintRule 函数形式的检查规则
+type lintRule struct {
+	name string
+	fn   func(data []byte, codec string) []Finding
+}
+
+// Name 规则名
+func (r *lintRule) Name() string {
+	return r.name
+}
+
+// Lint 执行检查
+func (r *lintRule) Lint(data []byte, codec string) []Finding {
+	findings := r.fn(data, codec)
+	for i := range findings {
+		findings[i].Rule = r.name
+	}
+	return findings
+}
+
+// NewLintRule 使用函数创建检查规则
+func NewLintRule(name string, fn func(data []byte, codec string) []Finding) LintRule {
+	return &lintRule{name: name, fn: fn}
+}
+
+var (
+	// LintDuplicateKeys 检查同一层级中重复的配置项
+	LintDuplicateKeys = NewLintRule("duplicate-key", lintDuplicateKeys)
+	// LintTabs 检查yaml中使用tab缩进的行
+	LintTabs = NewLintRule("tab-indent", lintTabs)
+	// LintSuspiciousStrings 检查疑似被误写为字符串的布尔值，以及yaml 1.1风格的yes/no/on/off
+	LintSuspiciousStrings = NewLintRule("suspicious-string", lintSuspiciousStrings)
+	// LintPlaceholders 检查未被替换的 ${xxx} 占位符
+	LintPlaceholders = NewLintRule("unresolved-placeholder", lintPlaceholders)
+
+	// DefaultLintRules 未指定规则时 Lint 使用的规则
+	DefaultLintRules = []LintRule{LintDuplicateKeys, LintTabs, LintSuspiciousStrings, LintPlaceholders}
+)
+
+// Lint 使用rules检查codec编码的配置内容，未指定rules时使用 DefaultLintRules
+// 配置无法解析时返回规则名为 syntax 的问题
+func Lint(data []byte, codec string, rules ...LintRule) []Finding {
+	if len(rules) == 0 {
+		rules = DefaultLintRules
+	}
+
+	var findings []Finding
+	if c := GetCodec(codec); c == nil {
+		findings = append(findings, Finding{Rule: "syntax", Message: ErrCodecNotExist.Error()})
+	} else if err := decode(c, data, &map[string]interface{}{}); err != nil {
+		line, col := errorPosition(data, err)
+		findings = append(findings, Finding{Rule: "syntax", Line: line, Column: col, Message: err.Error()})
+	}
+
+	for _, r := range rules {
+		findings = append(findings, r.Lint(data, codec)...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+func lintDuplicateKeys(data []byte, codec string) []Finding {
+	switch codec {
+	case "yaml":
+		var root yaml.Node
+		if err := unmarshalYAML(data, &root); err != nil {
+			return nil
+		}
+		var findings []Finding
+		walkYAML(&root, "", func(path string, key, _ *yaml.Node, seen map[string]*yaml.Node) {
+			if prev, ok := seen[key.Value]; ok {
+				findings = append(findings, Finding{
+					Key:     joinKey(path, key.Value),
+					Line:    key.Line,
+					Column:  key.Column,
+					Message: fmt.Sprintf("duplicate key, first defined at line %d", prev.Line),
+				})
+			}
+		})
+		return findings
+	case "json":
+		return jsonDuplicateKeys(data)
+	}
+	// toml解码时已经会对重复的配置项报错
+	return nil
+}
+
+// walkYAML 遍历yaml中的全部映射项，seen为同一映射中已经出现过的key
+func walkYAML(node *yaml.Node, path string, fn func(path string, key, value *yaml.Node, seen map[string]*yaml.Node)) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			walkYAML(n, path, fn)
+		}
+	case yaml.MappingNode:
+		seen := make(map[string]*yaml.Node)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			fn(path, k, v, seen)
+			if _, ok := seen[k.Value]; !ok {
+				seen[k.Value] = k
+			}
+			walkYAML(v, joinKey(path, k.Value), fn)
+		}
+	case yaml.SequenceNode:
+		for i, n := range node.Content {
+			walkYAML(n, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	}
+}
+
+// jsonDuplicateKeys 按照token遍历json，检查对象中重复的key
+func jsonDuplicateKeys(data []byte) []Finding {
+	type frame struct {
+		object bool
+		path   string
+		keys   map[string]bool
+		key    string
+		index  int
+		// expectKey 对象中下一个token是否为key
+		expectKey bool
+	}
+
+	var findings []Finding
+	var stack []*frame
+	// valueDone 当前层级的一个值解析完成
+	valueDone := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.object {
+			top.expectKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if key, ok := tok.(string); ok && top != nil && top.object && top.expectKey {
+			if top.keys[key] {
+				// offset之后可能还有分隔符和空白，定位到key的引号处
+				if i := bytes.IndexByte(data[offset:], '"'); i >= 0 {
+					offset += int64(i)
+				}
+				line, col := offsetPosition(data, offset)
+				findings = append(findings, Finding{Key: joinKey(top.path, key), Line: line, Column: col, Message: "duplicate key"})
+			}
+			top.keys[key] = true
+			top.key = key
+			top.expectKey = false
+			continue
+		}
+
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			path := ""
+			if top != nil && top.object {
+				path = joinKey(top.path, top.key)
+			} else if top != nil {
+				path = fmt.Sprintf("%s[%d]", top.path, top.index)
+			}
+			object := tok == json.Delim('{')
+			stack = append(stack, &frame{object: object, path: path, keys: map[string]bool{}, expectKey: object})
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			valueDone()
+		default:
+			valueDone()
+		}
+	}
+	return findings
+}
+
+func lintTabs(data []byte, codec string) []Finding {
+	if codec != "yaml" {
+		return nil
+	}
+	var findings []Finding
+	for i, line := range strings.Split(string(data), "\n") {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if j := strings.IndexByte(indent, '\t'); j >= 0 {
+			findings = append(findings, Finding{Line: i + 1, Column: j + 1, Message: "tab character used for indentation"})
+		}
+	}
+	return findings
+}
+
+var (
+	boolLikeRegexp   = regexp.MustCompile(`^(?i:true|false)$`)
+	yaml11BoolRegexp = regexp.MustCompile(`^(?i:yes|no|on|off|y|n)$`)
+)
+
+func lintSuspiciousStrings(data []byte, codec string) []Finding {
+	if codec == "yaml" {
+		var root yaml.Node
+		if err := unmarshalYAML(data, &root); err != nil {
+			return nil
+		}
+		var findings []Finding
+		walkYAML(&root, "", func(path string, key, value *yaml.Node, _ map[string]*yaml.Node) {
+			if value.Kind != yaml.ScalarNode {
+				return
+			}
+			f := Finding{Key: joinKey(path, key.Value), Line: value.Line, Column: value.Column}
+			quoted := value.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0
+			switch {
+			case quoted && boolLikeRegexp.MatchString(value.Value):
+				f.Message = fmt.Sprintf("quoted value %q is a string, not a bool", value.Value)
+			case !quoted && value.Tag == "!!str" && yaml11BoolRegexp.MatchString(value.Value):
+				f.Message = fmt.Sprintf("value %q is a string in yaml 1.2, use true/false for bool", value.Value)
+			default:
+				return
+			}
+			findings = append(findings, f)
+		})
+		return findings
+	}
+
+	c := GetCodec(codec)
+	if c == nil {
+		return nil
+	}
+	tree := map[string]interface{}{}
+	if err := decode(c, data, &tree); err != nil {
+		return nil
+	}
+	var findings []Finding
+	walkTree("", tree, func(key string, v interface{}) {
+		if s, ok := v.(string); ok && boolLikeRegexp.MatchString(s) {
+			findings = append(findings, Finding{Key: key, Message: fmt.Sprintf("value %q is a string, not a bool", s)})
+		}
+	})
+	return findings
+}
+
+// walkTree 遍历配置树中的全部叶子节点
+func walkTree(path string, v interface{}, fn func(key string, v interface{})) {
+	if m, ok := toStringMap(v); ok {
+		for k, sub := range m {
+			walkTree(joinKey(path, k), sub, fn)
+		}
+		return
+	}
+	if list, ok := v.([]interface{}); ok {
+		for i, sub := range list {
+			walkTree(fmt.Sprintf("%s[%d]", path, i), sub, fn)
+		}
+		return
+	}
+	fn(path, v)
+}
+
+var placeholderRegexp = regexp.MustCompile(`\$\{[^}]*\}`)
+
+func lintPlaceholders(data []byte, codec string) []Finding {
+	var findings []Finding
+	for i, line := range strings.Split(string(data), "\n") {
+		// 忽略yaml、toml的注释内容
+		if codec != "json" {
+			if j := strings.Index(line, "#"); j >= 0 {
+				line = line[:j]
+			}
+		}
+		for _, loc := range placeholderRegexp.FindAllStringIndex(line, -1) {
+			findings = append(findings, Finding{
+				Line:    i + 1,
+				Column:  loc[0] + 1,
+				Message: fmt.Sprintf("unresolved placeholder %s", line[loc[0]:loc[1]]),
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"log"
+)
+
+// Logger 配置组件使用的日志接口
+type Logger interface {
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+var logger Logger = &stdLogger{}
+
+// SetLogger 设置配置组件以及框架各模块使用的日志实现，需要在启动时、加载配置之前调用
+func SetLogger(l Logger) {
+	logger = l
+}
+
+// Infof 使用 SetLogger 设置的日志实现输出info日志，框架各模块通过它输出日志
+func Infof(format string, args ...interface{}) {
+	logger.Infof(format, args...)
+}
+
+// Warnf 使用 SetLogger 设置的日志实现输出warn日志
+func Warnf(format string, args ...interface{}) {
+	logger.Warnf(format, args...)
+}
+
+// Errorf 使用 SetLogger 设置的日志实现输出error日志
+func Errorf(format string, args ...interface{}) {
+	logger.Errorf(format, args...)
+}
+
+// stdLogger 基于标准库log的默认实现
+type stdLogger struct{}
+
+// Infof 输出info日志
+func (*stdLogger) Infof(format string, args ...interface{}) {
+	log.Printf("[INFO] "+format, args...)
+}
+
+// Warnf 输出warn日志
+func (*stdLogger) Warnf(format string, args ...interface{}) {
+	log.Printf("[WARN] "+format, args...)
+}
+
+// Errorf 输出error日志
+func (*stdLogger) Errorf(format string, args ...interface{}) {
+	log.Printf("[ERROR] "+format, args...)
+}
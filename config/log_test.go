@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// captureLogger 记录日志，其他测试遗留的goroutine可能同时输出日志
+type captureLogger struct {
+	lock  sync.Mutex
+	lines []string
+}
+
+func (l *captureLogger) add(line string) {
+	l.lock.Lock()
+	l.lines = append(l.lines, line)
+	l.lock.Unlock()
+}
+
+func (l *captureLogger) Infof(format string, args ...interface{}) {
+	l.add("INFO " + fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) Warnf(format string, args ...interface{}) {
+	l.add("WARN " + fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) Errorf(format string, args ...interface{}) {
+	l.add("ERROR " + fmt.Sprintf(format, args...))
+}
+
+func TestSetLogger(t *testing.T) {
+	prev := logger
+	defer SetLogger(prev)
+
+	l := &captureLogger{}
+	SetLogger(l)
+	Infof("app/%s: started", "naming")
+	Warnf("app/%s: slow", "redis")
+	Errorf("app/%s: failed", "mq")
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var got []string
+	for _, line := range l.lines {
+		if strings.Contains(line, "app/naming") || strings.Contains(line, "app/redis") || strings.Contains(line, "app/mq") {
+			got = append(got, line)
+		}
+	}
+	want := []string{"INFO app/naming: started", "WARN app/redis: slow", "ERROR app/mq: failed"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expect %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import "reflect"
+
+// KeySpec GetMany 读取的配置项
+type KeySpec struct {
+	Key string
+	// Default 配置项不存在或无法转换时使用的默认值，
+	// 为int、int32、int64、uint、uint32、uint64、float32、float64、bool、string时配置值转换为相同类型
+	Default interface{}
+}
+
+// GetMany 从同一份配置中读取多个配置项，返回值与specs一一对应
+// 只获取一次当前生效的配置，同时读取的配置项不会跨越重新加载
+func (c *FrameworkConfig) GetMany(specs []KeySpec) []interface{} {
+	return c.Snapshot().GetMany(specs)
+}
+
+// GetMany 读取多个配置项，返回值与specs一一对应
+func (p Snapshot) GetMany(specs []KeySpec) []interface{} {
+	values := make([]interface{}, len(specs))
+	for i, spec := range specs {
+		values[i] = p.c.getSpec(p.s, spec)
+	}
+	return values
+}
+
+// getSpec 从snapshot中读取spec对应的配置项
+func (c *FrameworkConfig) getSpec(s *snapshot, spec KeySpec) interface{} {
+	v, err := c.lookup(s, spec.Key)
+	if err != nil {
+		return spec.Default
+	}
+
+	var kind reflect.Kind
+	var fn func(interface{}) (interface{}, error)
+	switch spec.Default.(type) {
+	case int:
+		kind, fn = reflect.Int, toInt
+	case int32:
+		kind, fn = reflect.Int32, toInt32
+	case int64:
+		kind, fn = reflect.Int64, toInt64
+	case uint:
+		kind, fn = reflect.Uint, toUint
+	case uint32:
+		kind, fn = reflect.Uint32, toUint32
+	case uint64:
+		kind, fn = reflect.Uint64, toUint64
+	case float32:
+		kind, fn = reflect.Float32, toFloat32
+	case float64:
+		kind, fn = reflect.Float64, toFloat64
+	case bool:
+		kind, fn = reflect.Bool, toBool
+	case string:
+		kind, fn = reflect.String, toString
+	default:
+		return v
+	}
+	if reflect.TypeOf(v) == reflect.TypeOf(spec.Default) {
+		return v
+	}
+	if result, ok := s.cast(spec.Key, kind, v, fn); ok {
+		return result
+	}
+	return spec.Default
+}
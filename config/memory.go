@@ -0,0 +1,72 @@
+package config
+
+import "fmt"
+
+// MemoryPath NewFromString、NewFromMap 创建的配置的路径，出现在错误信息和配置项来源中
+const MemoryPath = "memory"
+
+// memoryProvider 只包含一份配置的内容源，不注册到全局，内容不会变化
+type memoryProvider struct {
+	path string
+	data []byte
+}
+
+// Name provider名字
+func (p *memoryProvider) Name() string {
+	return "memory"
+}
+
+// Read 返回创建时的内容
+func (p *memoryProvider) Read(path string) ([]byte, error) {
+	if path != p.path {
+		return nil, fmt.Errorf("app/config: memory: %s not found", path)
+	}
+	return p.data, nil
+}
+
+// Watch 内容不会变化，不会回调
+func (p *memoryProvider) Watch(ProviderCallback) {}
+
+// NewFromString 使用yaml格式的内容创建完整功能的Config，不需要配置文件和内容源，通常用于单元测试：
+//
+//	c, err := config.NewFromString("server:\n  timeout: 100\n", config.WithDefaults(defaults))
+//	m := mymodule.New(c)
+//
+// opts 与 Load 相同，WithProvider、WithCodec 除外；创建的配置不会缓存到 DefaultConfigLoader 中，
+// 内容不会变化，需要模拟配置变化时使用 configtest.Load
+func NewFromString(yamlContent string, opts ...LoadOption) (Config, error) {
+	c, err := newMemoryConfig(MemoryPath, []byte(yamlContent), opts)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// newMemoryConfig 使用yaml格式的内容创建路径为path的配置
+func newMemoryConfig(path string, data []byte, opts []LoadOption) (*FrameworkConfig, error) {
+	c := newFullConfig(path)
+	for _, o := range opts {
+		o(c)
+	}
+	if err := c.loadMemory(data); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loadMemory 从yaml格式的内容加载配置，内容不会变化
+func (c *FrameworkConfig) loadMemory(data []byte) error {
+	c.p = &memoryProvider{path: c.path, data: data}
+	c.decoder = &YamlCodec{}
+	return c.Load()
+}
+
+// NewFromMap 使用配置树创建完整功能的Config，m按照yaml编码后与 NewFromString 相同，
+// 嵌套的配置段可以是 map[string]interface{} 或者带有yaml tag的结构体
+func NewFromMap(m map[string]interface{}, opts ...LoadOption) (Config, error) {
+	data, err := Encode(m, "yaml")
+	if err != nil {
+		return nil, fmt.Errorf("app/config: failed to encode map: %v", err)
+	}
+	return NewFromString(string(data), opts...)
+}
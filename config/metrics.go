@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 配置组件的指标名
+const (
+	metricLoads       = "app_config_load_total"
+	metricReloads     = "app_config_reload_total"
+	metricReadSeconds = "app_config_provider_read_seconds"
+	metricCacheSize   = "app_config_cache_size"
+	metricInfo        = "app_config_info"
+	metricSlowReads   = "app_config_provider_slow_reads_total"
+	metricDrift       = "app_config_drift"
+)
+
+// metricHelp 指标说明及类型
+var metricHelp = map[string][2]string{
+	metricLoads:       {"counter", "Number of config loads by path and result."},
+	metricReloads:     {"counter", "Number of config reloads by path and result."},
+	metricReadSeconds: {"summary", "Latency of provider reads in seconds."},
+	metricCacheSize:   {"gauge", "Number of configs cached by the loader."},
+	metricInfo:        {"gauge", "Currently served config, labeled with its content hash."},
+	metricSlowReads:   {"counter", "Number of provider reads exceeding the slow read threshold."},
+	metricDrift:       {"gauge", "Whether the served config differs from the provider, by path."},
+}
+
+// MetricSample 指标样本，可以转换为prometheus等指标系统的指标
+type MetricSample struct {
+	// Name 指标名，summary类型的指标以 _sum、_count 结尾
+	Name   string
+	Type   string
+	Help   string
+	Labels map[string]string
+	Value  float64
+}
+
+// metricSet 进程内的累计指标
+type metricSet struct {
+	mu     sync.Mutex
+	values map[string]*MetricSample
+}
+
+var metrics = &metricSet{values: make(map[string]*MetricSample)}
+
+// add 累加指标，labels为成对的标签名和标签值
+func (m *metricSet) add(name string, delta float64, labels ...string) {
+	id := name + "{" + strings.Join(labels, ",") + "}"
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.values[id]
+	if !ok {
+		s = newMetricSample(name, labels...)
+		m.values[id] = s
+	}
+	s.Value += delta
+}
+
+func (m *metricSet) samples() []MetricSample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]string, 0, len(m.values))
+	for id := range m.values {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	out := make([]MetricSample, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, *m.values[id])
+	}
+	return out
+}
+
+func newMetricSample(name string, labels ...string) *MetricSample {
+	base := strings.TrimSuffix(strings.TrimSuffix(name, "_sum"), "_count")
+	s := &MetricSample{
+		Name:   name,
+		Type:   metricHelp[base][0],
+		Help:   metricHelp[base][1],
+		Labels: make(map[string]string, len(labels)/2),
+	}
+	for i := 0; i+1 < len(labels); i += 2 {
+		s.Labels[labels[i]] = labels[i+1]
+	}
+	return s
+}
+
+func resultLabel(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+// Metrics 采集配置组件的指标，包括加载、重新加载次数，provider读取耗时，
+// 加载器（含租户加载器）缓存大小以及当前生效配置的内容hash
+func (loader *FullConfigLoader) Metrics() []MetricSample {
+	samples := metrics.samples()
+
+	cache := newMetricSample(metricCacheSize)
+	cache.Value = float64(loader.cacheSize())
+	for _, t := range loader.tenantLoaders() {
+		cache.Value += float64(t.cacheSize())
+	}
+	samples = append(samples, *cache)
+	for _, c := range loader.loadedWithTenants() {
+		s := c.snap()
+		info := newMetricSample(metricInfo, "path", c.path, "tenant", c.tenant, "hash", s.fingerprint, "version", s.version)
+		info.Value = 1
+		samples = append(samples, *info)
+
+		drift := newMetricSample(metricDrift, "path", c.path, "tenant", c.tenant)
+		if c.drifted() != nil {
+			drift.Value = 1
+		}
+		samples = append(samples, *drift)
+	}
+
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Name < samples[j].Name })
+	return samples
+}
+
+// MetricsHandler 返回以prometheus文本格式输出加载器指标的http.Handler，
+// 服务已经使用 client_golang 的registry时改用 config/promcollector 注册到同一个registry
+func (loader *FullConfigLoader) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writeMetrics(w, loader.Metrics())
+	})
+}
+
+// MetricsHandler 返回以prometheus文本格式输出默认加载器指标的http.Handler
+func MetricsHandler() http.Handler {
+	return DefaultConfigLoader.MetricsHandler()
+}
+
+// writeMetrics 按照prometheus文本格式输出指标
+func writeMetrics(w io.Writer, samples []MetricSample) {
+	written := make(map[string]bool)
+	for _, s := range samples {
+		base := strings.TrimSuffix(strings.TrimSuffix(s.Name, "_sum"), "_count")
+		if !written[base] {
+			written[base] = true
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", base, s.Help, base, s.Type)
+		}
+
+		keys := make([]string, 0, len(s.Labels))
+		for k := range s.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, fmt.Sprintf("%s=%q", k, s.Labels[k]))
+		}
+		labels := ""
+		if len(pairs) > 0 {
+			labels = "{" + strings.Join(pairs, ",") + "}"
+		}
+		fmt.Fprintf(w, "%s%s %v\n", s.Name, labels, s.Value)
+	}
+}
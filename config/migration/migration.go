@@ -0,0 +1,209 @@
+// Package migration 配置文件结构迁移
+// 配置结构变化时按照版本注册迁移步骤，加载时根据配置文件中的 config_version 依次执行，
+// 滚动发布期间新版本的服务仍然可以加载旧版本的配置文件：
+//
+//	# v1
+//	server:
+//	  timeout_ms: 1000
+//	log:
+//	  level: debug
+//
+//	m := migration.New(3)
+//	m.Register(1, migration.Rename("server.timeout_ms", "server.timeout"))          // v1 -> v2
+//	m.Register(2, migration.Rename("log", "plugins.log.default"), restructureLog) // v2 -> v3
+//	c, err := config.Load("app.yaml", m.LoadOption())
+//
+// 迁移在合并profile、环境变量等配置层之前执行，profile和租户配置文件也会按照各自的 config_version 迁移，
+// 没有 config_version 的配置文件视为版本1。迁移后配置中的 config_version 为当前版本
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cast"
+
+	"goProjectTmpl/config"
+)
+
+// VersionKey 配置文件中版本号的key
+const VersionKey = "config_version"
+
+// ErrNewerVersion 配置文件的版本高于当前版本，通常是回滚服务后读取了新版本的配置文件
+var ErrNewerVersion = errors.New("app/migration: config version is newer than supported")
+
+// Step 迁移步骤，原地修改配置树
+type Step func(tree map[string]interface{}) error
+
+// Migrator 按照版本注册的迁移步骤
+type Migrator struct {
+	current int
+
+	lock  sync.RWMutex
+	steps map[int][]Step
+}
+
+// New 创建Migrator，current为当前代码使用的配置版本
+func New(current int) *Migrator {
+	return &Migrator{current: current, steps: make(map[int][]Step)}
+}
+
+// Current 当前代码使用的配置版本
+func (m *Migrator) Current() int {
+	return m.current
+}
+
+// Register 注册从版本from迁移到from+1的步骤，同一版本多次注册时按照注册顺序执行
+func (m *Migrator) Register(from int, steps ...Step) {
+	m.lock.Lock()
+	m.steps[from] = append(m.steps[from], steps...)
+	m.lock.Unlock()
+}
+
+// Version 配置树中的版本号，没有 config_version 时返回1
+func Version(tree map[string]interface{}) (int, error) {
+	v, ok := tree[VersionKey]
+	if !ok || v == nil {
+		return 1, nil
+	}
+	version, err := cast.ToIntE(v)
+	if err != nil {
+		return 0, fmt.Errorf("app/migration: invalid %s %v: %v", VersionKey, v, err)
+	}
+	return version, nil
+}
+
+// Migrate 将tree原地迁移到当前版本，返回迁移前的版本
+// 任意步骤失败时返回错误，此时tree可能已被部分修改
+func (m *Migrator) Migrate(tree map[string]interface{}) (int, error) {
+	from, err := Version(tree)
+	if err != nil {
+		return 0, err
+	}
+	if from > m.current {
+		return from, fmt.Errorf("%w: %d > %d", ErrNewerVersion, from, m.current)
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	for v := from; v < m.current; v++ {
+		for _, step := range m.steps[v] {
+			if err := step(tree); err != nil {
+				return from, fmt.Errorf("app/migration: v%d -> v%d: %v", v, v+1, err)
+			}
+		}
+	}
+	tree[VersionKey] = m.current
+	return from, nil
+}
+
+// Transform 返回迁移配置文件的 config.Transform
+func (m *Migrator) Transform() config.Transform {
+	return func(path string, tree map[string]interface{}) (map[string]interface{}, error) {
+		from, err := m.Migrate(tree)
+		if err != nil {
+			return nil, err
+		}
+		if from != m.current {
+			config.Infof("app/migration: %s migrated from v%d to v%d", path, from, m.current)
+		}
+		return tree, nil
+	}
+}
+
+// LoadOption 加载和重新加载配置时迁移配置文件
+func (m *Migrator) LoadOption() config.LoadOption {
+	return config.WithTransform(m.Transform())
+}
+
+// Rename 将配置项或配置段从oldKey移动到newKey，key以 . 分隔
+// oldKey不存在时不做任何修改；newKey已存在时保留newKey的值并删除oldKey
+func Rename(oldKey, newKey string) Step {
+	return func(tree map[string]interface{}) error {
+		v, ok := remove(tree, oldKey)
+		if !ok {
+			return nil
+		}
+		if _, exists := lookup(tree, newKey); exists {
+			config.Warnf("app/migration: both %s and %s are set, %s is ignored", oldKey, newKey, oldKey)
+			return nil
+		}
+		return set(tree, newKey, v)
+	}
+}
+
+// Delete 删除不再使用的配置项或配置段
+func Delete(keys ...string) Step {
+	return func(tree map[string]interface{}) error {
+		for _, key := range keys {
+			remove(tree, key)
+		}
+		return nil
+	}
+}
+
+// Default 配置项不存在时设置为v，用于新版本中变为必需的配置项
+func Default(key string, v interface{}) Step {
+	return func(tree map[string]interface{}) error {
+		if _, ok := lookup(tree, key); ok {
+			return nil
+		}
+		return set(tree, key, v)
+	}
+}
+
+// lookup 获取tree中以 . 分隔的key的值
+func lookup(tree map[string]interface{}, key string) (interface{}, bool) {
+	var cur interface{} = tree
+	for _, seg := range strings.Split(key, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[seg]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// set 设置tree中以 . 分隔的key，中间层级不存在时创建对象
+func set(tree map[string]interface{}, key string, v interface{}) error {
+	segs := strings.Split(key, ".")
+	for i, seg := range segs[:len(segs)-1] {
+		next, ok := tree[seg]
+		if !ok {
+			m := make(map[string]interface{})
+			tree[seg] = m
+			tree = m
+			continue
+		}
+		if tree, ok = next.(map[string]interface{}); !ok {
+			return fmt.Errorf("%s is not an object", strings.Join(segs[:i+1], "."))
+		}
+	}
+	tree[segs[len(segs)-1]] = v
+	return nil
+}
+
+// remove 删除tree中以 . 分隔的key，返回删除前的值
+func remove(tree map[string]interface{}, key string) (interface{}, bool) {
+	segs := strings.Split(key, ".")
+	parent := tree
+	if len(segs) > 1 {
+		v, ok := lookup(tree, strings.Join(segs[:len(segs)-1], "."))
+		if !ok {
+			return nil, false
+		}
+		if parent, ok = v.(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+	v, ok := parent[segs[len(segs)-1]]
+	if ok {
+		delete(parent, segs[len(segs)-1])
+	}
+	return v, ok
+}
@@ -0,0 +1,80 @@
+package migration
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"goProjectTmpl/config/configtest"
+)
+
+func newMigrator() *Migrator {
+	m := New(3)
+	m.Register(1, Rename("server.timeout_ms", "server.timeout"))
+	m.Register(2, Rename("log", "plugins.log.default"), Default("server.network", "tcp"), Delete("legacy"))
+	return m
+}
+
+func TestLoad(t *testing.T) {
+	c, p := configtest.Load(t, "server:\n  timeout_ms: 1000\nlog:\n  level: debug\nlegacy: true\n", newMigrator().LoadOption())
+	for key, want := range map[string]string{
+		"server.timeout":            "1000",
+		"server.network":            "tcp",
+		"plugins.log.default.level": "debug",
+		VersionKey:                  "3",
+	} {
+		if got := c.GetString(key, ""); got != want {
+			t.Errorf("%s: expect %q, got %q", key, want, got)
+		}
+	}
+	if c.IsSet("legacy") || c.IsSet("server.timeout_ms") || c.IsSet("log") {
+		t.Error("expect the old keys to be removed")
+	}
+
+	// 重新加载时同样迁移，已是当前版本的配置文件不再迁移
+	p.Update(configtest.DefaultPath, []byte("config_version: 3\nserver:\n  timeout: 2000\n  timeout_ms: 1\n"))
+	if got := c.GetInt("server.timeout", 0); got != 2000 {
+		t.Fatalf("expect 2000, got %d", got)
+	}
+	if !c.IsSet("server.timeout_ms") {
+		t.Fatal("expect a v3 config to be left untouched")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name string
+		tree map[string]interface{}
+		from int
+		err  error
+	}{
+		{"without version", map[string]interface{}{}, 1, nil},
+		{"v2", map[string]interface{}{VersionKey: 2}, 2, nil},
+		{"newer", map[string]interface{}{VersionKey: 4}, 4, ErrNewerVersion},
+	}
+	for _, tt := range tests {
+		from, err := newMigrator().Migrate(tt.tree)
+		if from != tt.from || !errors.Is(err, tt.err) {
+			t.Errorf("%s: expect %d %v, got %d %v", tt.name, tt.from, tt.err, from, err)
+		}
+	}
+	if _, err := newMigrator().Migrate(map[string]interface{}{VersionKey: "x"}); err == nil {
+		t.Error("expect an error for an invalid version")
+	}
+}
+
+func TestRename(t *testing.T) {
+	// 新旧配置同时存在时保留新配置
+	tree := map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": 2}}
+	if err := Rename("a", "b.c")(tree); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tree["a"]; ok || tree["b"].(map[string]interface{})["c"] != 2 {
+		t.Fatalf("expect b.c to be kept, got %v", tree)
+	}
+	// 中间层级不是对象时返回错误
+	tree = map[string]interface{}{"a": 1, "b": "x"}
+	if err := Rename("a", "b.c")(tree); err == nil || !strings.Contains(err.Error(), "b is not an object") {
+		t.Fatalf("expect an error, got %v", err)
+	}
+}
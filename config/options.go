@@ -1,5 +1,10 @@
 package config
 
+import (
+	"flag"
+	"time"
+)
+
 // WithCodec 使用指定名字的Codec
 func WithCodec(name string) LoadOption {
 	return func(c *FrameworkConfig) {
@@ -14,6 +19,173 @@ func WithProvider(name string) LoadOption {
 	}
 }
 
+// WithRequiredKeys 指定必须存在的配置项，加载时缺失任意一项都会返回包含全部缺失项的错误
+func WithRequiredKeys(keys ...string) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.requiredKeys = append(c.requiredKeys, keys...)
+	}
+}
+
+// WithSchema 加载和重新加载时使用schema检查配置
+func WithSchema(s Schema) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.schemas = append(c.schemas, s)
+	}
+}
+
+// WithTransform 加载和重新加载时在合并其他配置层之前使用t转换配置文件，多个Transform按照注册顺序执行
+func WithTransform(t Transform) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.transforms = append(c.transforms, t)
+	}
+}
+
+// WithReloadGate 重新加载时新配置需要经过g的许可才能生效，内容不变的重新加载不经过g
+func WithReloadGate(g ReloadGate) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.gate = g
+	}
+}
+
+// WithStrictDeprecation 配置中出现已废弃的配置项时加载失败
+func WithStrictDeprecation() LoadOption {
+	return func(c *FrameworkConfig) {
+		c.strictDeprecation = true
+	}
+}
+
+// WithUnknownKeys 检查配置中是否存在 RegisterStruct 或 Bind 未声明的配置项
+func WithUnknownKeys(mode UnknownKeyMode) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.unknownKeyMode = mode
+	}
+}
+
+// WithTracer 使用tracer记录配置加载、重新加载以及provider读取的链路追踪
+func WithTracer(t Tracer) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.tracer = t
+	}
+}
+
+// WithHistorySize 设置保留的重新加载记录数，默认保留64条，n<=0时不记录
+func WithHistorySize(n int) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.history = newHistory(n)
+	}
+}
+
+// WithDefaults 设置优先级最低的默认值，key可以使用 . 分隔的多级配置项
+func WithDefaults(defaults map[string]interface{}) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.defaults = defaults
+	}
+}
+
+// WithProfile 按顺序合并profile配置文件，例如 app.yaml 的 local 配置文件为 app.local.yaml
+func WithProfile(profiles ...string) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.profiles = append(c.profiles, profiles...)
+	}
+}
+
+// WithEnv 使用环境变量覆盖已存在的配置项，例如前缀 APP 下 APP_SERVER_BIN_PATH 覆盖 server.bin_path
+func WithEnv(prefix string) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.envPrefix = prefix
+	}
+}
+
+// WithEnvLookup 环境变量层和占位符使用lookup查找环境变量，代替进程当前的环境变量，
+// 例如使用 env.Snapshot 在启动时捕获的只读环境变量
+func WithEnvLookup(lookup func(string) (string, bool)) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.envLookup = lookup
+	}
+}
+
+// WithFlags 使用fs中显式设置的命令行参数覆盖同名配置项，参数名为 . 分隔的配置项，例如 -server.app
+func WithFlags(fs *flag.FlagSet) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.flags = fs
+	}
+}
+
+// WithOverrides 设置优先级最高的覆盖值，key可以使用 . 分隔的多级配置项
+func WithOverrides(overrides map[string]interface{}) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.overrides = overrides
+	}
+}
+
+// WithPlaceholders 解析配置值中的 ${key} 和 ${key:-default} 占位符，
+// 优先引用其他配置项，配置项不存在时使用同名环境变量
+func WithPlaceholders() LoadOption {
+	return func(c *FrameworkConfig) {
+		c.placeholders = true
+	}
+}
+
+// WithAutoReload 内容源通知配置变化时自动重新加载，而不只是清除加载器的缓存
+func WithAutoReload() LoadOption {
+	return func(c *FrameworkConfig) {
+		c.autoReload = true
+	}
+}
+
+// WithSlowReadThreshold 设置provider读取耗时的告警阈值，d<=0时不检查
+func WithSlowReadThreshold(d time.Duration) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.slowReadThreshold = d
+	}
+}
+
+// WithLazyDecode 加载时只建立顶层配置段的索引，值为对象的配置段在第一次访问时才解码，
+// 用于只使用其中少数配置段的超大配置文件。目前支持yaml和json，使用 WithSchema 时不生效；
+// 首次加载时配置段中的语法错误在第一次访问时才会发现，此时输出错误日志并视为空对象；
+// 重新加载时内容变化的配置段会立即解码，解码失败时拒绝重新加载，继续使用当前配置
+func WithLazyDecode() LoadOption {
+	return func(c *FrameworkConfig) {
+		c.lazy = true
+	}
+}
+
+// WithParseCache 将解码后的配置持久化到dir中，原始配置内容不变时重启进程可以跳过解码，
+// 用于解码耗时较长的超大配置文件。dir为空时写入配置文件所在目录，
+// 例如 app.yaml 的缓存文件为 .app.yaml.parsed。使用 WithLazyDecode 时不生效
+func WithParseCache(dir string) LoadOption {
+	return func(c *FrameworkConfig) {
+		c.parseCache = true
+		c.parseCacheDir = dir
+	}
+}
+
+// WithDiscardRaw 解码成功后不保留原始配置，减少超大配置文件的内存占用
+// 此时 Bytes 返回nil，Unmarshal 改为从解码后的配置树反序列化，校验错误中不再包含行列号
+func WithDiscardRaw() LoadOption {
+	return func(c *FrameworkConfig) {
+		c.discardRaw = true
+	}
+}
+
+// WithMmap provider支持时（例如file）映射配置文件而不是读取到内存中，用于数百MB的生成配置文件
+// 映射的内容只在解码期间使用，同时启用 WithDiscardRaw。
+// 配置文件应当通过重命名整体替换，映射期间被原地截断时本次加载或重新加载返回错误，继续使用原来的配置
+func WithMmap() LoadOption {
+	return func(c *FrameworkConfig) {
+		c.mmap = true
+		c.discardRaw = true
+	}
+}
+
+// WithCompactIndex 以按key排序的切片保存配置项索引并二分查找，代替每个配置项一个map元素，
+// 用于包含大量配置项的扁平键值表，以略慢的查找换取更少的内存占用
+func WithCompactIndex() LoadOption {
+	return func(c *FrameworkConfig) {
+		c.compactIndex = true
+	}
+}
+
 // options 配置选项
 type options struct{}
 
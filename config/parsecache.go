@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func init() {
+	// 解码后的配置树中可能出现的动态类型
+	gob.Register(map[string]interface{}{})
+	gob.Register(map[interface{}]interface{}{})
+	gob.Register([]interface{}{})
+	gob.Register([]map[string]interface{}{})
+	gob.Register(time.Time{})
+}
+
+// parseCacheEntry 持久化的解码结果，Hash为原始配置内容的sha256
+type parseCacheEntry struct {
+	Hash  string
+	Codec string
+	Tree  map[string]interface{}
+}
+
+// parseCachePath 返回解码缓存文件路径，例如 app.yaml 对应 .app.yaml.parsed
+func (c *FrameworkConfig) parseCachePath() string {
+	dir := c.parseCacheDir
+	if dir == "" {
+		dir = filepath.Dir(c.path)
+	}
+	return filepath.Join(dir, "."+filepath.Base(c.path)+".parsed")
+}
+
+// loadParseCache 读取解码缓存，缓存不存在或与原始配置内容不一致时返回false
+func (c *FrameworkConfig) loadParseCache(hash string) (map[string]interface{}, bool) {
+	f, err := os.Open(c.parseCachePath())
+	if err != nil {
+		return nil, false
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	_, err = buf.ReadFrom(f)
+	f.Close()
+	if err != nil {
+		return nil, false
+	}
+
+	var entry parseCacheEntry
+	if err := gob.NewDecoder(buf).Decode(&entry); err != nil {
+		logger.Warnf("app/config: ignore broken parse cache path=%s: %v", c.parseCachePath(), err)
+		return nil, false
+	}
+	if entry.Hash != hash || entry.Codec != c.decoder.Name() || entry.Tree == nil {
+		return nil, false
+	}
+	return entry.Tree, true
+}
+
+// storeParseCache 写入解码缓存，先写临时文件再重命名，避免其他进程读到不完整的内容
+func (c *FrameworkConfig) storeParseCache(hash string, tree map[string]interface{}) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	entry := parseCacheEntry{Hash: hash, Codec: c.decoder.Name(), Tree: tree}
+	if err := gob.NewEncoder(buf).Encode(&entry); err != nil {
+		logger.Warnf("app/config: failed to encode parse cache path=%s: %v", c.path, err)
+		return
+	}
+
+	path := c.parseCachePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		logger.Warnf("app/config: failed to write parse cache path=%s: %v", path, err)
+		return
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		logger.Warnf("app/config: failed to write parse cache path=%s: %v", path, err)
+		return
+	}
+	_, err = f.Write(buf.Bytes())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		logger.Warnf("app/config: failed to write parse cache path=%s: %v", path, err)
+	}
+}
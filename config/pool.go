@@ -0,0 +1,31 @@
+package config
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBuffer 放回缓冲池的缓冲区容量上限，避免个别超大配置长期占用内存
+const maxPooledBuffer = 16 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer 从缓冲池获取清空的缓冲区，用完后调用 putBuffer 放回，
+// 用于 WithDiscardRaw 时读取配置、解析缓存和审计日志的编码
+// 只能用于内容不会被调用方保留的场景
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	bufferPool.Put(buf)
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// contextLines 错误信息中展示的出错行前后的行数
+const contextLines = 2
+
+var lineRegexp = regexp.MustCompile(`(?i)line (\d+)`)
+
+// KeyLocator Codec的可选接口，用于定位配置项在原始配置中的行列位置
+type KeyLocator interface {
+	// Locate 返回key对应配置项所在的行和列，从1开始
+	Locate(data []byte, key string) (line int, column int, ok bool)
+}
+
+// PositionError 带有行列位置信息的配置错误
+type PositionError struct {
+	Path    string
+	Line    int
+	Column  int
+	Context string
+	Err     error
+}
+
+// Error 实现error接口
+func (e *PositionError) Error() string {
+	pos := strconv.Itoa(e.Line)
+	if e.Column > 0 {
+		pos += ":" + strconv.Itoa(e.Column)
+	}
+	msg := fmt.Sprintf("app/config: failed to parse %s:%s: %s", e.Path, pos, e.Err.Error())
+	if e.Context != "" {
+		msg += "\n" + e.Context
+	}
+	return msg
+}
+
+// Unwrap 返回原始错误
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError 为解码错误补充行列位置及出错行附近的内容
+func newParseError(path string, data []byte, err error) error {
+	line, col := errorPosition(data, err)
+	if line <= 0 {
+		return fmt.Errorf("app/config: failed to parse %s: %s", path, err.Error())
+	}
+	return &PositionError{
+		Path:    path,
+		Line:    line,
+		Column:  col,
+		Context: sourceContext(data, line, col),
+		Err:     err,
+	}
+}
+
+// errorPosition 从解码错误中获取行列位置
+func errorPosition(data []byte, err error) (line int, col int) {
+	var se *json.SyntaxError
+	if errors.As(err, &se) {
+		return offsetPosition(data, se.Offset)
+	}
+	var te *json.UnmarshalTypeError
+	if errors.As(err, &te) {
+		return offsetPosition(data, te.Offset)
+	}
+	if m := lineRegexp.FindStringSubmatch(err.Error()); m != nil {
+		line, _ = strconv.Atoi(m[1])
+	}
+	return line, 0
+}
+
+// offsetPosition 将字节偏移量转换为行列位置
+func offsetPosition(data []byte, offset int64) (line int, col int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	line, col = 1, 1
+	for _, b := range data[:offset] {
+		if b == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
+// sourceContext 返回出错行前后若干行的内容，并标记出错的行列
+func sourceContext(data []byte, line, col int) string {
+	lines := strings.Split(string(data), "\n")
+	if line > len(lines) {
+		return ""
+	}
+
+	start, end := line-contextLines, line+contextLines
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	width := len(strconv.Itoa(end))
+	var sb strings.Builder
+	for i := start; i <= end; i++ {
+		marker := "  "
+		if i == line {
+			marker = "> "
+		}
+		fmt.Fprintf(&sb, "%s%*d | %s\n", marker, width, i, lines[i-1])
+		if i == line && col > 0 {
+			fmt.Fprintf(&sb, "  %s | %s^\n", strings.Repeat(" ", width), strings.Repeat(" ", col-1))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// locateErrors 为校验错误补充配置项在原始配置中的位置
+func (c *FrameworkConfig) locateErrors(data []byte, err error) error {
+	locator, ok := c.decoder.(KeyLocator)
+	if !ok {
+		return err
+	}
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	for _, fe := range errs {
+		if line, col, ok := locator.Locate(data, fe.Key); ok {
+			fe.Line, fe.Column = line, col
+		}
+	}
+	return err
+}
+
+// Locate 实现KeyLocator接口，key支持 a.b[0].c 形式的列表下标
+func (c *YamlCodec) Locate(data []byte, key string) (int, int, bool) {
+	var root yaml.Node
+	if err := unmarshalYAML(data, &root); err != nil {
+		return 0, 0, false
+	}
+
+	node := &root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	line, col := node.Line, node.Column
+	for _, seg := range splitKeyPath(key) {
+		next, pos, ok := yamlChild(node, seg)
+		if !ok {
+			return 0, 0, false
+		}
+		node, line, col = next, pos.Line, pos.Column
+	}
+	return line, col, true
+}
+
+// yamlChild 查找yaml节点的子节点，返回子节点及其位置节点（map时为key节点）
+func yamlChild(node *yaml.Node, seg string) (*yaml.Node, *yaml.Node, bool) {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == seg {
+				return node.Content[i+1], node.Content[i], true
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(seg); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i], node.Content[i], true
+		}
+	}
+	return nil, nil, false
+}
+
+// splitKeyPath 将 a.b[0].c 拆分为 a、b、0、c
+func splitKeyPath(key string) []string {
+	var segs []string
+	for _, part := range strings.Split(key, ".") {
+		for {
+			i := strings.Index(part, "[")
+			if i < 0 {
+				break
+			}
+			if i > 0 {
+				segs = append(segs, part[:i])
+			}
+			j := strings.Index(part, "]")
+			if j < i {
+				break
+			}
+			segs = append(segs, part[i+1:j])
+			part = part[j+1:]
+		}
+		if part != "" {
+			segs = append(segs, part)
+		}
+	}
+	return segs
+}
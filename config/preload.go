@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PreloadItem 启动时需要加载的配置
+type PreloadItem struct {
+	Path    string
+	Options []LoadOption
+}
+
+// PreloadError 预加载时部分配置加载失败
+type PreloadError struct {
+	// Errors 加载失败的配置路径及原因
+	Errors map[string]error
+}
+
+// Error 实现error接口
+func (e *PreloadError) Error() string {
+	paths := make([]string, 0, len(e.Errors))
+	for path, err := range e.Errors {
+		paths = append(paths, fmt.Sprintf("%s: %v", path, err))
+	}
+	sort.Strings(paths)
+	return fmt.Sprintf("app/config: failed to preload %d config(s): %s", len(e.Errors), strings.Join(paths, "; "))
+}
+
+// preloadOptions 预加载选项
+type preloadOptions struct {
+	report      bool
+	concurrency int
+}
+
+// PreloadOption 预加载选项
+type PreloadOption func(*preloadOptions)
+
+// WithStartupReport 预加载完成后输出一次启动配置报告，参见 StartupReport
+func WithStartupReport() PreloadOption {
+	return func(o *preloadOptions) {
+		o.report = true
+	}
+}
+
+// WithConcurrency 最多同时加载n个配置，用于从远程内容源预加载大量配置，默认依次加载
+func WithConcurrency(n int) PreloadOption {
+	return func(o *preloadOptions) {
+		o.concurrency = n
+	}
+}
+
+// Preload 在服务启动时加载items中的配置，任意一个加载失败则返回错误
+// 加载失败的配置不影响其他配置的加载，全部失败原因通过 *PreloadError 返回
+func (loader *FullConfigLoader) Preload(items []PreloadItem, opts ...PreloadOption) error {
+	o := &preloadOptions{concurrency: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.concurrency < 1 {
+		o.concurrency = 1
+	}
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		errs = make(map[string]error)
+		sem  = make(chan struct{}, o.concurrency)
+	)
+	for _, item := range items {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(item PreloadItem) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if _, err := loader.Load(item.Path, item.Options...); err != nil {
+				lock.Lock()
+				errs[item.Path] = err
+				lock.Unlock()
+			}
+		}(item)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return &PreloadError{Errors: errs}
+	}
+
+	if o.report {
+		logger.Infof("%s", loader.StartupReport())
+	}
+	return nil
+}
+
+// StartupReport 生成已加载配置的报告，列出每个配置的路径、内容源、版本号、大小、profile，
+// 以及由配置文件之外的配置层（默认值、profile、租户、环境变量、命令行参数、覆盖值）提供的配置项，敏感配置项会被脱敏
+func (loader *FullConfigLoader) StartupReport() string {
+	var sb strings.Builder
+	sb.WriteString("app/config: startup report")
+	for _, c := range loader.loaded() {
+		info := c.info()
+		fmt.Fprintf(&sb, "\n  %s provider=%s codec=%s version=%s size=%d fingerprint=%.12s",
+			info.Path, info.Provider, info.Codec, info.Version, info.Size, info.Fingerprint)
+		if len(c.profiles) > 0 {
+			fmt.Fprintf(&sb, " profiles=%s", strings.Join(c.profiles, ","))
+		}
+		if c.tenant != "" {
+			fmt.Fprintf(&sb, " tenant=%s", c.tenant)
+		}
+
+		s := c.snap()
+		keys := make([]string, 0, len(s.origins))
+		for k := range s.origins {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			o := s.origins[k]
+			v, _ := lookupTree(s.tree, k)
+			source := o.Layer
+			if o.Source != "" {
+				source += " " + o.Source
+			}
+			fmt.Fprintf(&sb, "\n    %s = %v (%s)", k, Redact(k, v), source)
+		}
+	}
+	return sb.String()
+}
+
+// Preload 使用默认加载器预加载配置
+func Preload(items []PreloadItem, opts ...PreloadOption) error {
+	return DefaultConfigLoader.Preload(items, opts...)
+}
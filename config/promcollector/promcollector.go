@@ -0,0 +1,92 @@
+// Package promcollector 将配置组件的指标以 prometheus.Collector 的形式注册到已有的registry
+// 服务已经使用 client_golang 暴露指标时，用它代替 config.MetricsHandler，配置组件的指标与其他指标从同一个接口输出：
+//
+//	prometheus.MustRegister(promcollector.New(config.DefaultConfigLoader))
+//	http.Handle("/metrics", promhttp.Handler())
+//
+// 依赖的 client_golang 只在引用本包时编译进二进制
+package promcollector
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"goProjectTmpl/config"
+)
+
+// Collector 采集加载器的指标，实现了 prometheus.Collector
+// 指标的标签随加载的配置变化，Describe 不输出描述，registry按照unchecked collector处理
+type Collector struct {
+	loader *config.FullConfigLoader
+}
+
+// New 创建采集loader指标的Collector，loader为nil时使用 config.DefaultConfigLoader
+func New(loader *config.FullConfigLoader) *Collector {
+	if loader == nil {
+		loader = config.DefaultConfigLoader
+	}
+	return &Collector{loader: loader}
+}
+
+// Describe 实现 prometheus.Collector
+func (c *Collector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect 实现 prometheus.Collector，summary类型指标的 _sum、_count 样本合并为一个指标
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	type summary struct {
+		desc   *prometheus.Desc
+		values []string
+		sum    float64
+		count  uint64
+	}
+	summaries := make(map[string]*summary)
+	var order []string
+
+	for _, s := range c.loader.Metrics() {
+		base := strings.TrimSuffix(strings.TrimSuffix(s.Name, "_sum"), "_count")
+		names, values := labels(s.Labels)
+		desc := prometheus.NewDesc(base, s.Help, names, nil)
+
+		switch s.Type {
+		case "counter":
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, s.Value, values...)
+		case "gauge":
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, s.Value, values...)
+		case "summary":
+			id := base + "{" + strings.Join(values, ",") + "}"
+			sm, ok := summaries[id]
+			if !ok {
+				sm = &summary{desc: desc, values: values}
+				summaries[id] = sm
+				order = append(order, id)
+			}
+			if strings.HasSuffix(s.Name, "_count") {
+				sm.count = uint64(s.Value)
+			} else {
+				sm.sum = s.Value
+			}
+		default:
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.UntypedValue, s.Value, values...)
+		}
+	}
+	for _, id := range order {
+		sm := summaries[id]
+		ch <- prometheus.MustNewConstSummary(sm.desc, sm.count, sm.sum, nil, sm.values...)
+	}
+}
+
+// labels 按照标签名排序返回标签名和标签值
+func labels(m map[string]string) ([]string, []string) {
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	values := make([]string, 0, len(names))
+	for _, k := range names {
+		values = append(values, m[k])
+	}
+	return names, values
+}
@@ -0,0 +1,58 @@
+package promcollector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"goProjectTmpl/config/configtest"
+)
+
+func TestCollect(t *testing.T) {
+	c, _ := configtest.Load(t, "server:\n  port: 80\n")
+	if c.GetInt("server.port", 0) != 80 {
+		t.Fatal("expect the config to be loaded")
+	}
+
+	r := prometheus.NewRegistry()
+	r.MustRegister(New(nil))
+	families, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := make(map[string]dto.MetricType)
+	for _, f := range families {
+		types[f.GetName()] = f.GetType()
+		// summary的 _sum、_count 合并为一个指标
+		if f.GetType() == dto.MetricType_SUMMARY {
+			for _, m := range f.GetMetric() {
+				if m.GetSummary().GetSampleCount() == 0 {
+					t.Errorf("%s: expect a sample count", f.GetName())
+				}
+			}
+		}
+	}
+	for name, want := range map[string]dto.MetricType{
+		"app_config_load_total":            dto.MetricType_COUNTER,
+		"app_config_provider_read_seconds": dto.MetricType_SUMMARY,
+		"app_config_cache_size":            dto.MetricType_GAUGE,
+		"app_config_info":                  dto.MetricType_GAUGE,
+	} {
+		got, ok := types[name]
+		if !ok {
+			t.Errorf("expect metric %s, got %v", name, types)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s: expect %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestLabels(t *testing.T) {
+	names, values := labels(map[string]string{"tenant": "", "path": "app.yaml", "hash": "h"})
+	if len(names) != 3 || names[0] != "hash" || names[1] != "path" || values[1] != "app.yaml" {
+		t.Fatalf("expect labels sorted by name, got %v %v", names, values)
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+// Provenance 获取配置项key当前生效值的来源，key不存在或为对象时返回false
+// 未经其他配置层覆盖的配置项来源为配置文件本身
+func (c *FrameworkConfig) Provenance(key string) (Origin, bool) {
+	s := c.snap()
+	if o, ok := s.origins[key]; ok {
+		return o, true
+	}
+	v, err := c.lookup(s, key)
+	if err != nil {
+		return Origin{}, false
+	}
+	if _, ok := toStringMap(v); ok {
+		return Origin{}, false
+	}
+	return Origin{Layer: LayerFile, Source: c.path}, true
+}
+
+// provenances 获取全部非对象配置项的来源
+func (c *FrameworkConfig) provenances() map[string]Origin {
+	out := make(map[string]Origin)
+	s := c.snap()
+	for key := range leafValues("", s.tree) {
+		if o, ok := s.origins[key]; ok {
+			out[key] = o
+			continue
+		}
+		out[key] = Origin{Layer: LayerFile, Source: c.path}
+	}
+	return out
+}
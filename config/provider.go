@@ -1,9 +1,12 @@
 package config
 
 import (
-	"fmt"
+	"bytes"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
@@ -33,6 +36,7 @@ type FileProvider struct {
 	watcher         *fsnotify.Watcher
 	cb              chan ProviderCallback
 	cache           map[string]string
+	cacheLock       sync.RWMutex
 }
 
 // Name Provider名字
@@ -40,24 +44,139 @@ func (*FileProvider) Name() string {
 	return "file"
 }
 
+// watch 监听path的变化
+func (fp *FileProvider) watch(path string) error {
+	if fp.disabledWatcher {
+		return nil
+	}
+	if err := fp.watcher.Add(path); err != nil {
+		return err
+	}
+	fp.cacheLock.Lock()
+	fp.cache[filepath.Clean(path)] = path
+	fp.cacheLock.Unlock()
+	return nil
+}
+
 // Read 读取指定文件
 func (fp *FileProvider) Read(path string) ([]byte, error) {
-
-	if !fp.disabledWatcher {
-		if err := fp.watcher.Add(path); err != nil {
-			return nil, err
-		}
-		fp.cache[filepath.Clean(path)] = path
+	if err := fp.watch(path); err != nil {
+		return nil, err
 	}
 
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
-		fmt.Printf("Failed to read file %v", err)
+		logger.Errorf("app/config: failed to read file %v", err)
 		return nil, err
 	}
 	return data, nil
 }
 
+// ReadWithVersion 读取指定文件，并以文件修改时间作为版本号
+func (fp *FileProvider) ReadWithVersion(path string) ([]byte, string, error) {
+	data, err := fp.Read(path)
+	if err != nil {
+		return nil, "", err
+	}
+	version, err := fileVersion(path)
+	if err != nil {
+		return data, "", nil
+	}
+	return data, version, nil
+}
+
+// ReadTo 实现 BufferedProvider 接口，将文件读取到buf中，并以文件修改时间作为版本号
+func (fp *FileProvider) ReadTo(path string, buf *bytes.Buffer) (string, error) {
+	if err := fp.watch(path); err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		logger.Errorf("app/config: failed to read file %v", err)
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	buf.Grow(int(info.Size()) + bytes.MinRead)
+	if _, err := buf.ReadFrom(f); err != nil {
+		logger.Errorf("app/config: failed to read file %s: %v", path, err)
+		return "", err
+	}
+	return info.ModTime().UTC().Format(time.RFC3339Nano), nil
+}
+
+// fileVersion 以文件修改时间作为版本号
+func fileVersion(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return info.ModTime().UTC().Format(time.RFC3339Nano), nil
+}
+
+// Write 将data写入文件，version不为空且文件修改时间不同时返回 ErrVersionConflict
+// 先写入同目录下的临时文件并落盘，再重命名覆盖原文件，监听方不会读到截断或写了一半的内容；
+// 重命名后重新监听新文件。检查版本号与写入之间不加锁，只用于发现明显的并发修改
+func (fp *FileProvider) Write(path string, data []byte, version string) (string, error) {
+	if version != "" {
+		cur, err := fileVersion(path)
+		if err != nil {
+			return "", err
+		}
+		if cur != version {
+			return "", ErrVersionConflict
+		}
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := writeFileAtomic(path, data, mode); err != nil {
+		return "", err
+	}
+	if !fp.disabledWatcher {
+		fp.cacheLock.RLock()
+		_, watched := fp.cache[filepath.Clean(path)]
+		fp.cacheLock.RUnlock()
+		if watched {
+			if err := fp.watcher.Add(path); err != nil {
+				logger.Errorf("app/config: failed to watch %s after write: %v", path, err)
+			}
+		}
+	}
+	return fileVersion(path)
+}
+
+// writeFileAtomic 通过同目录下的临时文件和重命名原子地替换path的内容
+func writeFileAtomic(path string, data []byte, mode os.FileMode) (err error) {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Chmod(mode); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
 // Watch 注册文件变化处理函数
 func (fp *FileProvider) Watch(cb ProviderCallback) {
 	if !fp.disabledWatcher {
@@ -77,7 +196,10 @@ func (fp *FileProvider) run() {
 
 		case e := <-fp.watcher.Events:
 			if data, err := ioutil.ReadFile(e.Name); err == nil {
-				if path, ok := fp.cache[e.Name]; ok {
+				fp.cacheLock.RLock()
+				path, ok := fp.cache[e.Name]
+				fp.cacheLock.RUnlock()
+				if ok {
 					for _, f := range fn {
 						go f(path, data)
 					}
@@ -0,0 +1,39 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package config
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// ReadMapped 实现 MappedProvider 接口，将文件映射到内存中，并以文件修改时间作为版本号
+func (fp *FileProvider) ReadMapped(path string) ([]byte, string, func(), error) {
+	if err := fp.watch(path); err != nil {
+		return nil, "", nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		logger.Errorf("app/config: failed to read file %v", err)
+		return nil, "", nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	version := info.ModTime().UTC().Format(time.RFC3339Nano)
+	if info.Size() == 0 {
+		return []byte{}, version, func() {}, nil
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		logger.Errorf("app/config: failed to map file %s: %v", path, err)
+		return nil, "", nil, err
+	}
+	return data, version, func() { syscall.Munmap(data) }, nil
+}
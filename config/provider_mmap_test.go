@@ -0,0 +1,38 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMappedFileTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	content := "server:\n  name: " + strings.Repeat("x", 3*os.Getpagesize()) + "\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := newFullConfig(path)
+	WithMmap()(c)
+
+	data, _, release, err := c.read(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data[:7], []byte("server:")) {
+		t.Fatalf("unexpected mapped content %q", data[:7])
+	}
+	// 原地截断后访问映射的内容返回错误而不是使进程崩溃
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.hashRead(data, release); err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Fatalf("expect a truncated error, got %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package config
+
+// ReadMapped 实现 MappedProvider 接口，当前平台不支持内存映射，读取整个文件
+func (fp *FileProvider) ReadMapped(path string) ([]byte, string, func(), error) {
+	data, version, err := fp.ReadWithVersion(path)
+	return data, version, func() {}, err
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RedactedValue 脱敏后展示的值
+const RedactedValue = "******"
+
+var (
+	sensitivePatterns []string
+	// sensitiveSet 已标记的模式，每次Bind都会重新标记结构体中的敏感字段，重复的模式不再追加
+	sensitiveSet  = map[string]bool{}
+	sensitiveLock = sync.RWMutex{}
+
+	indexRegexp = regexp.MustCompile(`\[\d+\]`)
+)
+
+func init() {
+	MarkSensitive("**.*password*", "**.*passwd*", "**.*secret*", "**.*token*", "**.*credential*", "**.*private_key*")
+}
+
+// MarkSensitive 标记敏感配置项，配置输出、差异对比、日志以及调试接口中都会对其脱敏
+// 模式按照 . 分段匹配（忽略大小写），* 匹配一段中的任意字符，** 匹配任意多段，
+// 例如 "db.password"、"*.token"、"**.secret"；列表下标在匹配时会被忽略
+func MarkSensitive(patterns ...string) {
+	sensitiveLock.Lock()
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		if sensitiveSet[p] {
+			continue
+		}
+		sensitiveSet[p] = true
+		sensitivePatterns = append(sensitivePatterns, p)
+	}
+	sensitiveLock.Unlock()
+}
+
+// IsSensitive 判断key是否为敏感配置项
+func IsSensitive(key string) bool {
+	segs := strings.Split(strings.ToLower(indexRegexp.ReplaceAllString(key, "")), ".")
+
+	sensitiveLock.RLock()
+	defer sensitiveLock.RUnlock()
+	for _, p := range sensitivePatterns {
+		if matchSegments(strings.Split(p, "."), segs) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments 按段匹配模式
+func matchSegments(pattern, segs []string) bool {
+	if len(pattern) == 0 {
+		return len(segs) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(pattern[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], segs[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segs[1:])
+}
+
+// Redact 如果key为敏感配置项，返回脱敏后的值，否则返回v的副本，其中敏感的子配置项被脱敏
+func Redact(key string, v interface{}) interface{} {
+	return redactTree(key, v)
+}
+
+// redactTree 返回配置树的副本，其中敏感配置项的值被替换
+func redactTree(path string, v interface{}) interface{} {
+	if path != "" && IsSensitive(path) {
+		return RedactedValue
+	}
+	if m, ok := toStringMap(v); ok {
+		out := make(map[string]interface{}, len(m))
+		for k, sub := range m {
+			out[k] = redactTree(joinKey(path, k), sub)
+		}
+		return out
+	}
+	if list, ok := v.([]interface{}); ok {
+		out := make([]interface{}, len(list))
+		for i, sub := range list {
+			out[i] = redactTree(fmt.Sprintf("%s[%d]", path, i), sub)
+		}
+		return out
+	}
+	return v
+}
+
+// markSensitiveFields 将结构体中带有 sensitive:"true" tag的字段标记为敏感配置项
+func markSensitiveFields(prefix string, t reflect.Type, tag string) {
+	var patterns []string
+	collectSensitive(prefix, t, tag, &patterns, map[reflect.Type]bool{})
+	if len(patterns) > 0 {
+		MarkSensitive(patterns...)
+	}
+}
+
+func collectSensitive(prefix string, t reflect.Type, tag string, patterns *[]string, visited map[reflect.Type]bool) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Map {
+		collectSensitive(joinKey(prefix, "*"), t.Elem(), tag, patterns, visited)
+		return
+	}
+	if t.Kind() != reflect.Struct || t == timeType || visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		name, inline, skip := fieldName(f, tag)
+		if skip {
+			continue
+		}
+		key := joinKey(prefix, name)
+		if inline {
+			key = prefix
+		}
+		if f.Tag.Get("sensitive") == "true" {
+			*patterns = append(*patterns, key)
+			if composite(f.Type) {
+				// map、结构体等字段下的每个配置项同样敏感，例如认证header
+				*patterns = append(*patterns, key+".**")
+			}
+			continue
+		}
+		collectSensitive(key, f.Type, tag, patterns, visited)
+	}
+}
+
+// composite 字段是否包含下级配置项
+func composite(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	case reflect.Struct:
+		return t != timeType
+	}
+	return false
+}
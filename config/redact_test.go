@@ -0,0 +1,31 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarkSensitiveDedup(t *testing.T) {
+	type db struct {
+		DSN string `yaml:"dsn" sensitive:"true"`
+	}
+	count := func() int {
+		sensitiveLock.RLock()
+		defer sensitiveLock.RUnlock()
+		return len(sensitivePatterns)
+	}
+
+	markSensitiveFields("redact_test", reflect.TypeOf(db{}), "yaml")
+	n := count()
+	// 每次Bind、RegisterStruct都会重新标记，模式不能重复追加
+	for i := 0; i < 10; i++ {
+		markSensitiveFields("redact_test", reflect.TypeOf(db{}), "yaml")
+		MarkSensitive("REDACT_TEST.dsn")
+	}
+	if got := count(); got != n {
+		t.Fatalf("expect %d patterns, got %d", n, got)
+	}
+	if !IsSensitive("redact_test.dsn") {
+		t.Fatal("expect redact_test.dsn to be sensitive")
+	}
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// ReloadError 批量重新加载时部分配置加载失败
+type ReloadError struct {
+	// Errors 加载失败的配置路径及原因，租户的配置为 租户:路径
+	Errors map[string]error
+}
+
+// Error 实现error接口
+func (e *ReloadError) Error() string {
+	paths := make([]string, 0, len(e.Errors))
+	for path, err := range e.Errors {
+		paths = append(paths, fmt.Sprintf("%s: %v", path, err))
+	}
+	sort.Strings(paths)
+	return fmt.Sprintf("app/config: failed to reload %d config(s): %s", len(e.Errors), strings.Join(paths, "; "))
+}
+
+// ReloadAll 重新加载加载器及其租户加载器中全部已加载的配置，返回成功加载的数量
+// 加载失败的配置继续使用原来的内容，失败原因通过 *ReloadError 返回
+func (loader *FullConfigLoader) ReloadAll() (int, error) {
+	reloaded := 0
+	errs := make(map[string]error)
+	for _, c := range loader.loadedWithTenants() {
+		if err := c.reloadAndReport(); err != nil {
+			errs[c.name()] = err
+			continue
+		}
+		reloaded++
+	}
+	if len(errs) > 0 {
+		return reloaded, &ReloadError{Errors: errs}
+	}
+	return reloaded, nil
+}
+
+// ReloadOnSignal 收到指定信号时重新加载全部配置，未指定信号时使用 SIGHUP
+// ctx结束后停止监听
+func (loader *FullConfigLoader) ReloadOnSignal(ctx context.Context, sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-ch:
+				n, err := loader.ReloadAll()
+				if err != nil {
+					logger.Errorf("app/config: reload on signal %v: %v", sig, err)
+					continue
+				}
+				logger.Infof("app/config: reloaded %d config(s) on signal %v", n, sig)
+			}
+		}
+	}()
+}
+
+// reloadResult 重新加载接口的返回内容
+type reloadResult struct {
+	Reloaded int               `json:"reloaded"`
+	Errors   map[string]string `json:"errors,omitempty"`
+	// DryRun 试运行时各个配置将产生的变更
+	DryRun []*DryRunResult `json:"dry_run,omitempty"`
+}
+
+// CheckBearer 检查请求是否携带 Authorization: Bearer <token> 头，缺少 Bearer 前缀时不通过，前缀不区分大小写
+func CheckBearer(r *http.Request, token string) bool {
+	const scheme = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if len(auth) < len(scheme) || !strings.EqualFold(auth[:len(scheme)], scheme) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(auth[len(scheme):]), []byte(token)) == 1
+}
+
+// ReloadHandler 返回触发重新加载全部配置的http.Handler，只接受POST请求
+// token不为空时，请求需要携带 Authorization: Bearer <token> 头。
+// 携带参数 dry_run=true 时只校验新配置并返回将产生的变更，参数 path 指定只处理该路径的配置
+func (loader *FullConfigLoader) ReloadHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if token != "" && !CheckBearer(r, token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var (
+			result *reloadResult
+			err    error
+		)
+		if r.URL.Query().Get("dry_run") == "true" {
+			result, err = loader.dryRunAll(r.URL.Query().Get("path"))
+		} else {
+			var n int
+			n, err = loader.ReloadAll()
+			result = &reloadResult{Reloaded: n}
+		}
+		status := http.StatusOK
+		if re, ok := err.(*ReloadError); ok {
+			result.Errors = make(map[string]string, len(re.Errors))
+			for path, e := range re.Errors {
+				result.Errors[path] = e.Error()
+			}
+			status = http.StatusInternalServerError
+		} else if err == ErrConfigNotExist {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(result)
+	})
+}
+
+// dryRunAll 试运行重新加载全部或指定路径的配置
+func (loader *FullConfigLoader) dryRunAll(path string) (*reloadResult, error) {
+	result := &reloadResult{DryRun: make([]*DryRunResult, 0)}
+	errs := make(map[string]error)
+	for _, c := range loader.loadedWithTenants() {
+		if path != "" && c.path != path {
+			continue
+		}
+		dr, err := c.DryRunReload()
+		if err != nil {
+			errs[c.name()] = err
+			continue
+		}
+		result.DryRun = append(result.DryRun, dr)
+	}
+	if len(errs) > 0 {
+		return result, &ReloadError{Errors: errs}
+	}
+	if path != "" && len(result.DryRun) == 0 {
+		return result, ErrConfigNotExist
+	}
+	return result, nil
+}
+
+// ReloadAll 重新加载默认加载器中全部已加载的配置
+func ReloadAll() (int, error) {
+	return DefaultConfigLoader.ReloadAll()
+}
+
+// ReloadOnSignal 收到指定信号时重新加载默认加载器中的全部配置，未指定信号时使用 SIGHUP
+func ReloadOnSignal(ctx context.Context, sigs ...os.Signal) {
+	DefaultConfigLoader.ReloadOnSignal(ctx, sigs...)
+}
+
+// ReloadHandler 返回触发重新加载默认加载器中全部配置的http.Handler，可挂载到 /admin/config/reload
+func ReloadHandler(token string) http.Handler {
+	return DefaultConfigLoader.ReloadHandler(token)
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sampleNode 示例配置中的一个配置项
+type sampleNode struct {
+	name     string
+	comment  string
+	value    interface{}
+	children []*sampleNode
+	// object 为true时表示对象，list同时为true时表示对象列表，children描述列表元素
+	object bool
+	list   bool
+}
+
+// child 获取或创建名为name的子对象
+func (n *sampleNode) child(name string) *sampleNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &sampleNode{name: name, object: true}
+	n.children = append(n.children, c)
+	return c
+}
+
+// Sample 根据结构体v生成带注释的示例配置，codec支持yaml、toml
+// 注释来自字段的 doc tag，并标注 required、default、enum、range 等约束
+func Sample(v interface{}, codec string) ([]byte, error) {
+	root := &sampleNode{object: true}
+	root.children = buildSample(reflect.TypeOf(v), codec).children
+	return renderSample(root, codec)
+}
+
+// RegisteredSample 根据 RegisterStruct 声明的全部结构生成整个配置文件的带注释示例配置
+func RegisteredSample(codec string) ([]byte, error) {
+	structLock.RLock()
+	keys := make([]string, 0, len(structMap))
+	types := make(map[string]reflect.Type, len(structMap))
+	for k, t := range structMap {
+		keys = append(keys, k)
+		types[k] = t
+	}
+	structLock.RUnlock()
+
+	sort.Strings(keys)
+	root := &sampleNode{object: true}
+	for _, key := range keys {
+		n := buildSample(types[key], codec)
+		if key == "" {
+			root.children = n.children
+			continue
+		}
+		parent := root
+		segs := strings.Split(key, ".")
+		for _, seg := range segs[:len(segs)-1] {
+			parent = parent.child(seg)
+		}
+		target := parent.child(segs[len(segs)-1])
+		*target = *n
+		target.name = segs[len(segs)-1]
+	}
+	return renderSample(root, codec)
+}
+
+func renderSample(root *sampleNode, codec string) ([]byte, error) {
+	var sb strings.Builder
+	switch codec {
+	case "yaml":
+		writeYAMLSample(&sb, root.children, 0)
+	case "toml":
+		writeTOMLSample(&sb, "", root.children)
+	default:
+		return nil, ErrConfigNotSupport
+	}
+	return []byte(strings.TrimLeft(sb.String(), "\n")), nil
+}
+
+// buildSample 根据类型t生成示例配置节点
+func buildSample(t reflect.Type, tag string) *sampleNode {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	n := &sampleNode{}
+	switch {
+	case t == durationType:
+		n.value = "0s"
+	case t == timeType:
+		n.value = ""
+	case t.Kind() == reflect.Struct:
+		n.object = true
+		sampleFields(n, t, tag)
+	case t.Kind() == reflect.Map:
+		n.value = map[string]interface{}{}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		et := t.Elem()
+		for et.Kind() == reflect.Ptr {
+			et = et.Elem()
+		}
+		if et.Kind() == reflect.Struct && et != timeType {
+			n.object, n.list = true, true
+			sampleFields(n, et, tag)
+		} else {
+			n.value = []interface{}{}
+		}
+	case t.Kind() == reflect.Interface:
+		n.value = nil
+	default:
+		n.value = reflect.Zero(t).Interface()
+	}
+	return n
+}
+
+// sampleFields 将结构体字段填充为n的子节点
+func sampleFields(n *sampleNode, t reflect.Type, tag string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		name, inline, skip := fieldName(f, tag)
+		if skip {
+			continue
+		}
+		if inline {
+			it := f.Type
+			for it.Kind() == reflect.Ptr {
+				it = it.Elem()
+			}
+			if it.Kind() == reflect.Struct {
+				sampleFields(n, it, tag)
+			}
+			continue
+		}
+
+		c := buildSample(f.Type, tag)
+		c.name = name
+		c.comment = sampleComment(f)
+		if def, ok := f.Tag.Lookup("default"); ok && !c.object {
+			c.value = sampleValue(f.Type, def)
+		}
+		n.children = append(n.children, c)
+	}
+}
+
+// sampleValue 将default tag的值转换为字段类型对应的示例值
+func sampleValue(t reflect.Type, def string) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != durationType {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if i, err := strconv.ParseInt(def, 10, 64); err == nil {
+				return i
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if u, err := strconv.ParseUint(def, 10, 64); err == nil {
+				return u
+			}
+		}
+	}
+	return schemaValue(t, def)
+}
+
+// sampleComment 根据字段的tag生成注释
+func sampleComment(f reflect.StructField) string {
+	var notes []string
+	for _, r := range strings.Split(f.Tag.Get("validate"), ",") {
+		if r == "required" {
+			notes = append(notes, "required")
+		}
+	}
+	if def, ok := f.Tag.Lookup("default"); ok {
+		notes = append(notes, "default: "+def)
+	}
+	if enum := f.Tag.Get("enum"); enum != "" {
+		notes = append(notes, "enum: "+enum)
+	}
+	if rng := f.Tag.Get("range"); rng != "" {
+		notes = append(notes, "range: "+rng)
+	}
+
+	comment := f.Tag.Get("doc")
+	if len(notes) > 0 {
+		if comment != "" {
+			comment += " "
+		}
+		comment += "(" + strings.Join(notes, ", ") + ")"
+	}
+	return comment
+}
+
+func writeComment(sb *strings.Builder, indent string, comment string) {
+	if comment != "" {
+		fmt.Fprintf(sb, "%s# %s\n", indent, comment)
+	}
+}
+
+func writeYAMLSample(sb *strings.Builder, nodes []*sampleNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, n := range nodes {
+		writeComment(sb, indent, n.comment)
+		switch {
+		case n.list:
+			fmt.Fprintf(sb, "%s%s:\n", indent, n.name)
+			var elem strings.Builder
+			writeYAMLSample(&elem, n.children, depth+2)
+			// 将列表元素的第一个配置项改写为 "- key: value" 的形式
+			inner := strings.Repeat("  ", depth+2)
+			marked := false
+			for _, line := range strings.SplitAfter(elem.String(), "\n") {
+				if !marked && strings.HasPrefix(line, inner) && !strings.HasPrefix(line[len(inner):], "#") {
+					line = strings.Repeat("  ", depth+1) + "- " + line[len(inner):]
+					marked = true
+				}
+				sb.WriteString(line)
+			}
+		case n.object && len(n.children) == 0:
+			fmt.Fprintf(sb, "%s%s: {}\n", indent, n.name)
+		case n.object:
+			fmt.Fprintf(sb, "%s%s:\n", indent, n.name)
+			writeYAMLSample(sb, n.children, depth+1)
+		default:
+			fmt.Fprintf(sb, "%s%s: %s\n", indent, n.name, yamlScalar(n.value))
+		}
+	}
+}
+
+func yamlScalar(v interface{}) string {
+	switch vv := v.(type) {
+	case nil:
+		return "~"
+	case []interface{}:
+		items := make([]string, 0, len(vv))
+		for _, item := range vv {
+			items = append(items, yamlScalar(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		return "{}"
+	}
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeTOMLSample(sb *strings.Builder, prefix string, nodes []*sampleNode) {
+	// TOML中表头之后的键值都属于该表，因此先输出标量，再输出子表
+	for _, n := range nodes {
+		if n.object {
+			continue
+		}
+		writeComment(sb, "", n.comment)
+		fmt.Fprintf(sb, "%s = %s\n", n.name, tomlScalar(n.value))
+	}
+	for _, n := range nodes {
+		if !n.object {
+			continue
+		}
+		name := joinKey(prefix, n.name)
+		sb.WriteString("\n")
+		writeComment(sb, "", n.comment)
+		if n.list {
+			fmt.Fprintf(sb, "[[%s]]\n", name)
+		} else {
+			fmt.Fprintf(sb, "[%s]\n", name)
+		}
+		writeTOMLSample(sb, name, n.children)
+	}
+}
+
+func tomlScalar(v interface{}) string {
+	switch vv := v.(type) {
+	case nil:
+		return `""`
+	case string:
+		return strconv.Quote(vv)
+	case []interface{}:
+		items := make([]string, 0, len(vv))
+		for _, item := range vv {
+			items = append(items, tomlScalar(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		return "{}"
+	case float32, float64:
+		s := fmt.Sprint(vv)
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		return s
+	}
+	return fmt.Sprint(v)
+}
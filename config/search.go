@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// searchExts 查找配置文件时依次尝试的扩展名及其编解码器
+var searchExts = []struct {
+	ext   string
+	codec string
+}{
+	{".yaml", "yaml"},
+	{".yml", "yaml"},
+	{".json", "json"},
+	{".toml", "toml"},
+}
+
+// SearchPaths 返回app在当前平台上的默认配置目录，按照查找顺序排列：
+//
+//	linux等:  ./conf、$XDG_CONFIG_HOME/app（未设置时为 ~/.config/app）、/etc/app
+//	darwin:   ./conf、$XDG_CONFIG_HOME/app（设置时）、~/Library/Application Support/app、/etc/app
+//	windows:  .\conf、%APPDATA%\app、%ProgramData%\app
+func SearchPaths(app string) []string {
+	home, _ := os.UserHomeDir()
+	return searchPaths(runtime.GOOS, os.Getenv, home, app)
+}
+
+func searchPaths(goos string, getenv func(string) string, home, app string) []string {
+	dirs := []string{"conf"}
+	add := func(base string) {
+		if base != "" {
+			dirs = append(dirs, filepath.Join(base, app))
+		}
+	}
+	switch goos {
+	case "windows":
+		add(getenv("APPDATA"))
+		add(getenv("ProgramData"))
+		return dirs
+	case "darwin":
+		add(getenv("XDG_CONFIG_HOME"))
+		if home != "" {
+			add(filepath.Join(home, "Library", "Application Support"))
+		}
+	default:
+		if xdg := getenv("XDG_CONFIG_HOME"); xdg != "" {
+			add(xdg)
+		} else if home != "" {
+			add(filepath.Join(home, ".config"))
+		}
+	}
+	return append(dirs, filepath.Join("/etc", app))
+}
+
+// Resolve 在dirs中依次查找名字为name的配置文件，返回找到的路径和按照扩展名对应的编解码器名字
+// name没有扩展名时依次尝试 .yaml、.yml、.json、.toml；name为绝对路径时不查找，只检查文件是否存在
+func Resolve(name string, dirs ...string) (path, codec string, err error) {
+	candidates := []string{name}
+	if filepath.Ext(name) == "" {
+		candidates = candidates[:0]
+		for _, e := range searchExts {
+			candidates = append(candidates, name+e.ext)
+		}
+	}
+	if filepath.IsAbs(name) {
+		dirs = []string{""}
+	}
+	for _, dir := range dirs {
+		for _, c := range candidates {
+			p := filepath.Join(dir, c)
+			if info, err := os.Stat(p); err == nil && !info.IsDir() {
+				return p, codecByExt(p), nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("%w: %s not found in %s", ErrConfigNotExist, name, strings.Join(dirs, ", "))
+}
+
+// codecByExt 按照扩展名选择编解码器，无法识别时使用yaml
+func codecByExt(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range searchExts {
+		if e.ext == ext {
+			return e.codec
+		}
+	}
+	return "yaml"
+}
+
+// WithSearchPaths 从本地文件加载时在dirs中查找配置文件，dirs为空时使用 SearchPaths(app)，
+// 例如 Load("config", WithSearchPaths("app")) 依次查找 ./conf/config.yaml、./conf/config.yml ... /etc/app/config.toml，
+// 编解码器按照找到的文件扩展名选择，WithCodec 不再生效。使用其他内容源时忽略
+func WithSearchPaths(app string, dirs ...string) LoadOption {
+	return func(c *FrameworkConfig) {
+		if len(dirs) == 0 {
+			dirs = SearchPaths(app)
+		}
+		c.searchDirs = dirs
+	}
+}
+
+// resolvePath 按照 WithSearchPaths 查找配置文件，更新路径和编解码器
+func (c *FrameworkConfig) resolvePath() error {
+	if len(c.searchDirs) == 0 || c.p == nil || c.p.Name() != "file" {
+		return nil
+	}
+	path, codec, err := Resolve(c.path, c.searchDirs...)
+	if err != nil {
+		return err
+	}
+	c.path = path
+	c.decoder = GetCodec(codec)
+	return nil
+}
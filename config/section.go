@@ -0,0 +1,38 @@
+package config
+
+import "strings"
+
+// WatchSection 配置重新加载后key对应的配置段发生变化时调用reload，key为空时任何变化都调用；
+// reload 返回错误时记录日志，调用方应当在reload成功之前继续使用原来的值：
+//
+//	config.WatchSection(c, SectionKey, func() error {
+//		opts, err := parse(c)
+//		if err != nil {
+//			return err
+//		}
+//		s.opts.Store(opts)
+//		return nil
+//	})
+func WatchSection(c Config, key string, reload func() error) {
+	c.OnChange(func(changes []Change) {
+		if !touches(changes, key) {
+			return
+		}
+		if err := reload(); err != nil {
+			logger.Errorf("app/config: failed to apply %s, keep using the old values: %v", key, err)
+		}
+	})
+}
+
+// touches changes中是否有key、key的下级配置项或上级配置段
+func touches(changes []Change, key string) bool {
+	if key == "" {
+		return len(changes) > 0
+	}
+	for _, ch := range changes {
+		if ch.Key == key || strings.HasPrefix(ch.Key, key+".") || strings.HasPrefix(key, ch.Key+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package config
+
+import "sync"
+
+// SinkRegistry 审计记录等输出目标的注册表，零值可用；
+// 各组件以各自的记录类型写入，对外提供类型化的 Register 函数，例如：
+//
+//	var sinks config.SinkRegistry
+//
+//	func RegisterAccessSink(s AccessSink) {
+//		sinks.Register(func(r interface{}) error { return s.Write(r.(*AccessRecord)) })
+//	}
+type SinkRegistry struct {
+	lock  sync.RWMutex
+	sinks []func(record interface{}) error
+}
+
+// Register 注册输出目标
+func (r *SinkRegistry) Register(write func(record interface{}) error) {
+	r.lock.Lock()
+	r.sinks = append(r.sinks, write)
+	r.lock.Unlock()
+}
+
+// Empty 是否没有注册输出目标，用于跳过生成记录
+func (r *SinkRegistry) Empty() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return len(r.sinks) == 0
+}
+
+// Write 将record写入全部输出目标，返回写入失败的错误，由调用方记录日志
+func (r *SinkRegistry) Write(record interface{}) []error {
+	r.lock.RLock()
+	sinks := append([]func(interface{}) error(nil), r.sinks...)
+	r.lock.RUnlock()
+	var errs []error
+	for _, write := range sinks {
+		if err := write(record); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
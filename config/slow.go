@@ -0,0 +1,16 @@
+package config
+
+import "time"
+
+// DefaultSlowReadThreshold 未使用 WithSlowReadThreshold 时provider读取耗时的告警阈值
+var DefaultSlowReadThreshold = time.Second
+
+// checkSlowRead 读取耗时超过阈值时输出告警日志并记录指标
+func (c *FrameworkConfig) checkSlowRead(elapsed time.Duration) {
+	if c.slowReadThreshold <= 0 || elapsed < c.slowReadThreshold {
+		return
+	}
+	logger.Warnf("app/config: slow provider read provider=%s path=%s elapsed=%s threshold=%s",
+		c.p.Name(), c.path, elapsed, c.slowReadThreshold)
+	metrics.add(metricSlowReads, 1, "provider", c.p.Name(), "path", c.path)
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// snapshot 某一时刻生效的配置，发布后不再修改
+// 读取时只需一次原子操作获取当前snapshot，重新加载时构造新的snapshot整体替换
+type snapshot struct {
+	raw []byte
+	// size 原始配置的字节数，使用 WithDiscardRaw 时raw被丢弃后仍然保留
+	size int
+	tree map[string]interface{}
+	// origins 由配置文件之外的配置层提供的配置项及其来源
+	origins     map[string]Origin
+	fingerprint string
+	version     string
+	loadedAt    time.Time
+	// index 以 . 分隔的完整key到配置值的索引，包括中间层级的对象
+	index map[string]interface{}
+	// keys、values 使用 WithCompactIndex 时代替index，按key排序
+	keys   []string
+	values []interface{}
+	// indexed index是否包含全部可访问的key，为false时index中找不到的key需要遍历配置树
+	indexed bool
+	// patched 由 DeltaProvider 推送的变更生成，raw为配置树重新编码的结果
+	patched bool
+	// dirty 包含 Set 修改的、尚未写回内容源的配置项
+	dirty bool
+
+	// casts typed getter的类型转换结果
+	castLock sync.RWMutex
+	casts    map[castKey]castResult
+}
+
+// emptySnapshot 尚未加载时使用的空配置
+var emptySnapshot = &snapshot{tree: map[string]interface{}{}, origins: map[string]Origin{}}
+
+func newSnapshot(data []byte, version string, tree map[string]interface{}, origins map[string]Origin) *snapshot {
+	s := &snapshot{
+		raw:         data,
+		size:        len(data),
+		tree:        tree,
+		origins:     origins,
+		fingerprint: contentHash(data),
+		version:     version,
+		loadedAt:    Now(),
+		index:       make(map[string]interface{}),
+		indexed:     true,
+	}
+	s.buildIndex("", tree)
+	return s
+}
+
+// buildIndex 将m中的配置项加入索引，延迟解码的配置段不展开
+func (s *snapshot) buildIndex(prefix string, m map[string]interface{}) {
+	for k, v := range m {
+		if k == "" || strings.Contains(k, ".") {
+			// 无法通过 . 分隔的key访问，交给遍历配置树处理
+			s.indexed = false
+			continue
+		}
+		key := joinKey(prefix, k)
+		s.index[key] = v
+		if _, ok := v.(*lazySection); ok {
+			s.indexed = false
+			continue
+		}
+		if sub, ok := toStringMap(v); ok {
+			s.buildIndex(key, sub)
+		}
+	}
+}
+
+// compactIndex 将索引转换为按key排序的切片，查找时二分查找
+func (s *snapshot) compactIndex() {
+	keys := make([]string, 0, len(s.index))
+	for k := range s.index {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]interface{}, len(keys))
+	for i, k := range keys {
+		values[i] = s.index[k]
+	}
+	s.keys, s.values, s.index = keys, values, nil
+}
+
+// indexLookup 在索引中查找key
+func (s *snapshot) indexLookup(key string) (interface{}, bool) {
+	if s.index != nil {
+		v, ok := s.index[key]
+		return v, ok
+	}
+	i := sort.SearchStrings(s.keys, key)
+	if i < len(s.keys) && s.keys[i] == key {
+		return s.values[i], true
+	}
+	return nil, false
+}
+
+// snap 获取当前生效的配置
+func (c *FrameworkConfig) snap() *snapshot {
+	if s, ok := c.current.Load().(*snapshot); ok {
+		return s
+	}
+	return emptySnapshot
+}
+
+// publish 发布新的配置，返回被替换的配置
+func (c *FrameworkConfig) publish(s *snapshot) *snapshot {
+	if c.discardRaw {
+		s.raw = nil
+	}
+	if c.compactIndex && s.index != nil {
+		s.compactIndex()
+	}
+	prev := c.snap()
+	c.current.Store(s)
+	return prev
+}
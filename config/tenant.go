@@ -0,0 +1,95 @@
+package config
+
+import (
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+)
+
+// TenantPath 返回租户配置文件路径，例如 conf/app.yaml 中租户 acme 的配置文件为 conf/tenants/acme/app.yaml
+func TenantPath(path, tenant string) string {
+	return filepath.Join(filepath.Dir(path), "tenants", tenant, filepath.Base(path))
+}
+
+// ForTenant 返回租户的加载器，通过它加载的配置在公共配置文件上合并租户配置文件，
+// 优先级高于profile配置文件、低于环境变量。租户加载器有独立的缓存，
+// 公共配置文件或租户配置文件变化时只影响该租户的配置：
+//
+//	c, err := config.DefaultConfigLoader.ForTenant("acme").Load("conf/app.yaml", config.WithAutoReload())
+//	// 合并 conf/app.yaml 与 conf/tenants/acme/app.yaml
+func (loader *FullConfigLoader) ForTenant(tenant string) *FullConfigLoader {
+	loader.tenantLock.Lock()
+	defer loader.tenantLock.Unlock()
+	if t, ok := loader.tenants[tenant]; ok {
+		return t
+	}
+	if loader.tenants == nil {
+		loader.tenants = make(map[string]*FullConfigLoader)
+	}
+	t := newFullConfigLoad()
+	t.tenant, t.watches = tenant, loader.watches
+	loader.tenants[tenant] = t
+	return t
+}
+
+// Tenants 已创建加载器的租户，按名字排序
+func (loader *FullConfigLoader) Tenants() []string {
+	loader.tenantLock.Lock()
+	defer loader.tenantLock.Unlock()
+	tenants := make([]string, 0, len(loader.tenants))
+	for t := range loader.tenants {
+		tenants = append(tenants, t)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// RemoveTenant 移除租户的加载器并清除其缓存，注销其配置对内容源变化的监听，
+// 之后调用 ForTenant 会创建新的加载器
+func (loader *FullConfigLoader) RemoveTenant(tenant string) {
+	loader.tenantLock.Lock()
+	t, ok := loader.tenants[tenant]
+	delete(loader.tenants, tenant)
+	loader.tenantLock.Unlock()
+	if !ok {
+		return
+	}
+	atomic.StoreInt32(&t.removed, 1)
+	t.watches.drop(t)
+	for _, s := range t.shards {
+		s.rwl.Lock()
+		s.configMap = map[string]Config{}
+		s.loadedMap = map[string]*FrameworkConfig{}
+		s.rwl.Unlock()
+	}
+	logger.Infof("app/config: tenant %s removed", tenant)
+}
+
+// tenantLoaders 返回全部租户加载器
+func (loader *FullConfigLoader) tenantLoaders() []*FullConfigLoader {
+	loader.tenantLock.Lock()
+	defer loader.tenantLock.Unlock()
+	tenants := make([]*FullConfigLoader, 0, len(loader.tenants))
+	for _, t := range loader.tenants {
+		tenants = append(tenants, t)
+	}
+	return tenants
+}
+
+// name 配置在批量操作结果中的名字，租户的配置为 租户:路径
+func (c *FrameworkConfig) name() string {
+	if c.tenant == "" {
+		return c.path
+	}
+	return c.tenant + ":" + c.path
+}
+
+// isRemoved 租户加载器是否已被移除
+func (loader *FullConfigLoader) isRemoved() bool {
+	return atomic.LoadInt32(&loader.removed) == 1
+}
+
+// ForTenant 返回默认加载器中租户的加载器
+func ForTenant(tenant string) *FullConfigLoader {
+	return DefaultConfigLoader.ForTenant(tenant)
+}
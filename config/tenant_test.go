@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTenantLoaders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeFile(t, path, "server:\n  port: 80\n  name: base\n")
+	writeFile(t, TenantPath(path, "acme"), "server:\n  name: acme\n")
+
+	loader := newFullConfigLoad()
+	if _, err := loader.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loader.ForTenant("acme").Load(path, WithAutoReload())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetString("server.name", ""); got != "acme" {
+		t.Fatalf("expect the tenant overlay, got %q", got)
+	}
+	if got := c.GetInt("server.port", 0); got != 80 {
+		t.Fatalf("expect the base value, got %d", got)
+	}
+
+	if n, err := loader.ReloadAll(); err != nil || n != 2 {
+		t.Fatalf("expect 2 configs reloaded including the tenant, got %d, %v", n, err)
+	}
+
+	w := httptest.NewRecorder()
+	loader.DebugHandler().ServeHTTP(w, httptest.NewRequest("GET", "/debug/config?tenant=acme", nil))
+	var out []*loadedConfig
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || out[0].Tenant != "acme" {
+		t.Fatalf("expect the tenant config in the debug output, got %s", w.Body.String())
+	}
+
+	tenantInfo := false
+	for _, s := range loader.Metrics() {
+		if s.Name == metricInfo && s.Labels["tenant"] == "acme" {
+			tenantInfo = true
+		}
+	}
+	if !tenantInfo {
+		t.Fatal("expect an info metric for the tenant config")
+	}
+
+	// 清除缓存后重新加载，替换而不是重复登记监听
+	watches := len(loader.watches.entries)
+	loader.evict("yaml.file." + path)
+	if _, err := loader.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(loader.watches.entries); got != watches {
+		t.Fatalf("expect %d watches after loading again, got %d", watches, got)
+	}
+
+	loader.RemoveTenant("acme")
+	// 租户的配置文件和公共配置文件两个监听都被注销
+	if got := len(loader.watches.entries); got != watches-2 {
+		t.Fatalf("expect the tenant watches to be dropped, %d -> %d", watches, got)
+	}
+	if n, _ := loader.ReloadAll(); n != 1 {
+		t.Fatalf("expect the removed tenant to be skipped, got %d", n)
+	}
+}
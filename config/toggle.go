@@ -0,0 +1,109 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/spf13/cast"
+)
+
+// OnChange 注册配置变化回调，每次重新加载成功且配置有变化时按注册顺序调用
+func (c *FrameworkConfig) OnChange(fn func([]Change)) {
+	c.hookLock.Lock()
+	c.changeHooks = append(c.changeHooks, fn)
+	c.hookLock.Unlock()
+}
+
+func (c *FrameworkConfig) notifyChange(changes []Change) {
+	c.hookLock.Lock()
+	hooks := append(([]func([]Change))(nil), c.changeHooks...)
+	c.hookLock.Unlock()
+	for _, fn := range hooks {
+		fn(changes)
+	}
+}
+
+// Toggle 绑定到配置项的运行时开关，配置重新加载后自动更新
+type Toggle struct {
+	key       string
+	def       interface{}
+	lock      sync.RWMutex
+	value     interface{}
+	callbacks []func(old, new interface{})
+}
+
+// NewToggle 将key绑定为运行时开关，配置项不存在时使用def
+// 配置需要通过 Reload、ReloadOnSignal 或 WithAutoReload 重新加载
+func NewToggle(c Config, key string, def interface{}) *Toggle {
+	t := &Toggle{key: key, def: def, value: c.Get(key, def)}
+	c.OnChange(func([]Change) {
+		t.set(c.Get(key, def))
+	})
+	return t
+}
+
+// Key 开关绑定的配置项
+func (t *Toggle) Key() string {
+	return t.key
+}
+
+// Value 获取开关当前的值
+func (t *Toggle) Value() interface{} {
+	t.lock.RLock()
+	v := t.value
+	t.lock.RUnlock()
+	return v
+}
+
+// Bool 获取bool类型的开关值
+func (t *Toggle) Bool() bool {
+	return cast.ToBool(t.Value())
+}
+
+// String 获取string类型的开关值
+func (t *Toggle) String() string {
+	return cast.ToString(t.Value())
+}
+
+// OnChange 注册开关值变化的回调
+func (t *Toggle) OnChange(fn func(old, new interface{})) {
+	t.lock.Lock()
+	t.callbacks = append(t.callbacks, fn)
+	t.lock.Unlock()
+}
+
+func (t *Toggle) set(v interface{}) {
+	t.lock.Lock()
+	old := t.value
+	if reflect.DeepEqual(old, v) {
+		t.lock.Unlock()
+		return
+	}
+	t.value = v
+	callbacks := append(([]func(old, new interface{}))(nil), t.callbacks...)
+	t.lock.Unlock()
+
+	logger.Infof("app/config: toggle %s changed from %v to %v", t.key, Redact(t.key, old), Redact(t.key, v))
+	for _, fn := range callbacks {
+		fn(old, v)
+	}
+}
+
+// Toggles 常用的运行时开关
+type Toggles struct {
+	// LogLevel 日志级别，对应配置项 log.level，默认为 info
+	LogLevel *Toggle
+	// Debug 调试模式，对应配置项 debug.enabled
+	Debug *Toggle
+	// Pprof 是否开启pprof，对应配置项 pprof.enabled
+	Pprof *Toggle
+}
+
+// NewToggles 绑定 log.level、debug.enabled、pprof.enabled 三个常用的运行时开关
+func NewToggles(c Config) *Toggles {
+	return &Toggles{
+		LogLevel: NewToggle(c, "log.level", "info"),
+		Debug:    NewToggle(c, "debug.enabled", false),
+		Pprof:    NewToggle(c, "pprof.enabled", false),
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"context"
+)
+
+// Tracer 链路追踪接口，可基于OpenTelemetry等实现，通过 WithTracer 启用
+type Tracer interface {
+	// Start 开始名为name的span，返回携带该span的context
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span 链路追踪中的一个span
+type Span interface {
+	// SetAttribute 设置span属性
+	SetAttribute(key string, value interface{})
+	// RecordError 记录错误，并将span标记为失败
+	RecordError(err error)
+	// End 结束span
+	End()
+}
+
+// noopTracer 未启用链路追踪时的空实现
+type noopTracer struct{}
+
+// Start 开始span
+func (noopTracer) Start(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+// SetAttribute 设置span属性
+func (noopSpan) SetAttribute(string, interface{}) {}
+
+// RecordError 记录错误
+func (noopSpan) RecordError(error) {}
+
+// End 结束span
+func (noopSpan) End() {}
+
+// startSpan 开始span并设置配置的通用属性
+func (c *FrameworkConfig) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	ctx, span := c.tracer.Start(ctx, name)
+	span.SetAttribute("config.path", c.path)
+	span.SetAttribute("config.provider", c.p.Name())
+	span.SetAttribute("config.codec", c.decoder.Name())
+	return ctx, span
+}
+
+// endSpan 记录结果并结束span
+func endSpan(span Span, err error) {
+	span.SetAttribute("config.outcome", resultLabel(err))
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// UnknownKeyMode 配置中出现未声明配置项时的处理方式
+type UnknownKeyMode uint8
+
+const (
+	// UnknownKeyIgnore 不检查
+	UnknownKeyIgnore UnknownKeyMode = 0
+	// UnknownKeyWarn 输出告警日志
+	UnknownKeyWarn UnknownKeyMode = 1
+	// UnknownKeyError 加载失败
+	UnknownKeyError UnknownKeyMode = 2
+)
+
+var (
+	structMap  = make(map[string]reflect.Type)
+	structLock = sync.RWMutex{}
+)
+
+// RegisterStruct 声明key对应配置子树的结构，v为结构体或结构体指针
+// 使用 WithUnknownKeys 加载时，会检查配置中是否存在结构体未声明的配置项，
+// key为空字符串时声明整个配置文件的结构。
+// 结构体中带有 sensitive:"true" tag的字段会被标记为敏感配置项
+func RegisterStruct(key string, v interface{}) {
+	t := reflect.TypeOf(v)
+	structLock.Lock()
+	structMap[key] = t
+	structLock.Unlock()
+
+	lock.RLock()
+	tags := make([]string, 0, len(codecMap))
+	for name := range codecMap {
+		tags = append(tags, name)
+	}
+	lock.RUnlock()
+	for _, tag := range tags {
+		markSensitiveFields(key, t, tag)
+	}
+}
+
+// declaredStructs 获取全局声明的结构以及当前配置绑定的结构
+func (c *FrameworkConfig) declaredStructs() map[string]reflect.Type {
+	declared := make(map[string]reflect.Type)
+	structLock.RLock()
+	for k, t := range structMap {
+		declared[k] = t
+	}
+	structLock.RUnlock()
+
+	c.bindLock.Lock()
+	for _, b := range c.bindings {
+		declared[b.key] = b.typ
+	}
+	c.bindLock.Unlock()
+	return declared
+}
+
+// checkUnknown 检查配置中未声明的配置项
+func (c *FrameworkConfig) checkUnknown(data map[string]interface{}) error {
+	if c.unknownKeyMode == UnknownKeyIgnore {
+		return nil
+	}
+
+	declared := c.declaredStructs()
+	var unknown []string
+	for key, t := range declared {
+		var sub interface{} = data
+		if key != "" {
+			v, err := c.search(data, c.parseKey(key))
+			if err != nil {
+				continue
+			}
+			sub = v
+		}
+		unknown = append(unknown, unknownKeys(key, sub, t, c.decoder.Name(), declared)...)
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	if c.unknownKeyMode == UnknownKeyWarn {
+		for _, key := range unknown {
+			logger.Warnf("app/config: unknown key path=%s key=%s", c.path, key)
+		}
+		return nil
+	}
+
+	errs := make(ValidationErrors, 0, len(unknown))
+	for _, key := range unknown {
+		errs = append(errs, &FieldError{Key: key, Rule: "unknown", Err: fmt.Errorf("unknown key")})
+	}
+	return errs
+}
+
+// unknownKeys 找出配置树in中类型t未声明的配置项
+func unknownKeys(path string, in interface{}, t reflect.Type, tag string, declared map[string]reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var unknown []string
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == timeType {
+			return nil
+		}
+		m, ok := toStringMap(in)
+		if !ok {
+			return nil
+		}
+		for k, v := range m {
+			key := joinKey(path, k)
+			if _, ok := declared[key]; ok {
+				continue
+			}
+			ft, ok := structField(t, k, tag)
+			if !ok {
+				unknown = append(unknown, key)
+				continue
+			}
+			unknown = append(unknown, unknownKeys(key, v, ft, tag, declared)...)
+		}
+	case reflect.Map:
+		m, ok := toStringMap(in)
+		if !ok {
+			return nil
+		}
+		for k, v := range m {
+			unknown = append(unknown, unknownKeys(joinKey(path, k), v, t.Elem(), tag, declared)...)
+		}
+	case reflect.Slice, reflect.Array:
+		iv := reflect.ValueOf(in)
+		if iv.Kind() != reflect.Slice && iv.Kind() != reflect.Array {
+			return nil
+		}
+		for i := 0; i < iv.Len(); i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			unknown = append(unknown, unknownKeys(p, iv.Index(i).Interface(), t.Elem(), tag, declared)...)
+		}
+	}
+	return unknown
+}
+
+// structField 按照配置中的名字查找结构体字段类型，包括内嵌的结构体
+func structField(t reflect.Type, name string, tag string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		fname, inline, skip := fieldName(f, tag)
+		if skip {
+			continue
+		}
+		if inline {
+			it := f.Type
+			for it.Kind() == reflect.Ptr {
+				it = it.Elem()
+			}
+			if it.Kind() == reflect.Map {
+				return it.Elem(), true
+			}
+			if it.Kind() == reflect.Struct {
+				if ft, ok := structField(it, name, tag); ok {
+					return ft, true
+				}
+			}
+			continue
+		}
+		if strings.EqualFold(fname, name) {
+			return f.Type, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,336 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ValidationRule 校验规则，param为规则参数，如 min=1 中的 "1"
+type ValidationRule func(v reflect.Value, param string) error
+
+// KeyValidator 针对指定key的配置子树的校验函数
+type KeyValidator func(v interface{}) error
+
+var (
+	ruleMap       = make(map[string]ValidationRule)
+	keyValidators = make(map[string][]KeyValidator)
+	validateLock  = sync.RWMutex{}
+)
+
+func init() {
+	RegisterValidationRule("required", ruleRequired)
+	RegisterValidationRule("min", ruleMin)
+	RegisterValidationRule("max", ruleMax)
+	RegisterValidationRule("len", ruleLen)
+	RegisterValidationRule("oneof", ruleOneOf)
+}
+
+// RegisterValidationRule 注册validate tag中可用的校验规则
+func RegisterValidationRule(name string, rule ValidationRule) {
+	validateLock.Lock()
+	ruleMap[name] = rule
+	validateLock.Unlock()
+}
+
+func getValidationRule(name string) ValidationRule {
+	validateLock.RLock()
+	r := ruleMap[name]
+	validateLock.RUnlock()
+	return r
+}
+
+// RegisterValidator 注册key对应配置子树的校验函数，配置加载和重新加载时执行
+// 配置中不存在该key时不会执行，必需的配置项请使用 WithRequiredKeys
+func RegisterValidator(key string, fn KeyValidator) {
+	validateLock.Lock()
+	keyValidators[key] = append(keyValidators[key], fn)
+	validateLock.Unlock()
+}
+
+// runValidators 执行全部已注册key的校验函数
+func (c *FrameworkConfig) runValidators(data map[string]interface{}) error {
+	validateLock.RLock()
+	defer validateLock.RUnlock()
+
+	var errs ValidationErrors
+	for key, fns := range keyValidators {
+		v, err := c.search(data, c.parseKey(key))
+		if err != nil {
+			continue
+		}
+		for _, fn := range fns {
+			if err := fn(v); err != nil {
+				errs = append(errs, &FieldError{Key: key, Rule: "validator", Err: err})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Key < errs[j].Key })
+		return errs
+	}
+	return nil
+}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Key   string
+	Rule  string
+	Param string
+	Value interface{}
+	Err   error
+	// Line、Column 配置项在原始配置中的位置，未知时为0
+	Line   int
+	Column int
+}
+
+// Error 实现error接口
+func (fe *FieldError) Error() string {
+	key := fe.Key
+	if fe.Line > 0 {
+		key = fmt.Sprintf("%s (line %d, column %d)", fe.Key, fe.Line, fe.Column)
+	}
+	if fe.Value == nil {
+		return fmt.Sprintf("%s: %s", key, fe.Err.Error())
+	}
+	return fmt.Sprintf("%s: %s (value: %v)", key, fe.Err.Error(), redactTree(fe.Key, fe.Value))
+}
+
+// ValidationErrors 聚合的校验错误
+type ValidationErrors []*FieldError
+
+// Error 实现error接口
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(ve))
+	for _, fe := range ve {
+		msgs = append(msgs, fe.Error())
+	}
+	return fmt.Sprintf("app/config: validation failed: %s", strings.Join(msgs, "; "))
+}
+
+// Validate 按照结构体字段上的validate tag校验v，错误中的字段名使用yaml tag
+// 没有对应的配置树，v中的全部字段都视为已设置，零值同样需要满足规则
+func Validate(v interface{}) error {
+	return validateStruct("", allPresent{}, v, "yaml")
+}
+
+// allPresent 作为配置树时全部字段都视为已设置
+type allPresent struct{}
+
+// validateStruct 按照validate tag校验从配置子树in解码得到的v，
+// in中不存在的字段为零值时只检查required，显式设置的零值与其他值一样检查全部规则
+func validateStruct(path string, in interface{}, v interface{}, tag string) error {
+	var errs ValidationErrors
+	validateValue(path, in, reflect.ValueOf(v), tag, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// subtree 返回配置子树in中name对应的子树，以及该字段是否在配置中设置
+func subtree(in interface{}, name string) (interface{}, bool) {
+	if _, ok := in.(allPresent); ok {
+		return in, true
+	}
+	m, _ := toStringMap(in)
+	return lookupField(m, name)
+}
+
+// element 返回配置子树in中第i个元素对应的子树
+func element(in interface{}, i int) interface{} {
+	if _, ok := in.(allPresent); ok {
+		return in
+	}
+	iv := reflect.ValueOf(in)
+	if (iv.Kind() == reflect.Slice || iv.Kind() == reflect.Array) && i < iv.Len() {
+		return iv.Index(i).Interface()
+	}
+	return nil
+}
+
+func validateValue(path string, in interface{}, v reflect.Value, tag string, errs *ValidationErrors) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" && !f.Anonymous {
+				continue
+			}
+			name, inline, skip := fieldName(f, tag)
+			if skip {
+				continue
+			}
+			key := joinKey(path, name)
+			if inline {
+				key = path
+			}
+			sub, set := in, true
+			if !inline {
+				sub, set = subtree(in, name)
+			}
+			fv := v.Field(i)
+			// 配置中未设置且没有默认值的字段仅由required负责检查
+			unset := !set && fv.IsZero()
+			if rules := f.Tag.Get("validate"); rules != "" && rules != "-" {
+				checkRules(key, fv, unset, rules, errs)
+			}
+			if enum := f.Tag.Get("enum"); enum != "" && !unset {
+				checkEnum(key, fv, enum, errs)
+			}
+			if rng := f.Tag.Get("range"); rng != "" && !unset {
+				checkRange(key, fv, rng, errs)
+			}
+			validateValue(key, sub, fv, tag, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(fmt.Sprintf("%s[%d]", path, i), element(in, i), v.Index(i), tag, errs)
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			name := fmt.Sprint(k.Interface())
+			sub, _ := subtree(in, name)
+			validateValue(joinKey(path, name), sub, v.MapIndex(k), tag, errs)
+		}
+	}
+}
+
+func checkRules(key string, v reflect.Value, unset bool, rules string, errs *ValidationErrors) {
+	for _, r := range strings.Split(rules, ",") {
+		if r == "" {
+			continue
+		}
+		name, param := r, ""
+		if i := strings.Index(r, "="); i >= 0 {
+			name, param = r[:i], r[i+1:]
+		}
+		if unset && name != "required" {
+			continue
+		}
+		checkRule(key, v, name, name, param, errs)
+	}
+}
+
+// checkEnum 检查enum tag，如 enum:"debug,info,warn,error"
+func checkEnum(key string, v reflect.Value, enum string, errs *ValidationErrors) {
+	opts := strings.Split(enum, ",")
+	for i := range opts {
+		opts[i] = strings.TrimSpace(opts[i])
+	}
+	checkRule(key, v, "enum", "oneof", strings.Join(opts, " "), errs)
+}
+
+// checkRange 检查range tag，如 range:"1-65535"、range:"1s-1m"，省略一侧表示不限制
+func checkRange(key string, v reflect.Value, rng string, errs *ValidationErrors) {
+	// 从第二个字符开始查找分隔符，以支持负数下限
+	i := strings.Index(rng[1:], "-") + 1
+	if i == 0 {
+		*errs = append(*errs, &FieldError{Key: key, Rule: "range", Param: rng, Err: fmt.Errorf("invalid range %q", rng)})
+		return
+	}
+	if lo := strings.TrimSpace(rng[:i]); lo != "" {
+		checkRule(key, v, "range", "min", lo, errs)
+	}
+	if hi := strings.TrimSpace(rng[i+1:]); hi != "" {
+		checkRule(key, v, "range", "max", hi, errs)
+	}
+}
+
+// checkRule 执行名为rule的校验规则，校验错误中的规则名为name
+func checkRule(key string, v reflect.Value, name, rule, param string, errs *ValidationErrors) {
+	fe := &FieldError{Key: key, Rule: name, Param: param}
+	if v.CanInterface() {
+		fe.Value = v.Interface()
+	}
+	fn := getValidationRule(rule)
+	if fn == nil {
+		fe.Err = fmt.Errorf("unknown validation rule %q", rule)
+	} else {
+		fe.Err = fn(v, param)
+	}
+	if fe.Err != nil {
+		*errs = append(*errs, fe)
+	}
+}
+
+func ruleRequired(v reflect.Value, _ string) error {
+	if v.IsZero() {
+		return fmt.Errorf("is required")
+	}
+	return nil
+}
+
+func ruleMin(v reflect.Value, param string) error {
+	return compare(v, param, func(n, p float64) bool { return n >= p }, "must be at least "+param)
+}
+
+func ruleMax(v reflect.Value, param string) error {
+	return compare(v, param, func(n, p float64) bool { return n <= p }, "must be at most "+param)
+}
+
+func ruleLen(v reflect.Value, param string) error {
+	return compare(v, param, func(n, p float64) bool { return n == p }, "must have length "+param)
+}
+
+func ruleOneOf(v reflect.Value, param string) error {
+	s := fmt.Sprint(v.Interface())
+	for _, opt := range strings.Fields(param) {
+		if s == opt {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of [%s]", param)
+}
+
+// compare 数值类型比较值，字符串、切片、map比较长度，time.Duration支持 "5s" 形式的参数
+func compare(v reflect.Value, param string, ok func(n, p float64) bool, msg string) error {
+	var n float64
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		n = float64(v.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	default:
+		return fmt.Errorf("rule not applicable to %s", v.Kind())
+	}
+
+	var p float64
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(param)
+		if err != nil {
+			return fmt.Errorf("invalid rule param %q", param)
+		}
+		p = float64(d)
+	} else {
+		f, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rule param %q", param)
+		}
+		p = f
+	}
+
+	if !ok(n, p) {
+		return fmt.Errorf("%s", msg)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type validateServer struct {
+	Port    int           `yaml:"port" validate:"min=1,max=65535"`
+	Name    string        `yaml:"name" validate:"required"`
+	Tags    []string      `yaml:"tags" validate:"min=1"`
+	Timeout time.Duration `yaml:"timeout" validate:"min=1ms" default:"1s"`
+}
+
+func TestUnmarshalKeyValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		bad  []string
+	}{
+		{"valid", "server:\n  port: 8000\n  name: demo\n  tags: [a]\n", nil},
+		{"absent optional fields", "server:\n  name: demo\n", nil},
+		{"absent required field", "server:\n  port: 8000\n", []string{"server.name"}},
+		{"explicit zero", "server:\n  port: 0\n  name: demo\n", []string{"server.port"}},
+		{"explicit empty string", "server:\n  name: \"\"\n", []string{"server.name"}},
+		{"explicit empty list", "server:\n  name: demo\n  tags: []\n", []string{"server.tags"}},
+		{"explicit zero duration", "server:\n  name: demo\n  timeout: 0\n", []string{"server.timeout"}},
+		{"out of range", "server:\n  port: 70000\n  name: demo\n", []string{"server.port"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewFromString(tt.yaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var s validateServer
+			assertFieldErrors(t, c.UnmarshalKey("server", &s), tt.bad)
+		})
+	}
+}
+
+func TestUnmarshalValidate(t *testing.T) {
+	c, err := NewFromString("server:\n  port: 0\n  name: demo\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Server validateServer `yaml:"server"`
+	}
+	assertFieldErrors(t, c.Unmarshal(&out), []string{"server.port"})
+}
+
+func TestValidateWithoutTree(t *testing.T) {
+	// 没有配置树时零值也按规则检查
+	assertFieldErrors(t, Validate(&validateServer{Name: "demo", Tags: []string{"a"}, Timeout: time.Second}), []string{"port"})
+}
+
+// assertFieldErrors 检查err是否恰好包含keys对应的校验错误
+func assertFieldErrors(t *testing.T, err error, keys []string) {
+	t.Helper()
+	if len(keys) == 0 {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	var ve ValidationErrors
+	if !errors.As(err, &ve) {
+		t.Fatalf("expect ValidationErrors for %v, got %v", keys, err)
+	}
+	got := map[string]bool{}
+	for _, fe := range ve {
+		got[fe.Key] = true
+	}
+	for _, k := range keys {
+		if !got[k] {
+			t.Errorf("expect an error on %s, got %v", k, err)
+		}
+	}
+	if len(got) != len(keys) {
+		t.Errorf("expect errors on %v, got %v", keys, err)
+	}
+}
+
+type rangeEnumConfig struct {
+	Port  int           `yaml:"port" range:"1-65535"`
+	Ratio float64       `yaml:"ratio" range:"-1-1"`
+	Wait  time.Duration `yaml:"wait" range:"1s-"`
+	Mode  string        `yaml:"mode" enum:"single, cluster, sentinel"`
+}
+
+func TestRangeEnum(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		bad  []string
+	}{
+		{"absent", "redis:\n  ratio: 0.5\n", nil},
+		{"valid", "redis:\n  port: 6379\n  wait: 2s\n  mode: cluster\n", nil},
+		{"zero port", "redis:\n  port: 0\n", []string{"redis.port"}},
+		{"zero ratio in range", "redis:\n  ratio: 0\n", nil},
+		{"negative ratio", "redis:\n  ratio: -2\n", []string{"redis.ratio"}},
+		{"zero wait", "redis:\n  wait: 0s\n", []string{"redis.wait"}},
+		{"empty mode", "redis:\n  mode: \"\"\n", []string{"redis.mode"}},
+		{"unknown mode", "redis:\n  mode: shard\n", []string{"redis.mode"}},
+		{"port above range", "redis:\n  port: 65536\n", []string{"redis.port"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewFromString(tt.yaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var r rangeEnumConfig
+			assertFieldErrors(t, c.UnmarshalKey("redis", &r), tt.bad)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"reflect"
+	"time"
+)
+
+// Snapshot 某一版本配置的只读视图，读取的全部配置项都来自同一版本
+// 处理请求时需要读取多个相关配置项的场景，先获取Snapshot再读取，不会读到重新加载前后混合的配置
+type Snapshot struct {
+	c *FrameworkConfig
+	s *snapshot
+}
+
+// Snapshot 获取当前生效配置的只读视图，之后的重新加载不影响已获取的Snapshot
+func (c *FrameworkConfig) Snapshot() Snapshot {
+	return Snapshot{c: c, s: c.snap()}
+}
+
+// Version 内容源提供的版本号
+func (p Snapshot) Version() string {
+	return p.s.version
+}
+
+// Fingerprint 配置内容的sha256
+func (p Snapshot) Fingerprint() string {
+	return p.s.fingerprint
+}
+
+// LoadedAt 配置生效的时间
+func (p Snapshot) LoadedAt() time.Time {
+	return p.s.loadedAt
+}
+
+// Get 根据key读取配置
+func (p Snapshot) Get(key string, defaultValue interface{}) interface{} {
+	if v, err := p.c.lookup(p.s, key); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+// IsSet 根据key判断配置是否存在
+func (p Snapshot) IsSet(key string) bool {
+	_, err := p.c.lookup(p.s, key)
+	return err == nil
+}
+
+// GetInt 根据key读取int类型配置
+func (p Snapshot) GetInt(key string, defaultValue int) int {
+	c, s := p.c, p.s
+	v, err := c.lookup(s, key)
+	if err != nil {
+		return defaultValue
+	}
+	if result, ok := v.(int); ok {
+		return result
+	}
+	if result, ok := s.cast(key, reflect.Int, v, toInt); ok {
+		return result.(int)
+	}
+	return defaultValue
+}
+
+// GetInt32 根据key读取int32类型配置
+func (p Snapshot) GetInt32(key string, defaultValue int32) int32 {
+	c, s := p.c, p.s
+	v, err := c.lookup(s, key)
+	if err != nil {
+		return defaultValue
+	}
+	if result, ok := v.(int32); ok {
+		return result
+	}
+	if result, ok := s.cast(key, reflect.Int32, v, toInt32); ok {
+		return result.(int32)
+	}
+	return defaultValue
+}
+
+// GetInt64 根据key读取int64类型配置
+func (p Snapshot) GetInt64(key string, defaultValue int64) int64 {
+	c, s := p.c, p.s
+	v, err := c.lookup(s, key)
+	if err != nil {
+		return defaultValue
+	}
+	if result, ok := v.(int64); ok {
+		return result
+	}
+	if result, ok := s.cast(key, reflect.Int64, v, toInt64); ok {
+		return result.(int64)
+	}
+	return defaultValue
+}
+
+// GetUint 根据key读取int类型配置
+func (p Snapshot) GetUint(key string, defaultValue uint) uint {
+	c, s := p.c, p.s
+	v, err := c.lookup(s, key)
+	if err != nil {
+		return defaultValue
+	}
+	if result, ok := v.(uint); ok {
+		return result
+	}
+	if result, ok := s.cast(key, reflect.Uint, v, toUint); ok {
+		return result.(uint)
+	}
+	return defaultValue
+}
+
+// GetUint32 根据key读取uint32类型配置
+func (p Snapshot) GetUint32(key string, defaultValue uint32) uint32 {
+	c, s := p.c, p.s
+	v, err := c.lookup(s, key)
+	if err != nil {
+		return defaultValue
+	}
+	if result, ok := v.(uint32); ok {
+		return result
+	}
+	if result, ok := s.cast(key, reflect.Uint32, v, toUint32); ok {
+		return result.(uint32)
+	}
+	return defaultValue
+}
+
+// GetUint64 根据key读取uint64类型配置
+func (p Snapshot) GetUint64(key string, defaultValue uint64) uint64 {
+	c, s := p.c, p.s
+	v, err := c.lookup(s, key)
+	if err != nil {
+		return defaultValue
+	}
+	if result, ok := v.(uint64); ok {
+		return result
+	}
+	if result, ok := s.cast(key, reflect.Uint64, v, toUint64); ok {
+		return result.(uint64)
+	}
+	return defaultValue
+}
+
+// GetFloat64 根据key读取float64类型配置
+func (p Snapshot) GetFloat64(key string, defaultValue float64) float64 {
+	c, s := p.c, p.s
+	v, err := c.lookup(s, key)
+	if err != nil {
+		return defaultValue
+	}
+	if result, ok := v.(float64); ok {
+		return result
+	}
+	if result, ok := s.cast(key, reflect.Float64, v, toFloat64); ok {
+		return result.(float64)
+	}
+	return defaultValue
+}
+
+// GetFloat32 根据key读取float32类型配置
+func (p Snapshot) GetFloat32(key string, defaultValue float32) float32 {
+	c, s := p.c, p.s
+	v, err := c.lookup(s, key)
+	if err != nil {
+		return defaultValue
+	}
+	if result, ok := v.(float32); ok {
+		return result
+	}
+	if result, ok := s.cast(key, reflect.Float32, v, toFloat32); ok {
+		return result.(float32)
+	}
+	return defaultValue
+}
+
+// GetBool 根据key读取bool类型配置
+func (p Snapshot) GetBool(key string, defaultValue bool) bool {
+	c, s := p.c, p.s
+	v, err := c.lookup(s, key)
+	if err != nil {
+		return defaultValue
+	}
+	if result, ok := v.(bool); ok {
+		return result
+	}
+	if result, ok := s.cast(key, reflect.Bool, v, toBool); ok {
+		return result.(bool)
+	}
+	return defaultValue
+}
+
+// GetString 根据key读取string类型配置
+func (p Snapshot) GetString(key string, defaultValue string) string {
+	c, s := p.c, p.s
+	value, err := c.lookup(s, key)
+	if err != nil {
+		return defaultValue
+	}
+
+	if result, ok := value.(string); ok {
+		return result
+	}
+
+	if result, ok := s.cast(key, reflect.String, value, toString); ok {
+		return result.(string)
+	}
+
+	return defaultValue
+}
+
+// UnmarshalKey 将key对应的配置子树反序列化到out，并按照validate tag校验结果
+func (p Snapshot) UnmarshalKey(key string, out interface{}) error {
+	c, s := p.c, p.s
+	var sub interface{} = s.tree
+	if key != "" {
+		v, err := c.lookup(s, key)
+		if err == ErrConfigNotExist {
+			return &ErrKeyNotFound{Key: key}
+		}
+		if err != nil {
+			return err
+		}
+		sub = v
+	}
+
+	if err := decodeTree(key, sub, out, c.decoder.Name()); err != nil {
+		return err
+	}
+	return c.locateErrors(s.raw, validateStruct(key, sub, out, c.decoder.Name()))
+}
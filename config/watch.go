@@ -0,0 +1,94 @@
+package config
+
+import "sync"
+
+// watchHub 将内容源的变化分发给加载器中的配置，每个内容源只向provider注册一次回调。
+// 配置的回调登记在hub中，移除租户或者配置被重新加载时注销，不会在provider中留下闭包
+type watchHub struct {
+	lock    sync.RWMutex
+	watched map[DataProvider]bool
+	deltas  map[DataProvider]bool
+	entries []*watchEntry
+}
+
+// watchEntry 一个配置对内容源中path变化的回调，同一加载器中id相同的回调只保留最新的
+type watchEntry struct {
+	owner    *FullConfigLoader
+	id       string
+	p        DataProvider
+	path     string
+	onChange func()
+	onDelta  func(version string, deltas []Delta)
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{watched: make(map[DataProvider]bool), deltas: make(map[DataProvider]bool)}
+}
+
+// watch 登记回调，内容源第一次被监听时向provider注册分发函数
+func (h *watchHub) watch(e *watchEntry) {
+	h.lock.Lock()
+	replaced := false
+	for i, old := range h.entries {
+		if old.owner == e.owner && old.id == e.id {
+			h.entries[i], replaced = e, true
+			break
+		}
+	}
+	if !replaced {
+		h.entries = append(h.entries, e)
+	}
+	p, register := e.p, false
+	if e.onDelta != nil {
+		register, h.deltas[p] = !h.deltas[p], true
+	} else {
+		register, h.watched[p] = !h.watched[p], true
+	}
+	h.lock.Unlock()
+
+	// provider可能在注册时同步回调，不能持有锁
+	switch {
+	case !register:
+	case e.onDelta != nil:
+		p.(DeltaProvider).WatchDelta(func(path, version string, deltas []Delta) {
+			for _, e := range h.match(p, path, true) {
+				e.onDelta(version, deltas)
+			}
+		})
+	default:
+		p.Watch(func(path string, _ []byte) {
+			for _, e := range h.match(p, path, false) {
+				e.onChange()
+			}
+		})
+	}
+}
+
+// match 返回监听内容源p中path变化的回调
+func (h *watchHub) match(p DataProvider, path string, delta bool) []*watchEntry {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	var matched []*watchEntry
+	for _, e := range h.entries {
+		if e.p == p && e.path == path && (e.onDelta != nil) == delta {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// drop 注销加载器登记的全部回调
+func (h *watchHub) drop(owner *FullConfigLoader) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	entries := h.entries[:0]
+	for _, e := range h.entries {
+		if e.owner != owner {
+			entries = append(entries, e)
+		}
+	}
+	for i := len(entries); i < len(h.entries); i++ {
+		h.entries[i] = nil
+	}
+	h.entries = entries
+}
@@ -0,0 +1,67 @@
+package config
+
+import "fmt"
+
+// Set 在运行时修改配置项，修改同样经过校验和绑定解码，只在当前进程生效，
+// Save 或 Push 时写回内容源，写回之前重新加载会丢失修改。
+// 使用了其他配置层、schema或延迟解码的配置无法确定修改属于哪一层，返回 ErrConfigNotSupport
+func (c *FrameworkConfig) Set(key string, value interface{}) error {
+	if c.readOnly {
+		return fmt.Errorf("%w: %s", ErrProviderReadOnly, c.path)
+	}
+	if c.layered() || len(c.schemas) > 0 || c.lazy {
+		return ErrConfigNotSupport
+	}
+	return c.patch(c.snap().version, []Delta{{Key: key, Value: value}}, true)
+}
+
+// Save 将 Set 的修改写回内容源，没有修改时不写入
+// 内容源需要实现 Writer，写入时携带加载时的版本号，内容源中的配置已被修改时返回 ErrVersionConflict，
+// 此时需要重新加载后再次修改
+func (c *FrameworkConfig) Save() error {
+	w, ok := c.p.(Writer)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrProviderReadOnly, c.p.Name())
+	}
+	s := c.snap()
+	if !s.dirty {
+		return nil
+	}
+	data := s.raw
+	if data == nil {
+		var err error
+		if data, err = Encode(s.tree, c.decoder.Name()); err != nil {
+			return err
+		}
+	}
+	version, err := w.Write(c.path, data, s.version)
+	if err != nil {
+		return fmt.Errorf("app/config: failed to save %s: %w", c.path, err)
+	}
+	logger.Infof("app/config: saved path=%s version=%s", c.path, version)
+	// 读取写入后的版本号，内容与当前配置相同，不会产生变更通知
+	return c.reloadAndReport()
+}
+
+// Push 将路径为path的全部已加载配置中 Set 的修改写回内容源
+func (loader *FullConfigLoader) Push(path string) error {
+	found := false
+	for _, c := range loader.loaded() {
+		if c.path != path {
+			continue
+		}
+		found = true
+		if err := c.Save(); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return ErrConfigNotExist
+	}
+	return nil
+}
+
+// Push 将默认加载器中路径为path的配置的修改写回内容源
+func Push(path string) error {
+	return DefaultConfigLoader.Push(path)
+}
@@ -0,0 +1,154 @@
+package configserver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config/configtest"
+)
+
+// newTestServer 创建声明了 app 的配置服务，app 为内容源up中的 shared.yaml
+func newTestServer(t *testing.T, up *configtest.Provider, token string) (*Server, *httptest.Server) {
+	t.Helper()
+	c, _ := configtest.Load(t, "configserver:\n  token: "+token+"\n  documents:\n    app:\n      provider: "+up.Name()+"\n      path: shared.yaml\n")
+	s := New(c)
+	ts := httptest.NewServer(s)
+	t.Cleanup(func() {
+		s.Close()
+		ts.Close()
+	})
+	return s, ts
+}
+
+func get(t *testing.T, rawurl, token string) (int, string, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, rawurl, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header.Get(VersionHeader), string(body)
+}
+
+func TestServeDocument(t *testing.T) {
+	up := configtest.NewProvider()
+	up.Set("shared.yaml", []byte("a: 1\n"))
+	_, ts := newTestServer(t, up, "secret")
+
+	if code, _, _ := get(t, ts.URL+"/documents/app", ""); code != http.StatusUnauthorized {
+		t.Fatalf("expect 401 without token, got %d", code)
+	}
+	if code, _, _ := get(t, ts.URL+"/documents/other", "secret"); code != http.StatusNotFound {
+		t.Fatalf("expect 404 for an undeclared document, got %d", code)
+	}
+	code, version, body := get(t, ts.URL+"/documents/app", "secret")
+	if code != http.StatusOK || body != "a: 1\n" {
+		t.Fatalf("expect the document, got %d %q", code, body)
+	}
+
+	// 版本不变时长轮询超时返回304
+	if code, _, _ := get(t, ts.URL+"/documents/app?version="+version+"&wait=50", "secret"); code != http.StatusNotModified {
+		t.Fatalf("expect 304, got %d", code)
+	}
+	// 等待期间内容变化时返回新版本
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		up.Update("shared.yaml", []byte("a: 2\n"))
+	}()
+	code, next, body := get(t, ts.URL+"/documents/app?version="+version+"&wait=3000", "secret")
+	if code != http.StatusOK || body != "a: 2\n" || next == version {
+		t.Fatalf("expect the new version, got %d %s %q", code, next, body)
+	}
+}
+
+func TestProviderWatch(t *testing.T) {
+	up := configtest.NewProvider()
+	up.Set("shared.yaml", []byte("a: 1\n"))
+	_, ts := newTestServer(t, up, "")
+
+	p := NewProvider("configserver-watch", ts.URL, "")
+	defer p.Close()
+	changed := make(chan string, 4)
+	p.Watch(func(path string, data []byte) { changed <- path + " " + string(data) })
+	data, err := p.Read("app")
+	if err != nil || string(data) != "a: 1\n" {
+		t.Fatalf("expect a: 1, got %q %v", data, err)
+	}
+
+	// 监听连接建立前的变化同样会在连接后推送
+	up.Update("shared.yaml", []byte("a: 2\n"))
+	select {
+	case got := <-changed:
+		if got != "app a: 2\n" {
+			t.Fatalf("unexpected change %q", got)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expect a change event")
+	}
+}
+
+func TestLeaderPropagation(t *testing.T) {
+	up := configtest.NewProvider()
+	up.Set("/app/app.yaml", []byte("a: 1\n"))
+	empty, _ := configtest.Load(t, "app: {}\n")
+
+	leaderSrv := New(empty)
+	ts := httptest.NewServer(leaderSrv)
+	defer func() {
+		leaderSrv.Close()
+		ts.Close()
+	}()
+	leader := NewLeaderProvider("leader-a", leaderSrv, &StaticElector{Addr: ts.URL, Self: true}, LeaderOptions{Upstream: up.Name()})
+	// 跟随者不读取Upstream，Upstream不存在时同样可以读取领导者发布的配置
+	follower := NewLeaderProvider("leader-b", New(empty), &StaticElector{Addr: ts.URL}, LeaderOptions{Upstream: "not-exist"})
+
+	if data, err := leader.Read("/app/app.yaml"); err != nil || string(data) != "a: 1\n" {
+		t.Fatalf("leader: expect a: 1, got %q %v", data, err)
+	}
+	if data, err := follower.Read("/app/app.yaml"); err != nil || string(data) != "a: 1\n" {
+		t.Fatalf("follower: expect a: 1, got %q %v", data, err)
+	}
+	changed := make(chan string, 4)
+	follower.Watch(func(path string, data []byte) { changed <- path + " " + string(data) })
+
+	// 校验失败的内容不会发布
+	up.Update("/app/app.yaml", []byte("a: [1\n"))
+	if doc, err := leaderSrv.Get(url.PathEscape("/app/app.yaml")); err != nil || string(doc.Data) != "a: 1\n" {
+		t.Fatalf("expect the old version to stay published, got %v", err)
+	}
+
+	up.Update("/app/app.yaml", []byte("a: 2\n"))
+	select {
+	case got := <-changed:
+		if got != "/app/app.yaml a: 2\n" {
+			t.Fatalf("unexpected change %q", got)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expect the follower to receive the published version")
+	}
+}
+
+func TestReloadDeclarations(t *testing.T) {
+	up := configtest.NewProvider()
+	up.Set("shared.yaml", []byte("a: 1\n"))
+	c, p := configtest.Load(t, "configserver:\n  documents:\n    app:\n      provider: "+up.Name()+"\n      path: shared.yaml\n")
+	s := New(c)
+	defer s.Close()
+	if _, err := s.Get("app"); err != nil {
+		t.Fatal(err)
+	}
+	// 删除声明后不能再读取
+	p.Update(configtest.DefaultPath, []byte("configserver:\n  documents: {}\n"))
+	if _, err := s.Get("app"); err == nil {
+		t.Fatal("expect an error after the declaration is removed")
+	}
+}
@@ -0,0 +1,239 @@
+package configserver
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+
+	"goProjectTmpl/config"
+)
+
+// Elector 选主，例如基于etcd的election、k8s的lease实现
+type Elector interface {
+	// Leader 返回领导者配置服务的地址，例如 http://10.0.0.1:9028/config，self为true表示当前实例是领导者
+	Leader() (addr string, self bool)
+	// Watch 注册领导者变化回调
+	Watch(func())
+}
+
+// StaticElector 固定的领导者，用于单机部署或通过配置指定领导者
+type StaticElector struct {
+	Addr string
+	Self bool
+}
+
+// Leader 返回固定的领导者
+func (e *StaticElector) Leader() (string, bool) {
+	return e.Addr, e.Self
+}
+
+// Watch 领导者不会变化，不调用回调
+func (e *StaticElector) Watch(func()) {}
+
+// LeaderOptions 领导者模式的参数
+type LeaderOptions struct {
+	// Upstream 领导者读取的内容源，例如etcd
+	Upstream string
+	// Token 访问领导者配置服务的token，与领导者 configserver 配置段中的token相同
+	Token string
+	// Codec 校验配置内容使用的编解码器，默认为yaml
+	Codec string
+	// Validate 校验配置内容，不为空时代替Codec的解码校验
+	Validate func(path string, data []byte) error
+}
+
+// LeaderProvider 领导者模式的内容源，实现了 config.DataProvider 和 config.VersionedProvider
+// 只有领导者从 Upstream 读取配置，校验通过后通过 Server.Publish 发布，跟随者从领导者的配置服务读取，
+// 减少远程内容源的压力，并且全部实例使用同一份校验过的配置：
+//
+//	s := configserver.New(c)
+//	adm.Handle("/config/", http.StripPrefix("/config", s))
+//	config.RegisterProvider(configserver.NewLeaderProvider("leader", s, elector, configserver.LeaderOptions{Upstream: "etcd"}))
+//	remote, err := config.Load("/app/app.yaml", config.WithProvider("leader"), config.WithAutoReload())
+//
+// 领导者变化时重新读取已读取过的配置文件并调用变化回调
+type LeaderProvider struct {
+	name    string
+	srv     *Server
+	elector Elector
+	opts    LeaderOptions
+
+	lock      sync.Mutex
+	addr      string
+	self      bool
+	follower  *Provider
+	watched   bool
+	paths     map[string]bool
+	callbacks []config.ProviderCallback
+}
+
+// NewLeaderProvider 创建名字为name的内容源，srv为当前实例的配置服务，成为领导者时在srv上发布配置
+func NewLeaderProvider(name string, srv *Server, e Elector, opts LeaderOptions) *LeaderProvider {
+	if opts.Codec == "" {
+		opts.Codec = "yaml"
+	}
+	p := &LeaderProvider{name: name, srv: srv, elector: e, opts: opts, paths: make(map[string]bool)}
+	p.elect()
+	e.Watch(p.onElect)
+	return p
+}
+
+// Name provider名字
+func (p *LeaderProvider) Name() string {
+	return p.name
+}
+
+// Read 读取配置文件
+func (p *LeaderProvider) Read(path string) ([]byte, error) {
+	data, _, err := p.ReadWithVersion(path)
+	return data, err
+}
+
+// ReadWithVersion 领导者从 Upstream 读取并发布配置文件，跟随者从领导者的配置服务读取
+func (p *LeaderProvider) ReadWithVersion(path string) ([]byte, string, error) {
+	p.lock.Lock()
+	p.paths[path] = true
+	self, follower := p.self, p.follower
+	p.lock.Unlock()
+	if !self {
+		return follower.ReadWithVersion(publishedName(path))
+	}
+
+	upstream, err := p.upstream()
+	if err != nil {
+		return nil, "", err
+	}
+	cur, err := read(upstream, path, Definition{Provider: p.opts.Upstream, Path: path})
+	if err != nil {
+		return nil, "", err
+	}
+	if err := p.validate(path, cur.Data); err != nil {
+		return nil, "", err
+	}
+	p.srv.Publish(publishedName(path), cur.Data, cur.Version)
+	return cur.Data, cur.Version, nil
+}
+
+// Watch 注册变化回调
+func (p *LeaderProvider) Watch(cb config.ProviderCallback) {
+	p.lock.Lock()
+	p.callbacks = append(p.callbacks, cb)
+	p.lock.Unlock()
+}
+
+// publishedName 配置文件在配置服务中的名字，路径中的 / 转义后作为一级路径
+func publishedName(path string) string {
+	return url.PathEscape(path)
+}
+
+// upstream 获取 Upstream 内容源，第一次获取时注册变化回调
+func (p *LeaderProvider) upstream() (config.DataProvider, error) {
+	upstream := config.GetProvider(p.opts.Upstream)
+	if upstream == nil {
+		return nil, fmt.Errorf("app/configserver: %s: %v", p.opts.Upstream, config.ErrProviderNotExist)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.watched {
+		p.watched = true
+		upstream.Watch(p.onUpstreamChange)
+	}
+	return upstream, nil
+}
+
+func (p *LeaderProvider) validate(path string, data []byte) error {
+	if p.opts.Validate != nil {
+		if err := p.opts.Validate(path, data); err != nil {
+			return fmt.Errorf("app/configserver: invalid %s: %v", path, err)
+		}
+		return nil
+	}
+	codec := config.GetCodec(p.opts.Codec)
+	if codec == nil {
+		return fmt.Errorf("app/configserver: %s: %v", p.opts.Codec, config.ErrCodecNotExist)
+	}
+	out := make(map[string]interface{})
+	if err := codec.Unmarshal(data, &out); err != nil {
+		return fmt.Errorf("app/configserver: invalid %s: %v", path, err)
+	}
+	return nil
+}
+
+// onUpstreamChange 领导者收到 Upstream 的变化后校验并发布，校验失败时跟随者继续使用原来的配置
+func (p *LeaderProvider) onUpstreamChange(path string, _ []byte) {
+	p.lock.Lock()
+	relevant := p.self && p.paths[path]
+	p.lock.Unlock()
+	if !relevant {
+		return
+	}
+	data, _, err := p.ReadWithVersion(path)
+	if err != nil {
+		config.Warnf("app/configserver: keep publishing the old version of %s: %v", path, err)
+		return
+	}
+	p.notify(path, data)
+}
+
+// onFollowerChange 跟随者收到领导者发布的新版本
+func (p *LeaderProvider) onFollowerChange(follower *Provider) config.ProviderCallback {
+	return func(name string, data []byte) {
+		p.lock.Lock()
+		current := p.follower == follower
+		p.lock.Unlock()
+		path, err := url.PathUnescape(name)
+		if !current || err != nil {
+			return
+		}
+		p.notify(path, data)
+	}
+}
+
+func (p *LeaderProvider) notify(path string, data []byte) {
+	p.lock.Lock()
+	callbacks := append([]config.ProviderCallback(nil), p.callbacks...)
+	p.lock.Unlock()
+	for _, cb := range callbacks {
+		cb(path, data)
+	}
+}
+
+// elect 根据 Elector 的结果切换角色，返回角色或领导者是否变化
+func (p *LeaderProvider) elect() bool {
+	addr, self := p.elector.Leader()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.follower != nil && addr == p.addr && self == p.self {
+		return false
+	}
+	if p.follower != nil {
+		p.follower.Close()
+	}
+	p.addr, p.self = addr, self
+	// 领导者也创建跟随者的Provider，简化读取时的判断，只有跟随者会使用
+	p.follower = NewProvider(p.name, addr, p.opts.Token)
+	p.follower.Watch(p.onFollowerChange(p.follower))
+	config.Infof("app/configserver: %s leader changed to %s, self: %v", p.name, addr, self)
+	return true
+}
+
+// onElect 领导者变化后重新读取已读取过的配置文件，成为领导者时同时发布
+func (p *LeaderProvider) onElect() {
+	if !p.elect() {
+		return
+	}
+	p.lock.Lock()
+	paths := make([]string, 0, len(p.paths))
+	for path := range p.paths {
+		paths = append(paths, path)
+	}
+	p.lock.Unlock()
+	for _, path := range paths {
+		data, _, err := p.ReadWithVersion(path)
+		if err != nil {
+			config.Warnf("app/configserver: failed to read %s from the new leader: %v", path, err)
+			continue
+		}
+		p.notify(path, data)
+	}
+}
@@ -0,0 +1,208 @@
+package configserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// 监听断开后重新连接的等待时间
+const (
+	minRetryDelay = time.Second
+	maxRetryDelay = 30 * time.Second
+)
+
+// Provider 从配置服务读取配置文件的内容源，路径为 configserver 配置段中声明的名字，
+// 实现了 config.DataProvider 和 config.VersionedProvider
+type Provider struct {
+	name  string
+	base  string
+	token string
+	// client 读取配置使用，stream 监听使用，不设置超时
+	client *http.Client
+	stream *http.Client
+	// ctx 调用 Close 后结束
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock      sync.Mutex
+	callbacks []config.ProviderCallback
+	versions  map[string]string
+	watching  map[string]bool
+}
+
+// NewProvider 创建名字为name的内容源，baseURL为配置服务的地址，例如 http://10.0.0.1:9028/config
+func NewProvider(name, baseURL, token string) *Provider {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Provider{
+		ctx:      ctx,
+		cancel:   cancel,
+		name:     name,
+		base:     strings.TrimRight(baseURL, "/"),
+		token:    token,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		stream:   &http.Client{},
+		versions: make(map[string]string),
+		watching: make(map[string]bool),
+	}
+}
+
+// Name provider名字
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// Read 读取配置文件
+func (p *Provider) Read(path string) ([]byte, error) {
+	data, _, err := p.ReadWithVersion(path)
+	return data, err
+}
+
+// ReadWithVersion 读取配置文件及其在配置服务中的版本号
+func (p *Provider) ReadWithVersion(path string) ([]byte, string, error) {
+	resp, err := p.get(p.client, "/documents/"+url.PathEscape(path))
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("app/configserver: %s: %v", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("app/configserver: %s: %s: %s", path, resp.Status, bytes.TrimSpace(data))
+	}
+	version := resp.Header.Get(VersionHeader)
+
+	p.lock.Lock()
+	p.versions[path] = version
+	p.startWatch(path)
+	p.lock.Unlock()
+	return data, version, nil
+}
+
+// Watch 注册变化回调，读取过的配置文件版本变化时调用
+func (p *Provider) Watch(cb config.ProviderCallback) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.callbacks = append(p.callbacks, cb)
+	for path := range p.versions {
+		p.startWatch(path)
+	}
+}
+
+// Close 停止监听，之后不再调用变化回调
+func (p *Provider) Close() {
+	p.cancel()
+}
+
+// startWatch 有回调时开始监听path，调用方需要持有锁
+func (p *Provider) startWatch(path string) {
+	if len(p.callbacks) == 0 || p.watching[path] {
+		return
+	}
+	p.watching[path] = true
+	go p.watch(path)
+}
+
+func (p *Provider) get(c *http.Client, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(p.ctx, http.MethodGet, p.base+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("app/configserver: %v", err)
+	}
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("app/configserver: %v", err)
+	}
+	return resp, nil
+}
+
+// watch 持续监听path，连接断开后按照退避时间重新连接
+func (p *Provider) watch(path string) {
+	delay := minRetryDelay
+	for {
+		connected, err := p.watchOnce(path)
+		if p.ctx.Err() != nil {
+			return
+		}
+		if connected {
+			delay = minRetryDelay
+		}
+		config.Warnf("app/configserver: watch %s disconnected, retry in %s: %v", path, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-p.ctx.Done():
+			return
+		}
+		if delay *= 2; delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+// watchOnce 读取一次事件流，版本变化时调用回调，connected 表示连接成功过
+func (p *Provider) watchOnce(path string) (connected bool, err error) {
+	resp, err := p.get(p.stream, "/watch/"+url.PathEscape(path))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("app/configserver: %s: %s", path, resp.Status)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	event := ""
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data := strings.TrimPrefix(line, "data: ")
+			if event == "error" {
+				return true, fmt.Errorf("app/configserver: %s: %s", path, data)
+			}
+			doc := &Document{}
+			if err := json.Unmarshal([]byte(data), doc); err != nil {
+				return true, fmt.Errorf("app/configserver: %s: invalid event: %v", path, err)
+			}
+			p.update(path, doc)
+		case line == "":
+			event = ""
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return true, fmt.Errorf("app/configserver: %s: %v", path, err)
+	}
+	return true, fmt.Errorf("app/configserver: %s: stream closed", path)
+}
+
+// update 版本与上次读取的不同时调用回调
+func (p *Provider) update(path string, doc *Document) {
+	p.lock.Lock()
+	if p.versions[path] == doc.Version || p.ctx.Err() != nil {
+		p.lock.Unlock()
+		return
+	}
+	p.versions[path] = doc.Version
+	callbacks := append([]config.ProviderCallback(nil), p.callbacks...)
+	p.lock.Unlock()
+	for _, cb := range callbacks {
+		cb(path, doc.Data)
+	}
+}
@@ -0,0 +1,373 @@
+// Package configserver 配置服务
+// 将已注册内容源中的配置文件通过http提供给其他服务，小规模部署时可以代替独立的配置中心。
+// 对外提供的配置文件需要在 configserver 配置段中按名字声明，请求中不能指定任意路径：
+//
+//	configserver:
+//	  token: xxx                  # 不为空时请求需要携带 Authorization: Bearer <token>
+//	  documents:
+//	    app:
+//	      provider: file          # 内容源，etcd等通过 config.RegisterProvider 注册
+//	      path: /etc/app/app.yaml
+//
+//	GET /documents/<name>                         返回配置内容，X-Config-Version 为版本号
+//	GET /documents/<name>?version=<v>&wait=30000  长轮询，版本与v相同时等待变化，超时返回304
+//	GET /watch/<name>                             text/event-stream，连接后推送当前版本，之后每次变化推送新版本
+//
+//	s := configserver.New(c)
+//	adm.Handle("/config/", http.StripPrefix("/config", s))
+//	// 停止http服务之前调用 s.Close() 结束监听连接
+//
+// 其他服务使用 NewProvider 创建的内容源读取和监听配置：
+//
+//	config.RegisterProvider(configserver.NewProvider("remote", "http://10.0.0.1:9028/config", token))
+//	c, err := config.Load("app", config.WithProvider("remote"), config.WithAutoReload())
+//
+// 单例类服务可以使用 NewLeaderProvider，只有选出的领导者读取远程内容源，校验后通过配置服务发布给其他实例
+package configserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中配置服务配置段的key
+const SectionKey = "configserver"
+
+// VersionHeader 响应中配置版本号的header
+const VersionHeader = "X-Config-Version"
+
+// maxWait 长轮询的最长等待时间
+const maxWait = 5 * time.Minute
+
+// ErrNotDeclared 配置文件没有在 configserver 配置段中声明
+var ErrNotDeclared = errors.New("app/configserver: document not declared")
+
+// Definition 配置文件所在的内容源
+type Definition struct {
+	Provider string `yaml:"provider" default:"file"`
+	Path     string `yaml:"path" validate:"required"`
+}
+
+// Options configserver 配置段
+type Options struct {
+	Token     string                `yaml:"token" sensitive:"true"`
+	Documents map[string]Definition `yaml:"documents"`
+}
+
+func init() {
+	// 使 sensitive tag 生效，客户端token在调试接口和审计记录中脱敏
+	config.RegisterStruct(SectionKey, Options{})
+}
+
+// Document 某个版本的配置文件
+type Document struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Data    []byte `json:"data"`
+}
+
+// document 已读取的配置文件，changed 在版本变化时关闭
+type document struct {
+	def     Definition
+	cur     *Document
+	changed chan struct{}
+}
+
+// Server 配置服务，实现了http.Handler
+type Server struct {
+	lock    sync.Mutex
+	opts    *Options
+	docs    map[string]*document
+	watched map[string]bool
+	// published 通过 Publish 发布的配置文件
+	published map[string]*document
+
+	// done 关闭后结束全部监听和长轮询请求
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New 解析c中的 configserver 配置段，配置重新加载后更新声明，声明变化的配置文件在下次请求时重新读取
+func New(c config.Config) *Server {
+	s := &Server{
+		opts:      &Options{},
+		docs:      make(map[string]*document),
+		watched:   make(map[string]bool),
+		published: make(map[string]*document),
+		done:      make(chan struct{}),
+	}
+	if err := s.reload(c); err != nil {
+		config.Errorf("app/configserver: %v", err)
+	}
+	config.WatchSection(c, SectionKey, func() error { return s.reload(c) })
+	return s
+}
+
+func (s *Server) reload(c config.Config) error {
+	opts := &Options{}
+	if c.IsSet(SectionKey) {
+		if err := c.UnmarshalKey(SectionKey, opts); err != nil {
+			return fmt.Errorf("app/configserver: failed to parse %s: %v", SectionKey, err)
+		}
+	}
+	for name, d := range opts.Documents {
+		if d.Provider == "" {
+			d.Provider = "file"
+			opts.Documents[name] = d
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.opts = opts
+	for name, doc := range s.docs {
+		if d, ok := opts.Documents[name]; !ok || d != doc.def {
+			// 等待中的请求重新查找声明
+			close(doc.changed)
+			delete(s.docs, name)
+		}
+	}
+	return nil
+}
+
+// Get 获取名字对应的配置文件的当前版本，没有读取过时从内容源读取
+func (s *Server) Get(name string) (*Document, error) {
+	doc, err := s.document(name)
+	if err != nil {
+		return nil, err
+	}
+	return doc.cur, nil
+}
+
+// document 获取名字对应的配置文件
+func (s *Server) document(name string) (*document, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if doc, ok := s.published[name]; ok {
+		return doc, nil
+	}
+	if doc, ok := s.docs[name]; ok {
+		return doc, nil
+	}
+	def, ok := s.opts.Documents[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotDeclared, name)
+	}
+	p := config.GetProvider(def.Provider)
+	if p == nil {
+		return nil, fmt.Errorf("app/configserver: %s: %v", def.Provider, config.ErrProviderNotExist)
+	}
+	if !s.watched[def.Provider] {
+		s.watched[def.Provider] = true
+		provider := def.Provider
+		p.Watch(func(path string, _ []byte) {
+			s.onChange(provider, path)
+		})
+	}
+	cur, err := read(p, name, def)
+	if err != nil {
+		return nil, err
+	}
+	doc := &document{def: def, cur: cur, changed: make(chan struct{})}
+	s.docs[name] = doc
+	return doc, nil
+}
+
+// read 从内容源读取配置文件，内容源不提供版本号时使用内容的摘要作为版本号
+func read(p config.DataProvider, name string, def Definition) (*Document, error) {
+	var (
+		data    []byte
+		version string
+		err     error
+	)
+	if vp, ok := p.(config.VersionedProvider); ok {
+		data, version, err = vp.ReadWithVersion(def.Path)
+	} else {
+		data, err = p.Read(def.Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("app/configserver: failed to read %s: %v", name, err)
+	}
+	if version == "" {
+		version = digest(data)
+	}
+	return &Document{Name: name, Version: version, Data: data}, nil
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Publish 发布名字为name的配置文件，不需要在 configserver 配置段中声明，并且优先于声明的同名配置文件，
+// version为空时使用内容的摘要，版本变化时唤醒等待中的请求
+func (s *Server) Publish(name string, data []byte, version string) {
+	if version == "" {
+		version = digest(data)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	old, ok := s.published[name]
+	if ok && old.cur.Version == version {
+		return
+	}
+	s.published[name] = &document{cur: &Document{Name: name, Version: version, Data: data}, changed: make(chan struct{})}
+	if ok {
+		close(old.changed)
+	}
+}
+
+// onChange 内容源通知变化时重新读取对应的配置文件，版本变化时唤醒等待中的请求
+func (s *Server) onChange(provider, path string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for name, doc := range s.docs {
+		if doc.def.Provider != provider || doc.def.Path != path {
+			continue
+		}
+		cur, err := read(config.GetProvider(provider), name, doc.def)
+		if err != nil {
+			config.Warnf("app/configserver: keep serving the old version of %s: %v", name, err)
+			continue
+		}
+		if cur.Version == doc.cur.Version {
+			continue
+		}
+		s.docs[name] = &document{def: doc.def, cur: cur, changed: make(chan struct{})}
+		close(doc.changed)
+		config.Infof("app/configserver: %s changed to %s", name, cur.Version)
+	}
+}
+
+// Close 结束全部监听和长轮询请求，需要在停止http服务之前调用，否则监听连接会一直阻塞停止过程
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// ServeHTTP 处理 /documents/<name> 和 /watch/<name>
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.lock.Lock()
+	token := s.opts.Token
+	s.lock.Unlock()
+	if token != "" && !config.CheckBearer(r, token) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/documents/"):
+		s.serveDocument(w, r, strings.TrimPrefix(r.URL.Path, "/documents/"))
+	case strings.HasPrefix(r.URL.Path, "/watch/"):
+		s.serveWatch(w, r, strings.TrimPrefix(r.URL.Path, "/watch/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveDocument 返回配置内容，携带version和wait参数时长轮询
+func (s *Server) serveDocument(w http.ResponseWriter, r *http.Request, name string) {
+	q := r.URL.Query()
+	wait, _ := strconv.Atoi(q.Get("wait"))
+	timeout := time.Duration(wait) * time.Millisecond
+	if timeout > maxWait {
+		timeout = maxWait
+	}
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		deadline = t.C
+	}
+
+	for {
+		doc, err := s.document(name)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if q.Get("version") != doc.cur.Version || deadline == nil {
+			w.Header().Set(VersionHeader, doc.cur.Version)
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write(doc.cur.Data)
+			return
+		}
+		select {
+		case <-doc.changed:
+		case <-deadline:
+			w.Header().Set(VersionHeader, doc.cur.Version)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			w.Header().Set(VersionHeader, doc.cur.Version)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+}
+
+// serveWatch 以 text/event-stream 推送配置的每个版本，事件内容为 Document 的json
+func (s *Server) serveWatch(w http.ResponseWriter, r *http.Request, name string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	doc, err := s.document(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	last := ""
+	for {
+		if doc.cur.Version != last {
+			last = doc.cur.Version
+			data, _ := json.Marshal(doc.cur)
+			if _, err := fmt.Fprintf(w, "id: %s\ndata: %s\n\n", last, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+		select {
+		case <-doc.changed:
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+		if doc, err = s.document(name); err != nil {
+			// 配置文件的声明被删除
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", err)
+			flusher.Flush()
+			return
+		}
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotDeclared) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadGateway)
+}
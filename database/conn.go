@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+)
+
+// connector 使用当前生效的dsn创建连接，凭据轮换后旧凭据创建的连接在归还连接池时被丢弃
+type connector struct {
+	drv driver.Driver
+	// dsn 当前生效的dsn
+	dsn atomic.Value
+	// gen dsn的版本，每次轮换加一
+	gen int64
+}
+
+func newConnector(drv driver.Driver, dsn string) *connector {
+	c := &connector{drv: drv}
+	c.dsn.Store(dsn)
+	return c
+}
+
+// rotate 替换dsn，已有的连接继续完成正在执行的请求，之后不再复用
+func (c *connector) rotate(dsn string) {
+	c.dsn.Store(dsn)
+	atomic.AddInt64(&c.gen, 1)
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	gen := atomic.LoadInt64(&c.gen)
+	dsn := c.dsn.Load().(string)
+
+	var conn driver.Conn
+	var err error
+	if dc, ok := c.drv.(driver.DriverContext); ok {
+		var cn driver.Connector
+		if cn, err = dc.OpenConnector(dsn); err == nil {
+			conn, err = cn.Connect(ctx)
+		}
+	} else {
+		conn, err = c.drv.Open(dsn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rotatingConn{Conn: conn, c: c, gen: gen}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.drv
+}
+
+// rotatingConn 记录创建连接时dsn版本的连接，转发驱动连接实现的可选接口
+type rotatingConn struct {
+	driver.Conn
+	c   *connector
+	gen int64
+}
+
+// IsValid dsn轮换后连接不再复用
+func (r *rotatingConn) IsValid() bool {
+	if atomic.LoadInt64(&r.c.gen) != r.gen {
+		return false
+	}
+	if v, ok := r.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (r *rotatingConn) ResetSession(ctx context.Context) error {
+	if s, ok := r.Conn.(driver.SessionResetter); ok {
+		return s.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (r *rotatingConn) Ping(ctx context.Context) error {
+	if p, ok := r.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (r *rotatingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := r.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return r.Conn.Prepare(query)
+}
+
+func (r *rotatingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := r.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return r.Conn.Begin()
+}
+
+func (r *rotatingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := r.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (r *rotatingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := r.Conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (r *rotatingConn) CheckNamedValue(v *driver.NamedValue) error {
+	if c, ok := r.Conn.(driver.NamedValueChecker); ok {
+		return c.CheckNamedValue(v)
+	}
+	return driver.ErrSkip
+}
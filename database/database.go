@@ -0,0 +1,239 @@
+// Package database 根据框架配置中的 plugins.database 配置段创建 *sql.DB 连接池
+// 凭据从内容源读取，内容变化时新连接使用新凭据，旧连接完成正在执行的请求后关闭：
+//
+//	plugins:
+//	  database:
+//	    mysql:
+//	      driver: mysql
+//	      dsn: root:{password}@tcp(localhost:3306)/app
+//	      secret:               # 替换dsn中的 {password}，dsn为空时内容即为dsn
+//	        provider: file
+//	        path: /etc/secrets/mysql
+//	      max_idle: 20
+//	      max_open: 100
+//	      max_lifetime: 180000  # 毫秒
+//
+//	plugin.Register("mysql", database.Factory{})
+//	db := database.Get("mysql")
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/lifecycle"
+	"goProjectTmpl/plugin"
+)
+
+// PluginType 数据库插件类型
+const PluginType = "database"
+
+// PasswordPlaceholder dsn中被凭据替换的部分
+const PasswordPlaceholder = "{password}"
+
+// ErrNoDSN 没有配置dsn也没有配置凭据
+var ErrNoDSN = errors.New("app/database: dsn or secret required")
+
+// Config 数据库插件配置，时间单位为毫秒，0表示不限制
+// 连接池大小和连接生命周期重新加载配置后立即生效
+type Config struct {
+	Driver string `yaml:"driver" validate:"required"`
+	// DSN 不使用凭据时其中通常带有密码，整体脱敏
+	DSN string `yaml:"dsn" sensitive:"true"`
+	// Secret 凭据所在的内容源
+	Secret *SecretConfig `yaml:"secret"`
+	// MaxIdle 最大空闲连接数
+	MaxIdle int `yaml:"max_idle" default:"2"`
+	// MaxOpen 最大在线连接数
+	MaxOpen int `yaml:"max_open"`
+	// MaxLifetime 连接最大生命周期
+	MaxLifetime int `yaml:"max_lifetime"`
+	// MaxIdleTime 连接最大空闲时间
+	MaxIdleTime int `yaml:"max_idle_time"`
+	// ConnectTimeout 初始化时检查连接的超时时间，为0时不检查
+	ConnectTimeout int `yaml:"connect_timeout"`
+}
+
+func init() {
+	// 使 sensitive tag 生效，plugins.database.<name>.dsn 在调试接口、差异对比和审计记录中脱敏
+	config.RegisterStruct("plugins."+PluginType, map[string]*Config{})
+}
+
+// SecretConfig 凭据所在的内容源，内容首尾的空白字符被忽略
+type SecretConfig struct {
+	Provider string `yaml:"provider" default:"file"`
+	Path     string `yaml:"path" validate:"required"`
+}
+
+// dsn 使用凭据生成dsn
+func (c *Config) dsn(secret string) string {
+	if c.Secret == nil {
+		return c.DSN
+	}
+	if c.DSN == "" {
+		return secret
+	}
+	return strings.Replace(c.DSN, PasswordPlaceholder, secret, -1)
+}
+
+// apply 设置连接池参数
+func (c *Config) apply(db *sql.DB) {
+	db.SetMaxIdleConns(c.MaxIdle)
+	db.SetMaxOpenConns(c.MaxOpen)
+	db.SetConnMaxLifetime(time.Duration(c.MaxLifetime) * time.Millisecond)
+	db.SetConnMaxIdleTime(time.Duration(c.MaxIdleTime) * time.Millisecond)
+}
+
+var (
+	dbs  = make(map[string]*sql.DB)
+	lock sync.RWMutex
+)
+
+// Get 获取已初始化的连接池，name为插件名字
+func Get(name string) *sql.DB {
+	lock.RLock()
+	defer lock.RUnlock()
+	return dbs[name]
+}
+
+// Factory 数据库插件工厂，使用 plugin.Register 以配置中的名字注册
+type Factory struct{}
+
+// Type 插件类型
+func (Factory) Type() string {
+	return PluginType
+}
+
+// Setup 创建连接池，监听凭据和连接池配置的变化，并在服务退出时关闭连接池
+func (Factory) Setup(name string, c plugin.Config) error {
+	cfg := &Config{}
+	if err := c.Unmarshal(cfg); err != nil {
+		return err
+	}
+	if cfg.DSN == "" && cfg.Secret == nil {
+		return ErrNoDSN
+	}
+	drv, err := openDriver(cfg.Driver)
+	if err != nil {
+		return err
+	}
+
+	var secret string
+	var p config.DataProvider
+	if cfg.Secret != nil {
+		if p = config.GetProvider(cfg.Secret.Provider); p == nil {
+			return fmt.Errorf("app/database: %s: %v", cfg.Secret.Provider, config.ErrProviderNotExist)
+		}
+		if secret, err = readSecret(p, cfg.Secret.Path); err != nil {
+			return err
+		}
+	}
+
+	conn := newConnector(drv, cfg.dsn(secret))
+	db := sql.OpenDB(conn)
+	cfg.apply(db)
+	if cfg.ConnectTimeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ConnectTimeout)*time.Millisecond)
+		err := db.PingContext(ctx)
+		cancel()
+		if err != nil {
+			db.Close()
+			return fmt.Errorf("app/database: failed to connect: %v", err)
+		}
+	}
+
+	r := &rotator{key: c.Key(), cfg: cfg, conn: conn, db: db, secret: secret}
+	if p != nil {
+		p.Watch(r.onSecret)
+	}
+	c.Framework().OnChange(func([]config.Change) {
+		r.onConfig(c)
+	})
+
+	lock.Lock()
+	old := dbs[name]
+	dbs[name] = db
+	lock.Unlock()
+	if old != nil {
+		old.Close()
+	}
+	lifecycle.OnStop(PluginType+"-"+name, func(context.Context) error {
+		return db.Close()
+	})
+	return nil
+}
+
+// openDriver 获取已注册的驱动
+func openDriver(name string) (driver.Driver, error) {
+	db, err := sql.Open(name, "")
+	if err != nil {
+		return nil, fmt.Errorf("app/database: %v", err)
+	}
+	drv := db.Driver()
+	db.Close()
+	return drv, nil
+}
+
+// readSecret 读取凭据
+func readSecret(p config.DataProvider, path string) (string, error) {
+	data, err := p.Read(path)
+	if err != nil {
+		return "", fmt.Errorf("app/database: failed to read secret %s: %v", path, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// rotator 在凭据或配置变化时更新连接池
+type rotator struct {
+	key    string
+	lock   sync.Mutex
+	cfg    *Config
+	conn   *connector
+	db     *sql.DB
+	secret string
+}
+
+// onSecret 凭据内容变化时轮换dsn
+func (r *rotator) onSecret(path string, data []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.cfg.Secret == nil || path != r.cfg.Secret.Path {
+		return
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == r.secret || secret == "" {
+		return
+	}
+	r.secret = secret
+	r.conn.rotate(r.cfg.dsn(secret))
+	config.Infof("app/database: %s credentials rotated", r.key)
+}
+
+// onConfig 框架配置重新加载后更新连接池参数，dsn变化时同时轮换dsn
+func (r *rotator) onConfig(c plugin.Config) {
+	cfg := &Config{}
+	if err := c.Unmarshal(cfg); err != nil {
+		config.Errorf("app/database: failed to parse %s, ignored: %v", r.key, err)
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if cfg.Driver != r.cfg.Driver || (cfg.Secret == nil) != (r.cfg.Secret == nil) ||
+		(cfg.Secret != nil && *cfg.Secret != *r.cfg.Secret) {
+		config.Warnf("app/database: %s driver or secret changed, restart required", r.key)
+		cfg.Driver, cfg.Secret = r.cfg.Driver, r.cfg.Secret
+	}
+	if cfg.dsn(r.secret) != r.cfg.dsn(r.secret) {
+		r.conn.rotate(cfg.dsn(r.secret))
+		config.Infof("app/database: %s dsn changed", r.key)
+	}
+	cfg.apply(r.db)
+	r.cfg = cfg
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"goProjectTmpl/config/configtest"
+	"goProjectTmpl/plugin"
+)
+
+// fakeDriver 记录每次建立连接使用的dsn
+type fakeDriver struct {
+	lock sync.Mutex
+	dsns []string
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.lock.Lock()
+	d.dsns = append(d.dsns, dsn)
+	d.lock.Unlock()
+	return fakeConn{}, nil
+}
+
+func (d *fakeDriver) last() string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if len(d.dsns) == 0 {
+		return ""
+	}
+	return d.dsns[len(d.dsns)-1]
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+var drv = &fakeDriver{}
+
+func init() {
+	sql.Register("databasetest", drv)
+}
+
+const databaseYAML = `plugins:
+  database:
+    %s:
+      driver: databasetest
+      dsn: root:{password}@tcp(localhost:3306)/app
+      secret:
+        provider: %s
+        path: mysql
+      max_open: %d
+`
+
+// setup 以name注册数据库插件并初始化，凭据从返回的内容源读取
+func setup(t *testing.T, name string) (*configtest.Provider, *configtest.Provider) {
+	t.Helper()
+	secrets := configtest.NewProvider()
+	secrets.Set("mysql", []byte("pw1\n"))
+	plugin.Register(name, Factory{})
+	c, p := configtest.Load(t, fmt.Sprintf(databaseYAML, name, secrets.Name(), 10))
+	if _, err := plugin.Setup(c); err != nil {
+		t.Fatal(err)
+	}
+	return secrets, p
+}
+
+func TestSetupAndRotate(t *testing.T) {
+	secrets, _ := setup(t, "db-rotate")
+	db := Get("db-rotate")
+	if db == nil {
+		t.Fatal("expect the pool to be registered")
+	}
+	ctx := context.Background()
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := drv.last(); got != "root:pw1@tcp(localhost:3306)/app" {
+		t.Fatalf("unexpected dsn %s", got)
+	}
+
+	// 凭据变化后旧连接不再复用，新连接使用新凭据
+	secrets.Update("mysql", []byte("pw2\n"))
+	for i := 0; i < 2; i++ {
+		if err := db.PingContext(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := drv.last(); got != "root:pw2@tcp(localhost:3306)/app" {
+		t.Fatalf("expect the rotated dsn, got %s", got)
+	}
+}
+
+func TestReloadPool(t *testing.T) {
+	secrets, p := setup(t, "db-reload")
+	db := Get("db-reload")
+	if got := db.Stats().MaxOpenConnections; got != 10 {
+		t.Fatalf("expect max_open 10, got %d", got)
+	}
+	p.Update(configtest.DefaultPath, []byte(fmt.Sprintf(databaseYAML, "db-reload", secrets.Name(), 20)))
+	if got := db.Stats().MaxOpenConnections; got != 20 {
+		t.Fatalf("expect max_open 20 after reload, got %d", got)
+	}
+}
+
+func TestSetupErrors(t *testing.T) {
+	plugin.Register("db-nodsn", Factory{})
+	c, _ := configtest.Load(t, "plugins:\n  database:\n    db-nodsn:\n      driver: databasetest\n")
+	if _, err := plugin.Setup(c); err == nil || !strings.Contains(err.Error(), ErrNoDSN.Error()) {
+		t.Fatalf("expect ErrNoDSN, got %v", err)
+	}
+}
+
+func TestDSN(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{DSN: "root:pw@/app"}, "root:pw@/app"},
+		{Config{Secret: &SecretConfig{}}, "secret"},
+		{Config{DSN: "root:{password}@/app", Secret: &SecretConfig{}}, "root:secret@/app"},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.dsn("secret"); got != tt.want {
+			t.Errorf("expect %s, got %s", tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package env
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Load 按顺序读取.env文件并设置进程中尚未设置的环境变量，已存在的环境变量不会被覆盖，
+// 多个文件中的同名变量以先读取的为准；文件不存在时忽略
+func Load(paths ...string) error {
+	for _, path := range paths {
+		vars, err := ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, kv := range vars {
+			if _, ok := os.LookupEnv(kv[0]); ok {
+				continue
+			}
+			if err := os.Setenv(kv[0], kv[1]); err != nil {
+				return fmt.Errorf("app/env: %s: %v", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ReadFile 解析.env文件，按照出现的顺序返回 [变量名, 值]
+func ReadFile(path string) ([][2]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("app/env: %s: %v", path, err)
+	}
+	return vars, nil
+}
+
+// Parse 解析.env格式的内容，每行一个 KEY=VALUE，支持 # 注释、export 前缀，
+// 双引号中的值支持 \n、\t、\"、\\ 转义，单引号中的值保持原样，不加引号的值去掉行尾注释和首尾空白
+func Parse(data []byte) ([][2]string, error) {
+	var vars [][2]string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("line %d: missing =", n)
+		}
+		key := strings.TrimSpace(line[:i])
+		val, err := parseValue(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		vars = append(vars, [2]string{key, val})
+	}
+	return vars, sc.Err()
+}
+
+func parseValue(s string) (string, error) {
+	if len(s) == 0 {
+		return "", nil
+	}
+	switch s[0] {
+	case '"':
+		end := closingQuote(s, '"')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted value %s", s)
+		}
+		v, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return "", err
+		}
+		return v, nil
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted value %s", s)
+		}
+		return s[1 : end+1], nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// closingQuote 返回与s[0]匹配的未转义的引号位置
+func closingQuote(s string, q byte) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case q:
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,171 @@
+// Package env 环境变量
+// 启动时先读取.env文件补充尚未设置的环境变量，再捕获进程环境变量的只读快照，
+// 配置的环境变量层、占位符和业务代码都从同一份快照读取，避免运行过程中 os.Setenv 导致前后不一致：
+//
+//	env.Load(".env.local", ".env")
+//	snap := env.Capture()
+//	c, err := config.Load("./app.yaml", snap.LoadOptions("APP")...)
+//	port := snap.Int("PORT", 8080)
+//	ec, err := snap.Config("APP") // 只读的Config，APP_SERVER_PORT 对应 server.port
+package env
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+
+	"goProjectTmpl/config"
+)
+
+// Snapshot 环境变量的只读快照
+type Snapshot struct {
+	vars map[string]string
+}
+
+// Capture 捕获进程当前的环境变量
+func Capture() *Snapshot {
+	environ := os.Environ()
+	vars := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if i := strings.Index(kv, "="); i > 0 {
+			vars[kv[:i]] = kv[i+1:]
+		}
+	}
+	return &Snapshot{vars: vars}
+}
+
+// FromMap 使用vars创建快照，通常用于测试
+func FromMap(vars map[string]string) *Snapshot {
+	s := &Snapshot{vars: make(map[string]string, len(vars))}
+	for k, v := range vars {
+		s.vars[k] = v
+	}
+	return s
+}
+
+// LoadOptions 加载配置时从快照读取环境变量，prefix不为空时同时启用 config.WithEnv(prefix)
+func (s *Snapshot) LoadOptions(prefix string) []config.LoadOption {
+	opts := []config.LoadOption{config.WithEnvLookup(s.Lookup)}
+	if prefix != "" {
+		opts = append(opts, config.WithEnv(prefix))
+	}
+	return opts
+}
+
+// Config 使用快照中的环境变量创建只读的配置，变量名到配置项的对应关系与 config.FromEnviron 相同，
+// 例如 APP_SERVER_PORT 对应 server.port
+func (s *Snapshot) Config(prefix string, opts ...config.LoadOption) (config.Config, error) {
+	environ := make([]string, 0, len(s.vars))
+	for _, name := range s.Names() {
+		environ = append(environ, name+"="+s.vars[name])
+	}
+	return config.NewFromEnviron(environ, prefix, opts...)
+}
+
+// Lookup 获取环境变量，与 os.LookupEnv 相同
+func (s *Snapshot) Lookup(name string) (string, bool) {
+	v, ok := s.vars[name]
+	return v, ok
+}
+
+// Names 快照中全部环境变量名，按名字排序
+func (s *Snapshot) Names() []string {
+	names := make([]string, 0, len(s.vars))
+	for name := range s.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Redacted 快照中全部环境变量，按照 config.IsSensitive 判断的敏感变量被脱敏，例如 DB_PASSWORD
+func (s *Snapshot) Redacted() map[string]string {
+	out := make(map[string]string, len(s.vars))
+	for k, v := range s.vars {
+		if config.IsSensitive(strings.ToLower(strings.Replace(k, "_", ".", -1))) {
+			v = config.RedactedValue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// String 获取string类型的环境变量，不存在时返回def
+func (s *Snapshot) String(name, def string) string {
+	if v, ok := s.vars[name]; ok {
+		return v
+	}
+	return def
+}
+
+// Int 获取int类型的环境变量，不存在或无法转换时返回def
+func (s *Snapshot) Int(name string, def int) int {
+	v, ok := s.vars[name]
+	if !ok {
+		return def
+	}
+	i, err := cast.ToIntE(v)
+	if err != nil {
+		return def
+	}
+	return i
+}
+
+// Bool 获取bool类型的环境变量，支持 1、t、true、0、f、false 等，不存在或无法转换时返回def
+func (s *Snapshot) Bool(name string, def bool) bool {
+	v, ok := s.vars[name]
+	if !ok {
+		return def
+	}
+	b, err := cast.ToBoolE(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// Float64 获取float64类型的环境变量，不存在或无法转换时返回def
+func (s *Snapshot) Float64(name string, def float64) float64 {
+	v, ok := s.vars[name]
+	if !ok {
+		return def
+	}
+	f, err := cast.ToFloat64E(v)
+	if err != nil {
+		return def
+	}
+	return f
+}
+
+// Duration 获取时长类型的环境变量，按照 time.ParseDuration 解析，整数表示毫秒，不存在或无法转换时返回def
+func (s *Snapshot) Duration(name string, def time.Duration) time.Duration {
+	v, ok := s.vars[name]
+	if !ok {
+		return def
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return d
+	}
+	if ms, err := cast.ToInt64E(v); err == nil {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return def
+}
+
+// Strings 获取以sep分隔的环境变量，去掉每项首尾的空白并忽略空项，不存在时返回def
+func (s *Snapshot) Strings(name, sep string, def []string) []string {
+	v, ok := s.vars[name]
+	if !ok {
+		return def
+	}
+	var out []string
+	for _, item := range strings.Split(v, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,115 @@
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+func TestParse(t *testing.T) {
+	data := `# comment
+export APP_NAME=demo
+PORT = 8080 # trailing comment
+EMPTY=
+QUOTED="line1\nline2 \"x\" # not a comment"
+RAW='a\nb # kept'
+`
+	vars, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]string{
+		{"APP_NAME", "demo"},
+		{"PORT", "8080"},
+		{"EMPTY", ""},
+		{"QUOTED", "line1\nline2 \"x\" # not a comment"},
+		{"RAW", `a\nb # kept`},
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Fatalf("expect %q, got %q", want, vars)
+	}
+
+	for _, bad := range []string{"NOVALUE", "=x", `A="open`, "A='open"} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("%s: expect an error", bad)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	local := filepath.Join(dir, ".env.local")
+	base := filepath.Join(dir, ".env")
+	ioutil.WriteFile(local, []byte("ENV_TEST_A=local\n"), 0644)
+	ioutil.WriteFile(base, []byte("ENV_TEST_A=base\nENV_TEST_B=base\nENV_TEST_C=base\n"), 0644)
+
+	os.Setenv("ENV_TEST_C", "process")
+	defer func() {
+		for _, k := range []string{"ENV_TEST_A", "ENV_TEST_B", "ENV_TEST_C"} {
+			os.Unsetenv(k)
+		}
+	}()
+	// 已存在的环境变量不会被覆盖，先读取的文件优先，文件不存在时忽略
+	if err := Load(local, filepath.Join(dir, "missing"), base); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]string{"ENV_TEST_A": "local", "ENV_TEST_B": "base", "ENV_TEST_C": "process"} {
+		if got := os.Getenv(k); got != want {
+			t.Errorf("%s: expect %s, got %s", k, want, got)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	vars := map[string]string{
+		"PORT":        "9000",
+		"DEBUG":       "true",
+		"RATIO":       "0.5",
+		"WAIT":        "2s",
+		"WAIT_MS":     "1500",
+		"HOSTS":       "a, b,,c",
+		"BAD":         "x",
+		"DB_PASSWORD": "secret",
+	}
+	s := FromMap(vars)
+	// 快照不受之后修改的影响
+	vars["PORT"] = "1"
+	if got := s.Int("PORT", 0); got != 9000 {
+		t.Fatalf("expect 9000, got %d", got)
+	}
+	if !s.Bool("DEBUG", false) || s.Float64("RATIO", 0) != 0.5 || s.Int("BAD", 7) != 7 || s.String("MISSING", "def") != "def" {
+		t.Fatal("unexpected typed values")
+	}
+	if s.Duration("WAIT", 0) != 2*time.Second || s.Duration("WAIT_MS", 0) != 1500*time.Millisecond || s.Duration("BAD", time.Second) != time.Second {
+		t.Fatal("unexpected durations")
+	}
+	if got := s.Strings("HOSTS", ",", nil); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected hosts %v", got)
+	}
+	if got := s.Redacted(); got["DB_PASSWORD"] != config.RedactedValue || got["PORT"] != "9000" {
+		t.Fatalf("unexpected redacted vars %v", got)
+	}
+}
+
+func TestSnapshotConfig(t *testing.T) {
+	s := FromMap(map[string]string{"APP_SERVER_PORT": "8000", "OTHER": "x"})
+	c, err := s.Config("APP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetInt("server.port", 0); got != 8000 {
+		t.Fatalf("expect server.port 8000, got %d", got)
+	}
+	if c.IsSet("other") {
+		t.Fatal("expect variables without the prefix to be ignored")
+	}
+}
@@ -0,0 +1,303 @@
+// Package experiment 基于动态配置的A/B实验
+// 实验定义在框架配置的 experiments 配置段中，配置重新加载后立即生效。同一个单元（用户、设备）在实验定义不变时
+// 总是分到同一个分组，分组只取决于实验的salt和单元标识，不依赖进程或机器：
+//
+//	experiments:
+//	  checkout_button:
+//	    enabled: true
+//	    salt: v1                  # 修改salt会重新分组，默认为实验名
+//	    traffic: 50               # 进入实验的单元百分比，0-100
+//	    targeting:                # 属性全部满足才进入实验，值为列表时满足其一
+//	      region: [cn, hk]
+//	    overrides:                # 指定单元的分组，不受traffic和targeting限制
+//	      u1001: green
+//	    variants:
+//	      - name: control
+//	        weight: 50
+//	        params: {color: blue}
+//	      - name: green
+//	        weight: 50
+//	        params: {color: green}
+//
+//	ex := experiment.New(c)
+//	ex.OnExposure(func(e *experiment.Exposure) { track(e) })
+//	a := ex.Assign("checkout_button", experiment.Unit{ID: uid, Attrs: map[string]string{"region": region}})
+//	color := a.String("color", "blue")
+package experiment
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cast"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中实验配置段的key
+const SectionKey = "experiments"
+
+// Unit 分组的单元，例如当前请求的用户或设备
+type Unit struct {
+	// ID 单元标识，决定分组
+	ID string
+	// Attrs 用于定向的属性，例如地域、客户端版本
+	Attrs map[string]string
+}
+
+// Variant 实验分组
+type Variant struct {
+	Name string `yaml:"name" validate:"required"`
+	// Weight 分组的相对权重
+	Weight float64                `yaml:"weight" validate:"min=0"`
+	Params map[string]interface{} `yaml:"params"`
+}
+
+// Definition 配置中单个实验的定义
+type Definition struct {
+	Enabled bool   `yaml:"enabled"`
+	Salt    string `yaml:"salt"`
+	// Traffic 进入实验的单元百分比，0-100
+	Traffic float64 `yaml:"traffic" default:"100" validate:"min=0,max=100"`
+	// Targeting 定向条件，值为字符串或字符串列表
+	Targeting map[string]interface{} `yaml:"targeting"`
+	// Overrides 单元标识到分组名字的固定分组
+	Overrides map[string]string `yaml:"overrides"`
+	Variants  []*Variant        `yaml:"variants"`
+}
+
+// Assignment 单元在实验中的分组，没有进入实验时Variant为空，参数全部使用默认值
+type Assignment struct {
+	Experiment string
+	Variant    string
+	Params     map[string]interface{}
+}
+
+// InExperiment 单元是否进入实验
+func (a *Assignment) InExperiment() bool {
+	return a.Variant != ""
+}
+
+// Param 获取分组参数，没有进入实验或分组没有该参数时返回def
+func (a *Assignment) Param(key string, def interface{}) interface{} {
+	if v, ok := a.Params[key]; ok {
+		return v
+	}
+	return def
+}
+
+// String 获取string类型的分组参数，值无法转换时使用def
+func (a *Assignment) String(key, def string) string {
+	v, err := cast.ToStringE(a.Param(key, def))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// Int 获取int类型的分组参数，值无法转换时使用def
+func (a *Assignment) Int(key string, def int) int {
+	v, err := cast.ToIntE(a.Param(key, def))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// Bool 获取bool类型的分组参数，值无法转换时使用def
+func (a *Assignment) Bool(key string, def bool) bool {
+	v, err := cast.ToBoolE(a.Param(key, def))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// Exposure 曝光记录，单元通过 Assign 进入实验时产生
+type Exposure struct {
+	Time       time.Time
+	Experiment string
+	Variant    string
+	Unit       string
+	// Override 是否由 overrides 指定分组
+	Override bool
+}
+
+// Experiments 实验集合
+type Experiments struct {
+	c config.Config
+	// defs 当前生效的实验定义，类型为 map[string]*Definition，配置重新加载后整体替换
+	defs atomic.Value
+
+	lock  sync.RWMutex
+	hooks []func(*Exposure)
+}
+
+// New 解析c中的 experiments 配置段，并在配置重新加载后更新实验定义
+// 配置段解析失败时继续使用原来的定义
+func New(c config.Config) *Experiments {
+	ex := &Experiments{c: c}
+	defs, err := parse(c)
+	if err != nil {
+		config.Errorf("app/experiment: %v", err)
+		defs = map[string]*Definition{}
+	}
+	ex.defs.Store(defs)
+	config.WatchSection(c, SectionKey, func() error {
+		defs, err := parse(c)
+		if err != nil {
+			return err
+		}
+		ex.defs.Store(defs)
+		return nil
+	})
+	return ex
+}
+
+// parse 解析并检查 experiments 配置段
+func parse(c config.Config) (map[string]*Definition, error) {
+	defs := make(map[string]*Definition)
+	if !c.IsSet(SectionKey) {
+		return defs, nil
+	}
+	if err := c.UnmarshalKey(SectionKey, &defs); err != nil {
+		return nil, err
+	}
+	for name, d := range defs {
+		if d.Salt == "" {
+			d.Salt = name
+		}
+		names := make(map[string]bool, len(d.Variants))
+		total := 0.0
+		for _, v := range d.Variants {
+			if names[v.Name] {
+				return nil, fmt.Errorf("app/experiment: %s: duplicate variant %s", name, v.Name)
+			}
+			names[v.Name] = true
+			total += v.Weight
+		}
+		if d.Enabled && total <= 0 {
+			return nil, fmt.Errorf("app/experiment: %s: no variant with positive weight", name)
+		}
+		for unit, variant := range d.Overrides {
+			if !names[variant] {
+				return nil, fmt.Errorf("app/experiment: %s: override %s: unknown variant %s", name, unit, variant)
+			}
+		}
+	}
+	return defs, nil
+}
+
+func (ex *Experiments) definitions() map[string]*Definition {
+	return ex.defs.Load().(map[string]*Definition)
+}
+
+// OnExposure 注册曝光回调，每次 Assign 使单元进入实验时同步调用，回调中不应执行耗时操作
+func (ex *Experiments) OnExposure(fn func(*Exposure)) {
+	ex.lock.Lock()
+	ex.hooks = append(ex.hooks, fn)
+	ex.lock.Unlock()
+}
+
+// Assign 计算单元在实验中的分组，进入实验时调用曝光回调
+func (ex *Experiments) Assign(name string, u Unit) *Assignment {
+	a, override := ex.assign(name, u)
+	if !a.InExperiment() {
+		return a
+	}
+	e := &Exposure{Time: config.Now(), Experiment: name, Variant: a.Variant, Unit: u.ID, Override: override}
+	ex.lock.RLock()
+	hooks := ex.hooks
+	ex.lock.RUnlock()
+	for _, fn := range hooks {
+		fn(e)
+	}
+	return a
+}
+
+// Peek 与 Assign 相同，但不调用曝光回调，用于日志、调试等不实际使用分组的场景
+func (ex *Experiments) Peek(name string, u Unit) *Assignment {
+	a, _ := ex.assign(name, u)
+	return a
+}
+
+func (ex *Experiments) assign(name string, u Unit) (*Assignment, bool) {
+	a := &Assignment{Experiment: name}
+	d, ok := ex.definitions()[name]
+	if !ok || !d.Enabled {
+		return a, false
+	}
+	if variant, ok := d.Overrides[u.ID]; ok {
+		if v := d.variant(variant); v != nil {
+			a.Variant, a.Params = v.Name, v.Params
+			return a, true
+		}
+	}
+	if !d.targeted(u) || bucket(d.Salt, "traffic", u.ID) >= d.Traffic {
+		return a, false
+	}
+	if v := d.pick(u.ID); v != nil {
+		a.Variant, a.Params = v.Name, v.Params
+	}
+	return a, false
+}
+
+func (d *Definition) variant(name string) *Variant {
+	for _, v := range d.Variants {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// targeted 单元的属性是否满足全部定向条件
+func (d *Definition) targeted(u Unit) bool {
+	for k, want := range d.Targeting {
+		got, ok := u.Attrs[k]
+		if !ok {
+			return false
+		}
+		matched := false
+		for _, v := range cast.ToStringSlice(want) {
+			if v == got {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// pick 按照权重选择分组，与进入实验的哈希相互独立，调整traffic不会改变已进入实验的单元的分组
+func (d *Definition) pick(unit string) *Variant {
+	total := 0.0
+	for _, v := range d.Variants {
+		total += v.Weight
+	}
+	point := bucket(d.Salt, "variant", unit) / 100 * total
+	for _, v := range d.Variants {
+		if point < v.Weight {
+			return v
+		}
+		point -= v.Weight
+	}
+	return nil
+}
+
+// bucket 将单元稳定地映射到 [0, 100) 区间
+func bucket(salt, stage, unit string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(salt))
+	h.Write([]byte{0})
+	h.Write([]byte(stage))
+	h.Write([]byte{0})
+	h.Write([]byte(unit))
+	return float64(h.Sum64()%10000) / 100
+}
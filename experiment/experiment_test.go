@@ -0,0 +1,91 @@
+package experiment
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"goProjectTmpl/config/configtest"
+)
+
+const experimentYAML = `experiments:
+  checkout_button:
+    enabled: true
+    traffic: %d
+    targeting:
+      region: [cn, hk]
+    overrides:
+      u1001: green
+    variants:
+      - name: control
+        weight: 50
+        params: {color: blue}
+      - name: green
+        weight: 50
+        params: {color: green, size: 2}
+`
+
+func TestAssign(t *testing.T) {
+	c, _ := configtest.Load(t, fmt.Sprintf(experimentYAML, 100))
+	ex := New(c)
+	var exposures []*Exposure
+	ex.OnExposure(func(e *Exposure) { exposures = append(exposures, e) })
+
+	cn := map[string]string{"region": "cn"}
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		u := Unit{ID: fmt.Sprintf("u%d", i), Attrs: cn}
+		a := ex.Assign("checkout_button", u)
+		// 同一个单元总是分到同一个分组
+		if b := ex.Peek("checkout_button", u); b.Variant != a.Variant {
+			t.Fatalf("%s: expect a stable variant, got %s and %s", u.ID, a.Variant, b.Variant)
+		}
+		counts[a.Variant]++
+	}
+	if math.Abs(float64(counts["control"]-counts["green"])) > 200 {
+		t.Fatalf("expect variants split by weight, got %v", counts)
+	}
+	// Peek 不产生曝光
+	if len(exposures) != 2000 {
+		t.Fatalf("expect 2000 exposures, got %d", len(exposures))
+	}
+
+	// 不满足定向条件的单元不进入实验，参数使用默认值
+	a := ex.Assign("checkout_button", Unit{ID: "u1", Attrs: map[string]string{"region": "us"}})
+	if a.InExperiment() || a.String("color", "blue") != "blue" {
+		t.Fatalf("expect not in experiment, got %+v", a)
+	}
+	// overrides 不受定向条件限制
+	a = ex.Assign("checkout_button", Unit{ID: "u1001"})
+	if a.Variant != "green" || a.Int("size", 0) != 2 || !exposures[len(exposures)-1].Override {
+		t.Fatalf("expect the override variant, got %+v", a)
+	}
+	if ex.Assign("unknown", Unit{ID: "u1"}).InExperiment() {
+		t.Fatal("expect an unknown experiment to assign no variant")
+	}
+}
+
+func TestReload(t *testing.T) {
+	c, p := configtest.Load(t, fmt.Sprintf(experimentYAML, 0))
+	ex := New(c)
+	u := Unit{ID: "u42", Attrs: map[string]string{"region": "hk"}}
+	if ex.Assign("checkout_button", u).InExperiment() {
+		t.Fatal("expect no unit in experiment with traffic 0")
+	}
+
+	p.Update(configtest.DefaultPath, []byte(fmt.Sprintf(experimentYAML, 100)))
+	variant := ex.Assign("checkout_button", u).Variant
+	if variant == "" {
+		t.Fatal("expect the unit in experiment after traffic is raised")
+	}
+
+	// 非法定义被拒绝，继续使用原来的定义
+	p.Update(configtest.DefaultPath, []byte("experiments:\n  checkout_button:\n    enabled: true\n    variants:\n      - name: a\n        weight: 0\n"))
+	if got := ex.Assign("checkout_button", u).Variant; got != variant {
+		t.Fatalf("expect the old definition to stay, got %q", got)
+	}
+	p.Update(configtest.DefaultPath, []byte("experiments:\n  checkout_button:\n    enabled: true\n    overrides: {u1: b}\n    variants:\n      - name: a\n        weight: 1\n"))
+	if got := ex.Assign("checkout_button", u).Variant; got != variant {
+		t.Fatalf("expect an override of an unknown variant to be rejected, got %q", got)
+	}
+}
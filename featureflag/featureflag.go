@@ -0,0 +1,219 @@
+// Package featureflag 基于动态配置的功能开关
+// 开关定义在框架配置的 featureflags 配置段中，配置重新加载后立即生效，支持按用户、按比例、按属性定向：
+//
+//	featureflags:
+//	  new_checkout:
+//	    default: false
+//	    rules:
+//	      - users: [u1001, u1002]   # 指定用户
+//	        value: true
+//	      - percentage: 20          # 20%的用户
+//	        attrs: {region: cn}
+//	        value: true
+//
+//	flags := featureflag.New(c)
+//	if flags.Bool("new_checkout", false).Value(featureflag.Target{User: uid}) { ... }
+package featureflag
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/spf13/cast"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中功能开关配置段的key
+const SectionKey = "featureflags"
+
+// Flags 功能开关集合
+type Flags struct {
+	c config.Config
+	// defs 当前生效的开关定义，类型为 map[string]*Definition，配置重新加载后整体替换
+	defs atomic.Value
+
+	lock        sync.Mutex
+	subscribers map[string][]func(name string)
+}
+
+// New 解析c中的 featureflags 配置段，并在配置重新加载后更新开关定义
+// 配置段解析失败时继续使用原来的定义
+func New(c config.Config) *Flags {
+	f := &Flags{c: c, subscribers: make(map[string][]func(string))}
+	defs, err := parse(c)
+	if err != nil {
+		config.Errorf("app/featureflag: %v", err)
+		defs = map[string]*Definition{}
+	}
+	f.defs.Store(defs)
+	config.WatchSection(c, SectionKey, f.reload)
+	return f
+}
+
+// parse 解析 featureflags 配置段
+func parse(c config.Config) (map[string]*Definition, error) {
+	defs := make(map[string]*Definition)
+	if !c.IsSet(SectionKey) {
+		return defs, nil
+	}
+	if err := c.UnmarshalKey(SectionKey, &defs); err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
+
+// reload 替换开关定义，并通知定义发生变化的开关的订阅者
+func (f *Flags) reload() error {
+	defs, err := parse(f.c)
+	if err != nil {
+		return err
+	}
+	old := f.definitions()
+	f.defs.Store(defs)
+
+	f.lock.Lock()
+	var notify []func()
+	for name, subs := range f.subscribers {
+		if reflect.DeepEqual(old[name], defs[name]) {
+			continue
+		}
+		for _, fn := range subs {
+			name, fn := name, fn
+			notify = append(notify, func() { fn(name) })
+		}
+	}
+	f.lock.Unlock()
+	for _, fn := range notify {
+		fn()
+	}
+	return nil
+}
+
+func (f *Flags) definitions() map[string]*Definition {
+	return f.defs.Load().(map[string]*Definition)
+}
+
+// Subscribe 注册开关定义变化的回调，开关被新增、删除或修改时调用
+func (f *Flags) Subscribe(name string, fn func(name string)) {
+	f.lock.Lock()
+	f.subscribers[name] = append(f.subscribers[name], fn)
+	f.lock.Unlock()
+}
+
+// Value 计算开关对t的值，开关不存在时返回def
+func (f *Flags) Value(name string, t Target, def interface{}) interface{} {
+	d, ok := f.definitions()[name]
+	if !ok {
+		return def
+	}
+	if v := d.resolve(name, t); v != nil {
+		return v
+	}
+	return def
+}
+
+// Flag 开关的通用部分
+type Flag struct {
+	f    *Flags
+	name string
+}
+
+// Name 开关名字
+func (fl Flag) Name() string {
+	return fl.name
+}
+
+// OnChange 注册开关定义变化的回调
+func (fl Flag) OnChange(fn func()) {
+	fl.f.Subscribe(fl.name, func(string) { fn() })
+}
+
+// Bool bool类型的开关
+type Bool struct {
+	Flag
+	def bool
+}
+
+// Bool 获取bool类型的开关，开关不存在或值无法转换时使用def
+func (f *Flags) Bool(name string, def bool) *Bool {
+	return &Bool{Flag: Flag{f: f, name: name}, def: def}
+}
+
+// Value 计算开关对t的值
+func (b *Bool) Value(t Target) bool {
+	v, err := cast.ToBoolE(b.f.Value(b.name, t, b.def))
+	if err != nil {
+		return b.def
+	}
+	return v
+}
+
+// Int int类型的开关
+type Int struct {
+	Flag
+	def int
+}
+
+// Int 获取int类型的开关，开关不存在或值无法转换时使用def
+func (f *Flags) Int(name string, def int) *Int {
+	return &Int{Flag: Flag{f: f, name: name}, def: def}
+}
+
+// Value 计算开关对t的值
+func (i *Int) Value(t Target) int {
+	v, err := cast.ToIntE(i.f.Value(i.name, t, i.def))
+	if err != nil {
+		return i.def
+	}
+	return v
+}
+
+// String string类型的开关
+type String struct {
+	Flag
+	def string
+}
+
+// String 获取string类型的开关，开关不存在或值无法转换时使用def
+func (f *Flags) String(name string, def string) *String {
+	return &String{Flag: Flag{f: f, name: name}, def: def}
+}
+
+// Value 计算开关对t的值
+func (s *String) Value(t Target) string {
+	v, err := cast.ToStringE(s.f.Value(s.name, t, s.def))
+	if err != nil {
+		return s.def
+	}
+	return v
+}
+
+// JSON 值为对象或列表的开关
+type JSON struct {
+	Flag
+}
+
+// JSON 获取值为对象或列表的开关
+func (f *Flags) JSON(name string) *JSON {
+	return &JSON{Flag: Flag{f: f, name: name}}
+}
+
+// Decode 计算开关对t的值并解码到out中，开关不存在时out保持不变
+// 值为字符串时按照json解析
+func (j *JSON) Decode(t Target, out interface{}) error {
+	v := j.f.Value(j.name, t, nil)
+	if v == nil {
+		return nil
+	}
+	if s, ok := v.(string); ok {
+		return json.Unmarshal([]byte(s), out)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,86 @@
+package featureflag
+
+import (
+	"fmt"
+	"testing"
+
+	"goProjectTmpl/config/configtest"
+)
+
+const flagsYAML = `featureflags:
+  new_checkout:
+    default: false
+    rules:
+      - users: [u1001]
+        value: true
+      - percentage: 20
+        attrs: {region: cn}
+        value: true
+  page_size:
+    default: 20
+  layout:
+    default: {columns: 2}
+`
+
+func TestValue(t *testing.T) {
+	c, _ := configtest.Load(t, flagsYAML)
+	flags := New(c)
+	checkout := flags.Bool("new_checkout", false)
+	if !checkout.Value(Target{User: "u1001"}) {
+		t.Error("expect the user rule to match")
+	}
+	if checkout.Value(Target{User: "u1002", Attrs: map[string]string{"region": "us"}}) {
+		t.Error("expect no rule to match outside cn")
+	}
+
+	// 按比例命中的用户约为20%，同一个用户的结果稳定
+	on := 0
+	for i := 0; i < 2000; i++ {
+		target := Target{User: fmt.Sprintf("u%d", i), Attrs: map[string]string{"region": "cn"}}
+		v := checkout.Value(target)
+		if v != checkout.Value(target) {
+			t.Fatalf("expect a stable value for %s", target.User)
+		}
+		if v {
+			on++
+		}
+	}
+	if on < 300 || on > 500 {
+		t.Fatalf("expect about 400 users, got %d", on)
+	}
+
+	if got := flags.Int("page_size", 10).Value(Target{}); got != 20 {
+		t.Errorf("expect 20, got %d", got)
+	}
+	if got := flags.String("missing", "x").Value(Target{}); got != "x" {
+		t.Errorf("expect the default for a missing flag, got %q", got)
+	}
+	var layout struct {
+		Columns int `json:"columns"`
+	}
+	if err := flags.JSON("layout").Decode(Target{}, &layout); err != nil || layout.Columns != 2 {
+		t.Errorf("expect columns 2, got %+v %v", layout, err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	c, p := configtest.Load(t, flagsYAML)
+	flags := New(c)
+	size := flags.Int("page_size", 10)
+	changed := map[string]int{}
+	size.OnChange(func() { changed["page_size"]++ })
+	flags.Bool("new_checkout", false).OnChange(func() { changed["new_checkout"]++ })
+
+	// 只通知定义变化的开关
+	p.Update(configtest.DefaultPath, []byte(flagsYAML+"  other:\n    default: 1\n"))
+	if len(changed) != 0 {
+		t.Fatalf("expect no notification, got %v", changed)
+	}
+	p.Update(configtest.DefaultPath, []byte("featureflags:\n  page_size:\n    default: 50\n"))
+	if got := size.Value(Target{}); got != 50 {
+		t.Fatalf("expect 50 after reload, got %d", got)
+	}
+	if changed["page_size"] != 1 || changed["new_checkout"] != 1 {
+		t.Fatalf("expect both flags notified once, got %v", changed)
+	}
+}
@@ -0,0 +1,74 @@
+package featureflag
+
+import "hash/fnv"
+
+// Target 计算开关值的对象，例如当前请求的用户
+type Target struct {
+	// User 用户标识，用于按用户和按比例定向
+	User string
+	// Attrs 其他属性，例如地域、客户端版本
+	Attrs map[string]string
+}
+
+// Definition 配置中单个开关的定义
+type Definition struct {
+	// Default 没有规则命中时的值
+	Default interface{} `yaml:"default"`
+	// Rules 定向规则，按照顺序匹配，第一个命中的规则生效
+	Rules []*Rule `yaml:"rules"`
+}
+
+// Rule 定向规则，配置的条件全部满足时命中，没有配置条件时总是命中
+type Rule struct {
+	// Users 命中的用户
+	Users []string `yaml:"users"`
+	// Percentage 按照用户标识哈希命中的百分比，0-100
+	Percentage *float64 `yaml:"percentage"`
+	// Attrs 需要全部相等的属性
+	Attrs map[string]string `yaml:"attrs"`
+	// Value 命中时的值
+	Value interface{} `yaml:"value"`
+}
+
+// resolve 按照规则计算开关值
+func (d *Definition) resolve(name string, t Target) interface{} {
+	for _, r := range d.Rules {
+		if r.match(name, t) {
+			return r.Value
+		}
+	}
+	return d.Default
+}
+
+func (r *Rule) match(name string, t Target) bool {
+	if len(r.Users) > 0 {
+		found := false
+		for _, u := range r.Users {
+			if u == t.User {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for k, v := range r.Attrs {
+		if t.Attrs[k] != v {
+			return false
+		}
+	}
+	if r.Percentage != nil && bucket(name, t.User) >= *r.Percentage {
+		return false
+	}
+	return true
+}
+
+// bucket 将用户稳定地映射到 [0, 100) 区间，同一个用户在不同开关中的位置不同
+func bucket(name, user string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(user))
+	return float64(h.Sum32()%10000) / 100
+}
@@ -3,8 +3,11 @@ module goProjectTmpl
 go 1.15
 
 require (
+	cuelang.org/go v0.3.2
 	github.com/BurntSushi/toml v0.4.1
 	github.com/fsnotify/fsnotify v1.5.1
+	github.com/prometheus/client_golang v1.11.1
+	github.com/prometheus/client_model v0.2.0
 	github.com/spf13/cast v1.4.1
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
@@ -0,0 +1,57 @@
+package i18n
+
+import (
+	"sync"
+
+	"goProjectTmpl/config"
+)
+
+// EmbedProviderName 内置消息文件的内容源名字
+const EmbedProviderName = "embed"
+
+// embedProvider 读取编译进二进制的消息文件，例如由 go-bindata 生成的内容
+type embedProvider struct {
+	lock      sync.RWMutex
+	files     map[string][]byte
+	callbacks []config.ProviderCallback
+}
+
+var embedded = &embedProvider{files: make(map[string][]byte)}
+
+func init() {
+	config.RegisterProvider(embedded)
+}
+
+// Embed 注册内置的消息文件，path为加载时使用的路径，重复注册时替换内容并通知已加载的消息重新加载
+func Embed(path string, data []byte) {
+	embedded.lock.Lock()
+	_, replaced := embedded.files[path]
+	embedded.files[path] = data
+	callbacks := append([]config.ProviderCallback(nil), embedded.callbacks...)
+	embedded.lock.Unlock()
+	if replaced {
+		for _, cb := range callbacks {
+			go cb(path, data)
+		}
+	}
+}
+
+func (*embedProvider) Name() string {
+	return EmbedProviderName
+}
+
+func (p *embedProvider) Read(path string) ([]byte, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	data, ok := p.files[path]
+	if !ok {
+		return nil, config.ErrConfigNotExist
+	}
+	return data, nil
+}
+
+func (p *embedProvider) Watch(cb config.ProviderCallback) {
+	p.lock.Lock()
+	p.callbacks = append(p.callbacks, cb)
+	p.lock.Unlock()
+}
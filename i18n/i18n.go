@@ -0,0 +1,169 @@
+// Package i18n 多语言消息
+// 每个语言的消息文件通过config的内容源和编解码加载，可以是本地文件、远程配置或内置的内容，
+// 消息文件变化时自动重新加载：
+//
+//	b := i18n.NewBundle("en")
+//	b.Load("en", "./i18n/en.yaml")
+//	b.Load("zh", "./i18n/zh.yaml")
+//	b.Load("zh-TW", "zh-TW.yaml", config.WithProvider(i18n.EmbedProviderName))
+//
+//	t := b.Translator("zh-Hant-TW", "zh-TW")
+//	t.T("order.created", id)   // 依次查找 zh-Hant-TW、zh-Hant、zh、zh-TW、en
+package i18n
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"goProjectTmpl/config"
+)
+
+// Bundle 全部语言的消息
+type Bundle struct {
+	def     string
+	lock    sync.RWMutex
+	locales map[string]config.Config
+}
+
+// NewBundle 创建Bundle，def为所有查找链最后的默认语言
+func NewBundle(def string) *Bundle {
+	return &Bundle{def: def, locales: make(map[string]config.Config)}
+}
+
+// Load 加载locale语言的消息文件，默认根据扩展名选择编解码、使用本地文件并自动重新加载，opts可以覆盖默认选项
+func (b *Bundle) Load(locale, path string, opts ...config.LoadOption) error {
+	opts = append([]config.LoadOption{config.WithCodec(codecName(path)), config.WithAutoReload()}, opts...)
+	c, err := config.Load(path, opts...)
+	if err != nil {
+		return fmt.Errorf("app/i18n: failed to load %s for %s: %v", path, locale, err)
+	}
+	b.lock.Lock()
+	b.locales[locale] = c
+	b.lock.Unlock()
+	return nil
+}
+
+// Locales 已加载的语言
+func (b *Bundle) Locales() []string {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	locales := make([]string, 0, len(b.locales))
+	for l := range b.locales {
+		locales = append(locales, l)
+	}
+	return locales
+}
+
+// codecName 根据文件扩展名选择编解码，默认为yaml
+func codecName(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	}
+	return "yaml"
+}
+
+// lookup 在locale语言中查找消息
+func (b *Bundle) lookup(locale, key string) (string, bool) {
+	b.lock.RLock()
+	c, ok := b.locales[locale]
+	b.lock.RUnlock()
+	if !ok {
+		return "", false
+	}
+	v, ok := c.Get(key, nil).(string)
+	return v, ok
+}
+
+// Translator 按照查找链翻译消息
+type Translator struct {
+	b     *Bundle
+	chain []string
+}
+
+// Translator 创建Translator，依次查找locales中的每个语言及其上级语言，最后查找默认语言
+// 例如 zh-Hant-TW 的上级语言为 zh-Hant、zh
+func (b *Bundle) Translator(locales ...string) *Translator {
+	var chain []string
+	seen := make(map[string]bool)
+	add := func(l string) {
+		if l != "" && !seen[l] {
+			seen[l] = true
+			chain = append(chain, l)
+		}
+	}
+	for _, l := range locales {
+		for _, p := range parents(l) {
+			add(p)
+		}
+	}
+	add(b.def)
+	return &Translator{b: b, chain: chain}
+}
+
+// parents 返回语言及其上级语言，_ 视为 -
+func parents(locale string) []string {
+	locale = strings.Replace(locale, "_", "-", -1)
+	var out []string
+	for locale != "" {
+		out = append(out, locale)
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return out
+}
+
+// Chain 查找链
+func (t *Translator) Chain() []string {
+	return append([]string(nil), t.chain...)
+}
+
+// Lookup 按照查找链查找消息
+func (t *Translator) Lookup(key string) (string, bool) {
+	for _, l := range t.chain {
+		if v, ok := t.b.lookup(l, key); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// T 翻译消息，有参数时按照 fmt.Sprintf 格式化，查找链中都不存在时返回key
+func (t *Translator) T(key string, args ...interface{}) string {
+	v, ok := t.Lookup(key)
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(v, args...)
+	}
+	return v
+}
+
+// Catalog 返回以查找链中的消息作为模板的 config.Catalog，用于按语言输出配置错误：
+//
+//	# zh.yaml
+//	config:
+//	  key_not_found: "配置项 {key} 不存在"
+//	  type_mismatch: "配置项 {key} 的值 {value} 无法转换为 {want}"
+//
+//	msg := config.Render(err, t.Catalog())
+func (t *Translator) Catalog() config.Catalog {
+	return catalog{t: t}
+}
+
+// catalog 实现 config.Catalog
+type catalog struct {
+	t *Translator
+}
+
+func (c catalog) Template(code string) (string, bool) {
+	return c.t.Lookup(code)
+}
@@ -0,0 +1,87 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/config/configtest"
+)
+
+func TestTranslator(t *testing.T) {
+	p := configtest.NewProvider()
+	p.Set("en.yaml", []byte("order:\n  created: \"order %s created\"\n  cancelled: cancelled\ngreeting: hello\n"))
+	p.Set("zh.json", []byte(`{"order": {"created": "订单 %s 已创建"}}`))
+	Embed("zh-TW.toml", []byte("greeting = \"哈囉\"\n"))
+
+	b := NewBundle("en")
+	for locale, path := range map[string]string{"en": "en.yaml", "zh": "zh.json"} {
+		if err := b.Load(locale, path, config.WithProvider(p.Name())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Load("zh-TW", "zh-TW.toml", config.WithProvider(EmbedProviderName)); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := b.Translator("zh_Hant_TW", "zh-TW")
+	if want := []string{"zh-Hant-TW", "zh-Hant", "zh", "zh-TW", "en"}; !reflect.DeepEqual(tr.Chain(), want) {
+		t.Fatalf("expect %v, got %v", want, tr.Chain())
+	}
+	tests := []struct {
+		key  string
+		args []interface{}
+		want string
+	}{
+		{"order.created", []interface{}{"42"}, "订单 42 已创建"},
+		{"greeting", nil, "哈囉"},
+		{"order.cancelled", nil, "cancelled"},
+		{"order.unknown", nil, "order.unknown"},
+	}
+	for _, tt := range tests {
+		if got := tr.T(tt.key, tt.args...); got != tt.want {
+			t.Errorf("%s: expect %q, got %q", tt.key, tt.want, got)
+		}
+	}
+
+	// 消息文件变化时自动重新加载
+	p.Update("en.yaml", []byte("order:\n  cancelled: canceled\n"))
+	if got := tr.T("order.cancelled"); got != "canceled" {
+		t.Fatalf("expect the reloaded message, got %q", got)
+	}
+}
+
+func TestEmbedReload(t *testing.T) {
+	Embed("embed-reload.yaml", []byte("greeting: v1\n"))
+	b := NewBundle("en")
+	if err := b.Load("en", "embed-reload.yaml", config.WithProvider(EmbedProviderName)); err != nil {
+		t.Fatal(err)
+	}
+	// 重复注册时通知已加载的消息重新加载
+	Embed("embed-reload.yaml", []byte("greeting: v2\n"))
+	tr := b.Translator()
+	deadline := time.Now().Add(3 * time.Second)
+	for tr.T("greeting") != "v2" {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect v2, got %q", tr.T("greeting"))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	p := configtest.NewProvider()
+	p.Set("zh.yaml", []byte("config:\n  key_not_found: \"配置项 {key} 不存在\"\n"))
+	b := NewBundle("zh")
+	if err := b.Load("zh", "zh.yaml", config.WithProvider(p.Name())); err != nil {
+		t.Fatal(err)
+	}
+	cat := b.Translator("zh").Catalog()
+	if tmpl, ok := cat.Template("config.key_not_found"); !ok || tmpl != "配置项 {key} 不存在" {
+		t.Fatalf("unexpected template %q", tmpl)
+	}
+	if _, ok := cat.Template("config.other"); ok {
+		t.Fatal("expect no template")
+	}
+}
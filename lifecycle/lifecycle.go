@@ -0,0 +1,197 @@
+// Package lifecycle 服务优雅退出
+// 模块注册停止函数，退出时先等待 shutdown.drain_delay（例如等待负载均衡摘除实例），
+// 再在 shutdown.timeout 内按照注册的相反顺序执行停止函数
+//
+//	shutdown:
+//	  timeout: 30s      # 执行全部停止函数的超时时间，整数表示毫秒
+//	  drain_delay: 5s   # 开始停止前的等待时间，整数表示毫秒
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// 退出相关配置项
+const (
+	TimeoutKey    = "shutdown.timeout"
+	DrainDelayKey = "shutdown.drain_delay"
+)
+
+// 默认值
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultDrainDelay = 0
+)
+
+// ErrTimeout 停止函数未在 shutdown.timeout 内完成
+var ErrTimeout = errors.New("app/lifecycle: shutdown timed out")
+
+// Hook 停止函数，ctx在 shutdown.timeout 到达时结束
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	hook Hook
+}
+
+// Manager 管理停止函数的执行
+type Manager struct {
+	lock    sync.Mutex
+	cfg     config.Config
+	hooks   []namedHook
+	stopped bool
+	// shutting Shutdown 或 Stop 已经开始
+	shutting bool
+}
+
+// NewManager 创建Manager，超时时间从c中读取，c为nil时使用默认值
+func NewManager(c config.Config) *Manager {
+	return &Manager{cfg: c}
+}
+
+// DefaultManager 默认的Manager
+var DefaultManager = NewManager(nil)
+
+// SetConfig 设置读取超时时间的配置，退出时读取当时生效的值
+func (m *Manager) SetConfig(c config.Config) {
+	m.lock.Lock()
+	m.cfg = c
+	m.lock.Unlock()
+}
+
+// OnStop 注册停止函数，退出时按照注册的相反顺序执行
+func (m *Manager) OnStop(name string, hook Hook) {
+	m.lock.Lock()
+	m.hooks = append(m.hooks, namedHook{name: name, hook: hook})
+	m.lock.Unlock()
+}
+
+// ShuttingDown 是否已经开始退出，包括等待 shutdown.drain_delay 的阶段，可用于就绪检查
+func (m *Manager) ShuttingDown() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.shutting
+}
+
+// Shutdown 等待 shutdown.drain_delay 后执行全部停止函数，只执行一次
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.lock.Lock()
+	m.shutting = true
+	m.lock.Unlock()
+	if delay := m.duration(DrainDelayKey, DefaultDrainDelay); delay > 0 {
+		config.Infof("app/lifecycle: draining for %v", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+	return m.Stop(ctx)
+}
+
+// Stop 不等待 shutdown.drain_delay，立即在 shutdown.timeout 内按照注册的相反顺序执行停止函数，只执行一次
+// 超时后不再等待未完成的停止函数，剩余的停止函数不再执行
+func (m *Manager) Stop(ctx context.Context) error {
+	m.lock.Lock()
+	if m.stopped {
+		m.lock.Unlock()
+		return nil
+	}
+	m.stopped = true
+	m.shutting = true
+	hooks := append([]namedHook(nil), m.hooks...)
+	m.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, m.duration(TimeoutKey, DefaultTimeout))
+	defer cancel()
+
+	var failed []string
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if err := run(ctx, h.hook); err != nil {
+			config.Errorf("app/lifecycle: failed to stop %s: %v", h.name, err)
+			failed = append(failed, fmt.Sprintf("%s: %v", h.name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("app/lifecycle: %d hook(s) failed: %s", len(failed), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// run 执行停止函数，ctx结束时不再等待，ctx已结束时不执行
+func run(ctx context.Context, hook Hook) error {
+	if ctx.Err() != nil {
+		return ErrTimeout
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- hook(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ErrTimeout
+	}
+}
+
+// ShutdownOnSignal 阻塞直到ctx结束或收到退出信号，之后执行 Shutdown，未指定信号时使用 SIGINT、SIGTERM
+func (m *Manager) ShutdownOnSignal(ctx context.Context, sigs ...os.Signal) error {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	defer signal.Stop(ch)
+
+	select {
+	case <-ctx.Done():
+	case sig := <-ch:
+		config.Infof("app/lifecycle: received signal %v, shutting down", sig)
+	}
+	return m.Shutdown(context.Background())
+}
+
+// duration 读取时长配置，字符串按照 time.ParseDuration 解析，整数表示毫秒
+func (m *Manager) duration(key string, def time.Duration) time.Duration {
+	m.lock.Lock()
+	c := m.cfg
+	m.lock.Unlock()
+	if c == nil {
+		return def
+	}
+	switch v := c.Get(key, nil).(type) {
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+		config.Warnf("app/lifecycle: invalid duration %s=%q, using %v", key, v, def)
+	case int:
+		return time.Duration(v) * time.Millisecond
+	case int64:
+		return time.Duration(v) * time.Millisecond
+	case float64:
+		return time.Duration(v * float64(time.Millisecond))
+	}
+	return def
+}
+
+// OnStop 在默认Manager中注册停止函数
+func OnStop(name string, hook Hook) {
+	DefaultManager.OnStop(name, hook)
+}
+
+// Shutdown 执行默认Manager中的停止函数
+func Shutdown(ctx context.Context) error {
+	return DefaultManager.Shutdown(ctx)
+}
@@ -0,0 +1,114 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+func TestStopOrder(t *testing.T) {
+	m := NewManager(nil)
+	var order []string
+	for _, name := range []string{"db", "cache", "server"} {
+		name := name
+		m.OnStop(name, func(context.Context) error {
+			order = append(order, name)
+			if name == "cache" {
+				return errors.New("boom")
+			}
+			return nil
+		})
+	}
+	err := m.Stop(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "cache: boom") {
+		t.Fatalf("expect the cache error, got %v", err)
+	}
+	// 按照注册的相反顺序执行，失败的停止函数不影响后续执行
+	if want := []string{"server", "cache", "db"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("expect %v, got %v", want, order)
+	}
+	if !m.ShuttingDown() {
+		t.Fatal("expect ShuttingDown after Stop")
+	}
+	// 只执行一次
+	if err := m.Stop(context.Background()); err != nil || len(order) != 3 {
+		t.Fatalf("expect Stop to run once, got %v %v", err, order)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	c, err := config.NewFromString("shutdown:\n  timeout: 20\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(c)
+	ran := false
+	m.OnStop("after", func(context.Context) error {
+		ran = true
+		return nil
+	})
+	m.OnStop("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	start := time.Now()
+	err = m.Stop(context.Background())
+	if err == nil || !strings.Contains(err.Error(), ErrTimeout.Error()) {
+		t.Fatalf("expect ErrTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expect Stop to return after the timeout, took %v", elapsed)
+	}
+	// 超时后剩余的停止函数不再执行
+	if ran {
+		t.Fatal("expect the remaining hook to be skipped")
+	}
+}
+
+func TestDrainDelay(t *testing.T) {
+	c, err := config.NewFromString("shutdown:\n  drain_delay: 50ms\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(c)
+	stopped := make(chan time.Time, 1)
+	m.OnStop("server", func(context.Context) error {
+		stopped <- time.Now()
+		return nil
+	})
+	start := time.Now()
+	go m.Shutdown(context.Background())
+	deadline := time.Now().Add(3 * time.Second)
+	for !m.ShuttingDown() {
+		if time.Now().After(deadline) {
+			t.Fatal("expect ShuttingDown during drain")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case at := <-stopped:
+		if at.Sub(start) < 50*time.Millisecond {
+			t.Fatalf("expect hooks to run after drain_delay, ran after %v", at.Sub(start))
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expect the hook to run")
+	}
+}
+
+func TestDuration(t *testing.T) {
+	c, err := config.NewFromString("a: 1500\nb: 2s\nc: bad\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(c)
+	for key, want := range map[string]time.Duration{"a": 1500 * time.Millisecond, "b": 2 * time.Second, "c": time.Second, "d": time.Second} {
+		if got := m.duration(key, time.Second); got != want {
+			t.Errorf("%s: expect %v, got %v", key, want, got)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Package metrics 指标采集
+// 提供counter、gauge、histogram，按照框架配置的 metrics 配置段通过prometheus接口暴露或定时推送到StatsD：
+//
+//	metrics:
+//	  labels: {app: goProjectTmpl}   # 所有指标的公共标签
+//	  prometheus:
+//	    addr: 127.0.0.1:9091         # 为空时不监听，可以将 metrics.Handler() 挂载到已有的http服务
+//	    path: /metrics
+//	  statsd:
+//	    addr: 127.0.0.1:8125         # 为空时不推送
+//	    prefix: app.
+//	  push_interval: 10000           # 毫秒
+//
+//	orders := metrics.NewCounter("orders_total", "Number of orders.")
+//	orders.Inc("channel", "web")
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 指标类型
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultBuckets histogram默认的分桶上限，单位为秒
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// series 一组标签对应的指标值
+type series struct {
+	labels []string
+	value  float64
+	// histogram的分桶计数、总和与总数
+	counts []uint64
+	sum    float64
+	count  uint64
+	// pushed 上次推送到StatsD时的值，用于计算counter的增量
+	pushed      float64
+	pushedSum   float64
+	pushedCount uint64
+}
+
+// metric 同名的全部指标
+type metric struct {
+	name    string
+	help    string
+	typ     string
+	buckets []float64
+
+	lock   sync.Mutex
+	series map[string]*series
+}
+
+// get 获取labels对应的指标值，labels为成对的标签名和标签值，调用方需要持有锁
+func (m *metric) get(labels []string) *series {
+	id := strings.Join(labels, "\xff")
+	s, ok := m.series[id]
+	if !ok {
+		s = &series{labels: append([]string(nil), labels...)}
+		if m.typ == TypeHistogram {
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[id] = s
+	}
+	return s
+}
+
+// Registry 指标集合
+type Registry struct {
+	lock    sync.RWMutex
+	metrics map[string]*metric
+}
+
+// NewRegistry 创建指标集合
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]*metric)}
+}
+
+// DefaultRegistry 默认的指标集合
+var DefaultRegistry = NewRegistry()
+
+// register 注册指标，同名指标已存在时返回已存在的指标
+func (r *Registry) register(name, help, typ string, buckets []float64) *metric {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if m, ok := r.metrics[name]; ok {
+		return m
+	}
+	m := &metric{name: name, help: help, typ: typ, buckets: buckets, series: make(map[string]*series)}
+	r.metrics[name] = m
+	return m
+}
+
+// sorted 按照名字排序的全部指标
+func (r *Registry) sorted() []*metric {
+	r.lock.RLock()
+	out := make([]*metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		out = append(out, m)
+	}
+	r.lock.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+// Counter 只增不减的指标
+type Counter struct {
+	m *metric
+}
+
+// NewCounter 在r中注册counter
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return &Counter{m: r.register(name, help, TypeCounter, nil)}
+}
+
+// Add 累加v，labels为成对的标签名和标签值，v为负数时忽略
+func (c *Counter) Add(v float64, labels ...string) {
+	if v < 0 {
+		return
+	}
+	c.m.lock.Lock()
+	c.m.get(labels).value += v
+	c.m.lock.Unlock()
+}
+
+// Inc 加一
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Gauge 可增可减的指标
+type Gauge struct {
+	m *metric
+}
+
+// NewGauge 在r中注册gauge
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return &Gauge{m: r.register(name, help, TypeGauge, nil)}
+}
+
+// Set 设置为v
+func (g *Gauge) Set(v float64, labels ...string) {
+	g.m.lock.Lock()
+	g.m.get(labels).value = v
+	g.m.lock.Unlock()
+}
+
+// Add 累加v
+func (g *Gauge) Add(v float64, labels ...string) {
+	g.m.lock.Lock()
+	g.m.get(labels).value += v
+	g.m.lock.Unlock()
+}
+
+// Histogram 分桶统计的指标
+type Histogram struct {
+	m *metric
+}
+
+// NewHistogram 在r中注册histogram，buckets为空时使用 DefaultBuckets
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Histogram{m: r.register(name, help, TypeHistogram, buckets)}
+}
+
+// Observe 记录一次观测值
+func (h *Histogram) Observe(v float64, labels ...string) {
+	h.m.lock.Lock()
+	s := h.m.get(labels)
+	for i, b := range h.m.buckets {
+		if v <= b {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+	h.m.lock.Unlock()
+}
+
+// NewCounter 在默认指标集合中注册counter
+func NewCounter(name, help string) *Counter {
+	return DefaultRegistry.NewCounter(name, help)
+}
+
+// NewGauge 在默认指标集合中注册gauge
+func NewGauge(name, help string) *Gauge {
+	return DefaultRegistry.NewGauge(name, help)
+}
+
+// NewHistogram 在默认指标集合中注册histogram
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return DefaultRegistry.NewHistogram(name, help, buckets)
+}
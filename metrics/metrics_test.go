@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config/configtest"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	r := NewRegistry()
+	orders := r.NewCounter("orders_total", "Number of orders.")
+	orders.Inc("channel", "web")
+	orders.Add(2, "channel", "web")
+	orders.Add(-1, "channel", "web")
+	r.NewGauge("queue_size", "Queue size.").Set(3)
+	latency := r.NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1})
+	latency.Observe(0.05)
+	latency.Observe(0.5)
+	// 同名指标返回已存在的指标
+	r.NewCounter("orders_total", "").Inc("channel", "web")
+
+	var buf bytes.Buffer
+	r.WritePrometheus(&buf)
+	want := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 2
+latency_seconds_sum 0.55
+latency_seconds_count 2
+# HELP orders_total Number of orders.
+# TYPE orders_total counter
+orders_total{channel="web"} 4
+# HELP queue_size Queue size.
+# TYPE queue_size gauge
+queue_size 3
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected output:\n%s", got)
+	}
+}
+
+// listenUDP 监听StatsD推送，返回地址和读取下一个包的函数
+func listenUDP(t *testing.T) (string, func() string) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String(), func() string {
+		buf := make([]byte, 65536)
+		conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("expect a statsd packet: %v", err)
+		}
+		return string(buf[:n])
+	}
+}
+
+func TestPushStatsD(t *testing.T) {
+	addr, read := listenUDP(t)
+	r := NewRegistry()
+	c := r.NewCounter("requests", "")
+	c.Add(3, "code", "200")
+	r.NewGauge("conns", "").Set(5)
+	h := r.NewHistogram("latency", "", nil)
+	h.Observe(0.2)
+
+	if err := r.PushStatsD(addr, "app."); err != nil {
+		t.Fatal(err)
+	}
+	want := "app.conns:5|g\napp.latency.count:1|c\napp.latency.sum:0.2|c\napp.requests:3|c|#code:200"
+	if got := read(); got != want {
+		t.Fatalf("unexpected packet:\n%s", got)
+	}
+
+	// counter推送增量，没有变化时不推送
+	c.Add(2, "code", "200")
+	if err := r.PushStatsD(addr, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(); got != "conns:5|g\nrequests:2|c|#code:200" {
+		t.Fatalf("unexpected packet:\n%s", got)
+	}
+}
+
+func TestPacketSize(t *testing.T) {
+	addr, read := listenUDP(t)
+	r := NewRegistry()
+	g := r.NewGauge("g", "")
+	for i := 0; i < 200; i++ {
+		g.Set(float64(i), "id", fmt.Sprintf("%03d", i))
+	}
+	if err := r.PushStatsD(addr, ""); err != nil {
+		t.Fatal(err)
+	}
+	lines := 0
+	for lines < 200 {
+		packet := read()
+		if len(packet) > maxPacketSize {
+			t.Fatalf("expect packets within %d bytes, got %d", maxPacketSize, len(packet))
+		}
+		lines += strings.Count(packet, "\n") + 1
+	}
+}
+
+func TestReporter(t *testing.T) {
+	defer SetLabels(nil)
+	addr, read := listenUDP(t)
+	c, p := configtest.Load(t, fmt.Sprintf("metrics:\n  labels: {app: a}\n  statsd:\n    addr: %s\n  push_interval: 3600000\n", addr))
+	m := NewReporter()
+	if err := m.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	gauge := NewGauge("reporter_test_gauge", "")
+	gauge.Set(1)
+
+	// 推送间隔和公共标签重新加载后立即生效
+	p.Update(configtest.DefaultPath, []byte(fmt.Sprintf("metrics:\n  labels: {app: b}\n  statsd:\n    addr: %s\n  push_interval: 10\n", addr)))
+	m.Reload(c, nil)
+	if got := read(); !strings.Contains(got, "reporter_test_gauge:1|g|#app:b") {
+		t.Fatalf("expect the gauge with the new label, got:\n%s", got)
+	}
+
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,176 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中指标配置段的key
+const SectionKey = "metrics"
+
+// Config 指标配置
+type Config struct {
+	// Labels 所有指标的公共标签
+	Labels     map[string]string `yaml:"labels"`
+	Prometheus struct {
+		// Addr 监听地址，为空时不监听
+		Addr string `yaml:"addr"`
+		Path string `yaml:"path" default:"/metrics"`
+	} `yaml:"prometheus"`
+	StatsD struct {
+		// Addr StatsD地址，为空时不推送
+		Addr   string `yaml:"addr"`
+		Prefix string `yaml:"prefix"`
+	} `yaml:"statsd"`
+	// PushInterval 推送到StatsD的间隔，单位毫秒
+	PushInterval int `yaml:"push_interval" default:"10000" validate:"min=1"`
+}
+
+// Reporter 按照 metrics 配置段暴露和推送默认指标集合，实现了app的Module、Starter、Stopper、Reloader接口
+// 公共标签、StatsD地址和推送间隔重新加载配置后立即生效，prometheus监听地址变化需要重启服务
+type Reporter struct {
+	lock sync.Mutex
+	cfg  *Config
+	srv  *http.Server
+	// reset 推送配置变化时通知推送协程
+	reset chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewReporter 创建Reporter
+func NewReporter() *Reporter {
+	return &Reporter{reset: make(chan struct{}, 1), done: make(chan struct{})}
+}
+
+// Name 模块名
+func (r *Reporter) Name() string {
+	return "metrics"
+}
+
+// Init 解析 metrics 配置段并设置公共标签
+func (r *Reporter) Init(c config.Config) error {
+	cfg, err := parse(c)
+	if err != nil {
+		return err
+	}
+	SetLabels(cfg.Labels)
+	r.lock.Lock()
+	r.cfg = cfg
+	r.lock.Unlock()
+	return nil
+}
+
+func parse(c config.Config) (*Config, error) {
+	cfg := &Config{PushInterval: 10000}
+	cfg.Prometheus.Path = "/metrics"
+	if !c.IsSet(SectionKey) {
+		return cfg, nil
+	}
+	if err := c.UnmarshalKey(SectionKey, cfg); err != nil {
+		return nil, fmt.Errorf("app/metrics: failed to parse %s: %v", SectionKey, err)
+	}
+	return cfg, nil
+}
+
+// Start 监听prometheus接口并启动StatsD推送协程
+func (r *Reporter) Start() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if addr := r.cfg.Prometheus.Addr; addr != "" {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("app/metrics: %v", err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle(r.cfg.Prometheus.Path, Handler())
+		r.srv = &http.Server{Handler: mux}
+		go func() {
+			if err := r.srv.Serve(l); err != nil && err != http.ErrServerClosed {
+				config.Errorf("app/metrics: prometheus endpoint stopped: %v", err)
+			}
+		}()
+		config.Infof("app/metrics: serving prometheus metrics on %s%s", l.Addr(), r.cfg.Prometheus.Path)
+	}
+	r.wg.Add(1)
+	go r.push()
+	return nil
+}
+
+// push 按照当前配置定时推送到StatsD
+func (r *Reporter) push() {
+	defer r.wg.Done()
+	for {
+		r.lock.Lock()
+		cfg := r.cfg
+		r.lock.Unlock()
+
+		var tick <-chan time.Time
+		var timer *time.Timer
+		if cfg.StatsD.Addr != "" {
+			timer = time.NewTimer(time.Duration(cfg.PushInterval) * time.Millisecond)
+			tick = timer.C
+		}
+		select {
+		case <-r.done:
+			if timer != nil {
+				timer.Stop()
+			}
+			if cfg.StatsD.Addr != "" {
+				r.pushOnce(cfg)
+			}
+			return
+		case <-r.reset:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-tick:
+			r.pushOnce(cfg)
+		}
+	}
+}
+
+func (r *Reporter) pushOnce(cfg *Config) {
+	if err := DefaultRegistry.PushStatsD(cfg.StatsD.Addr, cfg.StatsD.Prefix); err != nil {
+		config.Errorf("app/metrics: failed to push to statsd %s: %v", cfg.StatsD.Addr, err)
+	}
+}
+
+// Reload 更新公共标签和StatsD推送配置
+func (r *Reporter) Reload(c config.Config, changes []config.Change) {
+	cfg, err := parse(c)
+	if err != nil {
+		config.Errorf("app/metrics: failed to reload, keep using the old config: %v", err)
+		return
+	}
+	SetLabels(cfg.Labels)
+	r.lock.Lock()
+	if cfg.Prometheus != r.cfg.Prometheus {
+		config.Warnf("app/metrics: %s.prometheus changed, restart required", SectionKey)
+	}
+	r.cfg = cfg
+	r.lock.Unlock()
+	select {
+	case r.reset <- struct{}{}:
+	default:
+	}
+}
+
+// Stop 推送最后一次指标并停止prometheus接口
+func (r *Reporter) Stop(ctx context.Context) error {
+	close(r.done)
+	r.wg.Wait()
+	r.lock.Lock()
+	srv := r.srv
+	r.lock.Unlock()
+	if srv != nil {
+		return srv.Shutdown(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"goProjectTmpl/config"
+)
+
+// globalLabels 所有指标的公共标签，类型为 map[string]string，配置重新加载后替换
+var globalLabels atomic.Value
+
+func init() {
+	globalLabels.Store(map[string]string{})
+}
+
+// SetLabels 设置所有指标的公共标签
+func SetLabels(labels map[string]string) {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	globalLabels.Store(copied)
+}
+
+// labelSet 合并公共标签和指标自身的标签，指标自身的标签优先
+func labelSet(pairs []string, extra ...string) map[string]string {
+	global := globalLabels.Load().(map[string]string)
+	out := make(map[string]string, len(global)+len(pairs)/2+len(extra)/2)
+	for k, v := range global {
+		out[k] = v
+	}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		out[pairs[i]] = pairs[i+1]
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		out[extra[i]] = extra[i+1]
+	}
+	return out
+}
+
+// formatLabels 按照prometheus文本格式输出标签
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// WritePrometheus 按照prometheus文本格式输出r中的全部指标
+func (r *Registry) WritePrometheus(w io.Writer) {
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+	for _, m := range r.sorted() {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.typ)
+		m.lock.Lock()
+		ids := make([]string, 0, len(m.series))
+		for id := range m.series {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			s := m.series[id]
+			if m.typ != TypeHistogram {
+				fmt.Fprintf(bw, "%s%s %s\n", m.name, formatLabels(labelSet(s.labels)), formatFloat(s.value))
+				continue
+			}
+			for i, b := range m.buckets {
+				fmt.Fprintf(bw, "%s_bucket%s %d\n", m.name, formatLabels(labelSet(s.labels, "le", formatFloat(b))), s.counts[i])
+			}
+			fmt.Fprintf(bw, "%s_bucket%s %d\n", m.name, formatLabels(labelSet(s.labels, "le", "+Inf")), s.count)
+			fmt.Fprintf(bw, "%s_sum%s %s\n", m.name, formatLabels(labelSet(s.labels)), formatFloat(s.sum))
+			fmt.Fprintf(bw, "%s_count%s %d\n", m.name, formatLabels(labelSet(s.labels)), s.count)
+		}
+		m.lock.Unlock()
+	}
+}
+
+// Handler 以prometheus文本格式输出默认指标集合和配置组件的指标
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		DefaultRegistry.WritePrometheus(w)
+		config.MetricsHandler().ServeHTTP(w, r)
+	})
+}
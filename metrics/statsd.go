@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+)
+
+// maxPacketSize 单个UDP包的最大字节数，超过时拆分为多个包
+const maxPacketSize = 1432
+
+// PushStatsD 将r中的指标以DogStatsD格式推送到addr，标签作为tag
+// counter和histogram推送上次推送以来的增量，gauge推送当前值
+func (r *Registry) PushStatsD(addr, prefix string) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return fmt.Errorf("app/metrics: %v", err)
+	}
+	defer conn.Close()
+
+	var buf bytes.Buffer
+	flush := func() error {
+		if buf.Len() == 0 {
+			return nil
+		}
+		_, err := conn.Write(buf.Bytes())
+		buf.Reset()
+		return err
+	}
+	write := func(line string) error {
+		if buf.Len() > 0 && buf.Len()+1+len(line) > maxPacketSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(line)
+		return nil
+	}
+
+	for _, m := range r.sorted() {
+		var lines []string
+		m.lock.Lock()
+		for _, s := range m.series {
+			tags := formatTags(labelSet(s.labels))
+			switch m.typ {
+			case TypeCounter:
+				if d := s.value - s.pushed; d > 0 {
+					lines = append(lines, fmt.Sprintf("%s%s:%s|c%s", prefix, m.name, formatFloat(d), tags))
+				}
+				s.pushed = s.value
+			case TypeGauge:
+				lines = append(lines, fmt.Sprintf("%s%s:%s|g%s", prefix, m.name, formatFloat(s.value), tags))
+			case TypeHistogram:
+				if d := s.count - s.pushedCount; d > 0 {
+					lines = append(lines,
+						fmt.Sprintf("%s%s.count:%d|c%s", prefix, m.name, d, tags),
+						fmt.Sprintf("%s%s.sum:%s|c%s", prefix, m.name, formatFloat(s.sum-s.pushedSum), tags))
+				}
+				s.pushedCount, s.pushedSum = s.count, s.sum
+			}
+		}
+		m.lock.Unlock()
+		sort.Strings(lines)
+		for _, line := range lines {
+			if err := write(line); err != nil {
+				return fmt.Errorf("app/metrics: %v", err)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return fmt.Errorf("app/metrics: %v", err)
+	}
+	return nil
+}
+
+// formatTags 按照DogStatsD格式输出标签
+func formatTags(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b bytes.Buffer
+	b.WriteString("|#")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k + ":" + labels[k])
+	}
+	return b.String()
+}
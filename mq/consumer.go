@@ -0,0 +1,147 @@
+package mq
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// consumer 一个消费组，单个协程拉取消息，concurrency 个协程处理消息
+type consumer struct {
+	name    string
+	sub     Subscription
+	handler Handler
+	// opts 当前的配置，类型为 *ConsumerOptions
+	opts atomic.Value
+
+	msgs chan *Message
+	// quit 每收到一个信号减少一个处理协程
+	quit chan struct{}
+	// ctx 拉取协程的ctx，停止时结束
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock    sync.Mutex
+	workers int
+	wg      sync.WaitGroup
+}
+
+func newConsumer(name string, sub Subscription, h Handler, o *ConsumerOptions) *consumer {
+	c := &consumer{name: name, sub: sub, handler: h, msgs: make(chan *Message), quit: make(chan struct{})}
+	c.opts.Store(o)
+	return c
+}
+
+func (c *consumer) options() *ConsumerOptions {
+	return c.opts.Load().(*ConsumerOptions)
+}
+
+// start 启动拉取协程和处理协程
+func (c *consumer) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.lock.Lock()
+	c.ctx, c.cancel = ctx, cancel
+	c.lock.Unlock()
+	c.wg.Add(1)
+	go c.fetch(ctx)
+	c.resize(c.options().Concurrency)
+}
+
+// fetch 拉取消息直到ctx结束，之后关闭消息通道，处理协程处理完已拉取的消息后退出
+func (c *consumer) fetch(ctx context.Context) {
+	defer c.wg.Done()
+	defer close(c.msgs)
+	for {
+		m, err := c.sub.Fetch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			config.Errorf("app/mq: %s failed to fetch: %v", c.name, err)
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		select {
+		case c.msgs <- m:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// resize 将处理协程数调整为n，停止后处理协程会自行退出，不再等待减少的信号被接收
+func (c *consumer) resize(n int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for ; c.workers < n; c.workers++ {
+		c.wg.Add(1)
+		go c.work()
+	}
+	for ; c.workers > n; c.workers-- {
+		go func(ctx context.Context) {
+			select {
+			case c.quit <- struct{}{}:
+			case <-ctx.Done():
+			}
+		}(c.ctx)
+	}
+}
+
+func (c *consumer) work() {
+	defer c.wg.Done()
+	for {
+		select {
+		case m, ok := <-c.msgs:
+			if !ok {
+				return
+			}
+			c.process(m)
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// process 按照当前的重试策略处理消息，处理完成或重试耗尽后提交位点
+func (c *consumer) process(m *Message) {
+	ctx := context.Background()
+	p := c.options().Retry
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = c.handler(ctx, m); err == nil || attempt >= p.MaxAttempts {
+			break
+		}
+		time.Sleep(p.delay(attempt))
+	}
+	if err != nil {
+		config.Errorf("app/mq: %s dropped a message of %s after %d attempt(s): %v", c.name, m.Topic, p.MaxAttempts, err)
+	}
+	if err := c.sub.Commit(ctx, m); err != nil {
+		config.Errorf("app/mq: %s failed to commit: %v", c.name, err)
+	}
+}
+
+// stop 停止拉取消息，等待已拉取的消息处理完成直到ctx结束，之后关闭订阅
+func (c *consumer) stop(ctx context.Context) error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		config.Warnf("app/mq: %s stopped before in-flight messages were processed", c.name)
+	}
+	return c.sub.Close()
+}
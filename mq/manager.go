@@ -0,0 +1,224 @@
+package mq
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"goProjectTmpl/config"
+)
+
+// Config mq 配置段
+type Config struct {
+	Producers map[string]*ProducerOptions `yaml:"producers"`
+	Consumers map[string]*ConsumerOptions `yaml:"consumers"`
+}
+
+// Manager 管理 mq 配置段中的生产者和消费组，实现了app的Module、Starter、Stopper、Reloader接口
+type Manager struct {
+	lock      sync.RWMutex
+	handlers  map[string]Handler
+	producers map[string]Producer
+	consumers map[string]*consumer
+	cfg       *Config
+}
+
+// NewManager 创建Manager
+func NewManager() *Manager {
+	return &Manager{handlers: make(map[string]Handler), producers: make(map[string]Producer), consumers: make(map[string]*consumer)}
+}
+
+// DefaultManager 默认的Manager
+var DefaultManager = NewManager()
+
+// Handle 注册消费组的处理函数，需要在 Init 之前调用
+func (m *Manager) Handle(consumer string, h Handler) {
+	m.lock.Lock()
+	m.handlers[consumer] = h
+	m.lock.Unlock()
+}
+
+// Name 模块名
+func (m *Manager) Name() string {
+	return "mq"
+}
+
+// Init 创建全部生产者并订阅全部消费组，任意一个失败时关闭已创建的生产者和订阅并返回错误
+func (m *Manager) Init(c config.Config) error {
+	cfg, err := parse(c)
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	producers := make(map[string]Producer, len(cfg.Producers))
+	consumers := make(map[string]*consumer, len(cfg.Consumers))
+	fail := func(err error) error {
+		for _, p := range producers {
+			p.Close()
+		}
+		for _, c := range consumers {
+			c.sub.Close()
+		}
+		return err
+	}
+	for _, name := range sortedNames(cfg.Producers) {
+		o := cfg.Producers[name]
+		d := GetDriver(o.Driver)
+		if d == nil {
+			return fail(fmt.Errorf("app/mq: producer %s: driver %s not registered", name, o.Driver))
+		}
+		p, err := d.NewProducer(name, o)
+		if err != nil {
+			return fail(fmt.Errorf("app/mq: producer %s: %v", name, err))
+		}
+		producers[name] = p
+	}
+	for _, name := range sortedNames(cfg.Consumers) {
+		o := cfg.Consumers[name]
+		h, ok := m.handlers[name]
+		if !ok {
+			return fail(fmt.Errorf("app/mq: consumer %s: %v", name, ErrNoHandler))
+		}
+		d := GetDriver(o.Driver)
+		if d == nil {
+			return fail(fmt.Errorf("app/mq: consumer %s: driver %s not registered", name, o.Driver))
+		}
+		sub, err := d.Subscribe(name, o)
+		if err != nil {
+			return fail(fmt.Errorf("app/mq: consumer %s: %v", name, err))
+		}
+		consumers[name] = newConsumer(name, sub, h, o)
+	}
+	m.cfg, m.producers, m.consumers = cfg, producers, consumers
+	return nil
+}
+
+// parse 解析 mq 配置段
+func parse(c config.Config) (*Config, error) {
+	cfg := &Config{}
+	if c.IsSet(SectionKey) {
+		if err := c.UnmarshalKey(SectionKey, cfg); err != nil {
+			return nil, fmt.Errorf("app/mq: failed to parse %s: %v", SectionKey, err)
+		}
+	}
+	return cfg, nil
+}
+
+// sortedNames 按照名字排序，保证创建顺序稳定
+func sortedNames(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		names = append(names, k.String())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start 开始消费
+func (m *Manager) Start() error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	for _, c := range m.consumers {
+		c.start()
+		config.Infof("app/mq: consumer %s started with %d worker(s)", c.name, c.options().Concurrency)
+	}
+	return nil
+}
+
+// Producer 获取名字对应的生产者，不存在时返回nil
+func (m *Manager) Producer(name string) Producer {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.producers[name]
+}
+
+// Reload 更新消费组的并发数和重试策略，其他变化需要重启服务
+func (m *Manager) Reload(c config.Config, changes []config.Change) {
+	cfg, err := parse(c)
+	if err != nil {
+		config.Errorf("app/mq: failed to reload, keep using the old config: %v", err)
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.cfg == nil {
+		return
+	}
+	for name, o := range m.cfg.Producers {
+		if !reflect.DeepEqual(o, cfg.Producers[name]) {
+			config.Warnf("app/mq: producer %s changed, restart required", name)
+		}
+	}
+	for name := range cfg.Producers {
+		if _, ok := m.cfg.Producers[name]; !ok {
+			config.Warnf("app/mq: producer %s added, restart required", name)
+		}
+	}
+	for name, c := range m.consumers {
+		o, ok := cfg.Consumers[name]
+		if !ok {
+			config.Warnf("app/mq: consumer %s removed, restart required", name)
+			continue
+		}
+		old := c.options()
+		if restartRequired(old, o) {
+			config.Warnf("app/mq: consumer %s driver, brokers, topics or group changed, restart required", name)
+		}
+		live := *old
+		live.Concurrency, live.Retry = o.Concurrency, o.Retry
+		c.opts.Store(&live)
+		if live.Concurrency != old.Concurrency {
+			c.resize(live.Concurrency)
+			config.Infof("app/mq: consumer %s concurrency changed from %d to %d", name, old.Concurrency, live.Concurrency)
+		}
+	}
+	for name := range cfg.Consumers {
+		if _, ok := m.consumers[name]; !ok {
+			config.Warnf("app/mq: consumer %s added, restart required", name)
+		}
+	}
+}
+
+// restartRequired 修改后需要重启服务的配置是否变化
+func restartRequired(old, o *ConsumerOptions) bool {
+	return old.Driver != o.Driver || old.Group != o.Group ||
+		!reflect.DeepEqual(old.Brokers, o.Brokers) || !reflect.DeepEqual(old.Topics, o.Topics) ||
+		!reflect.DeepEqual(old.Extra, o.Extra)
+}
+
+// Stop 停止全部消费组，等待已拉取的消息处理完成，之后关闭全部生产者
+func (m *Manager) Stop(ctx context.Context) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	var wg sync.WaitGroup
+	errs := make(chan error, len(m.consumers)+len(m.producers))
+	for _, c := range m.consumers {
+		wg.Add(1)
+		go func(c *consumer) {
+			defer wg.Done()
+			errs <- c.stop(ctx)
+		}(c)
+	}
+	wg.Wait()
+	for _, p := range m.producers {
+		errs <- p.Close()
+	}
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetProducer 获取默认Manager中名字对应的生产者
+func GetProducer(name string) Producer {
+	return DefaultManager.Producer(name)
+}
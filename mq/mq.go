@@ -0,0 +1,140 @@
+// Package mq 根据框架配置中的 mq 配置段创建消息队列的生产者和消费组
+// 为了不引入具体的kafka、nats库，生产者和订阅由按照 driver 注册的 Driver 创建：
+//
+//	mq:
+//	  producers:
+//	    orders:
+//	      driver: kafka
+//	      brokers: [127.0.0.1:9092]
+//	      topic: orders
+//	  consumers:
+//	    order-worker:
+//	      driver: kafka
+//	      brokers: [127.0.0.1:9092]
+//	      topics: [orders]
+//	      group: order-worker
+//	      concurrency: 4        # 并发处理消息的协程数，重新加载配置后立即生效
+//	      retry:                # 处理失败时的重试，重新加载配置后立即生效
+//	        max_attempts: 3
+//	        backoff: 100        # 毫秒，每次重试翻倍
+//	        max_backoff: 5000
+//
+//	mq.RegisterDriver("kafka", myKafkaDriver{})
+//	mq.DefaultManager.Handle("order-worker", func(ctx context.Context, m *mq.Message) error { ... })
+//	app.Register(mq.DefaultManager)
+//	err := mq.GetProducer("orders").Send(ctx, &mq.Message{Key: id, Value: body})
+package mq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// SectionKey 框架配置中消息队列配置段的key
+const SectionKey = "mq"
+
+// ErrNoHandler 消费组没有注册处理函数
+var ErrNoHandler = errors.New("app/mq: no handler registered")
+
+// Message 消息
+type Message struct {
+	// Topic 发送时为空则使用生产者配置的topic
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+	// Metadata 驱动附加的信息，例如kafka的partition和offset，用于 Commit
+	Metadata interface{}
+}
+
+// Handler 消息处理函数，返回错误时按照 retry 配置重试
+type Handler func(ctx context.Context, m *Message) error
+
+// ProducerOptions 生产者配置，时间单位为毫秒，修改后需要重启服务
+type ProducerOptions struct {
+	Driver  string   `yaml:"driver" validate:"required"`
+	Brokers []string `yaml:"brokers" validate:"required"`
+	// Topic 默认的topic
+	Topic string `yaml:"topic"`
+	// Timeout 发送超时时间，0表示不限制
+	Timeout int `yaml:"timeout"`
+	// Extra 驱动自己解析的配置，例如kafka的acks、compression
+	Extra map[string]interface{} `yaml:"extra"`
+}
+
+// ConsumerOptions 消费组配置，concurrency 和 retry 重新加载配置后立即生效，其他配置修改后需要重启服务
+type ConsumerOptions struct {
+	Driver  string   `yaml:"driver" validate:"required"`
+	Brokers []string `yaml:"brokers" validate:"required"`
+	Topics  []string `yaml:"topics" validate:"required"`
+	Group   string   `yaml:"group" validate:"required"`
+	// Concurrency 并发处理消息的协程数
+	Concurrency int         `yaml:"concurrency" default:"1" validate:"min=1"`
+	Retry       RetryPolicy `yaml:"retry"`
+	// Extra 驱动自己解析的配置，例如kafka的起始位点
+	Extra map[string]interface{} `yaml:"extra"`
+}
+
+// RetryPolicy 消息处理失败时的重试策略，时间单位为毫秒，重试耗尽后记录错误并提交位点
+type RetryPolicy struct {
+	// MaxAttempts 最多处理次数，包括第一次
+	MaxAttempts int `yaml:"max_attempts" default:"1" validate:"min=1"`
+	// Backoff 第一次重试前的等待时间，之后每次翻倍
+	Backoff    int `yaml:"backoff" default:"100" validate:"min=0"`
+	MaxBackoff int `yaml:"max_backoff" default:"5000" validate:"min=0"`
+}
+
+// delay 第attempt次处理失败后的等待时间，attempt从1开始
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := time.Duration(p.Backoff) * time.Millisecond
+	max := time.Duration(p.MaxBackoff) * time.Millisecond
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if max > 0 && d > max {
+		d = max
+	}
+	return d
+}
+
+// Producer 生产者
+type Producer interface {
+	Send(ctx context.Context, m *Message) error
+	Close() error
+}
+
+// Subscription 驱动创建的消费组订阅
+type Subscription interface {
+	// Fetch 阻塞直到收到消息，ctx结束时返回ctx的错误
+	Fetch(ctx context.Context) (*Message, error)
+	// Commit 消息处理完成后提交位点，重试耗尽的消息同样会提交
+	Commit(ctx context.Context, m *Message) error
+	Close() error
+}
+
+// Driver 消息队列驱动，例如kafka、nats
+type Driver interface {
+	NewProducer(name string, o *ProducerOptions) (Producer, error)
+	Subscribe(name string, o *ConsumerOptions) (Subscription, error)
+}
+
+var (
+	drivers    = make(map[string]Driver)
+	driverLock sync.RWMutex
+)
+
+// RegisterDriver 注册驱动
+func RegisterDriver(name string, d Driver) {
+	driverLock.Lock()
+	drivers[name] = d
+	driverLock.Unlock()
+}
+
+// GetDriver 获取驱动
+func GetDriver(name string) Driver {
+	driverLock.RLock()
+	defer driverLock.RUnlock()
+	return drivers[name]
+}
@@ -0,0 +1,214 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config/configtest"
+)
+
+// memSub 内存中的订阅，消息从msgs读取
+type memSub struct {
+	msgs    chan *Message
+	commits int32
+	closed  int32
+}
+
+func (s *memSub) Fetch(ctx context.Context) (*Message, error) {
+	select {
+	case m := <-s.msgs:
+		return m, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *memSub) Commit(context.Context, *Message) error {
+	atomic.AddInt32(&s.commits, 1)
+	return nil
+}
+
+func (s *memSub) Close() error {
+	atomic.StoreInt32(&s.closed, 1)
+	return nil
+}
+
+type memProducer struct {
+	closed int32
+}
+
+func (p *memProducer) Send(context.Context, *Message) error { return nil }
+
+func (p *memProducer) Close() error {
+	atomic.StoreInt32(&p.closed, 1)
+	return nil
+}
+
+// memDriver 记录创建的生产者和订阅
+type memDriver struct {
+	lock      sync.Mutex
+	subs      map[string]*memSub
+	producers map[string]*memProducer
+}
+
+func newMemDriver(name string) *memDriver {
+	d := &memDriver{subs: make(map[string]*memSub), producers: make(map[string]*memProducer)}
+	RegisterDriver(name, d)
+	return d
+}
+
+func (d *memDriver) NewProducer(name string, _ *ProducerOptions) (Producer, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	p := &memProducer{}
+	d.producers[name] = p
+	return p, nil
+}
+
+func (d *memDriver) Subscribe(name string, _ *ConsumerOptions) (Subscription, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	s := &memSub{msgs: make(chan *Message)}
+	d.subs[name] = s
+	return s, nil
+}
+
+const mqYAML = `mq:
+  producers:
+    orders:
+      driver: %s
+      brokers: [127.0.0.1:9092]
+  consumers:
+    worker:
+      driver: %s
+      brokers: [127.0.0.1:9092]
+      topics: [orders]
+      group: worker
+      concurrency: %d
+`
+
+func mqConfig(driver string, concurrency int) string {
+	return fmt.Sprintf(mqYAML, driver, driver, concurrency)
+}
+
+// waitFor 等待cond成立，超时时报告测试失败
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestManagerResizeAndStop(t *testing.T) {
+	d := newMemDriver("mem-resize")
+	c, p := configtest.Load(t, mqConfig("mem-resize", 1))
+
+	// 处理函数阻塞到release关闭，inflight为同时处理的消息数
+	var inflight, handled int32
+	release := make(chan struct{})
+	m := NewManager()
+	m.Handle("worker", func(context.Context, *Message) error {
+		atomic.AddInt32(&inflight, 1)
+		<-release
+		atomic.AddInt32(&inflight, -1)
+		atomic.AddInt32(&handled, 1)
+		return nil
+	})
+	if err := m.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	sub := d.subs["worker"]
+
+	// 并发数从1扩大到4，4条消息同时处理
+	p.Update(configtest.DefaultPath, []byte(mqConfig("mem-resize", 4)))
+	m.Reload(c, nil)
+	for i := 0; i < 4; i++ {
+		sub.msgs <- &Message{Topic: "orders"}
+	}
+	waitFor(t, "4 messages in flight", func() bool { return atomic.LoadInt32(&inflight) == 4 })
+	close(release)
+	waitFor(t, "4 messages handled", func() bool { return atomic.LoadInt32(&handled) == 4 })
+
+	// 缩小到1后仍然可以处理消息，停止不会因为未被接收的减少信号阻塞
+	p.Update(configtest.DefaultPath, []byte(mqConfig("mem-resize", 1)))
+	m.Reload(c, nil)
+	if got := m.consumers["worker"].options().Concurrency; got != 1 {
+		t.Fatalf("expect concurrency 1, got %d", got)
+	}
+	sub.msgs <- &Message{Topic: "orders"}
+	waitFor(t, "5 messages handled", func() bool { return atomic.LoadInt32(&handled) == 5 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := m.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("expect Stop to return before the deadline")
+	}
+	if atomic.LoadInt32(&sub.commits) != 5 || atomic.LoadInt32(&sub.closed) != 1 {
+		t.Fatalf("expect 5 commits and a closed subscription, got %d commits", atomic.LoadInt32(&sub.commits))
+	}
+	if atomic.LoadInt32(&d.producers["orders"].closed) != 1 {
+		t.Fatal("expect the producer to be closed")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	d := newMemDriver("mem-retry")
+	c, _ := configtest.Load(t, mqConfig("mem-retry", 1)+"      retry:\n        max_attempts: 3\n        backoff: 1\n")
+	var attempts int32
+	m := NewManager()
+	m.Handle("worker", func(context.Context, *Message) error {
+		atomic.AddInt32(&attempts, 1)
+		return errors.New("failed")
+	})
+	if err := m.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	m.Start()
+	defer m.Stop(context.Background())
+
+	sub := d.subs["worker"]
+	sub.msgs <- &Message{Topic: "orders"}
+	// 重试耗尽后同样提交位点
+	waitFor(t, "the message to be committed", func() bool { return atomic.LoadInt32(&sub.commits) == 1 })
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("expect 3 attempts, got %d", got)
+	}
+}
+
+func TestInitWithoutHandler(t *testing.T) {
+	d := newMemDriver("mem-nohandler")
+	c, _ := configtest.Load(t, mqConfig("mem-nohandler", 1))
+	err := NewManager().Init(c)
+	if err == nil || !strings.Contains(err.Error(), ErrNoHandler.Error()) {
+		t.Fatalf("expect ErrNoHandler, got %v", err)
+	}
+	// 已创建的生产者被关闭
+	if atomic.LoadInt32(&d.producers["orders"].closed) != 1 {
+		t.Fatal("expect the producer to be closed")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, Backoff: 100, MaxBackoff: 300}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 4: 300 * time.Millisecond} {
+		if got := p.delay(attempt); got != want {
+			t.Errorf("attempt %d: expect %v, got %v", attempt, want, got)
+		}
+	}
+}
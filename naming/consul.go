@@ -0,0 +1,140 @@
+package naming
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// consul 通过consul agent的HTTP接口注册和发现服务，实例使用TTL健康检查
+type consul struct {
+	base   string
+	token  string
+	ttl    time.Duration
+	client *http.Client
+}
+
+func newConsul(cfg *Config) (Backend, error) {
+	addr := cfg.Address
+	if addr == "" {
+		addr = "127.0.0.1:8500"
+	}
+	if u, err := url.Parse(addr); err != nil || u.Scheme == "" || u.Host == "" {
+		addr = "http://" + addr
+	}
+	return &consul{
+		base:   addr,
+		token:  cfg.Token,
+		ttl:    time.Duration(cfg.TTL) * time.Millisecond,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+// do 调用consul接口，out不为nil时解码响应
+func (c *consul) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, c.base+path, &body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("consul %s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(msg))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func (c *consul) Register(ctx context.Context, ins *Instance) error {
+	host, port, err := net.SplitHostPort(ins.Address)
+	if err != nil {
+		return err
+	}
+	p, _ := strconv.Atoi(port)
+	reg := map[string]interface{}{
+		"ID":      ins.ID,
+		"Name":    ins.Service,
+		"Address": host,
+		"Port":    p,
+		"Meta":    ins.Metadata,
+		"Check": map[string]interface{}{
+			"CheckID":                        checkID(ins),
+			"TTL":                            c.ttl.String(),
+			"DeregisterCriticalServiceAfter": (c.ttl * 10).String(),
+		},
+	}
+	if err := c.do(ctx, http.MethodPut, "/v1/agent/service/register", reg, nil); err != nil {
+		return err
+	}
+	return c.Heartbeat(ctx, ins)
+}
+
+func (c *consul) Deregister(ctx context.Context, ins *Instance) error {
+	return c.do(ctx, http.MethodPut, "/v1/agent/service/deregister/"+url.PathEscape(ins.ID), nil, nil)
+}
+
+func (c *consul) Heartbeat(ctx context.Context, ins *Instance) error {
+	return c.do(ctx, http.MethodPut, "/v1/agent/check/pass/"+url.PathEscape(checkID(ins)), nil, nil)
+}
+
+func checkID(ins *Instance) string {
+	return "service:" + ins.ID
+}
+
+func (c *consul) Resolve(ctx context.Context, service string) ([]*Instance, error) {
+	var entries []struct {
+		Node struct {
+			Address string
+		}
+		Service struct {
+			ID      string
+			Service string
+			Address string
+			Port    int
+			Meta    map[string]string
+		}
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/health/service/"+url.PathEscape(service)+"?passing=true", nil, &entries); err != nil {
+		return nil, err
+	}
+	out := make([]*Instance, 0, len(entries))
+	for _, e := range entries {
+		host := e.Service.Address
+		if host == "" {
+			host = e.Node.Address
+		}
+		out = append(out, &Instance{
+			Service:  e.Service.Service,
+			ID:       e.Service.ID,
+			Address:  net.JoinHostPort(host, strconv.Itoa(e.Service.Port)),
+			Metadata: e.Service.Meta,
+		})
+	}
+	if len(out) == 0 {
+		return nil, ErrNotFound
+	}
+	return out, nil
+}
@@ -0,0 +1,63 @@
+package naming
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+)
+
+// errReadOnly 后端不支持注册实例
+var errReadOnly = errors.New("app/naming: backend does not support registration")
+
+// dnsBackend 通过DNS发现服务，优先使用SRV记录，没有SRV记录时使用A/AAAA记录和配置的端口
+type dnsBackend struct {
+	resolver *net.Resolver
+	port     int
+}
+
+func newDNS(cfg *Config) (Backend, error) {
+	r := net.DefaultResolver
+	if cfg.Address != "" {
+		// 使用指定的DNS服务器
+		addr := cfg.Address
+		r = &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		}}
+	}
+	return &dnsBackend{resolver: r, port: cfg.DNSPort}, nil
+}
+
+func (d *dnsBackend) Register(context.Context, *Instance) error {
+	return errReadOnly
+}
+
+func (d *dnsBackend) Deregister(context.Context, *Instance) error {
+	return errReadOnly
+}
+
+func (d *dnsBackend) Resolve(ctx context.Context, service string) ([]*Instance, error) {
+	var out []*Instance
+	if _, srvs, err := d.resolver.LookupSRV(ctx, "", "", service); err == nil {
+		for _, srv := range srvs {
+			addr := net.JoinHostPort(srv.Target, strconv.Itoa(int(srv.Port)))
+			out = append(out, &Instance{Service: service, ID: service + "-" + addr, Address: addr})
+		}
+		if len(out) > 0 {
+			return out, nil
+		}
+	}
+	hosts, err := d.resolver.LookupHost(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hosts {
+		addr := net.JoinHostPort(h, strconv.Itoa(d.port))
+		out = append(out, &Instance{Service: service, ID: service + "-" + addr, Address: addr})
+	}
+	if len(out) == 0 {
+		return nil, ErrNotFound
+	}
+	return out, nil
+}
@@ -0,0 +1,188 @@
+package naming
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/server"
+)
+
+var (
+	resolver     Resolver
+	resolverLock sync.RWMutex
+)
+
+// Resolve 使用已初始化的 Module 的后端发现服务
+func Resolve(ctx context.Context, service string) ([]*Instance, error) {
+	resolverLock.RLock()
+	r := resolver
+	resolverLock.RUnlock()
+	if r == nil {
+		return nil, fmt.Errorf("app/naming: no resolver initialized")
+	}
+	return r.Resolve(ctx, service)
+}
+
+// Module 按照 naming 配置段创建后端，在server启动后注册其中的service，停止前注销，实现了app的Module、Starter、Stopper接口
+// 需要在server之后注册到app，使得退出时先注销实例再停止server
+type Module struct {
+	srv *server.Server
+
+	lock      sync.Mutex
+	cfg       *Config
+	backend   Backend
+	instances []*Instance
+	done      chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewModule 创建Module，srv为nil时只提供服务发现
+func NewModule(srv *server.Server) *Module {
+	return &Module{srv: srv, done: make(chan struct{})}
+}
+
+// Name 模块名
+func (m *Module) Name() string {
+	return "naming"
+}
+
+// Backend 服务注册与发现的后端，Init 之前为nil
+func (m *Module) Backend() Backend {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.backend
+}
+
+// Init 创建后端，并作为 Resolve 使用的后端
+func (m *Module) Init(c config.Config) error {
+	b, cfg, err := New(c)
+	if err != nil {
+		return err
+	}
+	m.lock.Lock()
+	m.cfg, m.backend = cfg, b
+	m.lock.Unlock()
+	resolverLock.Lock()
+	resolver = b
+	resolverLock.Unlock()
+	return nil
+}
+
+// Start 注册server中的全部service，后端支持心跳时每 ttl/3 上报一次
+func (m *Module) Start() error {
+	if m.srv == nil {
+		return nil
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, name := range m.srv.Services() {
+		addr := m.srv.Addr(name)
+		if addr == nil {
+			continue
+		}
+		ins := &Instance{Service: name, Address: advertise(addr, m.cfg.AdvertiseIP)}
+		ins.ID = ins.Service + "-" + ins.Address
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(m.cfg.TTL)*time.Millisecond)
+		err := m.backend.Register(ctx, ins)
+		cancel()
+		if err == errReadOnly {
+			config.Warnf("app/naming: backend %s does not support registration, %s not registered", m.cfg.Backend, name)
+			return nil
+		}
+		if err != nil {
+			// 启动失败时不会调用 Stop，注销已注册的实例
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(m.cfg.TTL)*time.Millisecond)
+			m.deregisterAll(ctx)
+			cancel()
+			return fmt.Errorf("app/naming: failed to register %s: %v", ins.ID, err)
+		}
+		config.Infof("app/naming: registered %s at %s", name, ins.Address)
+		m.instances = append(m.instances, ins)
+	}
+	if h, ok := m.backend.(Heartbeater); ok && len(m.instances) > 0 {
+		m.wg.Add(1)
+		go m.heartbeat(h, append([]*Instance(nil), m.instances...), time.Duration(m.cfg.TTL)*time.Millisecond)
+	}
+	return nil
+}
+
+// heartbeat 定时上报实例存活
+func (m *Module) heartbeat(h Heartbeater, instances []*Instance, ttl time.Duration) {
+	defer m.wg.Done()
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			for _, ins := range instances {
+				ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+				if err := h.Heartbeat(ctx, ins); err != nil {
+					config.Errorf("app/naming: heartbeat of %s failed: %v", ins.ID, err)
+				}
+				cancel()
+			}
+		}
+	}
+}
+
+// Stop 停止心跳并注销全部已注册的实例，可以重复调用
+func (m *Module) Stop(ctx context.Context) error {
+	m.stopOnce.Do(func() { close(m.done) })
+	m.wg.Wait()
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.deregisterAll(ctx)
+}
+
+// deregisterAll 注销全部已注册的实例，返回第一个错误，调用方需要持有锁
+func (m *Module) deregisterAll(ctx context.Context) error {
+	var first error
+	for _, ins := range m.instances {
+		if err := m.backend.Deregister(ctx, ins); err != nil {
+			config.Errorf("app/naming: failed to deregister %s: %v", ins.ID, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	m.instances = nil
+	return first
+}
+
+// advertise 返回注册的实例地址，ip为空且监听所有地址时使用第一个非回环的IPv4地址
+func advertise(addr net.Addr, ip string) string {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	if ip != "" {
+		return net.JoinHostPort(ip, port)
+	}
+	if h := net.ParseIP(host); h != nil && h.IsUnspecified() {
+		if local := localIP(); local != "" {
+			host = local
+		}
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// localIP 第一个非回环的IPv4地址
+func localIP() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+			return ipnet.IP.String()
+		}
+	}
+	return ""
+}
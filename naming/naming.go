@@ -0,0 +1,139 @@
+// Package naming 服务注册与发现
+// 后端和TTL在框架配置的 naming 配置段中选择，内置 static、dns、consul，其他后端（例如etcd）通过 RegisterBackend 接入：
+//
+//	naming:
+//	  backend: consul
+//	  address: 127.0.0.1:8500
+//	  ttl: 10000            # 毫秒，实例每 ttl/3 上报一次心跳
+//	  static:               # backend为static时的服务列表
+//	    app.redis.business.master: [127.0.0.1:6380]
+//
+//	srv := server.New()
+//	app.Register(srv)
+//	app.Register(naming.NewModule(srv))   // server启动后注册实例，停止前注销实例
+package naming
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中服务注册与发现配置段的key
+const SectionKey = "naming"
+
+// ErrNotFound 服务没有可用的实例
+var ErrNotFound = errors.New("app/naming: service not found")
+
+// Instance 服务实例
+type Instance struct {
+	// Service 服务名
+	Service string
+	// ID 实例的唯一标识，默认为 服务名-地址
+	ID string
+	// Address 实例地址，格式为 host:port
+	Address  string
+	Metadata map[string]string
+}
+
+// Registry 服务注册
+type Registry interface {
+	Register(ctx context.Context, ins *Instance) error
+	Deregister(ctx context.Context, ins *Instance) error
+}
+
+// Heartbeater Registry的可选接口，按照TTL上报实例存活
+type Heartbeater interface {
+	Heartbeat(ctx context.Context, ins *Instance) error
+}
+
+// Resolver 服务发现
+type Resolver interface {
+	Resolve(ctx context.Context, service string) ([]*Instance, error)
+}
+
+// Backend 服务注册与发现的后端
+type Backend interface {
+	Registry
+	Resolver
+}
+
+// Config 服务注册与发现配置，时间单位为毫秒
+type Config struct {
+	Backend string `yaml:"backend" default:"static"`
+	// Address 后端地址，例如consul agent的地址
+	Address string `yaml:"address"`
+	// Token 后端的鉴权信息
+	Token string `yaml:"token" sensitive:"true"`
+	// TTL 实例存活时间，心跳间隔为 ttl/3，至少1000毫秒
+	TTL int `yaml:"ttl" default:"10000" validate:"min=1000"`
+	// AdvertiseIP 注册的实例地址中的IP，为空时使用监听地址，监听所有地址时使用第一个非回环的IPv4地址
+	AdvertiseIP string `yaml:"advertise_ip"`
+	// Static backend为static时的服务列表
+	Static map[string][]string `yaml:"static"`
+	// DNSPort backend为dns且没有SRV记录时实例的端口
+	DNSPort int `yaml:"dns_port"`
+}
+
+func init() {
+	// 使 sensitive tag 生效，注册中心的token在调试接口和审计记录中脱敏
+	config.RegisterStruct(SectionKey, Config{})
+}
+
+// BackendFactory 根据配置创建后端
+type BackendFactory func(cfg *Config) (Backend, error)
+
+var (
+	backends = map[string]BackendFactory{
+		"static": newStatic,
+		"dns":    newDNS,
+		"consul": newConsul,
+	}
+	backendLock sync.RWMutex
+)
+
+// RegisterBackend 注册后端，naming.backend 通过名字引用
+func RegisterBackend(name string, f BackendFactory) {
+	backendLock.Lock()
+	backends[name] = f
+	backendLock.Unlock()
+}
+
+// GetBackend 获取后端
+func GetBackend(name string) BackendFactory {
+	backendLock.RLock()
+	defer backendLock.RUnlock()
+	return backends[name]
+}
+
+// parse 解析 naming 配置段
+func parse(c config.Config) (*Config, error) {
+	cfg := &Config{Backend: "static", TTL: 10000}
+	if !c.IsSet(SectionKey) {
+		return cfg, nil
+	}
+	if err := c.UnmarshalKey(SectionKey, cfg); err != nil {
+		return nil, fmt.Errorf("app/naming: failed to parse %s: %v", SectionKey, err)
+	}
+	return cfg, nil
+}
+
+// New 按照c中的 naming 配置段创建后端
+func New(c config.Config) (Backend, *Config, error) {
+	cfg, err := parse(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	f := GetBackend(cfg.Backend)
+	if f == nil {
+		return nil, nil, fmt.Errorf("app/naming: backend %s not registered", cfg.Backend)
+	}
+	b, err := f(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("app/naming: failed to create backend %s: %v", cfg.Backend, err)
+	}
+	return b, cfg, nil
+}
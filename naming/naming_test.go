@@ -0,0 +1,138 @@
+package naming
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/config/configtest"
+	"goProjectTmpl/server"
+)
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		yaml string
+		ttl  int
+		bad  bool
+	}{
+		{"app: {}\n", 10000, false},
+		{"naming:\n  backend: static\n", 10000, false},
+		{"naming:\n  ttl: 1000\n", 1000, false},
+		// ttl/3 为0时 time.NewTicker 会panic
+		{"naming:\n  ttl: 1\n", 0, true},
+		{"naming:\n  ttl: 2\n", 0, true},
+		{"naming:\n  ttl: 0\n", 0, true},
+	}
+	for _, tt := range tests {
+		c, err := config.NewFromString(tt.yaml)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := parse(c)
+		if tt.bad {
+			if err == nil {
+				t.Errorf("%q: expect an error", tt.yaml)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: %v", tt.yaml, err)
+		}
+		if cfg.TTL != tt.ttl {
+			t.Errorf("%q: expect ttl %d, got %d", tt.yaml, tt.ttl, cfg.TTL)
+		}
+	}
+}
+
+// beatBackend 记录注册、心跳和注销的后端
+type beatBackend struct {
+	static
+	beats, deregistered int32
+}
+
+func (b *beatBackend) Heartbeat(context.Context, *Instance) error {
+	atomic.AddInt32(&b.beats, 1)
+	return nil
+}
+
+func (b *beatBackend) Deregister(ctx context.Context, ins *Instance) error {
+	atomic.AddInt32(&b.deregistered, 1)
+	return b.static.Deregister(ctx, ins)
+}
+
+func TestModuleHeartbeat(t *testing.T) {
+	b := &beatBackend{static: static{instances: make(map[string]map[string]*Instance)}}
+	RegisterBackend("beat", func(*Config) (Backend, error) { return b, nil })
+	c, _ := configtest.Load(t, "server:\n  service:\n    - name: app.demo\n      ip: 127.0.0.1\nnaming:\n  backend: beat\n  ttl: 1000\n")
+
+	srv := server.New()
+	srv.Handle("app.demo", http.NotFoundHandler())
+	if err := srv.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop(context.Background())
+
+	m := NewModule(srv)
+	if err := m.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	instances, err := Resolve(context.Background(), "app.demo")
+	if err != nil || len(instances) != 1 || instances[0].Address != srv.Addr("app.demo").String() {
+		t.Fatalf("expect the registered instance, got %v %v", instances, err)
+	}
+
+	// 每 ttl/3 上报一次心跳
+	deadline := time.Now().Add(3 * time.Second)
+	for atomic.LoadInt32(&b.beats) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("expect heartbeats")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 停止后不再上报心跳，实例被注销，重复调用没有影响
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	beats := atomic.LoadInt32(&b.beats)
+	time.Sleep(400 * time.Millisecond)
+	if got := atomic.LoadInt32(&b.beats); got != beats {
+		t.Fatalf("expect no heartbeat after Stop, got %d more", got-beats)
+	}
+	if got := atomic.LoadInt32(&b.deregistered); got != 1 {
+		t.Fatalf("expect 1 deregistration, got %d", got)
+	}
+	if _, err := Resolve(context.Background(), "app.demo"); err != ErrNotFound {
+		t.Fatalf("expect ErrNotFound after Stop, got %v", err)
+	}
+}
+
+func TestStaticResolve(t *testing.T) {
+	c, err := config.NewFromString("naming:\n  static:\n    app.redis: [127.0.0.1:6379, 127.0.0.1:6380]\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instances, err := b.Resolve(context.Background(), "app.redis")
+	if err != nil || len(instances) != 2 {
+		t.Fatalf("expect 2 instances, got %v %v", instances, err)
+	}
+	if _, err := b.Resolve(context.Background(), "app.mysql"); err != ErrNotFound {
+		t.Fatalf("expect ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package naming
+
+import (
+	"context"
+	"sync"
+)
+
+// static 配置中的静态服务列表，注册的实例只在本进程内可见
+type static struct {
+	lock      sync.RWMutex
+	services  map[string][]string
+	instances map[string]map[string]*Instance
+}
+
+func newStatic(cfg *Config) (Backend, error) {
+	return &static{services: cfg.Static, instances: make(map[string]map[string]*Instance)}, nil
+}
+
+func (s *static) Register(_ context.Context, ins *Instance) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	m, ok := s.instances[ins.Service]
+	if !ok {
+		m = make(map[string]*Instance)
+		s.instances[ins.Service] = m
+	}
+	m[ins.ID] = ins
+	return nil
+}
+
+func (s *static) Deregister(_ context.Context, ins *Instance) error {
+	s.lock.Lock()
+	delete(s.instances[ins.Service], ins.ID)
+	s.lock.Unlock()
+	return nil
+}
+
+func (s *static) Resolve(_ context.Context, service string) ([]*Instance, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var out []*Instance
+	for _, addr := range s.services[service] {
+		out = append(out, &Instance{Service: service, ID: service + "-" + addr, Address: addr})
+	}
+	for _, ins := range s.instances[service] {
+		out = append(out, ins)
+	}
+	if len(out) == 0 {
+		return nil, ErrNotFound
+	}
+	return out, nil
+}
@@ -0,0 +1,200 @@
+// Package plugin 插件注册与初始化
+// 组件按照 类型+名字 注册插件工厂，启动时根据框架配置中 plugins 配置段实例化：
+//
+//	plugins:
+//	  log:            # 插件类型
+//	    default:      # 插件名字，其下的内容由插件自己解析
+//	      level: debug
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中插件配置段的key
+const SectionKey = "plugins"
+
+// ErrCycle 插件之间存在循环依赖
+var ErrCycle = errors.New("app/plugin: dependency cycle")
+
+// Factory 插件工厂
+type Factory interface {
+	// Type 插件类型，例如 log、registry、database
+	Type() string
+	// Setup 根据插件配置初始化插件
+	Setup(name string, c Config) error
+}
+
+// Depender Factory的可选接口，声明初始化前需要完成初始化的插件
+type Depender interface {
+	// DependsOn 依赖的插件，格式为 类型-名字，例如 log-default
+	DependsOn() []string
+}
+
+// Config 单个插件的配置
+type Config interface {
+	// Key 插件配置在框架配置中的key，例如 plugins.log.default
+	Key() string
+	// Unmarshal 将插件配置解码到out中
+	Unmarshal(out interface{}) error
+	// Framework 完整的框架配置
+	Framework() config.Config
+}
+
+// section 框架配置中的插件配置段
+type section struct {
+	key string
+	cfg config.Config
+}
+
+func (s *section) Key() string {
+	return s.key
+}
+
+func (s *section) Unmarshal(out interface{}) error {
+	return s.cfg.UnmarshalKey(s.key, out)
+}
+
+func (s *section) Framework() config.Config {
+	return s.cfg
+}
+
+var (
+	factories = make(map[string]map[string]Factory)
+	lock      sync.RWMutex
+)
+
+// Register 注册名为name的插件工厂
+func Register(name string, f Factory) {
+	lock.Lock()
+	defer lock.Unlock()
+	m, ok := factories[f.Type()]
+	if !ok {
+		m = make(map[string]Factory)
+		factories[f.Type()] = m
+	}
+	m[name] = f
+}
+
+// Get 根据类型和名字获取插件工厂
+func Get(typ, name string) Factory {
+	lock.RLock()
+	defer lock.RUnlock()
+	return factories[typ][name]
+}
+
+// plugin 配置中声明的插件
+type plugin struct {
+	typ     string
+	name    string
+	factory Factory
+}
+
+func (p *plugin) id() string {
+	return p.typ + "-" + p.name
+}
+
+// Setup 按照依赖顺序初始化c中 plugins 配置段声明的全部插件，返回初始化的顺序
+// 配置中声明的插件没有注册工厂、依赖的插件未配置或存在循环依赖时，不初始化任何插件并返回错误
+func Setup(c config.Config) ([]string, error) {
+	plugins, err := configured(c)
+	if err != nil {
+		return nil, err
+	}
+	ordered, err := sortPlugins(plugins)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(ordered))
+	for _, p := range ordered {
+		key := strings.Join([]string{SectionKey, p.typ, p.name}, ".")
+		if err := p.factory.Setup(p.name, &section{key: key, cfg: c}); err != nil {
+			return ids, fmt.Errorf("app/plugin: failed to setup %s: %v", p.id(), err)
+		}
+		ids = append(ids, p.id())
+	}
+	return ids, nil
+}
+
+// configured 返回配置中声明的全部插件
+func configured(c config.Config) (map[string]*plugin, error) {
+	types, _ := c.Get(SectionKey, nil).(map[string]interface{})
+	plugins := make(map[string]*plugin)
+	for typ, v := range types {
+		names, _ := v.(map[string]interface{})
+		for name := range names {
+			f := Get(typ, name)
+			if f == nil {
+				return nil, fmt.Errorf("app/plugin: no factory registered for %s-%s", typ, name)
+			}
+			p := &plugin{typ: typ, name: name, factory: f}
+			plugins[p.id()] = p
+		}
+	}
+	return plugins, nil
+}
+
+// sortPlugins 将插件按照依赖关系排序，相互之间没有依赖的插件按照 类型-名字 排序
+func sortPlugins(plugins map[string]*plugin) ([]*plugin, error) {
+	ids := make([]string, 0, len(plugins))
+	for id := range plugins {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	pending := make(map[string]int, len(plugins))
+	dependents := make(map[string][]string)
+	for _, id := range ids {
+		d, ok := plugins[id].factory.(Depender)
+		if !ok {
+			continue
+		}
+		for _, dep := range d.DependsOn() {
+			if _, ok := plugins[dep]; !ok {
+				return nil, fmt.Errorf("app/plugin: %s depends on %s which is not configured", id, dep)
+			}
+			pending[id]++
+			dependents[dep] = append(dependents[dep], id)
+		}
+	}
+
+	var ready, ordered []string
+	for _, id := range ids {
+		if pending[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, id)
+		for _, next := range dependents[id] {
+			if pending[next]--; pending[next] == 0 {
+				ready = append(ready, next)
+				sort.Strings(ready)
+			}
+		}
+	}
+	if len(ordered) != len(ids) {
+		var cycle []string
+		for _, id := range ids {
+			if pending[id] > 0 {
+				cycle = append(cycle, id)
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", ErrCycle, strings.Join(cycle, ", "))
+	}
+
+	out := make([]*plugin, len(ordered))
+	for i, id := range ordered {
+		out[i] = plugins[id]
+	}
+	return out, nil
+}
@@ -0,0 +1,92 @@
+package plugin
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"goProjectTmpl/config"
+)
+
+// fakeFactory 记录初始化时的插件配置
+type fakeFactory struct {
+	typ  string
+	deps []string
+	got  map[string]interface{}
+	err  error
+}
+
+func (f *fakeFactory) Type() string { return f.typ }
+
+func (f *fakeFactory) Setup(name string, c Config) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.got = make(map[string]interface{})
+	return c.Unmarshal(&f.got)
+}
+
+func (f *fakeFactory) DependsOn() []string { return f.deps }
+
+func TestSetup(t *testing.T) {
+	log := &fakeFactory{typ: "log"}
+	reg := &fakeFactory{typ: "registry", deps: []string{"log-order"}}
+	db := &fakeFactory{typ: "database", deps: []string{"registry-order"}}
+	Register("order", log)
+	Register("order", reg)
+	Register("order", db)
+
+	c, err := config.NewFromString("plugins:\n  database:\n    order:\n      dsn: x\n  registry:\n    order: {}\n  log:\n    order:\n      level: debug\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := Setup(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"log-order", "registry-order", "database-order"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("expect %v, got %v", want, ids)
+	}
+	if log.got["level"] != "debug" || db.got["dsn"] != "x" {
+		t.Fatalf("unexpected plugin configs %v %v", log.got, db.got)
+	}
+}
+
+func TestSetupErrors(t *testing.T) {
+	Register("missing", &fakeFactory{typ: "dep", deps: []string{"log-none"}})
+	Register("failing", &fakeFactory{typ: "fail", err: errors.New("boom")})
+
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"unregistered", "plugins:\n  log:\n    unknown: {}\n", "no factory registered for log-unknown"},
+		{"missing dependency", "plugins:\n  dep:\n    missing: {}\n", "not configured"},
+		{"failed", "plugins:\n  fail:\n    failing: {}\n", "failed to setup fail-failing: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := config.NewFromString(tt.yaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Setup(c); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expect %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCycle(t *testing.T) {
+	plugins := map[string]*plugin{
+		"a-x": {typ: "a", name: "x", factory: &fakeFactory{typ: "a", deps: []string{"b-x"}}},
+		"b-x": {typ: "b", name: "x", factory: &fakeFactory{typ: "b", deps: []string{"a-x"}}},
+		"c-x": {typ: "c", name: "x", factory: &fakeFactory{typ: "c"}},
+	}
+	_, err := sortPlugins(plugins)
+	if !errors.Is(err, ErrCycle) || !strings.Contains(err.Error(), "a-x, b-x") {
+		t.Fatalf("expect a cycle of a-x and b-x, got %v", err)
+	}
+}
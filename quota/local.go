@@ -0,0 +1,74 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+func init() {
+	RegisterBackend("local", NewLocalBackend())
+}
+
+// sweepInterval 清理过期用量的最小间隔
+const sweepInterval = time.Minute
+
+// LocalBackend 进程内的用量存储，配额只在单个实例内生效，用于单机部署和测试
+type LocalBackend struct {
+	lock      sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+}
+
+type counter struct {
+	used    int64
+	expires time.Time
+}
+
+// NewLocalBackend 创建进程内的用量存储
+func NewLocalBackend() *LocalBackend {
+	return &LocalBackend{counters: make(map[string]*counter)}
+}
+
+// Usage 返回key的当前用量
+func (b *LocalBackend) Usage(ctx context.Context, key string) (int64, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if c, ok := b.counters[key]; ok && config.Now().Before(c.expires) {
+		return c.used, nil
+	}
+	return 0, nil
+}
+
+// Consume 用量加n后不超过limit时增加用量
+func (b *LocalBackend) Consume(ctx context.Context, key string, n, limit int64, ttl time.Duration) (int64, bool, error) {
+	now := config.Now()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.sweep(now)
+	c, ok := b.counters[key]
+	if !ok || !now.Before(c.expires) {
+		c = &counter{expires: now.Add(ttl)}
+		b.counters[key] = c
+	}
+	if c.used+n > limit {
+		return c.used, false, nil
+	}
+	c.used += n
+	return c.used, true, nil
+}
+
+// sweep 删除过期的用量，调用方需要持有锁
+func (b *LocalBackend) sweep(now time.Time) {
+	if now.Sub(b.lastSweep) < sweepInterval {
+		return
+	}
+	b.lastSweep = now
+	for key, c := range b.counters {
+		if !now.Before(c.expires) {
+			delete(b.counters, key)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Package quota 基于动态配置的配额
+// 按照租户和接口在框架配置的 quota 配置段中声明固定窗口内的用量上限，配置重新加载后立即使用新的上限，
+// 调整容量只需要推送配置，不需要重新部署。用量保存在 Backend 中，多个实例共用redis等存储时配额在集群内共享：
+//
+//	quota:
+//	  backend: local          # 用量存储，redis等通过 RegisterBackend 注册，默认为进程内的local
+//	  defaults:               # 没有单独配置的租户使用的配额
+//	    /api/export:
+//	      limit: 100
+//	      window: 86400000    # 毫秒，窗口按照时间对齐，例如每天0点(UTC)重置
+//	  tenants:
+//	    t1001:
+//	      /api/export:
+//	        limit: 1000
+//	        window: 86400000
+//
+//	q := quota.New(c)
+//	st, err := q.Consume(ctx, tenant, "/api/export", 1)
+//	if errors.Is(err, quota.ErrExceeded) { ... st.Reset ... }
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中配额配置段的key
+const SectionKey = "quota"
+
+// ErrExceeded 用量超过配额
+var ErrExceeded = errors.New("app/quota: quota exceeded")
+
+// Limit 单个接口的配额
+type Limit struct {
+	// Limit 窗口内的用量上限
+	Limit int64 `yaml:"limit" validate:"min=0"`
+	// Window 窗口长度，单位毫秒
+	Window int `yaml:"window" default:"60000" validate:"min=1"`
+}
+
+// Options quota 配置段
+type Options struct {
+	Backend  string                       `yaml:"backend" default:"local"`
+	Defaults map[string]*Limit            `yaml:"defaults"`
+	Tenants  map[string]map[string]*Limit `yaml:"tenants"`
+}
+
+// Backend 用量存储
+type Backend interface {
+	// Usage 返回key的当前用量，key不存在或已过期时返回0
+	Usage(ctx context.Context, key string) (int64, error)
+	// Consume 用量加n后不超过limit时增加用量并返回ok为true，否则不修改用量并返回false，
+	// used为操作后的用量；key在ttl后过期，检查和增加需要是原子的，例如redis的lua脚本
+	Consume(ctx context.Context, key string, n, limit int64, ttl time.Duration) (used int64, ok bool, err error)
+}
+
+var (
+	backends    = make(map[string]Backend)
+	backendLock sync.RWMutex
+)
+
+// RegisterBackend 注册名字为name的用量存储
+func RegisterBackend(name string, b Backend) {
+	backendLock.Lock()
+	backends[name] = b
+	backendLock.Unlock()
+}
+
+// GetBackend 获取名字为name的用量存储
+func GetBackend(name string) Backend {
+	backendLock.RLock()
+	defer backendLock.RUnlock()
+	return backends[name]
+}
+
+// Status 租户在某个接口上的配额状态
+type Status struct {
+	// Unlimited 没有配置配额，此时其他字段为零值
+	Unlimited bool
+	Limit     int64
+	Used      int64
+	Remaining int64
+	// Reset 当前窗口结束、用量清零的时间
+	Reset time.Time
+}
+
+// Quota 配额
+type Quota struct {
+	// opts 当前的 *Options
+	opts atomic.Value
+}
+
+// New 解析c中的 quota 配置段，配置重新加载后立即使用新的配额，配置段解析失败时继续使用原来的配额
+func New(c config.Config) *Quota {
+	q := &Quota{}
+	opts, err := parse(c)
+	if err != nil {
+		config.Errorf("app/quota: %v", err)
+		opts = &Options{Backend: "local"}
+	}
+	q.opts.Store(opts)
+	config.WatchSection(c, SectionKey, func() error {
+		opts, err := parse(c)
+		if err != nil {
+			return err
+		}
+		q.opts.Store(opts)
+		return nil
+	})
+	return q
+}
+
+func parse(c config.Config) (*Options, error) {
+	opts := &Options{Backend: "local"}
+	if c.IsSet(SectionKey) {
+		if err := c.UnmarshalKey(SectionKey, opts); err != nil {
+			return nil, fmt.Errorf("app/quota: failed to parse %s: %v", SectionKey, err)
+		}
+	}
+	return opts, nil
+}
+
+// limit 租户在接口上的配额，租户没有单独配置时使用defaults
+func (o *Options) limit(tenant, api string) *Limit {
+	if l, ok := o.Tenants[tenant][api]; ok {
+		return l
+	}
+	return o.Defaults[api]
+}
+
+// window 当前窗口的存储key和结束时间，窗口按照Unix时间对齐，同一集群内的实例使用相同的key
+func window(tenant, api string, l *Limit) (string, time.Time) {
+	size := time.Duration(l.Window) * time.Millisecond
+	start := config.Now().Truncate(size)
+	key := tenant + "/" + api + "/" + strconv.FormatInt(start.UnixNano()/int64(time.Millisecond), 10)
+	return key, start.Add(size)
+}
+
+// Check 返回租户在接口上的配额状态，不消耗配额
+func (q *Quota) Check(ctx context.Context, tenant, api string) (*Status, error) {
+	opts := q.opts.Load().(*Options)
+	l := opts.limit(tenant, api)
+	if l == nil {
+		return &Status{Unlimited: true}, nil
+	}
+	b, err := backend(opts)
+	if err != nil {
+		return nil, err
+	}
+	key, reset := window(tenant, api, l)
+	used, err := b.Usage(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("app/quota: %s: %v", key, err)
+	}
+	return status(l, used, reset), nil
+}
+
+// Consume 消耗n个配额，超过配额时不消耗并返回 ErrExceeded，返回的状态可用于设置 Retry-After 等响应头
+func (q *Quota) Consume(ctx context.Context, tenant, api string, n int64) (*Status, error) {
+	opts := q.opts.Load().(*Options)
+	l := opts.limit(tenant, api)
+	if l == nil {
+		return &Status{Unlimited: true}, nil
+	}
+	b, err := backend(opts)
+	if err != nil {
+		return nil, err
+	}
+	key, reset := window(tenant, api, l)
+	used, ok, err := b.Consume(ctx, key, n, l.Limit, reset.Sub(config.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("app/quota: %s: %v", key, err)
+	}
+	st := status(l, used, reset)
+	if !ok {
+		return st, fmt.Errorf("%w: %s %s", ErrExceeded, tenant, api)
+	}
+	return st, nil
+}
+
+func backend(opts *Options) (Backend, error) {
+	b := GetBackend(opts.Backend)
+	if b == nil {
+		return nil, fmt.Errorf("app/quota: backend %s not registered", opts.Backend)
+	}
+	return b, nil
+}
+
+func status(l *Limit, used int64, reset time.Time) *Status {
+	remaining := l.Limit - used
+	if remaining < 0 {
+		// 配额调低后已有用量可能超过新的上限
+		remaining = 0
+	}
+	return &Status{Limit: l.Limit, Used: used, Remaining: remaining, Reset: reset}
+}
@@ -0,0 +1,90 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config/configtest"
+)
+
+const quotaYAML = `quota:
+  backend: %s
+  defaults:
+    /api/export:
+      limit: 2
+      window: 60000
+  tenants:
+    t1001:
+      /api/export:
+        limit: 3
+        window: 60000
+`
+
+func load(t *testing.T, backend string) (*Quota, *configtest.Provider, *configtest.Clock) {
+	t.Helper()
+	clock := configtest.NewClock(time.Date(2020, 1, 1, 0, 0, 30, 0, time.UTC))
+	configtest.UseClock(t, clock)
+	RegisterBackend(backend, NewLocalBackend())
+	c, p := configtest.Load(t, fmt.Sprintf(quotaYAML, backend))
+	return New(c), p, clock
+}
+
+func TestConsume(t *testing.T) {
+	q, _, clock := load(t, "quota-consume")
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := q.Consume(ctx, "t2002", "/api/export", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	st, err := q.Consume(ctx, "t2002", "/api/export", 1)
+	if !errors.Is(err, ErrExceeded) || st.Remaining != 0 || st.Used != 2 {
+		t.Fatalf("expect ErrExceeded with the default limit, got %+v %v", st, err)
+	}
+	// 窗口按照时间对齐，分钟结束时重置
+	if want := time.Date(2020, 1, 1, 0, 1, 0, 0, time.UTC); !st.Reset.Equal(want) {
+		t.Fatalf("expect reset at %v, got %v", want, st.Reset)
+	}
+
+	// 单独配置的租户使用自己的配额
+	if st, err := q.Consume(ctx, "t1001", "/api/export", 3); err != nil || st.Remaining != 0 {
+		t.Fatalf("expect t1001 to consume 3, got %+v %v", st, err)
+	}
+	// 没有配置配额的接口不限制
+	if st, err := q.Consume(ctx, "t1001", "/api/import", 100); err != nil || !st.Unlimited {
+		t.Fatalf("expect unlimited, got %+v %v", st, err)
+	}
+
+	clock.Advance(30 * time.Second)
+	if st, err := q.Check(ctx, "t2002", "/api/export"); err != nil || st.Used != 0 || st.Remaining != 2 {
+		t.Fatalf("expect a new window, got %+v %v", st, err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	q, p, _ := load(t, "quota-reload")
+	ctx := context.Background()
+	q.Consume(ctx, "t2002", "/api/export", 2)
+
+	// 调高上限后立即生效，窗口内已有的用量保留
+	p.Update(configtest.DefaultPath, []byte("quota:\n  backend: quota-reload\n  defaults:\n    /api/export:\n      limit: 5\n"))
+	st, err := q.Consume(ctx, "t2002", "/api/export", 1)
+	if err != nil || st.Used != 3 || st.Remaining != 2 {
+		t.Fatalf("expect the new limit with the used quota kept, got %+v %v", st, err)
+	}
+
+	// 配额调低后剩余为0
+	p.Update(configtest.DefaultPath, []byte("quota:\n  backend: quota-reload\n  defaults:\n    /api/export:\n      limit: 1\n"))
+	if st, _ := q.Check(ctx, "t2002", "/api/export"); st.Remaining != 0 {
+		t.Fatalf("expect no remaining quota, got %+v", st)
+	}
+
+	// 未注册的存储
+	p.Update(configtest.DefaultPath, []byte("quota:\n  backend: missing\n  defaults:\n    /api/export:\n      limit: 1\n"))
+	if _, err := q.Check(ctx, "t2002", "/api/export"); err == nil {
+		t.Fatal("expect an error for an unregistered backend")
+	}
+}
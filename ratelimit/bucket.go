@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// bucket 单个路由或调用方的限流状态，配置变化时原地更新参数，保留已有的状态
+type bucket interface {
+	// reserve 申请一次请求，返回需要等待的时间，wait为false时只在不需要等待时成功
+	reserve(now time.Time, wait bool) (time.Duration, bool)
+	// update 更新限流参数
+	update(l *Limit)
+}
+
+func newBucket(l *Limit, now time.Time) bucket {
+	if l.Algorithm == LeakyBucket {
+		b := &leakyBucket{next: now}
+		b.update(l)
+		return b
+	}
+	b := &tokenBucket{tokens: float64(l.Burst), last: now}
+	b.update(l)
+	return b
+}
+
+// tokenBucket 令牌桶，每秒产生rate个令牌，最多积累burst个，允许突发
+type tokenBucket struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) reserve(now time.Time, wait bool) (time.Duration, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	// 等待中的请求最多预支burst个令牌
+	if !wait || b.tokens-1 < -b.burst {
+		return 0, false
+	}
+	delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	b.tokens--
+	return delay, true
+}
+
+func (b *tokenBucket) update(l *Limit) {
+	b.lock.Lock()
+	b.rate, b.burst = l.Rate, float64(l.Burst)
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.lock.Unlock()
+}
+
+// leakyBucket 漏桶，请求按照 1/rate 的固定间隔放行，最多burst个请求排队，不允许突发
+type leakyBucket struct {
+	lock     sync.Mutex
+	interval time.Duration
+	queue    int
+	// next 下一个请求可以放行的时间
+	next time.Time
+}
+
+func (b *leakyBucket) reserve(now time.Time, wait bool) (time.Duration, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.next.Before(now) {
+		b.next = now
+	}
+	delay := b.next.Sub(now)
+	if delay > 0 && (!wait || delay > time.Duration(b.queue)*b.interval) {
+		return 0, false
+	}
+	b.next = b.next.Add(b.interval)
+	return delay, true
+}
+
+func (b *leakyBucket) update(l *Limit) {
+	b.lock.Lock()
+	b.interval = time.Duration(float64(time.Second) / l.Rate)
+	b.queue = l.Burst
+	b.lock.Unlock()
+}
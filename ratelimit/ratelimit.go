@@ -0,0 +1,214 @@
+// Package ratelimit 基于动态配置的限流
+// 按照路由或调用方在框架配置的 ratelimit 配置段中声明限流参数，配置重新加载后原地更新，不丢失已有的限流状态：
+//
+//	ratelimit:
+//	  routes:
+//	    /api/order:
+//	      rate: 100           # 每秒请求数
+//	      burst: 200          # 令牌桶容量
+//	  callers:
+//	    app.mall.service:
+//	      algorithm: leaky_bucket
+//	      rate: 10
+//	      burst: 20           # 漏桶排队的请求数
+//
+//	l := ratelimit.New(c)
+//	server.RegisterFilter("ratelimit", l.Filter)
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中限流配置段的key
+const SectionKey = "ratelimit"
+
+// 限流对象的类型
+const (
+	KindRoute  = "routes"
+	KindCaller = "callers"
+)
+
+// 限流算法
+const (
+	TokenBucket = "token_bucket"
+	LeakyBucket = "leaky_bucket"
+)
+
+// ErrLimited 请求被限流
+var ErrLimited = errors.New("app/ratelimit: rate limited")
+
+// Limit 单个路由或调用方的限流参数
+type Limit struct {
+	Algorithm string `yaml:"algorithm" default:"token_bucket" enum:"token_bucket,leaky_bucket"`
+	// Rate 每秒放行的请求数
+	Rate float64 `yaml:"rate"`
+	// Burst 令牌桶的容量，或漏桶中排队的请求数
+	Burst int `yaml:"burst" validate:"min=0"`
+}
+
+// Config 限流配置
+type Config struct {
+	Routes  map[string]*Limit `yaml:"routes"`
+	Callers map[string]*Limit `yaml:"callers"`
+}
+
+// limits 按照类型返回限流参数
+func (c *Config) limits(kind string) map[string]*Limit {
+	if kind == KindCaller {
+		return c.Callers
+	}
+	return c.Routes
+}
+
+// check 检查限流参数，令牌桶的容量至少为1
+func (c *Config) check() error {
+	for _, kind := range []string{KindRoute, KindCaller} {
+		for key, l := range c.limits(kind) {
+			if l.Rate <= 0 {
+				return fmt.Errorf("%s.%s.%s: rate must be positive", SectionKey, kind, key)
+			}
+			if l.Algorithm == TokenBucket && l.Burst < 1 {
+				l.Burst = 1
+			}
+		}
+	}
+	return nil
+}
+
+// Limiter 限流器
+type Limiter struct {
+	c       config.Config
+	lock    sync.RWMutex
+	cfg     *Config
+	buckets map[string]bucket
+}
+
+// New 解析c中的 ratelimit 配置段，并在配置重新加载后更新限流参数，配置段解析失败时继续使用原来的参数
+func New(c config.Config) *Limiter {
+	l := &Limiter{c: c, cfg: &Config{}, buckets: make(map[string]bucket)}
+	if err := l.reload(); err != nil {
+		config.Errorf("app/ratelimit: %v", err)
+	}
+	config.WatchSection(c, SectionKey, l.reload)
+	return l
+}
+
+// reload 重新解析限流参数，已有的限流状态原地更新，算法变化或删除的限流对象重新开始计算
+func (l *Limiter) reload() error {
+	cfg := &Config{}
+	if l.c.IsSet(SectionKey) {
+		if err := l.c.UnmarshalKey(SectionKey, cfg); err != nil {
+			return err
+		}
+	}
+	if err := cfg.check(); err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for id, b := range l.buckets {
+		kind, key := splitID(id)
+		nl, ok := cfg.limits(kind)[key]
+		if !ok || nl.Algorithm != l.cfg.limits(kind)[key].Algorithm {
+			delete(l.buckets, id)
+			continue
+		}
+		b.update(nl)
+	}
+	l.cfg = cfg
+	return nil
+}
+
+// bucket 获取限流对象的状态，没有配置限流时返回nil
+func (l *Limiter) bucket(kind, key string) bucket {
+	id := kind + "/" + key
+	l.lock.RLock()
+	b, ok := l.buckets[id]
+	lim := l.cfg.limits(kind)[key]
+	l.lock.RUnlock()
+	if ok || lim == nil {
+		return b
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if b, ok = l.buckets[id]; ok {
+		return b
+	}
+	if lim = l.cfg.limits(kind)[key]; lim == nil {
+		return nil
+	}
+	b = newBucket(lim, time.Now())
+	l.buckets[id] = b
+	return b
+}
+
+// splitID 拆分 类型/key 形式的限流对象标识，路由中的 / 属于key
+func splitID(id string) (kind, key string) {
+	i := strings.IndexByte(id, '/')
+	return id[:i], id[i+1:]
+}
+
+// Allow 是否放行一次请求，kind为 KindRoute 或 KindCaller，没有配置限流时总是放行
+func (l *Limiter) Allow(kind, key string) bool {
+	b := l.bucket(kind, key)
+	if b == nil {
+		return true
+	}
+	_, ok := b.reserve(time.Now(), false)
+	return ok
+}
+
+// Wait 等待直到可以放行一次请求，排队已满时返回 ErrLimited，ctx在放行前结束时返回ctx的错误
+func (l *Limiter) Wait(ctx context.Context, kind, key string) error {
+	b := l.bucket(kind, key)
+	if b == nil {
+		return nil
+	}
+	delay, ok := b.reserve(time.Now(), true)
+	if !ok {
+		return ErrLimited
+	}
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// AllowRoute 是否放行路由的一次请求
+func (l *Limiter) AllowRoute(route string) bool {
+	return l.Allow(KindRoute, route)
+}
+
+// AllowCaller 是否放行调用方的一次请求
+func (l *Limiter) AllowCaller(caller string) bool {
+	return l.Allow(KindCaller, caller)
+}
+
+// Filter http拦截器，按照请求路径限流，被限流的请求返回429，签名与 server.Filter 一致
+func (l *Limiter) Filter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.AllowRoute(r.URL.Path) {
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config/configtest"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newBucket(&Limit{Algorithm: TokenBucket, Rate: 10, Burst: 2}, now)
+	for i := 0; i < 2; i++ {
+		if _, ok := b.reserve(now, false); !ok {
+			t.Fatalf("expect burst request %d to pass", i)
+		}
+	}
+	if _, ok := b.reserve(now, false); ok {
+		t.Fatal("expect the bucket to be empty")
+	}
+	// 100ms后产生一个令牌
+	if _, ok := b.reserve(now.Add(100*time.Millisecond), false); !ok {
+		t.Fatal("expect a token after 100ms")
+	}
+	// 等待的请求预支令牌
+	if d, ok := b.reserve(now.Add(100*time.Millisecond), true); !ok || d != 100*time.Millisecond {
+		t.Fatalf("expect to wait 100ms, got %v %v", d, ok)
+	}
+}
+
+func TestLeakyBucket(t *testing.T) {
+	now := time.Now()
+	b := newBucket(&Limit{Algorithm: LeakyBucket, Rate: 10, Burst: 1}, now)
+	if d, ok := b.reserve(now, false); !ok || d != 0 {
+		t.Fatal("expect the first request to pass")
+	}
+	// 不允许突发，最多排队1个请求
+	if _, ok := b.reserve(now, false); ok {
+		t.Fatal("expect no burst")
+	}
+	if d, ok := b.reserve(now, true); !ok || d != 100*time.Millisecond {
+		t.Fatalf("expect to queue for 100ms, got %v %v", d, ok)
+	}
+	if _, ok := b.reserve(now, true); ok {
+		t.Fatal("expect the queue to be full")
+	}
+}
+
+func TestReload(t *testing.T) {
+	c, p := configtest.Load(t, "ratelimit:\n  routes:\n    /api/order:\n      rate: 1\n      burst: 1\n")
+	l := New(c)
+	if !l.AllowRoute("/api/order") || l.AllowRoute("/api/order") {
+		t.Fatal("expect exactly one request to pass")
+	}
+	if !l.AllowRoute("/api/user") {
+		t.Fatal("expect routes without a limit to pass")
+	}
+
+	// 参数原地更新，已消耗的令牌不会恢复
+	p.Update(configtest.DefaultPath, []byte("ratelimit:\n  routes:\n    /api/order:\n      rate: 1\n      burst: 5\n"))
+	if l.AllowRoute("/api/order") {
+		t.Fatal("expect the bucket state to be kept")
+	}
+
+	// 非法参数被拒绝，继续使用原来的参数
+	p.Update(configtest.DefaultPath, []byte("ratelimit:\n  routes:\n    /api/order:\n      rate: 0\n"))
+	if got := l.cfg.Routes["/api/order"].Burst; got != 5 {
+		t.Fatalf("expect the old limit to stay, got burst %d", got)
+	}
+
+	// 删除后不再限流
+	p.Update(configtest.DefaultPath, []byte("ratelimit: {}\n"))
+	for i := 0; i < 10; i++ {
+		if !l.AllowRoute("/api/order") {
+			t.Fatal("expect no limit after the route is removed")
+		}
+	}
+}
+
+func TestWaitAndFilter(t *testing.T) {
+	c, _ := configtest.Load(t, "ratelimit:\n  callers:\n    app.mall:\n      algorithm: leaky_bucket\n      rate: 1\n      burst: 0\n  routes:\n    /limited:\n      rate: 1\n      burst: 1\n")
+	l := New(c)
+	if err := l.Wait(context.Background(), KindCaller, "app.mall"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Wait(context.Background(), KindCaller, "app.mall"); err != ErrLimited {
+		t.Fatalf("expect ErrLimited, got %v", err)
+	}
+
+	h := l.Filter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	codes := []int{}
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited", nil))
+		codes = append(codes, w.Code)
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Fatalf("expect 200 then 429, got %v", codes)
+	}
+}
@@ -0,0 +1,273 @@
+// Package redis 根据框架配置中的 redis 配置段创建redis客户端
+// 为了不引入具体的redis库，客户端由按照 mode 注册的 Builder 创建，例如使用go-redis：
+//
+//	redis.RegisterBuilder(redis.ModeSingle, func(o *redis.Options) (redis.Client, error) {
+//		return goredis.NewClient(&goredis.Options{Addr: o.Addrs[0], Password: o.Password, DB: o.DB,
+//			PoolSize: o.PoolSize, ReadTimeout: o.Duration(o.ReadTimeout)}), nil
+//	})
+//	app.Register(redis.DefaultManager)
+//	rdb := redis.Get("default").(*goredis.Client)
+//
+// 配置重新加载后，配置变化的客户端使用新配置重新创建并原子替换，旧客户端在 close_delay 之后关闭，
+// 因此连接池大小、超时时间等配置不需要重启服务即可生效。调用方每次使用时应通过 Get 获取最新的客户端
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中redis配置段的key，其下按照名字配置多个客户端
+const SectionKey = "redis"
+
+// 部署模式
+const (
+	ModeSingle   = "single"
+	ModeCluster  = "cluster"
+	ModeSentinel = "sentinel"
+)
+
+// Options 单个客户端的配置，时间单位为毫秒，0表示使用redis库的默认值
+//
+//	redis:
+//	  default:
+//	    mode: sentinel
+//	    addrs: [127.0.0.1:26379]
+//	    master_name: mymaster
+//	    pool_size: 20
+//	    read_timeout: 500
+type Options struct {
+	Mode string `yaml:"mode" default:"single" enum:"single,cluster,sentinel"`
+	// Addrs 节点地址，sentinel模式下为哨兵地址
+	Addrs []string `yaml:"addrs" validate:"required"`
+	// MasterName sentinel模式下的主节点名字
+	MasterName string `yaml:"master_name"`
+	Username   string `yaml:"username"`
+	Password   string `yaml:"password" sensitive:"true"`
+	DB         int    `yaml:"db"`
+
+	PoolSize     int `yaml:"pool_size"`
+	MinIdleConns int `yaml:"min_idle_conns"`
+	MaxRetries   int `yaml:"max_retries"`
+	DialTimeout  int `yaml:"dial_timeout"`
+	ReadTimeout  int `yaml:"read_timeout"`
+	WriteTimeout int `yaml:"write_timeout"`
+	PoolTimeout  int `yaml:"pool_timeout"`
+	IdleTimeout  int `yaml:"idle_timeout"`
+
+	// CloseDelay 重新创建客户端后，旧客户端等待正在执行的命令完成的时间
+	CloseDelay int `yaml:"close_delay" default:"5000"`
+}
+
+func init() {
+	// 使 sensitive tag 生效，redis.<name>.password 在调试接口和审计记录中脱敏
+	config.RegisterStruct(SectionKey, map[string]*Options{})
+}
+
+// Duration 将毫秒转换为time.Duration
+func (o *Options) Duration(ms int) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
+
+// check 检查不同模式下必须的配置
+func (o *Options) check() error {
+	if len(o.Addrs) == 0 {
+		return errors.New("addrs required")
+	}
+	if o.Mode == ModeSentinel && o.MasterName == "" {
+		return errors.New("master_name required in sentinel mode")
+	}
+	return nil
+}
+
+// Client redis客户端，例如go-redis的 *redis.Client、*redis.ClusterClient
+type Client interface {
+	Close() error
+}
+
+// Builder 根据配置创建客户端
+type Builder func(o *Options) (Client, error)
+
+var (
+	builders    = make(map[string]Builder)
+	builderLock sync.RWMutex
+)
+
+// RegisterBuilder 注册mode对应的客户端创建函数
+func RegisterBuilder(mode string, b Builder) {
+	builderLock.Lock()
+	builders[mode] = b
+	builderLock.Unlock()
+}
+
+// GetBuilder 获取mode对应的客户端创建函数
+func GetBuilder(mode string) Builder {
+	builderLock.RLock()
+	defer builderLock.RUnlock()
+	return builders[mode]
+}
+
+// build 使用mode对应的Builder创建客户端
+func build(name string, o *Options) (Client, error) {
+	if err := o.check(); err != nil {
+		return nil, fmt.Errorf("app/redis: %s: %v", name, err)
+	}
+	b := GetBuilder(o.Mode)
+	if b == nil {
+		return nil, fmt.Errorf("app/redis: %s: no builder registered for mode %s", name, o.Mode)
+	}
+	cli, err := b(o)
+	if err != nil {
+		return nil, fmt.Errorf("app/redis: %s: %v", name, err)
+	}
+	return cli, nil
+}
+
+// instance 名字对应的客户端，重新创建时原子替换
+type instance struct {
+	opts   *Options
+	client atomic.Value
+}
+
+// Manager 管理 redis 配置段中的全部客户端，实现了app的Module、Stopper、Reloader接口
+type Manager struct {
+	lock      sync.RWMutex
+	instances map[string]*instance
+}
+
+// NewManager 创建Manager
+func NewManager() *Manager {
+	return &Manager{instances: make(map[string]*instance)}
+}
+
+// DefaultManager 默认的Manager
+var DefaultManager = NewManager()
+
+// Name 模块名
+func (m *Manager) Name() string {
+	return "redis"
+}
+
+// Init 创建 redis 配置段中的全部客户端，任意一个创建失败时关闭已创建的客户端并返回错误
+func (m *Manager) Init(c config.Config) error {
+	opts, err := parse(c)
+	if err != nil {
+		return err
+	}
+	instances := make(map[string]*instance, len(opts))
+	for _, name := range sortedNames(opts) {
+		cli, err := build(name, opts[name])
+		if err != nil {
+			for _, in := range instances {
+				in.client.Load().(Client).Close()
+			}
+			return err
+		}
+		in := &instance{opts: opts[name]}
+		in.client.Store(cli)
+		instances[name] = in
+	}
+	m.lock.Lock()
+	m.instances = instances
+	m.lock.Unlock()
+	return nil
+}
+
+// parse 解析 redis 配置段
+func parse(c config.Config) (map[string]*Options, error) {
+	opts := make(map[string]*Options)
+	if !c.IsSet(SectionKey) {
+		return opts, nil
+	}
+	if err := c.UnmarshalKey(SectionKey, &opts); err != nil {
+		return nil, fmt.Errorf("app/redis: failed to parse %s: %v", SectionKey, err)
+	}
+	return opts, nil
+}
+
+// sortedNames 按照名字排序，保证创建顺序稳定
+func sortedNames(opts map[string]*Options) []string {
+	names := make([]string, 0, len(opts))
+	for name := range opts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get 获取名字对应的最新客户端，不存在时返回nil
+func (m *Manager) Get(name string) Client {
+	m.lock.RLock()
+	in, ok := m.instances[name]
+	m.lock.RUnlock()
+	if !ok {
+		return nil
+	}
+	return in.client.Load().(Client)
+}
+
+// Reload 重新创建配置变化的客户端，创建失败时继续使用原来的客户端，新增或删除的客户端需要重启服务
+func (m *Manager) Reload(c config.Config, changes []config.Change) {
+	opts, err := parse(c)
+	if err != nil {
+		config.Errorf("app/redis: %v", err)
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for name, in := range m.instances {
+		o, ok := opts[name]
+		if !ok {
+			config.Warnf("app/redis: %s removed, restart required", name)
+			continue
+		}
+		if reflect.DeepEqual(o, in.opts) {
+			continue
+		}
+		cli, err := build(name, o)
+		if err != nil {
+			config.Errorf("app/redis: failed to rebuild %s, keep using the old client: %v", name, err)
+			continue
+		}
+		old := in.client.Load().(Client)
+		in.client.Store(cli)
+		delay := in.opts.Duration(in.opts.CloseDelay)
+		in.opts = o
+		time.AfterFunc(delay, func() {
+			old.Close()
+		})
+		config.Infof("app/redis: %s rebuilt with new options", name)
+	}
+	for name := range opts {
+		if _, ok := m.instances[name]; !ok {
+			config.Warnf("app/redis: %s added, restart required", name)
+		}
+	}
+}
+
+// Stop 关闭全部客户端
+func (m *Manager) Stop(ctx context.Context) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	var first error
+	for _, in := range m.instances {
+		if err := in.client.Load().(Client).Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Get 获取默认Manager中名字对应的最新客户端
+func Get(name string) Client {
+	return DefaultManager.Get(name)
+}
@@ -0,0 +1,113 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config/configtest"
+)
+
+// fakeClient 记录创建时的配置和是否被关闭
+type fakeClient struct {
+	opts   *Options
+	closed int32
+}
+
+func (c *fakeClient) Close() error {
+	atomic.StoreInt32(&c.closed, 1)
+	return nil
+}
+
+func init() {
+	RegisterBuilder(ModeSingle, func(o *Options) (Client, error) {
+		if o.DB < 0 {
+			return nil, errors.New("invalid db")
+		}
+		return &fakeClient{opts: o}, nil
+	})
+}
+
+func TestInit(t *testing.T) {
+	c, _ := configtest.Load(t, "redis:\n  default:\n    addrs: [127.0.0.1:6379]\n    pool_size: 10\n")
+	m := NewManager()
+	if err := m.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	cli := m.Get("default").(*fakeClient)
+	if cli.opts.PoolSize != 10 || cli.opts.Mode != ModeSingle || cli.opts.CloseDelay != 5000 {
+		t.Fatalf("unexpected options %+v", cli.opts)
+	}
+	if m.Get("other") != nil {
+		t.Fatal("expect nil for an unknown client")
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&cli.closed) != 1 {
+		t.Fatal("expect the client to be closed")
+	}
+}
+
+func TestInitErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"sentinel without master", "redis:\n  default:\n    mode: sentinel\n    addrs: [127.0.0.1:26379]\n", "master_name required"},
+		{"no builder", "redis:\n  default:\n    mode: cluster\n    addrs: [127.0.0.1:6379]\n", "no builder registered"},
+		{"builder error", "redis:\n  a:\n    addrs: [127.0.0.1:6379]\n  b:\n    addrs: [127.0.0.1:6379]\n    db: -1\n", "invalid db"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := configtest.Load(t, tt.yaml)
+			if err := NewManager().Init(c); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expect %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	c, p := configtest.Load(t, "redis:\n  default:\n    addrs: [127.0.0.1:6379]\n    close_delay: 10\n")
+	m := NewManager()
+	if err := m.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	old := m.Get("default").(*fakeClient)
+
+	// 配置不变时不重新创建
+	m.Reload(c, nil)
+	if m.Get("default") != Client(old) {
+		t.Fatal("expect the client to be kept")
+	}
+
+	// 创建失败时继续使用原来的客户端
+	p.Update(configtest.DefaultPath, []byte("redis:\n  default:\n    addrs: [127.0.0.1:6379]\n    db: -1\n"))
+	m.Reload(c, nil)
+	if m.Get("default") != Client(old) {
+		t.Fatal("expect the old client to be kept")
+	}
+
+	p.Update(configtest.DefaultPath, []byte("redis:\n  default:\n    addrs: [127.0.0.1:6379]\n    pool_size: 20\n"))
+	m.Reload(c, nil)
+	cli := m.Get("default").(*fakeClient)
+	if cli == old || cli.opts.PoolSize != 20 {
+		t.Fatalf("expect a new client with pool_size 20, got %+v", cli.opts)
+	}
+	// 旧客户端在原来的 close_delay 之后关闭
+	deadline := time.Now().Add(3 * time.Second)
+	for atomic.LoadInt32(&old.closed) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expect the old client to be closed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&cli.closed) != 0 {
+		t.Fatal("expect the new client to stay open")
+	}
+}
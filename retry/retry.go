@@ -0,0 +1,198 @@
+// Package retry 重试策略
+// 调用方按照名字使用重试策略，最多尝试次数、退避曲线、抖动和可重试的错误在框架配置的 retry 配置段中声明，
+// 配置重新加载后立即生效：
+//
+//	retry:
+//	  default:                # 没有单独配置的调用使用的策略
+//	    max_attempts: 3
+//	  payment:
+//	    max_attempts: 5
+//	    backoff: exponential  # constant、linear、exponential
+//	    delay: 100            # 毫秒，第一次重试前的等待时间
+//	    max_delay: 5000       # 毫秒，单次等待的上限
+//	    multiplier: 2         # exponential 的倍数
+//	    jitter: 0.2           # 等待时间随机增减的比例
+//	    retry_on: [timeout, temporary]
+//
+//	set := retry.NewSet(c)
+//	err := set.Get("payment").Do(ctx, func(ctx context.Context) error { return pay(ctx) })
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 退避曲线
+const (
+	BackoffConstant    = "constant"
+	BackoffLinear      = "linear"
+	BackoffExponential = "exponential"
+)
+
+// Policy 重试策略，时间单位为毫秒
+type Policy struct {
+	// MaxAttempts 最多尝试次数，包括第一次
+	MaxAttempts int    `yaml:"max_attempts" default:"3" validate:"min=1"`
+	Backoff     string `yaml:"backoff" default:"exponential" enum:"constant,linear,exponential"`
+	// Delay 第一次重试前的等待时间
+	Delay    int `yaml:"delay" default:"100" validate:"min=0"`
+	MaxDelay int `yaml:"max_delay" default:"5000" validate:"min=0"`
+	// Multiplier exponential 曲线每次等待时间的倍数
+	Multiplier float64 `yaml:"multiplier" default:"2" validate:"min=1"`
+	// Jitter 等待时间在 [1-jitter, 1+jitter] 倍之间随机
+	Jitter float64 `yaml:"jitter" default:"0.2" validate:"min=0,max=1"`
+	// RetryOn 可重试的错误类别，通过 RegisterClass 注册，为空时任何错误都重试
+	RetryOn []string `yaml:"retry_on"`
+}
+
+// DefaultPolicy 默认的重试策略
+var DefaultPolicy = Policy{MaxAttempts: 3, Backoff: BackoffExponential, Delay: 100, MaxDelay: 5000, Multiplier: 2, Jitter: 0.2}
+
+// Wait 第attempt次尝试失败后的等待时间，attempt从1开始
+func (p *Policy) Wait(attempt int) time.Duration {
+	d := float64(p.Delay)
+	switch p.Backoff {
+	case BackoffLinear:
+		d *= float64(attempt)
+	case BackoffExponential:
+		m := p.Multiplier
+		if m < 1 {
+			m = 1
+		}
+		d *= math.Pow(m, float64(attempt-1))
+	}
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d *= 1 - p.Jitter + 2*p.Jitter*rand.Float64()
+	}
+	return time.Duration(d * float64(time.Millisecond))
+}
+
+// Retryable 错误是否属于 RetryOn 中的任一类别，被 Permanent 包装的错误不重试
+func (p *Policy) Retryable(err error) bool {
+	var perm *permanentError
+	if errors.As(err, &perm) {
+		return false
+	}
+	if len(p.RetryOn) == 0 {
+		return true
+	}
+	for _, name := range p.RetryOn {
+		if match := getClass(name); match != nil && match(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// permanentError 不可重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent 包装不可重试的错误，Do 收到后立即返回原错误
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Retrier 按照当前的策略重试
+type Retrier struct {
+	name string
+	// policy 当前的重试策略，类型为 *Policy
+	policy atomic.Value
+}
+
+// New 创建Retrier
+func New(name string, p Policy) *Retrier {
+	r := &Retrier{name: name}
+	r.policy.Store(&p)
+	return r
+}
+
+// Name Retrier名字
+func (r *Retrier) Name() string {
+	return r.name
+}
+
+// Policy 当前的重试策略
+func (r *Retrier) Policy() Policy {
+	return *r.policy.Load().(*Policy)
+}
+
+// SetPolicy 替换重试策略，正在执行的 Do 从下一次等待开始使用新策略
+func (r *Retrier) SetPolicy(p Policy) {
+	r.policy.Store(&p)
+}
+
+// Do 执行fn，失败且错误可重试时等待后重试，直到成功、达到最多尝试次数或ctx结束，返回最后一次的错误
+func (r *Retrier) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		p := r.Policy()
+		if attempt >= p.MaxAttempts || !p.Retryable(err) {
+			var perm *permanentError
+			if errors.As(err, &perm) {
+				return perm.err
+			}
+			return err
+		}
+		t := time.NewTimer(p.Wait(attempt))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		}
+	}
+}
+
+var (
+	classes   = make(map[string]func(error) bool)
+	classLock sync.RWMutex
+)
+
+func init() {
+	RegisterClass("timeout", func(err error) bool {
+		var t interface{ Timeout() bool }
+		return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &t) && t.Timeout())
+	})
+	RegisterClass("temporary", func(err error) bool {
+		var t interface{ Temporary() bool }
+		return errors.As(err, &t) && t.Temporary()
+	})
+}
+
+// RegisterClass 注册可以在 retry_on 中使用的错误类别，内置 timeout 和 temporary
+func RegisterClass(name string, match func(error) bool) {
+	classLock.Lock()
+	classes[name] = match
+	classLock.Unlock()
+}
+
+func getClass(name string) func(error) bool {
+	classLock.RLock()
+	defer classLock.RUnlock()
+	return classes[name]
+}
@@ -0,0 +1,102 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config/configtest"
+)
+
+func TestWait(t *testing.T) {
+	tests := []struct {
+		p    Policy
+		want []time.Duration
+	}{
+		{Policy{Backoff: BackoffConstant, Delay: 100}, []time.Duration{100, 100, 100}},
+		{Policy{Backoff: BackoffLinear, Delay: 100}, []time.Duration{100, 200, 300}},
+		{Policy{Backoff: BackoffExponential, Delay: 100, Multiplier: 2, MaxDelay: 300}, []time.Duration{100, 200, 300}},
+	}
+	for _, tt := range tests {
+		for i, want := range tt.want {
+			if got := tt.p.Wait(i + 1); got != want*time.Millisecond {
+				t.Errorf("%s attempt %d: expect %v, got %v", tt.p.Backoff, i+1, want*time.Millisecond, got)
+			}
+		}
+	}
+
+	// 抖动在 [1-jitter, 1+jitter] 倍之间
+	p := Policy{Backoff: BackoffConstant, Delay: 100, Jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		if d := p.Wait(1); d < 80*time.Millisecond || d > 120*time.Millisecond {
+			t.Fatalf("expect a jittered wait within [80ms, 120ms], got %v", d)
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "timeout" }
+func (timeoutError) Timeout() bool { return true }
+
+func TestDo(t *testing.T) {
+	r := New("payment", Policy{MaxAttempts: 3, Backoff: BackoffConstant, Delay: 1, RetryOn: []string{"timeout"}})
+	attempts := 0
+	err := r.Do(context.Background(), func(context.Context) error {
+		attempts++
+		return timeoutError{}
+	})
+	if attempts != 3 || err != (timeoutError{}) {
+		t.Fatalf("expect 3 attempts, got %d %v", attempts, err)
+	}
+
+	// 不属于 retry_on 的错误和 Permanent 包装的错误不重试
+	for _, e := range []error{errors.New("bad request"), Permanent(timeoutError{})} {
+		attempts = 0
+		err = r.Do(context.Background(), func(context.Context) error {
+			attempts++
+			return e
+		})
+		if attempts != 1 {
+			t.Errorf("%v: expect 1 attempt, got %d", e, attempts)
+		}
+		if _, ok := err.(*permanentError); ok {
+			t.Errorf("expect Permanent to be unwrapped, got %T", err)
+		}
+	}
+
+	// ctx结束时返回最后一次的错误
+	r.SetPolicy(Policy{MaxAttempts: 10, Backoff: BackoffConstant, Delay: 1000})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := r.Do(ctx, func(context.Context) error { return timeoutError{} }); err != (timeoutError{}) || time.Since(start) > time.Second {
+		t.Fatalf("expect Do to return when ctx is done, got %v after %v", err, time.Since(start))
+	}
+}
+
+func TestSetReload(t *testing.T) {
+	c, p := configtest.Load(t, "retry:\n  default:\n    max_attempts: 2\n  payment:\n    max_attempts: 5\n")
+	s := NewSet(c)
+	if got := s.Get("payment").Policy().MaxAttempts; got != 5 {
+		t.Fatalf("expect 5, got %d", got)
+	}
+	if got := s.Get("order").Policy(); got.MaxAttempts != 2 || got.Backoff != BackoffExponential {
+		t.Fatalf("expect the default policy with defaults filled, got %+v", got)
+	}
+
+	p.Update(configtest.DefaultPath, []byte("retry:\n  payment:\n    max_attempts: 7\n"))
+	if got := s.Get("payment").Policy().MaxAttempts; got != 7 {
+		t.Fatalf("expect 7 after reload, got %d", got)
+	}
+	if got := s.Get("order").Policy().MaxAttempts; got != DefaultPolicy.MaxAttempts {
+		t.Fatalf("expect DefaultPolicy after default is removed, got %d", got)
+	}
+
+	// 不在枚举中的退避曲线被拒绝，继续使用原来的策略
+	p.Update(configtest.DefaultPath, []byte("retry:\n  payment:\n    backoff: random\n"))
+	if got := s.Get("payment").Policy().MaxAttempts; got != 7 {
+		t.Fatalf("expect the old policy to stay, got %d", got)
+	}
+}
@@ -0,0 +1,83 @@
+package retry
+
+import (
+	"sync"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中重试配置段的key
+const SectionKey = "retry"
+
+// DefaultName 没有单独配置的调用使用的策略名
+const DefaultName = "default"
+
+// Set 按照名字管理重试策略
+type Set struct {
+	c        config.Config
+	lock     sync.RWMutex
+	policies map[string]Policy
+	retriers map[string]*Retrier
+}
+
+// NewSet 解析c中的 retry 配置段，配置重新加载后更新全部Retrier的策略，配置段解析失败时继续使用原来的策略
+func NewSet(c config.Config) *Set {
+	s := &Set{c: c, policies: map[string]Policy{}, retriers: make(map[string]*Retrier)}
+	if err := s.reload(); err != nil {
+		config.Errorf("app/retry: %v", err)
+	}
+	config.WatchSection(c, SectionKey, s.reload)
+	return s
+}
+
+func (s *Set) reload() error {
+	policies := make(map[string]Policy)
+	if s.c.IsSet(SectionKey) {
+		if err := s.c.UnmarshalKey(SectionKey, &policies); err != nil {
+			return err
+		}
+	}
+	for name, p := range policies {
+		for _, class := range p.RetryOn {
+			if getClass(class) == nil {
+				config.Warnf("app/retry: %s: unknown error class %s", name, class)
+			}
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.policies = policies
+	for name, r := range s.retriers {
+		r.SetPolicy(s.policy(name))
+	}
+	return nil
+}
+
+// policy 名字对应的策略，调用方需要持有锁
+func (s *Set) policy(name string) Policy {
+	if p, ok := s.policies[name]; ok {
+		return p
+	}
+	if p, ok := s.policies[DefaultName]; ok {
+		return p
+	}
+	return DefaultPolicy
+}
+
+// Get 获取名字对应的Retrier，没有时按照配置的策略创建
+func (s *Set) Get(name string) *Retrier {
+	s.lock.RLock()
+	r, ok := s.retriers[name]
+	s.lock.RUnlock()
+	if ok {
+		return r
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if r, ok = s.retriers[name]; !ok {
+		r = New(name, s.policy(name))
+		s.retriers[name] = r
+	}
+	return r
+}
@@ -0,0 +1,67 @@
+package rollout
+
+import (
+	"context"
+	"sync"
+)
+
+func init() {
+	RegisterBackend("local", NewLocalBackend())
+}
+
+// LocalBackend 进程内的协调存储，只能协调同一进程中加载的配置，用于单机部署和测试
+type LocalBackend struct {
+	lock   sync.Mutex
+	sems   map[string]*semaphore
+	halted map[string]string
+}
+
+// semaphore 名额数为limit的信号量，limit变化时创建新的信号量，已获得的名额释放到原来的信号量
+type semaphore struct {
+	limit int
+	slots chan struct{}
+}
+
+// NewLocalBackend 创建进程内的协调存储
+func NewLocalBackend() *LocalBackend {
+	return &LocalBackend{sems: make(map[string]*semaphore), halted: make(map[string]string)}
+}
+
+// Acquire 获取key对应的信号量中的一个名额
+func (b *LocalBackend) Acquire(ctx context.Context, key string, limit int) (func(), error) {
+	b.lock.Lock()
+	s, ok := b.sems[key]
+	if !ok || s.limit != limit {
+		s = &semaphore{limit: limit, slots: make(chan struct{}, limit)}
+		b.sems[key] = s
+	}
+	b.lock.Unlock()
+
+	select {
+	case s.slots <- struct{}{}:
+		var once sync.Once
+		return func() {
+			once.Do(func() {
+				<-s.slots
+			})
+		}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Halt 暂停key对应的新配置的发布
+func (b *LocalBackend) Halt(ctx context.Context, key, reason string) error {
+	b.lock.Lock()
+	b.halted[key] = reason
+	b.lock.Unlock()
+	return nil
+}
+
+// Halted 返回key对应的新配置是否已暂停发布
+func (b *LocalBackend) Halted(ctx context.Context, key string) (string, bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	reason, ok := b.halted[key]
+	return reason, ok, nil
+}
@@ -0,0 +1,190 @@
+// Package rollout 分批生效新配置
+// 一组实例监听同一份远程配置时，内容源变化后全部实例会在同一时刻切换到新配置，新配置有问题时整个集群同时受影响。
+// Coordinator 实现了 config.ReloadGate，通过分布式信号量限制同时生效新配置的实例数，
+// 每个实例生效后观察一段时间并执行健康检查，检查失败时暂停该版本的发布，尚未生效的实例继续使用原来的配置：
+//
+//	rollout:
+//	  backend: etcd         # 分布式协调的存储，etcd、consul等通过 RegisterBackend 注册，默认为进程内的local
+//	  batch_size: 2         # 同时生效新配置的实例数
+//	  settle: 30000         # 毫秒，生效后观察的时间，之后执行健康检查并释放名额
+//	  timeout: 600000       # 毫秒，等待名额的最长时间，超时后放弃本次重新加载
+//
+//	co, err := rollout.New(c)
+//	co.AddHealthCheck("http", checkErrorRate)
+//	remote, err := config.Load("app.yaml", config.WithProvider("etcd"), config.WithAutoReload(), config.WithReloadGate(co))
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中分批生效配置段的key
+const SectionKey = "rollout"
+
+// ErrHalted 新配置的发布已被暂停，通常是已生效的实例健康检查失败
+var ErrHalted = errors.New("app/rollout: rollout halted")
+
+// Options rollout 配置段，时间单位为毫秒
+type Options struct {
+	Backend   string `yaml:"backend" default:"local"`
+	BatchSize int    `yaml:"batch_size" default:"1" validate:"min=1"`
+	Settle    int    `yaml:"settle" default:"30000" validate:"min=0"`
+	Timeout   int    `yaml:"timeout" default:"600000" validate:"min=0"`
+}
+
+// Backend 分布式协调的存储，例如基于etcd的lease和事务、consul的session和KV实现
+type Backend interface {
+	// Acquire 获取key对应的信号量中的一个名额，同时最多limit个持有者，ctx结束时返回ctx的错误
+	// 实例退出时名额需要自动释放，例如绑定到etcd的lease
+	Acquire(ctx context.Context, key string, limit int) (release func(), err error)
+	// Halt 暂停key对应的新配置的发布
+	Halt(ctx context.Context, key, reason string) error
+	// Halted 返回key对应的新配置是否已暂停发布及原因
+	Halted(ctx context.Context, key string) (reason string, halted bool, err error)
+}
+
+var (
+	backends    = make(map[string]Backend)
+	backendLock sync.RWMutex
+)
+
+// RegisterBackend 注册名字为name的协调存储
+func RegisterBackend(name string, b Backend) {
+	backendLock.Lock()
+	backends[name] = b
+	backendLock.Unlock()
+}
+
+// GetBackend 获取名字为name的协调存储
+func GetBackend(name string) Backend {
+	backendLock.RLock()
+	defer backendLock.RUnlock()
+	return backends[name]
+}
+
+// HealthCheck 新配置生效并观察 settle 之后执行的健康检查，返回错误时暂停发布
+type HealthCheck func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// Coordinator 分批生效新配置，实现了 config.ReloadGate
+type Coordinator struct {
+	// opts 当前的 *Options
+	opts atomic.Value
+
+	lock   sync.Mutex
+	checks []namedCheck
+}
+
+// New 解析c中的 rollout 配置段，配置重新加载后立即使用新的参数
+func New(c config.Config) (*Coordinator, error) {
+	opts, err := parse(c)
+	if err != nil {
+		return nil, err
+	}
+	co := &Coordinator{}
+	co.opts.Store(opts)
+	config.WatchSection(c, SectionKey, func() error {
+		opts, err := parse(c)
+		if err != nil {
+			return err
+		}
+		co.opts.Store(opts)
+		return nil
+	})
+	return co, nil
+}
+
+func parse(c config.Config) (*Options, error) {
+	opts := &Options{Backend: "local", BatchSize: 1, Settle: 30000, Timeout: 600000}
+	if c.IsSet(SectionKey) {
+		if err := c.UnmarshalKey(SectionKey, opts); err != nil {
+			return nil, fmt.Errorf("app/rollout: failed to parse %s: %v", SectionKey, err)
+		}
+	}
+	return opts, nil
+}
+
+// AddHealthCheck 注册健康检查
+func (co *Coordinator) AddHealthCheck(name string, check HealthCheck) {
+	co.lock.Lock()
+	co.checks = append(co.checks, namedCheck{name: name, check: check})
+	co.lock.Unlock()
+}
+
+// Wait 等待获得生效新配置的名额，新配置已暂停发布时返回 ErrHalted
+// 返回的done在新配置生效后调用，观察 settle 并执行健康检查后释放名额
+func (co *Coordinator) Wait(ctx context.Context, path, fingerprint string) (func(), error) {
+	opts := co.opts.Load().(*Options)
+	b := GetBackend(opts.Backend)
+	if b == nil {
+		return nil, fmt.Errorf("app/rollout: backend %s not registered", opts.Backend)
+	}
+	key := path + "@" + fingerprint
+	if err := checkHalted(ctx, b, key); err != nil {
+		return nil, err
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(opts.Timeout)*time.Millisecond)
+		defer cancel()
+	}
+	// 不同版本共用同一个信号量，发布新版本时上一个版本尚未观察完的实例同样占用名额
+	release, err := b.Acquire(ctx, path, opts.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("app/rollout: failed to acquire %s: %v", path, err)
+	}
+	// 等待期间已生效的实例可能暂停了发布
+	if err := checkHalted(ctx, b, key); err != nil {
+		release()
+		return nil, err
+	}
+	config.Infof("app/rollout: applying %s", key)
+
+	return func() {
+		go co.settle(b, key, time.Duration(opts.Settle)*time.Millisecond, release)
+	}, nil
+}
+
+func checkHalted(ctx context.Context, b Backend, key string) error {
+	reason, halted, err := b.Halted(ctx, key)
+	if err != nil {
+		return fmt.Errorf("app/rollout: %v", err)
+	}
+	if halted {
+		return fmt.Errorf("%w: %s: %s", ErrHalted, key, reason)
+	}
+	return nil
+}
+
+// settle 观察d后执行健康检查，失败时暂停发布，之后释放名额
+func (co *Coordinator) settle(b Backend, key string, d time.Duration, release func()) {
+	defer release()
+	time.Sleep(d)
+
+	co.lock.Lock()
+	checks := append([]namedCheck(nil), co.checks...)
+	co.lock.Unlock()
+	for _, c := range checks {
+		if err := c.check(context.Background()); err != nil {
+			reason := c.name + ": " + err.Error()
+			config.Errorf("app/rollout: %s unhealthy, halting rollout: %s", key, reason)
+			if err := b.Halt(context.Background(), key, reason); err != nil {
+				config.Errorf("app/rollout: failed to halt %s: %v", key, err)
+			}
+			return
+		}
+	}
+	config.Infof("app/rollout: %s healthy", key)
+}
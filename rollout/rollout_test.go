@@ -0,0 +1,125 @@
+package rollout
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/config/configtest"
+)
+
+// newCoordinator 使用独立的local协调存储创建Coordinator
+func newCoordinator(t *testing.T, name, section string) (*Coordinator, *configtest.Provider) {
+	t.Helper()
+	RegisterBackend(name, NewLocalBackend())
+	c, p := configtest.Load(t, "rollout:\n  backend: "+name+"\n"+section)
+	co, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return co, p
+}
+
+func TestBatchSize(t *testing.T) {
+	co, _ := newCoordinator(t, "rollout-batch", "  batch_size: 1\n  settle: 100\n")
+	ctx := context.Background()
+	done, err := co.Wait(ctx, "app.yaml", "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一个实例生效并观察结束前，第二个实例等待名额
+	second := make(chan time.Time, 1)
+	go func() {
+		if _, err := co.Wait(ctx, "app.yaml", "v2"); err != nil {
+			t.Error(err)
+		}
+		second <- time.Now()
+	}()
+	select {
+	case <-second:
+		t.Fatal("expect the second instance to wait for a slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+	applied := time.Now()
+	done()
+	select {
+	case at := <-second:
+		if at.Sub(applied) < 100*time.Millisecond {
+			t.Fatalf("expect the slot to be released after settle, got %v", at.Sub(applied))
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expect the second instance to get a slot")
+	}
+}
+
+func TestHaltOnUnhealthy(t *testing.T) {
+	co, _ := newCoordinator(t, "rollout-halt", "  batch_size: 2\n  settle: 10\n")
+	co.AddHealthCheck("errors", func(context.Context) error { return errors.New("error rate 5%") })
+
+	ctx := context.Background()
+	done, err := co.Wait(ctx, "app.yaml", "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		_, err := co.Wait(ctx, "app.yaml", "v2")
+		if errors.Is(err, ErrHalted) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expect ErrHalted, got %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// 只暂停该版本
+	if _, err := co.Wait(ctx, "app.yaml", "v3"); err != nil {
+		t.Fatalf("expect another version to proceed, got %v", err)
+	}
+}
+
+func TestReloadGate(t *testing.T) {
+	co, _ := newCoordinator(t, "rollout-gate", "  batch_size: 1\n  settle: 10\n")
+	co.AddHealthCheck("canary", func(context.Context) error { return errors.New("unhealthy") })
+
+	// 两个实例加载同一份配置，第一个实例生效新配置后健康检查失败
+	first, p1 := configtest.Load(t, "a: 1\n", config.WithReloadGate(co))
+	second, p2 := configtest.Load(t, "a: 1\n", config.WithReloadGate(co))
+	p1.Update(configtest.DefaultPath, []byte("a: 2\n"))
+	if got := first.GetInt("a", 0); got != 2 {
+		t.Fatalf("expect the first instance to apply a: 2, got %d", got)
+	}
+
+	sum := sha256.Sum256([]byte("a: 2\n"))
+	key := configtest.DefaultPath + "@" + hex.EncodeToString(sum[:])
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if _, ok, _ := GetBackend("rollout-gate").Halted(context.Background(), key); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expect the rollout of a: 2 to be halted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 尚未生效的实例继续使用原来的配置
+	p2.Update(configtest.DefaultPath, []byte("a: 2\n"))
+	if got := second.GetInt("a", 0); got != 1 {
+		t.Fatalf("expect the second instance to keep a: 1, got %d", got)
+	}
+}
+
+func TestReloadOptions(t *testing.T) {
+	co, p := newCoordinator(t, "rollout-reload", "  batch_size: 1\n")
+	p.Update(configtest.DefaultPath, []byte("rollout:\n  backend: rollout-reload\n  batch_size: 3\n"))
+	if got := co.opts.Load().(*Options).BatchSize; got != 3 {
+		t.Fatalf("expect batch_size 3 after reload, got %d", got)
+	}
+}
@@ -0,0 +1,181 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 任务的执行时间
+type Schedule interface {
+	// Next 返回t之后的下一次执行时间
+	Next(t time.Time) time.Time
+}
+
+// every 固定间隔执行
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// descriptors 预定义的cron表达式
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule 解析执行时间，支持5个字段的cron表达式（分 时 日 月 周）、@daily 等预定义表达式和 @every 30s
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("app/scheduler: invalid schedule %q", spec)
+		}
+		return every(d), nil
+	}
+	if s, ok := descriptors[spec]; ok {
+		spec = s
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("app/scheduler: invalid schedule %q: expect 5 fields", spec)
+	}
+	c := &cron{}
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("app/scheduler: invalid schedule %q: minute: %v", spec, err)
+	}
+	if c.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("app/scheduler: invalid schedule %q: hour: %v", spec, err)
+	}
+	if c.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("app/scheduler: invalid schedule %q: day of month: %v", spec, err)
+	}
+	if c.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("app/scheduler: invalid schedule %q: month: %v", spec, err)
+	}
+	if c.dow, err = parseField(fields[4], 0, 7, dowNames); err != nil {
+		return nil, fmt.Errorf("app/scheduler: invalid schedule %q: day of week: %v", spec, err)
+	}
+	// 7 与 0 都表示周日
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny, c.dowAny = fields[2] == "*", fields[4] == "*"
+	return c, nil
+}
+
+var (
+	monthNames = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	dowNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+// parseField 解析cron表达式的一个字段，支持 *、列表、范围和步长，返回取值的位图
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			step, part = n, part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			i := strings.Index(part, "-")
+			var err error
+			if lo, err = fieldValue(part[:i], names); err != nil {
+				return 0, err
+			}
+			if hi, err = fieldValue(part[i+1:], names); err != nil {
+				return 0, err
+			}
+		default:
+			v, err := fieldValue(part, names)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func fieldValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+// cron 5个字段的cron表达式
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// maxYears 查找下一次执行时间的最大年数，例如 2月30日 永远不会执行
+const maxYears = 5
+
+func (c *cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxYears, 0, 0)
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchDay 日和周都有限制时满足任意一个即可
+func (c *cron) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
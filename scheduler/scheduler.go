@@ -0,0 +1,274 @@
+// Package scheduler 定时任务
+// 任务在代码中注册执行函数，执行时间、是否启用和并发策略在框架配置的 jobs 配置段中声明，配置变化时立即重新调度：
+//
+//	jobs:
+//	  cleanup:
+//	    schedule: "*/5 * * * *"   # cron表达式，或 @daily、@every 30s
+//	    enabled: true
+//	    concurrency: forbid       # 上一次执行未结束时：forbid 跳过本次，replace 取消上一次，allow 同时执行
+//	    timeout: 60000            # 毫秒，单次执行的超时时间
+//
+//	s := scheduler.New()
+//	s.Register("cleanup", cleanup)
+//	app.Register(s)
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中定时任务配置段的key
+const SectionKey = "jobs"
+
+// 并发策略
+const (
+	ConcurrencyForbid  = "forbid"
+	ConcurrencyReplace = "replace"
+	ConcurrencyAllow   = "allow"
+)
+
+// JobFunc 任务的执行函数，ctx在超时、被replace策略取消或服务退出时结束
+type JobFunc func(ctx context.Context) error
+
+// JobConfig 单个任务的配置
+type JobConfig struct {
+	Schedule    string `yaml:"schedule"`
+	Enabled     bool   `yaml:"enabled" default:"true"`
+	Concurrency string `yaml:"concurrency" default:"forbid" enum:"forbid,replace,allow"`
+	// Timeout 单次执行的超时时间，0表示不限制
+	Timeout int `yaml:"timeout" validate:"min=0"`
+}
+
+// plan 解析后的任务配置
+type plan struct {
+	cfg      JobConfig
+	schedule Schedule
+}
+
+// job 注册的任务
+type job struct {
+	name string
+	fn   JobFunc
+
+	lock    sync.Mutex
+	plan    *plan
+	running map[int64]context.CancelFunc
+	seq     int64
+	// update 任务配置变化时通知调度协程重新计算下一次执行时间
+	update chan struct{}
+}
+
+// Scheduler 定时任务调度器，实现了app的Module、Starter、Stopper、Reloader接口
+type Scheduler struct {
+	lock    sync.Mutex
+	jobs    map[string]*job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+// New 创建Scheduler
+func New() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{jobs: make(map[string]*job), ctx: ctx, cancel: cancel}
+}
+
+// Register 注册任务，需要在 Init 之前调用，没有配置的任务不会执行
+func (s *Scheduler) Register(name string, fn JobFunc) {
+	s.lock.Lock()
+	s.jobs[name] = &job{name: name, fn: fn, running: make(map[int64]context.CancelFunc), update: make(chan struct{}, 1)}
+	s.lock.Unlock()
+}
+
+// Name 模块名
+func (s *Scheduler) Name() string {
+	return "scheduler"
+}
+
+// Init 解析 jobs 配置段，配置的任务没有注册或执行时间无效时返回错误
+func (s *Scheduler) Init(c config.Config) error {
+	plans, err := s.parse(c)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for name, j := range s.jobs {
+		j.plan = plans[name]
+	}
+	return nil
+}
+
+// parse 解析 jobs 配置段
+func (s *Scheduler) parse(c config.Config) (map[string]*plan, error) {
+	cfgs := make(map[string]*JobConfig)
+	if c.IsSet(SectionKey) {
+		if err := c.UnmarshalKey(SectionKey, &cfgs); err != nil {
+			return nil, fmt.Errorf("app/scheduler: failed to parse %s: %v", SectionKey, err)
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	plans := make(map[string]*plan, len(cfgs))
+	for name, cfg := range cfgs {
+		if _, ok := s.jobs[name]; !ok {
+			return nil, fmt.Errorf("app/scheduler: job %s not registered", name)
+		}
+		sched, err := ParseSchedule(cfg.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("app/scheduler: job %s: %v", name, err)
+		}
+		plans[name] = &plan{cfg: *cfg, schedule: sched}
+	}
+	return plans, nil
+}
+
+// Start 为每个注册的任务启动调度协程
+func (s *Scheduler) Start() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.started {
+		return nil
+	}
+	s.started = true
+	names := make([]string, 0, len(s.jobs))
+	for name := range s.jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s.wg.Add(1)
+		go s.loop(s.jobs[name])
+	}
+	return nil
+}
+
+// loop 调度单个任务，任务配置变化时重新计算下一次执行时间
+func (s *Scheduler) loop(j *job) {
+	defer s.wg.Done()
+	for {
+		j.lock.Lock()
+		p := j.plan
+		j.lock.Unlock()
+
+		var next <-chan time.Time
+		var timer *time.Timer
+		if p != nil && p.cfg.Enabled {
+			if at := p.schedule.Next(time.Now()); !at.IsZero() {
+				timer = time.NewTimer(time.Until(at))
+				next = timer.C
+			}
+		}
+
+		select {
+		case <-s.ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-j.update:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-next:
+			s.run(j, p)
+		}
+	}
+}
+
+// run 按照并发策略执行一次任务
+func (s *Scheduler) run(j *job, p *plan) {
+	j.lock.Lock()
+	if len(j.running) > 0 {
+		switch p.cfg.Concurrency {
+		case ConcurrencyForbid:
+			j.lock.Unlock()
+			config.Warnf("app/scheduler: job %s is still running, skipped", j.name)
+			return
+		case ConcurrencyReplace:
+			for _, cancel := range j.running {
+				cancel()
+			}
+		}
+	}
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if p.cfg.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(s.ctx, time.Duration(p.cfg.Timeout)*time.Millisecond)
+	} else {
+		ctx, cancel = context.WithCancel(s.ctx)
+	}
+	j.seq++
+	id := j.seq
+	j.running[id] = cancel
+	j.lock.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			cancel()
+			j.lock.Lock()
+			delete(j.running, id)
+			j.lock.Unlock()
+		}()
+		defer func() {
+			if e := recover(); e != nil {
+				config.Errorf("app/scheduler: job %s panic: %v", j.name, e)
+			}
+		}()
+		if err := j.fn(ctx); err != nil {
+			config.Errorf("app/scheduler: job %s failed: %v", j.name, err)
+		}
+	}()
+}
+
+// Reload jobs 配置段变化时重新调度，配置无效时继续使用原来的配置
+func (s *Scheduler) Reload(c config.Config, changes []config.Change) {
+	plans, err := s.parse(c)
+	if err != nil {
+		config.Errorf("app/scheduler: failed to reload, keep using the old schedules: %v", err)
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for name, j := range s.jobs {
+		j.lock.Lock()
+		old, p := j.plan, plans[name]
+		changed := (old == nil) != (p == nil) || (p != nil && p.cfg != old.cfg)
+		if changed {
+			j.plan = p
+		}
+		j.lock.Unlock()
+		if changed {
+			select {
+			case j.update <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// Stop 停止调度并取消正在执行的任务，等待任务结束直到ctx结束
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
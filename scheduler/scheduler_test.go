@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config/configtest"
+)
+
+func TestParseSchedule(t *testing.T) {
+	base := time.Date(2021, 3, 5, 10, 7, 30, 0, time.UTC) // 周五
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/5 * * * *", time.Date(2021, 3, 5, 10, 10, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2021, 3, 6, 2, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2021, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * mon-wed", time.Date(2021, 3, 8, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2021, 3, 7, 0, 0, 0, 0, time.UTC)},
+		// 日和周都有限制时满足任意一个
+		{"0 0 1 * sat", time.Date(2021, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jun *", time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 30s", base.Add(30 * time.Second)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("%s: %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(base); !got.Equal(tt.want) {
+			t.Errorf("%s: expect %v, got %v", tt.spec, tt.want, got)
+		}
+	}
+
+	for _, spec := range []string{"* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "@every -1s", "@every x"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("%s: expect an error", spec)
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	c, _ := configtest.Load(t, "jobs:\n  unknown:\n    schedule: \"@daily\"\n")
+	s := New()
+	if err := s.Init(c); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Fatalf("expect an unregistered job error, got %v", err)
+	}
+
+	c, _ = configtest.Load(t, "jobs:\n  cleanup:\n    schedule: \"61 * * * *\"\n")
+	s = New()
+	s.Register("cleanup", func(context.Context) error { return nil })
+	if err := s.Init(c); err == nil || !strings.Contains(err.Error(), "cleanup") {
+		t.Fatalf("expect an invalid schedule error, got %v", err)
+	}
+}
+
+func TestRunAndReload(t *testing.T) {
+	c, p := configtest.Load(t, "jobs:\n  tick:\n    schedule: \"@every 10ms\"\n")
+	var runs int32
+	s := New()
+	s.Register("tick", func(context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop(context.Background())
+
+	deadline := time.Now().Add(3 * time.Second)
+	for atomic.LoadInt32(&runs) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("expect the job to run")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 禁用后不再执行
+	p.Update(configtest.DefaultPath, []byte("jobs:\n  tick:\n    schedule: \"@every 10ms\"\n    enabled: false\n"))
+	s.Reload(c, nil)
+	time.Sleep(30 * time.Millisecond)
+	stopped := atomic.LoadInt32(&runs)
+	time.Sleep(50 * time.Millisecond)
+	if got := atomic.LoadInt32(&runs); got != stopped {
+		t.Fatalf("expect no runs after the job is disabled, got %d more", got-stopped)
+	}
+
+	// 无效配置继续使用原来的配置
+	p.Update(configtest.DefaultPath, []byte("jobs:\n  tick:\n    schedule: bad\n"))
+	s.Reload(c, nil)
+	s.jobs["tick"].lock.Lock()
+	enabled := s.jobs["tick"].plan.cfg.Enabled
+	s.jobs["tick"].lock.Unlock()
+	if enabled {
+		t.Fatal("expect the old schedule to be kept")
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	tests := []struct {
+		concurrency string
+		runs        int32
+		canceled    int32
+	}{
+		{ConcurrencyForbid, 1, 0},
+		{ConcurrencyReplace, 2, 1},
+		{ConcurrencyAllow, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.concurrency, func(t *testing.T) {
+			var runs, canceled int32
+			release := make(chan struct{})
+			s := New()
+			j := &job{name: "slow", running: make(map[int64]context.CancelFunc), update: make(chan struct{}, 1)}
+			j.fn = func(ctx context.Context) error {
+				atomic.AddInt32(&runs, 1)
+				select {
+				case <-ctx.Done():
+					atomic.AddInt32(&canceled, 1)
+				case <-release:
+				}
+				return nil
+			}
+			p := &plan{cfg: JobConfig{Enabled: true, Concurrency: tt.concurrency}}
+			s.run(j, p)
+			for atomic.LoadInt32(&runs) == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			s.run(j, p)
+			// replace策略先确认上一次执行被取消，避免与release同时就绪
+			for atomic.LoadInt32(&runs) < tt.runs || atomic.LoadInt32(&canceled) < tt.canceled {
+				time.Sleep(time.Millisecond)
+			}
+			close(release)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			if err := s.Stop(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if runs != tt.runs || canceled != tt.canceled {
+				t.Fatalf("expect %d runs and %d canceled, got %d and %d", tt.runs, tt.canceled, runs, canceled)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	done := make(chan error, 1)
+	s := New()
+	j := &job{name: "timeout", running: make(map[int64]context.CancelFunc), update: make(chan struct{}, 1)}
+	j.fn = func(ctx context.Context) error {
+		<-ctx.Done()
+		done <- ctx.Err()
+		return nil
+	}
+	s.run(j, &plan{cfg: JobConfig{Enabled: true, Concurrency: ConcurrencyForbid, Timeout: 10}})
+	select {
+	case err := <-done:
+		if err != context.DeadlineExceeded {
+			t.Fatalf("expect DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expect the job to time out")
+	}
+	s.Stop(context.Background())
+}
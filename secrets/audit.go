@@ -0,0 +1,46 @@
+package secrets
+
+import (
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// AccessRecord 密钥访问审计记录，不包含密钥的值
+type AccessRecord struct {
+	Time     time.Time `json:"time"`
+	Name     string    `json:"name"`
+	Provider string    `json:"provider"`
+	// Caller 调用 Get 的位置，格式为 文件:行号
+	Caller string `json:"caller"`
+	// Refreshed 本次访问是否从内容源重新读取
+	Refreshed bool   `json:"refreshed"`
+	Error     string `json:"error,omitempty"`
+}
+
+// AccessSink 访问审计记录的输出目标
+type AccessSink interface {
+	Write(*AccessRecord) error
+}
+
+// AccessSinkFunc 函数形式的AccessSink
+type AccessSinkFunc func(*AccessRecord) error
+
+// Write 实现AccessSink接口
+func (f AccessSinkFunc) Write(r *AccessRecord) error {
+	return f(r)
+}
+
+var sinks config.SinkRegistry
+
+// RegisterAccessSink 注册访问审计记录输出目标，每次调用 Get 都会写入一条记录
+func RegisterAccessSink(s AccessSink) {
+	sinks.Register(func(r interface{}) error { return s.Write(r.(*AccessRecord)) })
+}
+
+// audit 写入访问审计记录
+func audit(r *AccessRecord) {
+	for _, err := range sinks.Write(r) {
+		config.Errorf("app/secrets: failed to write access record of %s: %v", r.Name, err)
+	}
+}
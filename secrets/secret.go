@@ -0,0 +1,54 @@
+package secrets
+
+import (
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// Secret 密钥的值，打印、格式化和JSON序列化时都输出脱敏后的值，只有 Value、Bytes 返回明文
+type Secret struct {
+	name      string
+	value     []byte
+	expiresAt time.Time
+}
+
+// Name 密钥名
+func (s *Secret) Name() string {
+	return s.name
+}
+
+// Value 密钥明文
+func (s *Secret) Value() string {
+	return string(s.value)
+}
+
+// Bytes 密钥明文的副本
+func (s *Secret) Bytes() []byte {
+	return append([]byte(nil), s.value...)
+}
+
+// ExpiresAt 租约到期时间，零值表示没有租约
+func (s *Secret) ExpiresAt() time.Time {
+	return s.expiresAt
+}
+
+// String 返回脱敏后的值
+func (s *Secret) String() string {
+	return config.RedactedValue
+}
+
+// GoString 返回脱敏后的值，用于 %#v
+func (s *Secret) GoString() string {
+	return config.RedactedValue
+}
+
+// MarshalJSON 序列化为脱敏后的值
+func (s *Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + config.RedactedValue + `"`), nil
+}
+
+// MarshalText 序列化为脱敏后的值，用于yaml、结构化日志等
+func (s *Secret) MarshalText() ([]byte, error) {
+	return []byte(config.RedactedValue), nil
+}
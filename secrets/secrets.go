@@ -0,0 +1,220 @@
+// Package secrets 密钥管理
+// 密钥在框架配置的 secrets 配置段中只声明所在的内容源，值在 Get 时从内容源读取，只保存在内存中，
+// 不写入配置树、解析缓存或任何磁盘文件；Secret 在日志和序列化中始终脱敏，每次访问都写入审计记录：
+//
+//	secrets:
+//	  db_password:
+//	    provider: file      # 内容源，vault等通过 config.RegisterProvider 注册
+//	    path: /etc/secrets/db_password
+//	  api_token:
+//	    provider: vault
+//	    path: secret/data/api#token
+//	    ttl: 300000         # 毫秒，内容源没有租约时的有效期，0表示直到内容源通知变化
+//
+//	store := secrets.New(c)
+//	s, err := store.Get(ctx, "db_password")
+//	connect(s.Value())
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中密钥配置段的key
+const SectionKey = "secrets"
+
+// ErrNotDeclared 密钥没有在 secrets 配置段中声明
+var ErrNotDeclared = errors.New("app/secrets: secret not declared")
+
+// ErrExpired 租约已到期且重新读取失败
+var ErrExpired = errors.New("app/secrets: lease expired")
+
+// Definition 密钥所在的内容源
+type Definition struct {
+	Provider string `yaml:"provider" default:"file"`
+	Path     string `yaml:"path" validate:"required"`
+	// TTL 内容源没有租约时的有效期，单位毫秒，0表示直到内容源通知变化
+	TTL int `yaml:"ttl" validate:"min=0"`
+}
+
+// LeasedProvider DataProvider的可选接口，读取密钥的同时返回租约时长，例如vault的动态凭据
+// 租约剩余三分之一时 Get 会重新读取，lease<=0表示没有租约
+type LeasedProvider interface {
+	ReadLease(ctx context.Context, path string) (data []byte, lease time.Duration, err error)
+}
+
+// entry 已读取的密钥
+type entry struct {
+	def    Definition
+	secret *Secret
+	// refreshAt 需要重新读取的时间，零值表示不需要
+	refreshAt time.Time
+}
+
+// Store 按照名字读取密钥
+type Store struct {
+	c config.Config
+
+	lock    sync.Mutex
+	defs    map[string]Definition
+	entries map[string]*entry
+	watched map[string]bool
+}
+
+// New 解析c中的 secrets 配置段，配置重新加载后更新密钥声明，声明变化的密钥在下次 Get 时重新读取
+func New(c config.Config) *Store {
+	s := &Store{c: c, defs: map[string]Definition{}, entries: make(map[string]*entry), watched: make(map[string]bool)}
+	if err := s.reload(); err != nil {
+		config.Errorf("app/secrets: %v", err)
+	}
+	config.WatchSection(c, SectionKey, s.reload)
+	return s
+}
+
+func (s *Store) reload() error {
+	defs := make(map[string]Definition)
+	if s.c.IsSet(SectionKey) {
+		if err := s.c.UnmarshalKey(SectionKey, &defs); err != nil {
+			return err
+		}
+	}
+	for name, d := range defs {
+		if d.Provider == "" {
+			d.Provider = "file"
+			defs[name] = d
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.defs = defs
+	for name, e := range s.entries {
+		if d, ok := defs[name]; !ok || d != e.def {
+			delete(s.entries, name)
+		}
+	}
+	return nil
+}
+
+// Names 已声明的密钥名
+func (s *Store) Names() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	names := make([]string, 0, len(s.defs))
+	for name := range s.defs {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Get 获取密钥，没有读取过或租约即将到期时从内容源读取
+// 租约到期前重新读取失败时返回原来的值，到期后返回 ErrExpired
+func (s *Store) Get(ctx context.Context, name string) (*Secret, error) {
+	r := &AccessRecord{Time: config.Now(), Name: name, Caller: caller()}
+	sec, err := s.get(ctx, name, r)
+	if err != nil {
+		r.Error = err.Error()
+	}
+	audit(r)
+	return sec, err
+}
+
+func (s *Store) get(ctx context.Context, name string, r *AccessRecord) (*Secret, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	def, ok := s.defs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotDeclared, name)
+	}
+	r.Provider = def.Provider
+
+	now := config.Now()
+	e, ok := s.entries[name]
+	if ok && (e.refreshAt.IsZero() || now.Before(e.refreshAt)) {
+		return e.secret, nil
+	}
+
+	r.Refreshed = true
+	fresh, err := s.read(ctx, name, def)
+	if err != nil {
+		if ok && (e.secret.expiresAt.IsZero() || now.Before(e.secret.expiresAt)) {
+			config.Warnf("app/secrets: failed to refresh %s, keep using the old value: %v", name, err)
+			return e.secret, nil
+		}
+		if ok {
+			return nil, fmt.Errorf("%w: %s: %v", ErrExpired, name, err)
+		}
+		return nil, err
+	}
+	s.entries[name] = fresh
+	return fresh.secret, nil
+}
+
+// read 从内容源读取密钥，调用方需要持有锁
+func (s *Store) read(ctx context.Context, name string, def Definition) (*entry, error) {
+	p := config.GetProvider(def.Provider)
+	if p == nil {
+		return nil, fmt.Errorf("app/secrets: %s: %v", def.Provider, config.ErrProviderNotExist)
+	}
+	if !s.watched[def.Provider] {
+		s.watched[def.Provider] = true
+		p.Watch(func(path string, _ []byte) {
+			s.invalidate(def.Provider, path)
+		})
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var data []byte
+	var lease time.Duration
+	var err error
+	if lp, ok := p.(LeasedProvider); ok {
+		data, lease, err = lp.ReadLease(ctx, def.Path)
+	} else {
+		data, err = p.Read(def.Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("app/secrets: failed to read %s: %v", name, err)
+	}
+	if lease <= 0 && def.TTL > 0 {
+		lease = time.Duration(def.TTL) * time.Millisecond
+	}
+
+	e := &entry{def: def, secret: &Secret{name: name, value: bytes.TrimSpace(data)}}
+	if lease > 0 {
+		now := config.Now()
+		e.secret.expiresAt = now.Add(lease)
+		e.refreshAt = now.Add(lease - lease/3)
+	}
+	return e, nil
+}
+
+// invalidate 内容源通知变化时丢弃对应的密钥，下次 Get 时重新读取
+func (s *Store) invalidate(provider, path string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for name, e := range s.entries {
+		if e.def.Provider == provider && e.def.Path == path {
+			delete(s.entries, name)
+			config.Infof("app/secrets: %s changed", name)
+		}
+	}
+}
+
+// caller 返回 Store.Get 调用方的位置
+func caller() string {
+	_, file, line, ok := runtime.Caller(2)
+	if !ok {
+		return ""
+	}
+	return file + ":" + strconv.Itoa(line)
+}
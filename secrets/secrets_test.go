@@ -0,0 +1,143 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/config/configtest"
+)
+
+// accesses 收集的访问审计记录，不同测试使用不同的密钥名
+var accesses struct {
+	lock    sync.Mutex
+	records []*AccessRecord
+}
+
+func init() {
+	RegisterAccessSink(AccessSinkFunc(func(r *AccessRecord) error {
+		accesses.lock.Lock()
+		accesses.records = append(accesses.records, r)
+		accesses.lock.Unlock()
+		return nil
+	}))
+}
+
+func accessesOf(name string) []*AccessRecord {
+	accesses.lock.Lock()
+	defer accesses.lock.Unlock()
+	var out []*AccessRecord
+	for _, r := range accesses.records {
+		if r.Name == name {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func TestGet(t *testing.T) {
+	vault := configtest.NewProvider()
+	vault.Set("db", []byte("pw1\n"))
+	c, _ := configtest.Load(t, "secrets:\n  db_password:\n    provider: "+vault.Name()+"\n    path: db\n")
+	s := New(c)
+	ctx := context.Background()
+
+	sec, err := s.Get(ctx, "db_password")
+	if err != nil || sec.Value() != "pw1" {
+		t.Fatalf("expect pw1, got %v", err)
+	}
+	// 缓存的值不会重新读取，内容源通知变化后重新读取
+	if _, err := s.Get(ctx, "db_password"); err != nil {
+		t.Fatal(err)
+	}
+	vault.Update("db", []byte("pw2"))
+	if sec, err := s.Get(ctx, "db_password"); err != nil || sec.Value() != "pw2" {
+		t.Fatalf("expect pw2 after the change, got %v", err)
+	}
+
+	records := accessesOf("db_password")
+	if len(records) != 3 || !records[0].Refreshed || records[1].Refreshed || !records[2].Refreshed {
+		t.Fatalf("unexpected access records %+v", records)
+	}
+	if !strings.Contains(records[0].Caller, "secrets_test.go") || records[0].Provider != vault.Name() {
+		t.Fatalf("unexpected access record %+v", records[0])
+	}
+
+	if _, err := s.Get(ctx, "unknown"); !errors.Is(err, ErrNotDeclared) {
+		t.Fatalf("expect ErrNotDeclared, got %v", err)
+	}
+	if records := accessesOf("unknown"); len(records) != 1 || records[0].Error == "" {
+		t.Fatal("expect a failed access record")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	sec := &Secret{name: "token", value: []byte("plain")}
+	data, _ := json.Marshal(map[string]interface{}{"token": sec})
+	for _, out := range []string{fmt.Sprint(sec), fmt.Sprintf("%v %+v %#v %s", sec, sec, sec, sec), string(data)} {
+		if strings.Contains(out, "plain") || !strings.Contains(out, config.RedactedValue) {
+			t.Errorf("expect a redacted value, got %s", out)
+		}
+	}
+}
+
+func TestLease(t *testing.T) {
+	clock := configtest.NewClock(time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC))
+	configtest.UseClock(t, clock)
+	vault := configtest.NewProvider()
+	vault.Set("token", []byte("t1"))
+	c, _ := configtest.Load(t, "secrets:\n  api_token:\n    provider: "+vault.Name()+"\n    path: token\n    ttl: 3000\n")
+	s := New(c)
+	ctx := context.Background()
+
+	sec, err := s.Get(ctx, "api_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := clock.Now().Add(3 * time.Second); !sec.ExpiresAt().Equal(want) {
+		t.Fatalf("expect expiry at %v, got %v", want, sec.ExpiresAt())
+	}
+
+	// 剩余三分之一时重新读取，失败时在到期前继续使用原来的值
+	vault.SetError("token", errors.New("unavailable"))
+	clock.Advance(2500 * time.Millisecond)
+	if sec, err := s.Get(ctx, "api_token"); err != nil || sec.Value() != "t1" {
+		t.Fatalf("expect the old value before expiry, got %v", err)
+	}
+	clock.Advance(time.Second)
+	if _, err := s.Get(ctx, "api_token"); !errors.Is(err, ErrExpired) {
+		t.Fatalf("expect ErrExpired, got %v", err)
+	}
+
+	vault.SetError("token", nil)
+	vault.Set("token", []byte("t2"))
+	if sec, err := s.Get(ctx, "api_token"); err != nil || sec.Value() != "t2" {
+		t.Fatalf("expect t2, got %v", err)
+	}
+}
+
+func TestReloadDefinitions(t *testing.T) {
+	vault := configtest.NewProvider()
+	vault.Set("a", []byte("va"))
+	vault.Set("b", []byte("vb"))
+	c, p := configtest.Load(t, "secrets:\n  reload_key:\n    provider: "+vault.Name()+"\n    path: a\n")
+	s := New(c)
+	ctx := context.Background()
+	if sec, err := s.Get(ctx, "reload_key"); err != nil || sec.Value() != "va" {
+		t.Fatalf("expect va, got %v", err)
+	}
+	// 声明变化的密钥在下次 Get 时重新读取
+	p.Update(configtest.DefaultPath, []byte("secrets:\n  reload_key:\n    provider: "+vault.Name()+"\n    path: b\n"))
+	if sec, err := s.Get(ctx, "reload_key"); err != nil || sec.Value() != "vb" {
+		t.Fatalf("expect vb, got %v", err)
+	}
+	if names := s.Names(); len(names) != 1 || names[0] != "reload_key" {
+		t.Fatalf("unexpected names %v", names)
+	}
+}
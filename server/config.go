@@ -0,0 +1,100 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// SectionKey 框架配置中服务端配置段的key
+const SectionKey = "server"
+
+// Config 服务端配置
+type Config struct {
+	// Filter 针对所有http service处理函数前后的拦截器列表
+	Filter []string `yaml:"filter"`
+	// Service 业务服务提供的service
+	Service []*ServiceConfig `yaml:"service"`
+}
+
+// ServiceConfig 单个service的配置，时间单位为毫秒，0表示不限制
+// 地址、协议、TLS、读写超时变化时需要重启服务，Timeout、MaxConns、MaxBodyBytes 重新加载配置后立即生效
+type ServiceConfig struct {
+	// Name service的路由名称
+	Name string `yaml:"name" validate:"required"`
+	// IP 监听地址，IP和Nic二选一，优先IP
+	IP string `yaml:"ip"`
+	// Nic 监听的网卡
+	Nic  string `yaml:"nic"`
+	Port int    `yaml:"port" validate:"max=65535"`
+	// Network 网络监听类型
+	Network string `yaml:"network" default:"tcp"`
+	// Protocol 应用层协议，需要通过 RegisterProtocol 注册
+	Protocol string `yaml:"protocol" default:"http"`
+	// Timeout 请求最长处理时间
+	Timeout      int `yaml:"timeout"`
+	ReadTimeout  int `yaml:"read_timeout"`
+	WriteTimeout int `yaml:"write_timeout"`
+	IdleTimeout  int `yaml:"idle_timeout"`
+	// MaxConns 最大连接数，超过时新连接直接关闭
+	MaxConns int `yaml:"max_conns"`
+	// MaxBodyBytes 请求体的最大字节数
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+	// TLSCert TLSKey 证书和私钥文件，都配置时启用TLS，文件内容变化时自动重新加载
+	TLSCert string `yaml:"tls_cert"`
+	TLSKey  string `yaml:"tls_key"`
+}
+
+// Address 监听地址
+func (s *ServiceConfig) Address() (string, error) {
+	ip := s.IP
+	if ip == "" && s.Nic != "" {
+		var err error
+		if ip, err = nicIP(s.Nic); err != nil {
+			return "", err
+		}
+	}
+	return net.JoinHostPort(ip, strconv.Itoa(s.Port)), nil
+}
+
+// restartRequired 从old变为s是否需要重启service才能生效
+func (s *ServiceConfig) restartRequired(old *ServiceConfig) bool {
+	return s.IP != old.IP || s.Nic != old.Nic || s.Port != old.Port || s.Network != old.Network ||
+		s.Protocol != old.Protocol || s.TLSCert != old.TLSCert || s.TLSKey != old.TLSKey ||
+		s.ReadTimeout != old.ReadTimeout || s.WriteTimeout != old.WriteTimeout || s.IdleTimeout != old.IdleTimeout
+}
+
+// millis 将毫秒转换为time.Duration
+func millis(ms int) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
+
+// nicIP 返回网卡的第一个IPv4地址，没有IPv4地址时返回第一个地址
+func nicIP(name string) (string, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return "", fmt.Errorf("app/server: nic %s: %v", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", fmt.Errorf("app/server: nic %s: %v", name, err)
+	}
+	var first string
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ipnet.IP.To4() != nil {
+			return ipnet.IP.String(), nil
+		}
+		if first == "" {
+			first = ipnet.IP.String()
+		}
+	}
+	if first == "" {
+		return "", fmt.Errorf("app/server: nic %s has no address", name)
+	}
+	return first, nil
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+	"runtime/debug"
+	"sync"
+
+	"goProjectTmpl/config"
+)
+
+// Filter http处理函数前后的拦截器
+type Filter func(next http.Handler) http.Handler
+
+var (
+	filters    = map[string]Filter{"recovery": recovery}
+	filterLock sync.RWMutex
+)
+
+// RegisterFilter 注册拦截器，server.filter 中通过名字引用
+func RegisterFilter(name string, f Filter) {
+	filterLock.Lock()
+	filters[name] = f
+	filterLock.Unlock()
+}
+
+// GetFilter 获取拦截器
+func GetFilter(name string) Filter {
+	filterLock.RLock()
+	defer filterLock.RUnlock()
+	return filters[name]
+}
+
+// chain 按照配置顺序组装拦截器，第一个拦截器在最外层，未注册的拦截器被忽略
+func chain(names []string, h http.Handler) http.Handler {
+	for i := len(names) - 1; i >= 0; i-- {
+		f := GetFilter(names[i])
+		if f == nil {
+			config.Warnf("app/server: filter %s not registered, ignored", names[i])
+			continue
+		}
+		h = f(h)
+	}
+	return h
+}
+
+// recovery 拦截处理函数的panic，返回500
+func recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if e := recover(); e != nil {
+				if e == http.ErrAbortHandler {
+					panic(e)
+				}
+				config.Errorf("app/server: panic serving %s: %v\n%s", r.URL.Path, e, debug.Stack())
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net"
+	"sync/atomic"
+)
+
+// limitListener 限制同时存在的连接数，上限可以在运行中修改，超过上限的新连接直接关闭
+type limitListener struct {
+	net.Listener
+	max    int64
+	active int64
+}
+
+// setMax 修改连接数上限，0表示不限制
+func (l *limitListener) setMax(n int) {
+	atomic.StoreInt64(&l.max, int64(n))
+}
+
+func (l *limitListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		max := atomic.LoadInt64(&l.max)
+		if n := atomic.AddInt64(&l.active, 1); max > 0 && n > max {
+			atomic.AddInt64(&l.active, -1)
+			conn.Close()
+			continue
+		}
+		return &limitConn{Conn: conn, l: l}, nil
+	}
+}
+
+type limitConn struct {
+	net.Conn
+	l      *limitListener
+	closed int32
+}
+
+func (c *limitConn) Close() error {
+	if atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
+		atomic.AddInt64(&c.l.active, -1)
+	}
+	return c.Conn.Close()
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"goProjectTmpl/config"
+)
+
+// RouteConfig 单个路由的配置，时间单位为毫秒，0表示不限制
+type RouteConfig struct {
+	// Path 路由路径，匹配规则与 http.ServeMux 相同，以 / 结尾时按前缀匹配
+	Path string `yaml:"path" validate:"required"`
+	// Methods 允许的请求方法，为空时不限制
+	Methods []string `yaml:"methods"`
+	// Handler 处理函数的名字，需要通过 RegisterHandler 注册
+	Handler string `yaml:"handler" validate:"required"`
+	// Timeout 请求最长处理时间，在service的timeout之内生效
+	Timeout int `yaml:"timeout"`
+	// Filter 该路由的拦截器列表，在 server.filter 之内执行
+	Filter []string `yaml:"filter"`
+}
+
+var (
+	handlers    = make(map[string]http.Handler)
+	handlerLock sync.RWMutex
+)
+
+// RegisterHandler 注册处理函数，路由配置中通过名字引用
+func RegisterHandler(name string, h http.Handler) {
+	handlerLock.Lock()
+	handlers[name] = h
+	handlerLock.Unlock()
+}
+
+// GetHandler 获取处理函数
+func GetHandler(name string) http.Handler {
+	handlerLock.RLock()
+	defer handlerLock.RUnlock()
+	return handlers[name]
+}
+
+// Router 按照配置中的路由表分发请求，实现了http.Handler
+// 配置重新加载后整体重建路由表并原子替换，处理中的请求继续使用原来的路由表，新路由表构建失败时继续使用原来的：
+//
+//	server:
+//	  routes:
+//	    - path: /api/order
+//	      methods: [POST]
+//	      handler: order.create
+//	      timeout: 1000
+//	      filter: [auth]
+//	    - path: /static/
+//	      handler: static
+//
+//	server.RegisterHandler("order.create", http.HandlerFunc(createOrder))
+//	r, err := server.NewRouter(c, "server.routes")
+//	s.Handle("framework.company.service.App", r)
+type Router struct {
+	key string
+	// mux 当前的路由表，类型为 *http.ServeMux
+	mux atomic.Value
+}
+
+// NewRouter 解析c中key对应的路由表，路由引用的处理函数需要在此之前注册
+func NewRouter(c config.Config, key string) (*Router, error) {
+	r := &Router{key: key}
+	mux, err := r.build(c)
+	if err != nil {
+		return nil, err
+	}
+	r.mux.Store(mux)
+	config.WatchSection(c, key, func() error {
+		mux, err := r.build(c)
+		if err != nil {
+			return err
+		}
+		r.mux.Store(mux)
+		config.Infof("app/server: routes %s rebuilt", key)
+		return nil
+	})
+	return r, nil
+}
+
+// build 按照配置构建新的路由表，同一路径的多个路由按照请求方法分发
+func (r *Router) build(c config.Config) (*http.ServeMux, error) {
+	var routes []*RouteConfig
+	if c.IsSet(r.key) {
+		if err := c.UnmarshalKey(r.key, &routes); err != nil {
+			return nil, fmt.Errorf("app/server: failed to parse %s: %v", r.key, err)
+		}
+	}
+
+	paths := make([]string, 0, len(routes))
+	byPath := make(map[string]*methodHandler)
+	for _, rc := range routes {
+		h := GetHandler(rc.Handler)
+		if h == nil {
+			return nil, fmt.Errorf("app/server: route %s: handler %s not registered", rc.Path, rc.Handler)
+		}
+		h = chain(rc.Filter, withTimeout(h, rc.Timeout))
+
+		mh, ok := byPath[rc.Path]
+		if !ok {
+			mh = &methodHandler{methods: make(map[string]http.Handler)}
+			byPath[rc.Path] = mh
+			paths = append(paths, rc.Path)
+		}
+		if len(rc.Methods) == 0 {
+			if mh.any != nil {
+				return nil, fmt.Errorf("app/server: route %s: duplicate route", rc.Path)
+			}
+			mh.any = h
+			continue
+		}
+		for _, m := range rc.Methods {
+			m = strings.ToUpper(m)
+			if _, ok := mh.methods[m]; ok {
+				return nil, fmt.Errorf("app/server: route %s %s: duplicate route", m, rc.Path)
+			}
+			mh.methods[m] = h
+		}
+	}
+
+	mux := http.NewServeMux()
+	for _, p := range paths {
+		if err := register(mux, p, byPath[p]); err != nil {
+			return nil, fmt.Errorf("app/server: route %s: %v", p, err)
+		}
+	}
+	return mux, nil
+}
+
+// register 注册路由，http.ServeMux 对非法路径panic
+func register(mux *http.ServeMux, path string, h http.Handler) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+	mux.Handle(path, h)
+	return nil
+}
+
+// ServeHTTP 使用当前的路由表处理请求
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mux.Load().(*http.ServeMux).ServeHTTP(w, req)
+}
+
+// methodHandler 同一路径按照请求方法分发，any处理没有单独配置的方法
+type methodHandler struct {
+	methods map[string]http.Handler
+	any     http.Handler
+}
+
+func (mh *methodHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h, ok := mh.methods[r.Method]; ok {
+		h.ServeHTTP(w, r)
+		return
+	}
+	if mh.any != nil {
+		mh.any.ServeHTTP(w, r)
+		return
+	}
+	allow := make([]string, 0, len(mh.methods))
+	for m := range mh.methods {
+		allow = append(allow, m)
+	}
+	sort.Strings(allow)
+	w.Header().Set("Allow", strings.Join(allow, ", "))
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+// withTimeout 限制请求处理时间，ms<=0时不限制
+func withTimeout(h http.Handler, ms int) http.Handler {
+	if ms <= 0 {
+		return h
+	}
+	d := millis(ms)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goProjectTmpl/config/configtest"
+)
+
+func init() {
+	for _, name := range []string{"order.create", "order.get", "static"} {
+		name := name
+		RegisterHandler(name, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Handler", name)
+			if _, ok := r.Context().Deadline(); ok {
+				w.Header().Set("X-Deadline", "1")
+			}
+		}))
+	}
+}
+
+const routesYAML = `server:
+  routes:
+    - path: /api/order
+      methods: [post]
+      handler: order.create
+      timeout: 1000
+    - path: /api/order
+      methods: [GET]
+      handler: order.get
+    - path: /static/
+      handler: static
+`
+
+func route(r http.Handler, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestRouter(t *testing.T) {
+	c, _ := configtest.Load(t, routesYAML)
+	r, err := NewRouter(c, "server.routes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		method, target, handler string
+		deadline                bool
+	}{
+		{http.MethodPost, "/api/order", "order.create", true},
+		{http.MethodGet, "/api/order", "order.get", false},
+		{http.MethodDelete, "/static/app.js", "static", false},
+	}
+	for _, tt := range tests {
+		w := route(r, tt.method, tt.target)
+		if got := w.Header().Get("X-Handler"); got != tt.handler {
+			t.Errorf("%s %s: expect %s, got %q", tt.method, tt.target, tt.handler, got)
+		}
+		if got := w.Header().Get("X-Deadline") == "1"; got != tt.deadline {
+			t.Errorf("%s %s: expect deadline %v", tt.method, tt.target, tt.deadline)
+		}
+	}
+
+	w := route(r, http.MethodDelete, "/api/order")
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, POST" {
+		t.Fatalf("expect 405 with Allow: GET, POST, got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+	if w := route(r, http.MethodGet, "/other"); w.Code != http.StatusNotFound {
+		t.Fatalf("expect 404, got %d", w.Code)
+	}
+}
+
+func TestRouterReload(t *testing.T) {
+	c, p := configtest.Load(t, routesYAML)
+	r, err := NewRouter(c, "server.routes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Update(configtest.DefaultPath, []byte("server:\n  routes:\n    - path: /v2/order\n      handler: order.get\n"))
+	if got := route(r, http.MethodGet, "/v2/order").Header().Get("X-Handler"); got != "order.get" {
+		t.Fatalf("expect the new route, got %q", got)
+	}
+	if w := route(r, http.MethodGet, "/api/order"); w.Code != http.StatusNotFound {
+		t.Fatalf("expect the old route to be removed, got %d", w.Code)
+	}
+
+	// 新路由表构建失败时继续使用原来的
+	p.Update(configtest.DefaultPath, []byte("server:\n  routes:\n    - path: /v3/order\n      handler: not-registered\n"))
+	if got := route(r, http.MethodGet, "/v2/order").Header().Get("X-Handler"); got != "order.get" {
+		t.Fatalf("expect the old routes to be kept, got %q", got)
+	}
+}
+
+func TestRouterErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+	}{
+		{"not registered", "r:\n  - path: /a\n    handler: not-registered\n"},
+		{"duplicate", "r:\n  - path: /a\n    handler: static\n  - path: /a\n    handler: static\n"},
+		{"duplicate method", "r:\n  - path: /a\n    methods: [GET]\n    handler: static\n  - path: /a\n    methods: [get]\n    handler: static\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := configtest.Load(t, tt.yaml)
+			if _, err := NewRouter(c, "r"); err == nil {
+				t.Fatal("expect an error")
+			}
+		})
+	}
+}
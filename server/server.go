@@ -0,0 +1,236 @@
+// Package server 根据框架配置中的 server 配置段创建并启动服务
+// 每个service按照 protocol 选择协议实现，http协议内置，其他协议（例如gRPC）通过 RegisterProtocol 注册：
+//
+//	s := server.New()
+//	s.Handle("framework.company.service.App", mux)
+//	app.Register(s)
+//
+// server 配置段重新加载后，service的 timeout、max_conns、max_body_bytes 立即生效，其他配置需要重启服务；
+// tls_cert、tls_key 文件内容变化时新连接使用新证书。
+// http service 可以使用 NewRouter 按照配置中的路由表分发请求，路由表重新加载配置后整体替换
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/tlsconf"
+)
+
+// Server 按照配置启动的全部service，实现了app的Module、Starter、Stopper、Reloader接口
+type Server struct {
+	lock     sync.Mutex
+	impls    map[string]interface{}
+	cfg      *Config
+	services []*service
+}
+
+type service struct {
+	cfg       *ServiceConfig
+	transport Transport
+	listener  *limitListener
+}
+
+// New 创建Server
+func New() *Server {
+	return &Server{impls: make(map[string]interface{})}
+}
+
+// Register 注册service的实现，类型由service配置的协议决定
+func (s *Server) Register(name string, impl interface{}) {
+	s.lock.Lock()
+	s.impls[name] = impl
+	s.lock.Unlock()
+}
+
+// Handle 注册http service的处理函数
+func (s *Server) Handle(name string, h http.Handler) {
+	s.Register(name, h)
+}
+
+// Name 模块名
+func (s *Server) Name() string {
+	return "server"
+}
+
+// Init 解析 server 配置段，并为每个service创建协议实现
+func (s *Server) Init(c config.Config) error {
+	cfg := &Config{}
+	if err := c.UnmarshalKey(SectionKey, cfg); err != nil {
+		return fmt.Errorf("app/server: failed to parse %s: %v", SectionKey, err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	services := make([]*service, 0, len(cfg.Service))
+	for _, sc := range cfg.Service {
+		impl, ok := s.impls[sc.Name]
+		if !ok {
+			return fmt.Errorf("app/server: service %s not registered", sc.Name)
+		}
+		f := GetProtocol(sc.Protocol)
+		if f == nil {
+			return fmt.Errorf("app/server: service %s: protocol %s not registered", sc.Name, sc.Protocol)
+		}
+		t, err := f(sc, cfg.Filter, impl)
+		if err != nil {
+			return err
+		}
+		services = append(services, &service{cfg: sc, transport: t})
+	}
+	s.cfg = cfg
+	s.services = services
+	return nil
+}
+
+// Start 监听全部service的地址并开始提供服务
+func (s *Server) Start() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, svc := range s.services {
+		l, err := listen(svc.cfg)
+		if err != nil {
+			return err
+		}
+		svc.listener = &limitListener{Listener: l}
+		svc.listener.setMax(svc.cfg.MaxConns)
+
+		var ln net.Listener = svc.listener
+		if svc.cfg.TLSCert != "" && svc.cfg.TLSKey != "" {
+			m, err := tlsconf.New(tlsconf.Source{Cert: svc.cfg.TLSCert, Key: svc.cfg.TLSKey})
+			if err != nil {
+				l.Close()
+				return fmt.Errorf("app/server: service %s: failed to load tls key pair: %v", svc.cfg.Name, err)
+			}
+			ln = tls.NewListener(ln, m.ServerConfig())
+		}
+		config.Infof("app/server: service %s serving %s on %s", svc.cfg.Name, svc.cfg.Protocol, l.Addr())
+		go func(svc *service, ln net.Listener) {
+			if err := svc.transport.Serve(ln); err != nil {
+				config.Errorf("app/server: service %s stopped: %v", svc.cfg.Name, err)
+			}
+		}(svc, ln)
+	}
+	return nil
+}
+
+// listen 监听service配置的地址
+func listen(cfg *ServiceConfig) (net.Listener, error) {
+	addr, err := cfg.Address()
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.Listen(cfg.Network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("app/server: service %s: %v", cfg.Name, err)
+	}
+	return l, nil
+}
+
+// Stop 停止全部已启动的service，等待处理中的请求完成直到ctx结束，返回第一个错误
+func (s *Server) Stop(ctx context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	errs := make([]error, len(s.services))
+	var wg sync.WaitGroup
+	for i, svc := range s.services {
+		if svc.listener == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, svc *service) {
+			defer wg.Done()
+			errs[i] = svc.transport.Shutdown(ctx)
+		}(i, svc)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reload server 配置段变化时更新不需要重启即可生效的配置，其他变化输出警告
+func (s *Server) Reload(c config.Config, changes []config.Change) {
+	changed := false
+	for _, ch := range changes {
+		if ch.Key == SectionKey || strings.HasPrefix(ch.Key, SectionKey+".") {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return
+	}
+	cfg := &Config{}
+	if err := c.UnmarshalKey(SectionKey, cfg); err != nil {
+		config.Errorf("app/server: failed to parse %s, ignored: %v", SectionKey, err)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.cfg == nil {
+		return
+	}
+	if strings.Join(cfg.Filter, ",") != strings.Join(s.cfg.Filter, ",") {
+		config.Warnf("app/server: %s.filter changed, restart required", SectionKey)
+	}
+	names := make(map[string]*ServiceConfig, len(cfg.Service))
+	for _, sc := range cfg.Service {
+		names[sc.Name] = sc
+	}
+	for _, svc := range s.services {
+		sc, ok := names[svc.cfg.Name]
+		delete(names, svc.cfg.Name)
+		if !ok {
+			config.Warnf("app/server: service %s removed, restart required", svc.cfg.Name)
+			continue
+		}
+		if sc.restartRequired(svc.cfg) {
+			config.Warnf("app/server: service %s address, protocol, tls or read/write timeouts changed, restart required", sc.Name)
+		}
+		if svc.listener != nil {
+			svc.listener.setMax(sc.MaxConns)
+		}
+		if u, ok := svc.transport.(Updater); ok {
+			u.Update(sc)
+		}
+		svc.cfg.Timeout, svc.cfg.MaxConns, svc.cfg.MaxBodyBytes = sc.Timeout, sc.MaxConns, sc.MaxBodyBytes
+	}
+	for name := range names {
+		config.Warnf("app/server: service %s added, restart required", name)
+	}
+}
+
+// Services 按照配置顺序返回全部service的名字
+func (s *Server) Services() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	names := make([]string, 0, len(s.services))
+	for _, svc := range s.services {
+		names = append(names, svc.cfg.Name)
+	}
+	return names
+}
+
+// Addr 返回service实际监听的地址，未启动时返回nil
+func (s *Server) Addr(name string) net.Addr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, svc := range s.services {
+		if svc.cfg.Name == name && svc.listener != nil {
+			return svc.listener.Addr()
+		}
+	}
+	return nil
+}
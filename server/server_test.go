@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/config/configtest"
+)
+
+const serverYAML = `server:
+  filter: [recovery]
+  service:
+    - name: app
+      ip: 127.0.0.1
+      port: 0
+      max_body_bytes: %d
+      timeout: %d
+`
+
+// echo 返回请求体，ctx带有截止时间时设置 X-Deadline
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/panic" {
+		panic("boom")
+	}
+	if _, ok := r.Context().Deadline(); ok {
+		w.Header().Set("X-Deadline", "1")
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.Write(body)
+})
+
+func start(t *testing.T, yaml string) (*Server, *configtest.Provider, config.Config, string) {
+	t.Helper()
+	c, p := configtest.Load(t, yaml)
+	s := New()
+	s.Handle("app", echo)
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Stop(context.Background()) })
+	return s, p, c, "http://" + s.Addr("app").String()
+}
+
+func post(t *testing.T, url, body string) (int, http.Header) {
+	t.Helper()
+	resp, err := http.Post(url, "text/plain", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header
+}
+
+func TestServe(t *testing.T) {
+	s, _, _, url := start(t, fmt.Sprintf(serverYAML, 0, 0))
+	if got := s.Services(); len(got) != 1 || got[0] != "app" {
+		t.Fatalf("unexpected services %v", got)
+	}
+	if code, _ := post(t, url+"/echo", "hello"); code != http.StatusOK {
+		t.Fatalf("expect 200, got %d", code)
+	}
+	// recovery 拦截panic
+	if code, _ := post(t, url+"/panic", ""); code != http.StatusInternalServerError {
+		t.Fatalf("expect 500, got %d", code)
+	}
+}
+
+func TestReloadLimits(t *testing.T) {
+	s, p, c, url := start(t, fmt.Sprintf(serverYAML, 0, 0))
+	if code, h := post(t, url+"/echo", "hello world"); code != http.StatusOK || h.Get("X-Deadline") != "" {
+		t.Fatalf("expect 200 without deadline, got %d", code)
+	}
+
+	// max_body_bytes 和 timeout 重新加载后立即生效
+	p.Update(configtest.DefaultPath, []byte(fmt.Sprintf(serverYAML, 5, 1000)))
+	s.Reload(c, []config.Change{{Key: "server.service"}})
+	if code, _ := post(t, url+"/echo", "hello world"); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expect 413, got %d", code)
+	}
+	if code, h := post(t, url+"/echo", "hi"); code != http.StatusOK || h.Get("X-Deadline") != "1" {
+		t.Fatalf("expect 200 with deadline, got %d", code)
+	}
+}
+
+func TestInitErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"not registered", "server:\n  service:\n    - name: other\n", "service other not registered"},
+		{"unknown protocol", "server:\n  service:\n    - name: app\n      protocol: thrift\n", "protocol thrift not registered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := configtest.Load(t, tt.yaml)
+			s := New()
+			s.Handle("app", echo)
+			if err := s.Init(c); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expect %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	c, _ := configtest.Load(t, "server:\n  service:\n    - name: app\n")
+	s := New()
+	s.Register("app", "not a handler")
+	if err := s.Init(c); err == nil || !strings.Contains(err.Error(), "requires http.Handler") {
+		t.Fatalf("expect a handler type error, got %v", err)
+	}
+}
+
+func TestLimitListener(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &limitListener{Listener: inner}
+	l.setMax(1)
+	defer l.Close()
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+	first, err := net.Dial("tcp", inner.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	conn := <-accepted
+
+	// 超过上限的连接被直接关闭
+	second, err := net.Dial("tcp", inner.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	second.SetReadDeadline(time.Now().Add(3 * time.Second))
+	if _, err := second.Read(make([]byte, 1)); err == nil {
+		t.Fatal("expect the second connection to be closed")
+	}
+
+	// 连接关闭后释放名额，重复关闭只释放一次
+	conn.Close()
+	conn.Close()
+	if got := atomic.LoadInt64(&l.active); got != 0 {
+		t.Fatalf("expect 0 active connections, got %d", got)
+	}
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+	for _, name := range []string{"chain-a", "chain-b"} {
+		name := name
+		RegisterFilter(name, func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		})
+	}
+	h := chain([]string{"chain-a", "not-registered", "chain-b"}, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		order = append(order, "handler")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := strings.Join(order, ","); got != "chain-a,chain-b,handler" {
+		t.Fatalf("unexpected order %s", got)
+	}
+}
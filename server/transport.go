@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Transport 协议的服务端实现
+type Transport interface {
+	// Serve 在l上提供服务，阻塞直到服务停止
+	Serve(l net.Listener) error
+	// Shutdown 停止接受新请求并等待处理中的请求完成，ctx结束时强制停止
+	Shutdown(ctx context.Context) error
+}
+
+// Updater Transport的可选接口，重新加载配置后接收不需要重启即可生效的配置
+type Updater interface {
+	Update(cfg *ServiceConfig)
+}
+
+// TransportFactory 根据service配置和注册的服务实现创建Transport
+// 例如gRPC协议在其中创建 *grpc.Server 并注册impl，Serve、Shutdown 分别对应 Serve、GracefulStop
+type TransportFactory func(cfg *ServiceConfig, filters []string, impl interface{}) (Transport, error)
+
+var (
+	protocols    = map[string]TransportFactory{"http": newHTTPTransport}
+	protocolLock sync.RWMutex
+)
+
+// RegisterProtocol 注册应用层协议，service配置中的 protocol 通过名字引用
+func RegisterProtocol(name string, f TransportFactory) {
+	protocolLock.Lock()
+	protocols[name] = f
+	protocolLock.Unlock()
+}
+
+// GetProtocol 获取应用层协议
+func GetProtocol(name string) TransportFactory {
+	protocolLock.RLock()
+	defer protocolLock.RUnlock()
+	return protocols[name]
+}
+
+// httpTransport http协议，impl需要实现http.Handler
+type httpTransport struct {
+	srv *http.Server
+	// limits 可以热更新的配置，类型为 httpLimits
+	limits atomic.Value
+}
+
+type httpLimits struct {
+	timeout      time.Duration
+	maxBodyBytes int64
+}
+
+func newHTTPTransport(cfg *ServiceConfig, filters []string, impl interface{}) (Transport, error) {
+	h, ok := impl.(http.Handler)
+	if !ok {
+		return nil, fmt.Errorf("app/server: service %s: http service requires http.Handler, got %T", cfg.Name, impl)
+	}
+	t := &httpTransport{}
+	t.Update(cfg)
+	t.srv = &http.Server{
+		Handler:      chain(filters, t.limit(h)),
+		ReadTimeout:  millis(cfg.ReadTimeout),
+		WriteTimeout: millis(cfg.WriteTimeout),
+		IdleTimeout:  millis(cfg.IdleTimeout),
+	}
+	return t, nil
+}
+
+// limit 按照当前生效的配置限制请求处理时间和请求体大小
+func (t *httpTransport) limit(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limits := t.limits.Load().(httpLimits)
+		if limits.maxBodyBytes > 0 {
+			if r.ContentLength > limits.maxBodyBytes {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limits.maxBodyBytes)
+		}
+		if limits.timeout > 0 {
+			ctx, cancel := context.WithTimeout(r.Context(), limits.timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (t *httpTransport) Update(cfg *ServiceConfig) {
+	t.limits.Store(httpLimits{timeout: millis(cfg.Timeout), maxBodyBytes: cfg.MaxBodyBytes})
+}
+
+func (t *httpTransport) Serve(l net.Listener) error {
+	if err := t.srv.Serve(l); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (t *httpTransport) Shutdown(ctx context.Context) error {
+	return t.srv.Shutdown(ctx)
+}
@@ -0,0 +1,30 @@
+package sign
+
+import "goProjectTmpl/config"
+
+// FailureSink 校验失败审计记录的输出目标
+type FailureSink interface {
+	Write(*FailureRecord) error
+}
+
+// FailureSinkFunc 函数形式的FailureSink
+type FailureSinkFunc func(*FailureRecord) error
+
+// Write 实现FailureSink接口
+func (f FailureSinkFunc) Write(r *FailureRecord) error {
+	return f(r)
+}
+
+var sinks config.SinkRegistry
+
+// RegisterFailureSink 注册校验失败审计记录的输出目标，policy为warn时校验失败同样写入
+func RegisterFailureSink(s FailureSink) {
+	sinks.Register(func(r interface{}) error { return s.Write(r.(*FailureRecord)) })
+}
+
+// audit 写入校验失败审计记录
+func audit(r *FailureRecord) {
+	for _, err := range sinks.Write(r) {
+		config.Errorf("app/sign: failed to write failure record of %s: %v", subjectName(r.Subject), err)
+	}
+}
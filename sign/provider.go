@@ -0,0 +1,67 @@
+package sign
+
+import (
+	"fmt"
+	"strings"
+
+	"goProjectTmpl/config"
+)
+
+// SignatureSuffix 配置文件签名的路径后缀，签名与配置文件放在同一内容源中
+const SignatureSuffix = ".sig"
+
+// Provider 校验签名的内容源，从upstream读取配置文件及 <path>.sig，签名校验通过后才返回内容，
+// 实现了 config.DataProvider
+type Provider struct {
+	name     string
+	upstream string
+	v        *Verifier
+}
+
+// NewProvider 创建名字为name的内容源，配置文件和签名从名字为upstream的内容源读取
+func NewProvider(name, upstream string, v *Verifier) *Provider {
+	return &Provider{name: name, upstream: upstream, v: v}
+}
+
+// Name provider名字
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// Read 读取配置文件并校验签名，签名不存在视为校验失败
+func (p *Provider) Read(path string) ([]byte, error) {
+	up := config.GetProvider(p.upstream)
+	if up == nil {
+		return nil, fmt.Errorf("app/sign: %s: %v", p.upstream, config.ErrProviderNotExist)
+	}
+	data, err := up.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := up.Read(path + SignatureSuffix)
+	if err != nil {
+		sig = nil
+	}
+	if err := p.v.VerifyFor(path, data, sig); err != nil {
+		return nil, fmt.Errorf("app/sign: %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// Watch 注册变化回调，配置文件或签名变化后重新校验，校验通过时调用
+// 配置文件和签名分别更新时，中间状态的校验失败会被忽略并写入审计记录
+func (p *Provider) Watch(cb config.ProviderCallback) {
+	up := config.GetProvider(p.upstream)
+	if up == nil {
+		return
+	}
+	up.Watch(func(path string, _ []byte) {
+		path = strings.TrimSuffix(path, SignatureSuffix)
+		data, err := p.Read(path)
+		if err != nil {
+			config.Warnf("app/sign: ignore the change of %s: %v", path, err)
+			return
+		}
+		cb(path, data)
+	})
+}
@@ -0,0 +1,286 @@
+// Package sign 配置签名校验
+// 校验配置内容和配置包清单的Ed25519签名或cosign（sign-blob）签名，可信公钥在 sign 配置段中按名字声明，
+// 任意一个可信公钥校验通过即视为通过。校验失败时写入审计记录，并按照policy拒绝或只打印警告：
+//
+//	sign:
+//	  policy: reject          # reject 校验失败时返回错误，warn 只打印警告并继续使用
+//	  keys:
+//	    release-2026:
+//	      type: ed25519       # ed25519 或 cosign
+//	      public_key: MCowBQYDK2VwAyEA...   # PEM或base64，也可以通过provider和path读取
+//	    ci:
+//	      type: cosign
+//	      provider: file
+//	      path: /etc/app/cosign.pub
+//
+//	v := sign.New(c)
+//	b, err := bundle.Load(c, "bundle", "bundle", v.For("bundle"))
+//	config.RegisterProvider(sign.NewProvider("signed", "etcd", v)) // 同时读取 <path>.sig 并校验
+package sign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中签名校验配置段的key
+const SectionKey = "sign"
+
+// 校验失败时的处理策略
+const (
+	PolicyReject = "reject"
+	PolicyWarn   = "warn"
+)
+
+// 公钥类型
+const (
+	TypeEd25519 = "ed25519"
+	TypeCosign  = "cosign"
+)
+
+var (
+	// ErrNoTrustedKey 没有声明可信公钥
+	ErrNoTrustedKey = errors.New("app/sign: no trusted key")
+	// ErrInvalidSignature 签名无法被任何可信公钥校验通过
+	ErrInvalidSignature = errors.New("app/sign: invalid signature")
+)
+
+// Key 可信公钥，public_key 与 provider、path 二选一
+type Key struct {
+	Type      string `yaml:"type" default:"ed25519" enum:"ed25519,cosign"`
+	PublicKey string `yaml:"public_key"`
+	Provider  string `yaml:"provider" default:"file"`
+	Path      string `yaml:"path"`
+}
+
+// Options sign 配置段
+type Options struct {
+	Policy string         `yaml:"policy" default:"reject" enum:"reject,warn"`
+	Keys   map[string]Key `yaml:"keys"`
+}
+
+// trustedKey 解析后的公钥
+type trustedKey struct {
+	name   string
+	typ    string
+	public interface{}
+}
+
+// state 同一时刻生效的策略和公钥
+type state struct {
+	policy string
+	keys   []*trustedKey
+}
+
+// Verifier 使用可信公钥校验签名，配置重新加载后使用新的公钥和策略，新的公钥解析失败时继续使用原来的
+type Verifier struct {
+	// cur 当前的 *state
+	cur atomic.Value
+}
+
+// New 解析c中的 sign 配置段
+func New(c config.Config) *Verifier {
+	v := &Verifier{}
+	v.cur.Store(&state{policy: PolicyReject})
+	if err := v.reload(c); err != nil {
+		config.Errorf("app/sign: %v", err)
+	}
+	config.WatchSection(c, SectionKey, func() error { return v.reload(c) })
+	return v
+}
+
+func (v *Verifier) reload(c config.Config) error {
+	opts := &Options{Policy: PolicyReject}
+	if c.IsSet(SectionKey) {
+		if err := c.UnmarshalKey(SectionKey, opts); err != nil {
+			return fmt.Errorf("app/sign: failed to parse %s: %v", SectionKey, err)
+		}
+	}
+	st := &state{policy: opts.Policy}
+	if st.policy == "" {
+		st.policy = PolicyReject
+	}
+	names := make([]string, 0, len(opts.Keys))
+	for name := range opts.Keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		k, err := parseKey(name, opts.Keys[name])
+		if err != nil {
+			return err
+		}
+		st.keys = append(st.keys, k)
+	}
+	v.cur.Store(st)
+	return nil
+}
+
+// parseKey 读取并解析公钥，支持PEM编码的PKIX公钥，ed25519还支持base64编码的32字节公钥
+func parseKey(name string, k Key) (*trustedKey, error) {
+	if k.Type == "" {
+		k.Type = TypeEd25519
+	}
+	raw := []byte(k.PublicKey)
+	if k.PublicKey == "" {
+		if k.Path == "" {
+			return nil, fmt.Errorf("app/sign: key %s: public_key or path required", name)
+		}
+		if k.Provider == "" {
+			k.Provider = "file"
+		}
+		p := config.GetProvider(k.Provider)
+		if p == nil {
+			return nil, fmt.Errorf("app/sign: key %s: %s: %v", name, k.Provider, config.ErrProviderNotExist)
+		}
+		data, err := p.Read(k.Path)
+		if err != nil {
+			return nil, fmt.Errorf("app/sign: key %s: failed to read %s: %v", name, k.Path, err)
+		}
+		raw = data
+	}
+
+	var pub interface{}
+	if block, _ := pem.Decode(raw); block != nil {
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("app/sign: key %s: %v", name, err)
+		}
+		pub = parsed
+	} else {
+		der, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(raw)))
+		if err != nil {
+			return nil, fmt.Errorf("app/sign: key %s: invalid public key: %v", name, err)
+		}
+		if len(der) == ed25519.PublicKeySize {
+			pub = ed25519.PublicKey(der)
+		} else if pub, err = x509.ParsePKIXPublicKey(der); err != nil {
+			return nil, fmt.Errorf("app/sign: key %s: %v", name, err)
+		}
+	}
+
+	switch pub.(type) {
+	case ed25519.PublicKey:
+	case *ecdsa.PublicKey, *rsa.PublicKey:
+		if k.Type != TypeCosign {
+			return nil, fmt.Errorf("app/sign: key %s: %T requires type cosign", name, pub)
+		}
+	default:
+		return nil, fmt.Errorf("app/sign: key %s: unsupported public key %T", name, pub)
+	}
+	return &trustedKey{name: name, typ: k.Type, public: pub}, nil
+}
+
+// verify 使用公钥校验签名
+// ed25519 的签名为64字节的原始签名或其base64编码；cosign 的签名为 cosign sign-blob 输出的base64编码，
+// ecdsa和rsa公钥校验payload的sha256，ed25519公钥直接校验payload
+func (k *trustedKey) verify(payload, sig []byte) bool {
+	decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+	if err != nil {
+		if k.typ == TypeCosign {
+			return false
+		}
+		decoded = sig
+	}
+	switch pub := k.public.(type) {
+	case ed25519.PublicKey:
+		if len(decoded) != ed25519.SignatureSize {
+			// 恰好是合法base64的原始签名
+			decoded = sig
+		}
+		return ed25519.Verify(pub, payload, decoded)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(payload)
+		return ecdsa.VerifyASN1(pub, digest[:], decoded)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(payload)
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], decoded) == nil
+	}
+	return false
+}
+
+// Verify 校验payload的签名，校验失败时写入审计记录，policy为warn时只打印警告并返回nil
+func (v *Verifier) Verify(payload, signature []byte) error {
+	return v.VerifyFor("", payload, signature)
+}
+
+// VerifyFor 与 Verify 相同，subject为审计记录中被校验的对象，例如配置文件的路径
+func (v *Verifier) VerifyFor(subject string, payload, signature []byte) error {
+	st := v.cur.Load().(*state)
+	var err error
+	switch {
+	case len(st.keys) == 0:
+		err = ErrNoTrustedKey
+	case len(signature) == 0:
+		err = fmt.Errorf("%w: missing signature", ErrInvalidSignature)
+	default:
+		for _, k := range st.keys {
+			if k.verify(payload, signature) {
+				return nil
+			}
+		}
+		err = ErrInvalidSignature
+	}
+
+	digest := sha256.Sum256(payload)
+	audit(&FailureRecord{
+		Time:    config.Now(),
+		Subject: subject,
+		Digest:  fmt.Sprintf("%x", digest),
+		Policy:  st.policy,
+		Error:   err.Error(),
+	})
+	if st.policy == PolicyWarn {
+		config.Warnf("app/sign: accepting %s with policy warn: %v", subjectName(subject), err)
+		return nil
+	}
+	return err
+}
+
+func subjectName(subject string) string {
+	if subject == "" {
+		return "payload"
+	}
+	return subject
+}
+
+// Subject 校验固定对象的签名，实现了 bundle.Verifier
+type Subject struct {
+	v    *Verifier
+	name string
+}
+
+// For 返回审计记录中对象为name的校验器
+func (v *Verifier) For(name string) *Subject {
+	return &Subject{v: v, name: name}
+}
+
+// Verify 校验payload的签名
+func (s *Subject) Verify(payload, signature []byte) error {
+	return s.v.VerifyFor(s.name, payload, signature)
+}
+
+// FailureRecord 签名校验失败的审计记录
+type FailureRecord struct {
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject"`
+	// Digest 被校验内容的sha256
+	Digest string `json:"digest"`
+	// Policy 校验失败时生效的策略，warn 表示内容仍然被使用
+	Policy string `json:"policy"`
+	Error  string `json:"error"`
+}
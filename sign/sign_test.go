@@ -0,0 +1,196 @@
+package sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"goProjectTmpl/config/configtest"
+)
+
+// recorder 收集指定对象的审计记录，审计输出目标全局注册，不同测试使用不同的对象名
+type recorder struct {
+	lock    sync.Mutex
+	records []*FailureRecord
+}
+
+var failures = &recorder{}
+
+func init() {
+	RegisterFailureSink(FailureSinkFunc(func(r *FailureRecord) error {
+		failures.lock.Lock()
+		failures.records = append(failures.records, r)
+		failures.lock.Unlock()
+		return nil
+	}))
+}
+
+func (r *recorder) of(subject string) []*FailureRecord {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var out []*FailureRecord
+	for _, rec := range r.records {
+		if rec.Subject == subject {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+func newEd25519(t *testing.T) (string, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(pub), priv
+}
+
+const signYAML = `sign:
+  policy: %s
+  keys:
+    release:
+      type: ed25519
+      public_key: %s
+`
+
+func TestVerify(t *testing.T) {
+	pub, priv := newEd25519(t)
+	c, _ := configtest.Load(t, fmt.Sprintf(signYAML, "reject", pub))
+	v := New(c)
+
+	payload := []byte("a: 1\n")
+	sig := ed25519.Sign(priv, payload)
+	// 原始签名和base64编码的签名都可以校验通过
+	if err := v.VerifyFor("verify-ok", payload, sig); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.VerifyFor("verify-ok", payload, []byte(base64.StdEncoding.EncodeToString(sig))); err != nil {
+		t.Fatal(err)
+	}
+	if got := failures.of("verify-ok"); len(got) != 0 {
+		t.Fatalf("expect no failure records, got %d", len(got))
+	}
+
+	if err := v.VerifyFor("verify-bad", []byte("a: 2\n"), sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expect ErrInvalidSignature, got %v", err)
+	}
+	if err := v.VerifyFor("verify-bad", payload, nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expect ErrInvalidSignature for a missing signature, got %v", err)
+	}
+	records := failures.of("verify-bad")
+	if len(records) != 2 || records[0].Policy != PolicyReject {
+		t.Fatalf("expect 2 failure records with policy reject, got %d", len(records))
+	}
+	if digest := sha256.Sum256([]byte("a: 2\n")); records[0].Digest != fmt.Sprintf("%x", digest) {
+		t.Fatalf("unexpected digest %s", records[0].Digest)
+	}
+}
+
+func TestPolicyWarnAndReload(t *testing.T) {
+	pub, priv := newEd25519(t)
+	c, p := configtest.Load(t, fmt.Sprintf(signYAML, "warn", pub))
+	v := New(c)
+
+	// warn 策略接受校验失败的内容，同样写入审计记录
+	if err := v.For("warn").Verify([]byte("a: 1\n"), []byte("bad")); err != nil {
+		t.Fatalf("expect nil with policy warn, got %v", err)
+	}
+	if records := failures.of("warn"); len(records) != 1 || records[0].Policy != PolicyWarn {
+		t.Fatalf("expect a failure record with policy warn, got %v", records)
+	}
+
+	// 新的公钥无效时继续使用原来的公钥和策略
+	p.Update(configtest.DefaultPath, []byte(fmt.Sprintf(signYAML, "reject", "not-a-key")))
+	if err := v.Verify([]byte("a: 1\n"), []byte("bad")); err != nil {
+		t.Fatalf("expect the old policy to be kept, got %v", err)
+	}
+
+	other, _ := newEd25519(t)
+	p.Update(configtest.DefaultPath, []byte(fmt.Sprintf(signYAML, "reject", other)))
+	payload := []byte("a: 1\n")
+	if err := v.Verify(payload, ed25519.Sign(priv, payload)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expect the old key to be untrusted after reload, got %v", err)
+	}
+}
+
+func TestNoTrustedKey(t *testing.T) {
+	c, _ := configtest.Load(t, "a: 1\n")
+	if err := New(c).Verify([]byte("a: 1\n"), []byte("sig")); !errors.Is(err, ErrNoTrustedKey) {
+		t.Fatalf("expect ErrNoTrustedKey, got %v", err)
+	}
+}
+
+func TestCosign(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := configtest.NewProvider()
+	keys.Set("cosign.pub", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	// ecdsa公钥必须声明为cosign
+	if _, err := parseKey("ci", Key{Type: TypeEd25519, Provider: keys.Name(), Path: "cosign.pub"}); err == nil {
+		t.Fatal("expect an error for an ecdsa key of type ed25519")
+	}
+
+	c, _ := configtest.Load(t, "sign:\n  keys:\n    ci:\n      type: cosign\n      provider: "+keys.Name()+"\n      path: cosign.pub\n")
+	v := New(c)
+	payload := []byte("a: 1\n")
+	digest := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(payload, []byte(base64.StdEncoding.EncodeToString(sig))); err != nil {
+		t.Fatal(err)
+	}
+	// cosign 的签名必须是base64编码
+	if err := v.Verify(payload, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expect ErrInvalidSignature for a raw cosign signature, got %v", err)
+	}
+}
+
+func TestProvider(t *testing.T) {
+	pub, priv := newEd25519(t)
+	c, _ := configtest.Load(t, fmt.Sprintf(signYAML, "reject", pub))
+	up := configtest.NewProvider()
+	p := NewProvider("signed-test", up.Name(), New(c))
+
+	v1 := []byte("a: 1\n")
+	up.Set("app.yaml", v1)
+	if _, err := p.Read("app.yaml"); err == nil || !strings.Contains(err.Error(), "missing signature") {
+		t.Fatalf("expect a missing signature error, got %v", err)
+	}
+	up.Set("app.yaml"+SignatureSuffix, ed25519.Sign(priv, v1))
+	if data, err := p.Read("app.yaml"); err != nil || string(data) != "a: 1\n" {
+		t.Fatalf("expect a: 1, got %q %v", data, err)
+	}
+
+	// 只有签名校验通过的变化才回调，签名变化时回调配置文件的路径
+	changed := make(chan string, 4)
+	p.Watch(func(path string, data []byte) { changed <- path + " " + string(data) })
+	v2 := []byte("a: 2\n")
+	up.Update("app.yaml", v2)
+	up.Update("app.yaml"+SignatureSuffix, ed25519.Sign(priv, v2))
+	if len(changed) != 1 {
+		t.Fatalf("expect 1 change, got %d", len(changed))
+	}
+	if got := <-changed; got != "app.yaml a: 2\n" {
+		t.Fatalf("unexpected change %q", got)
+	}
+}
@@ -0,0 +1,229 @@
+// Package tlsconf TLS证书管理
+// 证书、私钥和CA证书通过config的内容源读取，内容变化时重新加载，
+// ServerConfig 和 ClientConfig 返回的 *tls.Config 在每次握手时使用最新的证书，轮换证书不需要重启服务：
+//
+//	tls:
+//	  provider: file        # 内容源，k8s secret挂载为文件时使用file，vault等通过 config.RegisterProvider 注册
+//	  cert: /etc/tls/tls.crt
+//	  key: /etc/tls/tls.key
+//	  ca: /etc/tls/ca.crt   # 可选，服务端用于校验客户端证书，客户端用于校验服务端证书
+//	  client_auth: true     # 服务端要求并校验客户端证书，需要配置ca
+//	  min_version: "1.2"
+//
+//	m, err := tlsconf.Load(c, "tls")
+//	ln = tls.NewListener(ln, m.ServerConfig())
+package tlsconf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"goProjectTmpl/config"
+)
+
+// ErrNoCertificate 没有配置证书或私钥
+var ErrNoCertificate = errors.New("app/tlsconf: cert and key required")
+
+// Source 证书所在的内容源
+type Source struct {
+	Provider string `yaml:"provider" default:"file"`
+	Cert     string `yaml:"cert"`
+	Key      string `yaml:"key"`
+	CA       string `yaml:"ca"`
+	// ClientAuth 服务端要求并校验客户端证书
+	ClientAuth bool `yaml:"client_auth"`
+	// MinVersion 最低TLS版本，1.0、1.1、1.2、1.3
+	MinVersion string `yaml:"min_version" default:"1.2"`
+	// ServerName 客户端校验服务端证书时使用的域名，为空时使用连接的地址
+	ServerName string `yaml:"server_name"`
+}
+
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// material 同一时刻生效的证书和CA证书
+type material struct {
+	cert *tls.Certificate
+	ca   *x509.CertPool
+}
+
+// Manager 管理一组证书，内容源通知变化时重新读取，新证书解析失败时继续使用原来的证书
+type Manager struct {
+	src        Source
+	minVersion uint16
+	p          config.DataProvider
+
+	// reloadLock 串行执行重新读取
+	reloadLock sync.Mutex
+	// cur 当前的证书，类型为 *material
+	cur atomic.Value
+}
+
+// Load 解析c中key对应的配置段并创建Manager
+func Load(c config.Config, key string) (*Manager, error) {
+	src := Source{Provider: "file", MinVersion: "1.2"}
+	if err := c.UnmarshalKey(key, &src); err != nil {
+		return nil, fmt.Errorf("app/tlsconf: failed to parse %s: %v", key, err)
+	}
+	return New(src)
+}
+
+// New 读取src中的证书并监听内容源的变化
+func New(src Source) (*Manager, error) {
+	if src.Provider == "" {
+		src.Provider = "file"
+	}
+	if src.MinVersion == "" {
+		src.MinVersion = "1.2"
+	}
+	minVersion, ok := versions[src.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("app/tlsconf: unsupported min_version %q", src.MinVersion)
+	}
+	if src.ClientAuth && src.CA == "" {
+		return nil, errors.New("app/tlsconf: client_auth requires ca")
+	}
+	p := config.GetProvider(src.Provider)
+	if p == nil {
+		return nil, fmt.Errorf("app/tlsconf: %s: %v", src.Provider, config.ErrProviderNotExist)
+	}
+
+	m := &Manager{src: src, minVersion: minVersion, p: p}
+	mat, err := m.read()
+	if err != nil {
+		return nil, err
+	}
+	m.cur.Store(mat)
+	p.Watch(m.onChange)
+	return m, nil
+}
+
+// read 从内容源读取全部证书
+func (m *Manager) read() (*material, error) {
+	mat := &material{}
+	if m.src.Cert != "" || m.src.Key != "" {
+		if m.src.Cert == "" || m.src.Key == "" {
+			return nil, ErrNoCertificate
+		}
+		certPEM, err := m.p.Read(m.src.Cert)
+		if err != nil {
+			return nil, fmt.Errorf("app/tlsconf: failed to read %s: %v", m.src.Cert, err)
+		}
+		keyPEM, err := m.p.Read(m.src.Key)
+		if err != nil {
+			return nil, fmt.Errorf("app/tlsconf: failed to read %s: %v", m.src.Key, err)
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("app/tlsconf: invalid key pair %s, %s: %v", m.src.Cert, m.src.Key, err)
+		}
+		mat.cert = &cert
+	}
+	if m.src.CA != "" {
+		caPEM, err := m.p.Read(m.src.CA)
+		if err != nil {
+			return nil, fmt.Errorf("app/tlsconf: failed to read %s: %v", m.src.CA, err)
+		}
+		mat.ca = x509.NewCertPool()
+		if !mat.ca.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("app/tlsconf: no certificate found in %s", m.src.CA)
+		}
+	}
+	return mat, nil
+}
+
+// onChange 证书内容变化时重新读取，证书和私钥分别更新时，中间状态解析失败会被忽略
+func (m *Manager) onChange(path string, _ []byte) {
+	if path != m.src.Cert && path != m.src.Key && path != m.src.CA {
+		return
+	}
+	m.reloadLock.Lock()
+	defer m.reloadLock.Unlock()
+	mat, err := m.read()
+	if err != nil {
+		config.Warnf("app/tlsconf: failed to reload, keep using the old certificate: %v", err)
+		return
+	}
+	m.cur.Store(mat)
+	config.Infof("app/tlsconf: %s reloaded", path)
+}
+
+func (m *Manager) current() *material {
+	return m.cur.Load().(*material)
+}
+
+// Certificate 当前的证书，没有配置证书时返回nil
+func (m *Manager) Certificate() *tls.Certificate {
+	return m.current().cert
+}
+
+// CertPool 当前的CA证书，没有配置ca时返回nil
+func (m *Manager) CertPool() *x509.CertPool {
+	return m.current().ca
+}
+
+// ServerConfig 服务端使用的 *tls.Config，每次握手时使用当前的证书和CA证书
+func (m *Manager) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: m.minVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			mat := m.current()
+			if mat.cert == nil {
+				return nil, ErrNoCertificate
+			}
+			cfg := &tls.Config{
+				MinVersion:   m.minVersion,
+				Certificates: []tls.Certificate{*mat.cert},
+			}
+			if m.src.ClientAuth {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				cfg.ClientCAs = mat.ca
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// ClientConfig 客户端使用的 *tls.Config，每次握手时使用当前的客户端证书，并使用当前的CA证书校验服务端证书，
+// 没有配置ca时使用系统CA证书
+func (m *Manager) ClientConfig() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: m.minVersion,
+		ServerName: m.src.ServerName,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert := m.current().cert; cert != nil {
+				return cert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+	}
+	if m.src.CA == "" {
+		return cfg
+	}
+	// RootCAs 不能在握手时替换，改为在 VerifyConnection 中使用当前的CA证书校验
+	cfg.InsecureSkipVerify = true
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		opts := x509.VerifyOptions{
+			DNSName:       cs.ServerName,
+			Roots:         m.current().ca,
+			Intermediates: x509.NewCertPool(),
+		}
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("app/tlsconf: no server certificate")
+		}
+		for _, c := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(c)
+		}
+		_, err := cs.PeerCertificates[0].Verify(opts)
+		return err
+	}
+	return cfg
+}
@@ -0,0 +1,166 @@
+package tlsconf
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"goProjectTmpl/config/configtest"
+)
+
+// authority 测试用的CA
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newAuthority(t *testing.T) *authority {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &authority{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue 签发localhost的证书，返回证书和私钥的PEM
+func (a *authority) issue(t *testing.T, serial int64) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// handshake 使用server和client完成一次握手，返回服务端证书的序列号
+func handshake(t *testing.T, server, client *tls.Config) (int64, error) {
+	t.Helper()
+	sc, cc := net.Pipe()
+	defer sc.Close()
+	defer cc.Close()
+	done := make(chan error, 1)
+	go func() {
+		done <- tls.Server(sc, server).Handshake()
+	}()
+	conn := tls.Client(cc, client)
+	if err := conn.Handshake(); err != nil {
+		<-done
+		return 0, err
+	}
+	if err := <-done; err != nil {
+		return 0, err
+	}
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func TestRotate(t *testing.T) {
+	ca := newAuthority(t)
+	certPEM, keyPEM := ca.issue(t, 10)
+	p := configtest.NewProvider()
+	p.Set("ca.crt", ca.pem)
+	p.Set("tls.crt", certPEM)
+	p.Set("tls.key", keyPEM)
+
+	c, _ := configtest.Load(t, "tls:\n  provider: "+p.Name()+"\n  cert: tls.crt\n  key: tls.key\n  ca: ca.crt\n  client_auth: true\n  server_name: localhost\n")
+	m, err := Load(c, "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serial, err := handshake(t, m.ServerConfig(), m.ClientConfig()); err != nil || serial != 10 {
+		t.Fatalf("expect serial 10, got %d %v", serial, err)
+	}
+
+	// 证书和私钥分别更新，中间状态被忽略
+	certPEM, keyPEM = ca.issue(t, 11)
+	p.Update("tls.crt", certPEM)
+	if serial, err := handshake(t, m.ServerConfig(), m.ClientConfig()); err != nil || serial != 10 {
+		t.Fatalf("expect the old certificate while the key is stale, got %d %v", serial, err)
+	}
+	p.Update("tls.key", keyPEM)
+	if serial, err := handshake(t, m.ServerConfig(), m.ClientConfig()); err != nil || serial != 11 {
+		t.Fatalf("expect serial 11 after rotation, got %d %v", serial, err)
+	}
+
+	// 更换CA后，旧CA签发的服务端证书校验失败
+	other := newAuthority(t)
+	server := m.ServerConfig()
+	p.Update("ca.crt", other.pem)
+	if _, err := handshake(t, server, m.ClientConfig()); err == nil {
+		t.Fatal("expect the handshake to fail with another ca")
+	}
+}
+
+func TestNew(t *testing.T) {
+	p := configtest.NewProvider()
+	p.Set("bad.crt", []byte("not a certificate"))
+	tests := []struct {
+		name string
+		src  Source
+	}{
+		{"min version", Source{Provider: p.Name(), MinVersion: "2.0"}},
+		{"client auth without ca", Source{Provider: p.Name(), ClientAuth: true}},
+		{"cert without key", Source{Provider: p.Name(), Cert: "tls.crt"}},
+		{"no provider", Source{Provider: "not-exist"}},
+		{"invalid ca", Source{Provider: p.Name(), CA: "bad.crt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.src); err == nil {
+				t.Fatal("expect an error")
+			}
+		})
+	}
+
+	// 只配置ca时用于客户端校验服务端证书
+	ca := newAuthority(t)
+	p.Set("ca.crt", ca.pem)
+	m, err := New(Source{Provider: p.Name(), CA: "ca.crt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Certificate() != nil || m.CertPool() == nil {
+		t.Fatal("expect only the ca to be loaded")
+	}
+}
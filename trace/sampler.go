@@ -0,0 +1,49 @@
+package trace
+
+import (
+	"encoding/binary"
+	"math"
+	"sync/atomic"
+)
+
+// Sampler 按照比例采样，比例可以在运行中修改
+// 与OpenTelemetry的TraceIDRatioBased一致，根据trace id的后8个字节决定是否采样，同一个trace的采样结果相同
+type Sampler struct {
+	// bound 采样阈值，trace id的后8个字节右移一位后小于bound时采样
+	bound uint64
+	// ratio 采样比例的位表示
+	ratio uint64
+}
+
+// NewSampler 创建采样比例为ratio的Sampler
+func NewSampler(ratio float64) *Sampler {
+	s := &Sampler{}
+	s.SetRatio(ratio)
+	return s
+}
+
+// SetRatio 修改采样比例，小于0视为0，大于1视为1
+func (s *Sampler) SetRatio(ratio float64) {
+	if ratio < 0 || math.IsNaN(ratio) {
+		ratio = 0
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	atomic.StoreUint64(&s.bound, uint64(ratio*(1<<63)))
+	atomic.StoreUint64(&s.ratio, math.Float64bits(ratio))
+}
+
+// Ratio 当前的采样比例
+func (s *Sampler) Ratio() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&s.ratio))
+}
+
+// ShouldSample trace是否采样
+func (s *Sampler) ShouldSample(traceID [16]byte) bool {
+	bound := atomic.LoadUint64(&s.bound)
+	if bound >= 1<<63 {
+		return true
+	}
+	return binary.BigEndian.Uint64(traceID[8:16])>>1 < bound
+}
@@ -0,0 +1,198 @@
+// Package trace 链路追踪
+// 按照框架配置的 trace 配置段安装链路追踪实现，采样比例在配置重新加载后立即生效：
+//
+//	trace:
+//	  service_name: goProjectTmpl
+//	  endpoint: 127.0.0.1:4317   # 导出地址，例如OpenTelemetry Collector
+//	  protocol: grpc              # grpc 或 http
+//	  insecure: true
+//	  sample_ratio: 0.1
+//	  resource:                   # 资源属性
+//	    deployment.environment: local
+//
+// 为了不引入OpenTelemetry依赖，具体实现通过 SetInstaller 接入，安装时创建exporter和TracerProvider，
+// 并将 Sampler.ShouldSample 包装为TracerProvider的采样器：
+//
+//	trace.SetInstaller(func(cfg *trace.Config, s *trace.Sampler) (config.Tracer, trace.Shutdown, error) {
+//		exp, _ := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+//		tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithSampler(ratioSampler{s}), ...)
+//		otel.SetTracerProvider(tp)
+//		return otelTracer{tp.Tracer(cfg.ServiceName)}, tp.Shutdown, nil
+//	})
+//	app.Register(trace.NewModule())
+package trace
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"goProjectTmpl/config"
+)
+
+// SectionKey 框架配置中链路追踪配置段的key
+const SectionKey = "trace"
+
+// Config 链路追踪配置
+type Config struct {
+	ServiceName string `yaml:"service_name"`
+	// Endpoint 导出地址，为空时不安装链路追踪实现
+	Endpoint string `yaml:"endpoint"`
+	Protocol string `yaml:"protocol" default:"grpc" enum:"grpc,http"`
+	Insecure bool   `yaml:"insecure"`
+	// Headers 导出请求携带的头，例如鉴权信息
+	Headers map[string]string `yaml:"headers" sensitive:"true"`
+	// SampleRatio 采样比例，0-1
+	SampleRatio float64 `yaml:"sample_ratio" default:"1"`
+	// Resource 资源属性
+	Resource map[string]string `yaml:"resource"`
+}
+
+func init() {
+	// 解码使用 UnmarshalKey，sensitive tag 需要通过 RegisterStruct 生效，exporter的认证header不出现在调试接口和审计记录中
+	config.RegisterStruct(SectionKey, Config{})
+}
+
+// Shutdown 导出剩余的span并释放资源
+type Shutdown func(ctx context.Context) error
+
+// Installer 按照配置安装链路追踪实现，返回的Tracer可通过 config.WithTracer 等方式使用
+type Installer func(cfg *Config, sampler *Sampler) (config.Tracer, Shutdown, error)
+
+var (
+	installer Installer
+	tracer    config.Tracer = noopTracer{}
+	lock      sync.RWMutex
+)
+
+// SetInstaller 设置链路追踪实现的安装函数
+func SetInstaller(i Installer) {
+	lock.Lock()
+	installer = i
+	lock.Unlock()
+}
+
+// Tracer 已安装的链路追踪实现，未安装时为空实现
+func Tracer() config.Tracer {
+	lock.RLock()
+	defer lock.RUnlock()
+	return tracer
+}
+
+// Start 使用已安装的链路追踪实现开始span
+func Start(ctx context.Context, name string) (context.Context, config.Span) {
+	return Tracer().Start(ctx, name)
+}
+
+// noopTracer 未安装链路追踪实现时的空实现
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string) (context.Context, config.Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+
+func (noopSpan) RecordError(error) {}
+
+func (noopSpan) End() {}
+
+// Module 按照 trace 配置段安装链路追踪实现，实现了app的Module、Stopper、Reloader接口
+// 采样比例重新加载配置后立即生效，其他配置变化需要重启服务
+type Module struct {
+	lock     sync.Mutex
+	cfg      *Config
+	sampler  *Sampler
+	shutdown Shutdown
+}
+
+// NewModule 创建Module
+func NewModule() *Module {
+	return &Module{sampler: NewSampler(1)}
+}
+
+// Name 模块名
+func (m *Module) Name() string {
+	return "trace"
+}
+
+// Sampler 采样器
+func (m *Module) Sampler() *Sampler {
+	return m.sampler
+}
+
+// Init 解析 trace 配置段并安装链路追踪实现，没有配置导出地址时不安装
+func (m *Module) Init(c config.Config) error {
+	cfg, err := parse(c)
+	if err != nil {
+		return err
+	}
+	m.sampler.SetRatio(cfg.SampleRatio)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.cfg = cfg
+	if cfg.Endpoint == "" {
+		return nil
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if installer == nil {
+		return fmt.Errorf("app/trace: %s.endpoint is set but no installer registered", SectionKey)
+	}
+	t, shutdown, err := installer(cfg, m.sampler)
+	if err != nil {
+		return fmt.Errorf("app/trace: failed to install: %v", err)
+	}
+	tracer, m.shutdown = t, shutdown
+	config.Infof("app/trace: exporting to %s via %s, sample ratio %v", cfg.Endpoint, cfg.Protocol, cfg.SampleRatio)
+	return nil
+}
+
+func parse(c config.Config) (*Config, error) {
+	cfg := &Config{Protocol: "grpc", SampleRatio: 1}
+	if !c.IsSet(SectionKey) {
+		return cfg, nil
+	}
+	if err := c.UnmarshalKey(SectionKey, cfg); err != nil {
+		return nil, fmt.Errorf("app/trace: failed to parse %s: %v", SectionKey, err)
+	}
+	return cfg, nil
+}
+
+// Reload 更新采样比例
+func (m *Module) Reload(c config.Config, changes []config.Change) {
+	cfg, err := parse(c)
+	if err != nil {
+		config.Errorf("app/trace: failed to reload, keep using the old config: %v", err)
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.cfg == nil {
+		return
+	}
+	if cfg.SampleRatio != m.cfg.SampleRatio {
+		m.sampler.SetRatio(cfg.SampleRatio)
+		config.Infof("app/trace: sample ratio changed from %v to %v", m.cfg.SampleRatio, cfg.SampleRatio)
+	}
+	m.cfg.SampleRatio = cfg.SampleRatio
+	if !reflect.DeepEqual(cfg, m.cfg) {
+		config.Warnf("app/trace: %s changed, restart required", SectionKey)
+	}
+}
+
+// Stop 导出剩余的span
+func (m *Module) Stop(ctx context.Context) error {
+	m.lock.Lock()
+	shutdown := m.shutdown
+	m.shutdown = nil
+	m.lock.Unlock()
+	if shutdown == nil {
+		return nil
+	}
+	return shutdown(ctx)
+}
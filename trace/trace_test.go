@@ -0,0 +1,111 @@
+package trace
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"goProjectTmpl/config"
+	"goProjectTmpl/config/configtest"
+)
+
+func TestSampler(t *testing.T) {
+	ids := make([][16]byte, 10000)
+	for i := range ids {
+		// 后8个字节均匀分布
+		binary.BigEndian.PutUint64(ids[i][8:], uint64(i)*(^uint64(0)/uint64(len(ids))))
+	}
+	count := func(s *Sampler) int {
+		n := 0
+		for _, id := range ids {
+			if s.ShouldSample(id) {
+				n++
+			}
+		}
+		return n
+	}
+
+	s := NewSampler(0)
+	if got := count(s); got != 0 {
+		t.Fatalf("ratio 0: expect no samples, got %d", got)
+	}
+	s.SetRatio(1.5)
+	if got := count(s); got != len(ids) || s.Ratio() != 1 {
+		t.Fatalf("ratio 1: expect all samples, got %d", got)
+	}
+	s.SetRatio(0.25)
+	if got := count(s); got < 2400 || got > 2600 {
+		t.Fatalf("ratio 0.25: expect about 2500 samples, got %d", got)
+	}
+	// 同一个trace的采样结果相同，比例提高后原来采样的trace仍然采样
+	lower := NewSampler(0.1)
+	for _, id := range ids {
+		if lower.ShouldSample(id) && !s.ShouldSample(id) {
+			t.Fatal("expect samples of a lower ratio to be kept")
+		}
+	}
+}
+
+// fakeTracer 记录是否被关闭
+type fakeTracer struct {
+	noopTracer
+	stopped bool
+}
+
+func TestModule(t *testing.T) {
+	ft := &fakeTracer{}
+	var installed *Config
+	SetInstaller(func(cfg *Config, s *Sampler) (config.Tracer, Shutdown, error) {
+		installed = cfg
+		return ft, func(context.Context) error {
+			ft.stopped = true
+			return nil
+		}, nil
+	})
+	defer func() {
+		SetInstaller(nil)
+		lock.Lock()
+		tracer = noopTracer{}
+		lock.Unlock()
+	}()
+
+	c, p := configtest.Load(t, "trace:\n  service_name: app\n  endpoint: 127.0.0.1:4317\n  sample_ratio: 0.5\n  headers:\n    authorization: secret\n")
+	m := NewModule()
+	if err := m.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if installed == nil || installed.Protocol != "grpc" || installed.Headers["authorization"] != "secret" {
+		t.Fatalf("unexpected installed config %+v", installed)
+	}
+	if Tracer() != config.Tracer(ft) || m.Sampler().Ratio() != 0.5 {
+		t.Fatal("expect the installed tracer and sample ratio 0.5")
+	}
+
+	// 采样比例重新加载后立即生效
+	p.Update(configtest.DefaultPath, []byte("trace:\n  service_name: app\n  endpoint: 127.0.0.1:4317\n  sample_ratio: 0.2\n"))
+	m.Reload(c, nil)
+	if got := m.Sampler().Ratio(); got != 0.2 {
+		t.Fatalf("expect sample ratio 0.2, got %v", got)
+	}
+
+	if err := m.Stop(context.Background()); err != nil || !ft.stopped {
+		t.Fatalf("expect the tracer to be shut down, got %v", err)
+	}
+}
+
+func TestNoInstaller(t *testing.T) {
+	c, _ := configtest.Load(t, "trace:\n  endpoint: 127.0.0.1:4317\n")
+	if err := NewModule().Init(c); err == nil || !strings.Contains(err.Error(), "no installer") {
+		t.Fatalf("expect a missing installer error, got %v", err)
+	}
+	// 没有配置导出地址时不安装
+	c, _ = configtest.Load(t, "trace:\n  sample_ratio: 0.3\n")
+	m := NewModule()
+	if err := m.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if _, span := Start(context.Background(), "noop"); span == nil {
+		t.Fatal("expect a noop span")
+	}
+}